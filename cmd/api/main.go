@@ -1,12 +1,31 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 
 	"github.com/joaopanucci/apsdigital/internal/config"
+	"github.com/joaopanucci/apsdigital/internal/domain/entities"
 	"github.com/joaopanucci/apsdigital/internal/infra/db"
+	"github.com/joaopanucci/apsdigital/internal/infra/events"
+	"github.com/joaopanucci/apsdigital/internal/infra/health"
 	"github.com/joaopanucci/apsdigital/internal/infra/http/router"
+	"github.com/joaopanucci/apsdigital/internal/infra/jobs"
+	"github.com/joaopanucci/apsdigital/internal/infra/jwtkeys"
+	"github.com/joaopanucci/apsdigital/internal/infra/logging"
+	"github.com/joaopanucci/apsdigital/internal/infra/metrics"
+	"github.com/joaopanucci/apsdigital/internal/infra/repositories"
+	"github.com/joaopanucci/apsdigital/internal/infra/storage"
+	"github.com/joaopanucci/apsdigital/internal/infra/tracing"
+	"github.com/joaopanucci/apsdigital/internal/infra/webhooks"
 
 	"github.com/gin-gonic/gin"
 )
@@ -15,6 +34,29 @@ func main() {
 	// Load configuration
 	cfg := config.LoadConfig()
 
+	// Structured logs; the standard log package writes through it too
+	logger := logging.New(os.Stdout, cfg.Log.Format, cfg.Log.Level)
+	slog.SetDefault(logger)
+
+	if err := cfg.Validate(); err != nil {
+		fatal("Invalid configuration", err)
+	}
+
+	// Before the database connection, whose queries are traced
+	sampleRatio, err := strconv.ParseFloat(cfg.Tracing.SampleRatio, 64)
+	if err != nil {
+		sampleRatio = 1
+	}
+	insecure, _ := strconv.ParseBool(cfg.Tracing.Insecure)
+	shutdownTracing := tracing.Setup(context.Background(), tracing.Options{
+		Exporter:    cfg.Tracing.Exporter,
+		Endpoint:    cfg.Tracing.Endpoint,
+		Insecure:    insecure,
+		SampleRatio: sampleRatio,
+		ServiceName: "apsdigital",
+		Environment: cfg.Server.Env,
+	})
+
 	// Connect to database
 	database, err := db.NewPostgresConnection(
 		cfg.Database.Host,
@@ -22,27 +64,209 @@ func main() {
 		cfg.Database.User,
 		cfg.Database.Password,
 		cfg.Database.DBName,
+		parseDuration(cfg.Log.SlowQueryThreshold, 500*time.Millisecond),
 	)
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		fatal("Failed to connect to database", err)
 	}
-	defer database.Close()
 
 	// Setup Gin mode
 	if cfg.Server.Env == "production" {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
+	// SIGINT/SIGTERM stops the background jobs and drains the server
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	workers, err := strconv.Atoi(cfg.Jobs.Workers)
+	if err != nil {
+		workers = 4
+	}
+	shutdownTimeout, err := time.ParseDuration(cfg.Jobs.ShutdownTimeout)
+	if err != nil {
+		shutdownTimeout = 30 * time.Second
+	}
+	jobRepo := repositories.NewJobRepository(database)
+	runner := jobs.NewRunner(jobRepo, jobs.Options{Workers: workers, ShutdownTimeout: shutdownTimeout})
+
+	cleanupInterval := cfg.JWT.RefreshTokenCleanupInterval
+	if _, err := time.ParseDuration(cleanupInterval); err != nil {
+		cleanupInterval = "1h"
+	}
+	runner.Register(entities.JobRefreshTokenCleanup, jobs.RefreshTokenCleanup(repositories.NewRefreshTokenRepository(database)))
+	if err := runner.Schedule("@every "+cleanupInterval, entities.JobRefreshTokenCleanup); err != nil {
+		fatal("Failed to schedule jobs", err)
+	}
+
+	// Live events are kept a day so clients can resume their streams
+	liveEventRepo := repositories.NewLiveEventRepository(database)
+	runner.Register(entities.JobLiveEventCleanup, jobs.LiveEventCleanup(liveEventRepo, 24*time.Hour))
+	if err := runner.Schedule("@hourly", entities.JobLiveEventCleanup); err != nil {
+		fatal("Failed to schedule jobs", err)
+	}
+
+	runner.Register(entities.JobPrune, jobs.Prune(jobRepo))
+	if err := runner.Schedule("@daily", entities.JobPrune); err != nil {
+		fatal("Failed to schedule jobs", err)
+	}
+
+	broker := events.NewBroker(database.Pool, liveEventRepo)
+	go broker.Run(ctx)
+
+	go webhooks.NewDispatcher(repositories.NewWebhookRepository(database)).Run(ctx, 5*time.Second)
+
+	// Load JWT signing keys
+	tokenLifetime, err := time.ParseDuration(cfg.JWT.Expiration)
+	if err != nil {
+		tokenLifetime = 24 * time.Hour
+	}
+	rotationInterval, err := time.ParseDuration(cfg.JWT.KeyRotationInterval)
+	if err != nil {
+		rotationInterval = 30 * 24 * time.Hour
+	}
+
+	keys, err := jwtkeys.NewManager(jwtkeys.Options{
+		Algorithm:        cfg.JWT.Algorithm,
+		Secret:           cfg.JWT.Secret,
+		Dir:              cfg.JWT.KeyDir,
+		RotationInterval: rotationInterval,
+		TokenLifetime:    tokenLifetime,
+	})
+	if err != nil {
+		fatal("Failed to load JWT keys", err)
+	}
+	go keys.Start(ctx, time.Hour)
+
+	// Readiness: the database is reachable and migrated and uploads can be written
+	files := storage.NewLocal(cfg.Upload.Path)
+	checker := health.NewChecker()
+	checker.Add("database", func(ctx context.Context) error {
+		return database.Pool.Ping(ctx)
+	})
+	checker.Add("migrations", func(ctx context.Context) error {
+		pending, err := database.PendingMigrations(ctx)
+		if err != nil {
+			return err
+		}
+		if len(pending) > 0 {
+			return fmt.Errorf("%d pending migrations, first %d", len(pending), pending[0])
+		}
+		return nil
+	})
+	checker.Add("storage", func(ctx context.Context) error {
+		return files.CheckWritable()
+	})
+
+	metrics.Registry.MustRegister(
+		metrics.NewPoolCollector(database.Pool),
+		metrics.NewPendingCollector(repositories.NewUserRepository(database), repositories.NewTabletRequestRepository(database)),
+	)
+
 	// Initialize router
-	r := router.NewRouter(database, cfg)
+	r := router.NewRouter(database, cfg, keys, broker, runner, checker)
+
+	// Started after the router registered the handlers of the services' jobs
+	jobsDone := make(chan struct{})
+	go func() {
+		runner.Run(ctx)
+		close(jobsDone)
+	}()
 
 	// Create uploads directory
 	if err := os.MkdirAll(cfg.Upload.Path, 0755); err != nil {
-		log.Printf("Warning: Failed to create uploads directory: %v", err)
+		slog.Warn("Failed to create uploads directory", "error", err)
+	}
+
+	srv := &http.Server{
+		Addr:              ":" + cfg.Server.Port,
+		Handler:           r,
+		ReadHeaderTimeout: parseDuration(cfg.Server.ReadHeaderTimeout, 10*time.Second),
+		ReadTimeout:       parseDuration(cfg.Server.ReadTimeout, 5*time.Minute),
+		WriteTimeout:      parseDuration(cfg.Server.WriteTimeout, 5*time.Minute),
+		IdleTimeout:       parseDuration(cfg.Server.IdleTimeout, 2*time.Minute),
+		ErrorLog:          slog.NewLogLogger(logger.Handler(), slog.LevelWarn),
+	}
+	// Event streams would otherwise hold shutdown until its timeout
+	srv.RegisterOnShutdown(broker.Close)
+
+	serverErr := make(chan error, 2)
+	go func() {
+		slog.Info("Server starting", "port", cfg.Server.Port)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+
+	// Metrics on a private address, kept up until the API has drained
+	var metricsSrv *http.Server
+	if cfg.Metrics.Addr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metrics.Handler(cfg.Metrics.Token))
+		metricsSrv = &http.Server{
+			Addr:              cfg.Metrics.Addr,
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second,
+			ErrorLog:          slog.NewLogLogger(logger.Handler(), slog.LevelWarn),
+		}
+		go func() {
+			slog.Info("Metrics server starting", "addr", cfg.Metrics.Addr)
+			if err := metricsSrv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				serverErr <- err
+			}
+		}()
+	}
+
+	exitCode := 0
+	select {
+	case <-ctx.Done():
+		slog.Info("Shutting down")
+	case err := <-serverErr:
+		slog.Error("Server failed", "error", err)
+		exitCode = 1
 	}
+	stop()
 
-	log.Printf("Server starting on port %s", cfg.Server.Port)
-	if err := r.Run(":" + cfg.Server.Port); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	// Fail readiness first so no new requests are routed here, then let in-flight
+	// requests and jobs finish
+	checker.Drain()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), parseDuration(cfg.Server.ShutdownTimeout, 30*time.Second))
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Warn("Requests still running at shutdown", "error", err)
+	}
+	if metricsSrv != nil {
+		metricsSrv.Shutdown(shutdownCtx)
+	}
+
+	<-jobsDone
+	database.Close()
+
+	// Flush the spans of the last requests and jobs
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFlush()
+	if err := shutdownTracing(flushCtx); err != nil {
+		slog.Warn("Failed to flush traces", "error", err)
+	}
+	slog.Info("Server stopped")
+
+	if exitCode != 0 {
+		os.Exit(exitCode)
+	}
+}
+
+// fatal logs an error that keeps the server from starting and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+// parseDuration reads a duration setting, falling back when it is invalid
+func parseDuration(value string, fallback time.Duration) time.Duration {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return fallback
 	}
+	return d
 }
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/joaopanucci/apsdigital/internal/config"
+	"github.com/joaopanucci/apsdigital/internal/domain/services"
+	"github.com/joaopanucci/apsdigital/internal/infra/db"
+	"github.com/joaopanucci/apsdigital/internal/infra/ibge"
+	"github.com/joaopanucci/apsdigital/internal/infra/repositories"
+)
+
+// ibge-sync imports municipalities from the IBGE localities dataset and prints the diff as JSON
+func main() {
+	cfg := config.LoadConfig()
+
+	source := flag.String("source", cfg.IBGE.LocalitiesURL, "IBGE localities JSON/CSV file path or URL")
+	state := flag.String("state", "", "only sync municipalities of this UF (e.g. MS)")
+	dryRun := flag.Bool("dry-run", false, "report the changes without applying them")
+	flag.Parse()
+
+	database, err := db.NewPostgresConnection(
+		cfg.Database.Host,
+		cfg.Database.Port,
+		cfg.Database.User,
+		cfg.Database.Password,
+		cfg.Database.DBName,
+		0,
+	)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer database.Close()
+
+	municipalityService := services.NewMunicipalityService(repositories.NewMunicipalityRepository(database), ibge.NewLocalityLoader())
+
+	report, err := municipalityService.SyncFromIBGE(context.Background(), *source, services.MunicipalitySyncOptions{
+		State:  *state,
+		DryRun: *dryRun,
+	})
+	if err != nil {
+		log.Fatalf("Sync failed: %v", err)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		log.Fatalf("Failed to write report: %v", err)
+	}
+
+	log.Printf("IBGE sync: %d created, %d updated, %d reactivated, %d deactivated, %d unchanged",
+		len(report.Created), len(report.Updated), len(report.Reactivated), len(report.Deactivated), report.Unchanged)
+}
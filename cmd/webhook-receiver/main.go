@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/joaopanucci/apsdigital/internal/infra/webhooks"
+)
+
+// webhook-receiver is a local endpoint for trying webhooks out: it verifies the signature
+// of each delivery, prints it and answers with the chosen status, so retries can be
+// exercised by answering 500
+func main() {
+	addr := flag.String("addr", ":9000", "address to listen on")
+	secret := flag.String("secret", "", "webhook secret (whsec_...); signatures are not checked when empty")
+	status := flag.Int("status", http.StatusOK, "status to answer deliveries with")
+	flag.Parse()
+
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		verified := "not checked"
+		if *secret != "" {
+			if err := webhooks.Verify(*secret, r.Header.Get(webhooks.HeaderSignature), body, 5*time.Minute); err != nil {
+				log.Printf("Rejected delivery %s: %v", r.Header.Get(webhooks.HeaderDelivery), err)
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+			verified = "valid"
+		}
+
+		var pretty bytes.Buffer
+		if err := json.Indent(&pretty, body, "", "  "); err != nil {
+			pretty.Write(body)
+		}
+		log.Printf("%s %s delivery=%s event=%s signature=%s\n%s",
+			r.Method, r.URL.Path,
+			r.Header.Get(webhooks.HeaderDelivery),
+			r.Header.Get(webhooks.HeaderEvent),
+			verified,
+			pretty.String(),
+		)
+
+		w.WriteHeader(*status)
+	})
+
+	log.Printf("Receiving webhooks on %s", *addr)
+	if err := http.ListenAndServe(*addr, nil); err != nil {
+		log.Fatalf("Failed to start receiver: %v", err)
+	}
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"fmt"
 	"log"
 	"os"
 
@@ -12,6 +13,12 @@ type Config struct {
 	JWT      JWTConfig
 	Server   ServerConfig
 	Upload   UploadConfig
+	IBGE     IBGEConfig
+	Assets   AssetsConfig
+	Jobs     JobsConfig
+	Log      LogConfig
+	Metrics  MetricsConfig
+	Tracing  TracingConfig
 }
 
 type DatabaseConfig struct {
@@ -22,21 +29,94 @@ type DatabaseConfig struct {
 	DBName   string
 }
 
+// DefaultJWTSecret is the development fallback; it must never be used in production
+const DefaultJWTSecret = "your-super-secret-jwt-key-here"
+
 type JWTConfig struct {
+	// Algorithm is HS256 (shared secret), RS256 or EdDSA (keys from KeyDir)
+	Algorithm              string
 	Secret                 string
 	Expiration            string
 	RefreshTokenExpiration string
+	// How often expired refresh tokens are purged
+	RefreshTokenCleanupInterval string
+	KeyDir                      string
+	// Age after which a new asymmetric signing key is generated
+	KeyRotationInterval string
 }
 
 type ServerConfig struct {
 	Port string
 	Env  string
+	// Timeouts of the HTTP server; reads and writes are generous for uploads and exports
+	ReadHeaderTimeout string
+	ReadTimeout       string
+	WriteTimeout      string
+	IdleTimeout       string
+	// How long shutdown waits for in-flight requests
+	ShutdownTimeout string
 }
 
 type UploadConfig struct {
 	Path string
 }
 
+// AssetsConfig covers the QR code labels of tablets
+type AssetsConfig struct {
+	// Public address the label URLs point to
+	BaseURL string
+	// Key signing the label URLs; changing it invalidates printed labels
+	URLSecret string
+	// Frontend page a scanned label redirects to, with the label URL in the qr parameter
+	ScanURL string
+}
+
+// DefaultAssetURLSecret is the development fallback; it must never be used in production
+const DefaultAssetURLSecret = "your-asset-url-secret-here"
+
+// JobsConfig covers the background job runner
+type JobsConfig struct {
+	// How many jobs each instance runs at once
+	Workers string
+	// How long shutdown waits for running jobs
+	ShutdownTimeout string
+}
+
+// LogConfig covers the application logs
+type LogConfig struct {
+	// debug, info, warn or error
+	Level string
+	// json, or text for reading logs in a terminal
+	Format string
+	// Queries slower than this are logged with the request that ran them
+	SlowQueryThreshold string
+}
+
+// MetricsConfig covers the Prometheus endpoint. With Addr set, /metrics is served on
+// that address only; otherwise it is served by the API when Token is set.
+type MetricsConfig struct {
+	Addr string
+	// Bearer token scrapers must send; optional on a private Addr
+	Token string
+}
+
+// TracingConfig covers OpenTelemetry tracing
+type TracingConfig struct {
+	// otlp, stdout or none
+	Exporter string
+	// OTLP/HTTP collector address (host:port); empty uses OTEL_EXPORTER_OTLP_ENDPOINT
+	Endpoint string
+	// Send to the collector over plain HTTP
+	Insecure string
+	// Fraction of new traces recorded, 0 to 1
+	SampleRatio string
+}
+
+type IBGEConfig struct {
+	// Default source for the municipality sync, a URL or file path
+	LocalitiesURL string
+}
+
 func LoadConfig() *Config {
 	err := godotenv.Load()
 	if err != nil {
@@ -52,18 +132,65 @@ func LoadConfig() *Config {
 			DBName:   getEnv("DB_NAME", "apsdigital"),
 		},
 		JWT: JWTConfig{
-			Secret:                 getEnv("JWT_SECRET", "your-super-secret-jwt-key-here"),
+			Algorithm:              getEnv("JWT_ALGORITHM", "HS256"),
+			Secret:                 getEnv("JWT_SECRET", DefaultJWTSecret),
 			Expiration:            getEnv("JWT_EXPIRATION", "24h"),
 			RefreshTokenExpiration: getEnv("REFRESH_TOKEN_EXPIRATION", "168h"),
+			RefreshTokenCleanupInterval: getEnv("REFRESH_TOKEN_CLEANUP_INTERVAL", "1h"),
+			KeyDir:                      getEnv("JWT_KEY_DIR", "./keys"),
+			KeyRotationInterval:         getEnv("JWT_KEY_ROTATION_INTERVAL", "720h"),
 		},
 		Server: ServerConfig{
-			Port: getEnv("PORT", "8080"),
-			Env:  getEnv("ENV", "development"),
+			Port:              getEnv("PORT", "8080"),
+			Env:               getEnv("ENV", "development"),
+			ReadHeaderTimeout: getEnv("SERVER_READ_HEADER_TIMEOUT", "10s"),
+			ReadTimeout:       getEnv("SERVER_READ_TIMEOUT", "5m"),
+			WriteTimeout:      getEnv("SERVER_WRITE_TIMEOUT", "5m"),
+			IdleTimeout:       getEnv("SERVER_IDLE_TIMEOUT", "2m"),
+			ShutdownTimeout:   getEnv("SERVER_SHUTDOWN_TIMEOUT", "30s"),
 		},
 		Upload: UploadConfig{
 			Path: getEnv("UPLOAD_PATH", "./uploads"),
 		},
+		IBGE: IBGEConfig{
+			LocalitiesURL: getEnv("IBGE_LOCALITIES_URL", "https://servicodados.ibge.gov.br/api/v1/localidades/municipios"),
+		},
+		Assets: AssetsConfig{
+			BaseURL:   getEnv("ASSET_BASE_URL", "http://localhost:8080"),
+			URLSecret: getEnv("ASSET_URL_SECRET", DefaultAssetURLSecret),
+			ScanURL:   getEnv("ASSET_SCAN_URL", "http://localhost:5173/tablets/scan"),
+		},
+		Jobs: JobsConfig{
+			Workers:         getEnv("JOB_WORKERS", "4"),
+			ShutdownTimeout: getEnv("JOB_SHUTDOWN_TIMEOUT", "30s"),
+		},
+		Log: LogConfig{
+			Level:              getEnv("LOG_LEVEL", "info"),
+			Format:             getEnv("LOG_FORMAT", "json"),
+			SlowQueryThreshold: getEnv("LOG_SLOW_QUERY_THRESHOLD", "500ms"),
+		},
+		Metrics: MetricsConfig{
+			Addr:  getEnv("METRICS_ADDR", ""),
+			Token: getEnv("METRICS_TOKEN", ""),
+		},
+		Tracing: TracingConfig{
+			Exporter:    getEnv("TRACING_EXPORTER", "none"),
+			Endpoint:    getEnv("TRACING_ENDPOINT", ""),
+			Insecure:    getEnv("TRACING_INSECURE", "false"),
+			SampleRatio: getEnv("TRACING_SAMPLE_RATIO", "1"),
+		},
+	}
+}
+
+// Validate rejects configurations that are unsafe to run with
+func (c *Config) Validate() error {
+	if c.Server.Env == "production" && c.JWT.Algorithm == "HS256" && c.JWT.Secret == DefaultJWTSecret {
+		return fmt.Errorf("JWT_SECRET must be set in production (or use JWT_ALGORITHM=RS256/EdDSA)")
+	}
+	if c.Server.Env == "production" && c.Assets.URLSecret == DefaultAssetURLSecret {
+		return fmt.Errorf("ASSET_URL_SECRET must be set in production")
 	}
+	return nil
 }
 
 func getEnv(key, defaultValue string) string {
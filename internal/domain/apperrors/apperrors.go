@@ -0,0 +1,190 @@
+// Package apperrors defines the errors the API reports to clients. Each carries a kind,
+// which decides the HTTP status, a stable code clients can match on and the key of its
+// message in the i18n catalog. Errors of other types are internal and never shown.
+package apperrors
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/joaopanucci/apsdigital/internal/i18n"
+)
+
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindValidation
+	KindUnauthorized
+	KindForbidden
+	KindNotFound
+	KindConflict
+	// The request is well formed but breaks a business rule
+	KindUnprocessable
+	KindTooLarge
+)
+
+// FieldError explains why one input field was rejected. Message is filled in the
+// client's language when the error is rendered.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// Key and Args pick the message from the catalog; Key defaults to Code
+	Key  string `json:"-"`
+	Args []any  `json:"-"`
+}
+
+// Localize is the field's message in lang
+func (f FieldError) Localize(lang string) string {
+	return i18n.Message(lang, messageKey(f.Key, f.Code), f.Args...)
+}
+
+type Error struct {
+	Kind Kind
+	Code string
+	// Key and Args pick the message from the catalog; Key defaults to Code
+	Key    string
+	Args   []any
+	Fields []FieldError
+	// Cause is logged but never shown to clients
+	Cause error
+}
+
+// Localize is the error's message in lang
+func (e *Error) Localize(lang string) string {
+	return i18n.Message(lang, messageKey(e.Key, e.Code), e.Args...)
+}
+
+func (e *Error) Error() string {
+	if e.Cause != nil {
+		return fmt.Sprintf("%s: %v", e.Localize(i18n.Default), e.Cause)
+	}
+	return e.Localize(i18n.Default)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Cause
+}
+
+// Is matches errors with the same code, so sentinels still match once wrapped or given a cause
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// Wrap returns a copy of e recording the error that caused it
+func (e *Error) Wrap(cause error) *Error {
+	wrapped := *e
+	wrapped.Cause = cause
+	return &wrapped
+}
+
+// WithFields returns a copy of e naming the fields at fault
+func (e *Error) WithFields(fields ...FieldError) *Error {
+	withFields := *e
+	withFields.Fields = append(append([]FieldError(nil), e.Fields...), fields...)
+	return &withFields
+}
+
+// WithMessage returns a copy of e showing the catalog message key instead, for errors
+// sharing a code but worded differently
+func (e *Error) WithMessage(key string, args ...any) *Error {
+	withMessage := *e
+	withMessage.Key = key
+	withMessage.Args = args
+	return &withMessage
+}
+
+func messageKey(key, code string) string {
+	if key != "" {
+		return key
+	}
+	return code
+}
+
+// New creates an error whose message is the catalog entry for code, filled with args
+func New(kind Kind, code string, args ...any) *Error {
+	return &Error{Kind: kind, Code: code, Args: args}
+}
+
+func NotFound(code string, args ...any) *Error {
+	return New(KindNotFound, code, args...)
+}
+
+func Conflict(code string, args ...any) *Error {
+	return New(KindConflict, code, args...)
+}
+
+func Forbidden(code string, args ...any) *Error {
+	return New(KindForbidden, code, args...)
+}
+
+func Unauthorized(code string, args ...any) *Error {
+	return New(KindUnauthorized, code, args...)
+}
+
+func Unprocessable(code string, args ...any) *Error {
+	return New(KindUnprocessable, code, args...)
+}
+
+func TooLarge(code string, args ...any) *Error {
+	return New(KindTooLarge, code, args...)
+}
+
+// Validation rejects the input, optionally naming the fields at fault
+func Validation(code string, fields ...FieldError) *Error {
+	return &Error{Kind: KindValidation, Code: code, Fields: fields}
+}
+
+// Invalid rejects a single field, explained by the catalog message key
+func Invalid(field, key string, args ...any) *Error {
+	return Validation(CodeInvalidInput, FieldError{Field: field, Code: "invalid", Key: key, Args: args})
+}
+
+// Required rejects a missing field
+func Required(field string) *Error {
+	return Validation(CodeInvalidInput, FieldError{Field: field, Code: "required"})
+}
+
+// InvalidFile rejects an uploaded file, saying with the catalog message key what is wrong with it
+func InvalidFile(key string, args ...any) *Error {
+	return Validation(CodeInvalidFile).WithMessage(key, args...)
+}
+
+// As finds the *Error in err's chain
+func As(err error) (*Error, bool) {
+	var e *Error
+	ok := errors.As(err, &e)
+	return e, ok
+}
+
+// KindOf is the kind of the *Error in err's chain, KindInternal when there is none
+func KindOf(err error) Kind {
+	if e, ok := As(err); ok {
+		return e.Kind
+	}
+	return KindInternal
+}
+
+// Codes shared across the API
+const (
+	CodeInternal        = "internal_error"
+	CodeInvalidInput    = "invalid_input"
+	CodeInvalidBody     = "invalid_body"
+	CodeInvalidFile     = "invalid_file"
+	CodeNotFound        = "not_found"
+	CodeAlreadyExists   = "already_exists"
+	CodeInUse           = "in_use"
+	CodeUnauthenticated = "unauthenticated"
+	CodeAccessDenied    = "access_denied"
+)
+
+var (
+	ErrInternal        = New(KindInternal, CodeInternal)
+	ErrNotFound        = NotFound(CodeNotFound)
+	ErrAlreadyExists   = Conflict(CodeAlreadyExists)
+	ErrInUse           = Conflict(CodeInUse)
+	ErrUnauthenticated = Unauthorized(CodeUnauthenticated)
+	ErrAccessDenied    = Forbidden(CodeAccessDenied)
+)
@@ -0,0 +1,94 @@
+package entities
+
+import (
+	"github.com/google/uuid"
+)
+
+// In every filter a nil MunicipalityIDs means no scope restriction, while an empty
+// slice matches nothing (a user whose scope covers no municipality).
+
+type UserFilter struct {
+	Municipality    string // Legacy free-text municipality
+	MunicipalityID  *int
+	MunicipalityIDs []int
+	Status          UserStatus
+	RoleID          *uuid.UUID
+	ProfessionID    *int
+	Search          string // Name, e-mail or exact CPF
+}
+
+type TabletFilter struct {
+	SerialNumber    string
+	Status          TabletStatus
+	Model           string
+	MunicipalityID  *int
+	MunicipalityIDs []int
+}
+
+// TabletTransferFilter matches transfers whose source or destination is in MunicipalityIDs
+type TabletTransferFilter struct {
+	Status          TabletTransferStatus
+	TabletID        *int
+	MunicipalityIDs []int
+}
+
+// TabletRequestFilter matches requests whose requester is in MunicipalityIDs
+type TabletRequestFilter struct {
+	Status          TabletRequestStatus
+	Type            TabletRequestType
+	UserID          *uuid.UUID
+	MunicipalityIDs []int
+}
+
+// NotificationFilter lists the notifications of one user
+type NotificationFilter struct {
+	UserID     uuid.UUID
+	UnreadOnly bool
+}
+
+type PaymentFilter struct {
+	MunicipalityID  *int
+	MunicipalityIDs []int
+	Year            int
+	Month           int
+	Competence      string
+}
+
+type ResolutionFilter struct {
+	MunicipalityID  *int
+	MunicipalityIDs []int
+	Year            int
+	Type            ResolutionType
+	Number          string
+	Competence      string
+}
+
+type MunicipalityFilter struct {
+	State          string
+	HealthRegionID *int
+	Search         string // Name or exact IBGE code
+}
+
+type ProfessionFilter struct {
+	Search string
+}
+
+type HealthUnitFilter struct {
+	MunicipalityID  *int
+	MunicipalityIDs []int
+	Type            string
+	Active          *bool
+	Search          string // Name or exact CNES
+}
+
+type WebhookFilter struct {
+	MunicipalityID  *int
+	MunicipalityIDs []int
+}
+
+// WebhookDeliveryFilter lists the delivery log of one subscription
+type WebhookDeliveryFilter struct {
+	SubscriptionID uuid.UUID
+	Status         WebhookDeliveryStatus
+	EventType      string
+}
@@ -0,0 +1,36 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/joaopanucci/apsdigital/internal/domain/apperrors"
+)
+
+// ErrHealthRegionNotFound is returned for unknown health regions
+var ErrHealthRegionNotFound = apperrors.NotFound("health_region_not_found")
+
+type HealthRegionType string
+
+const (
+	HealthRegionMacro HealthRegionType = "macro"
+	HealthRegionMicro HealthRegionType = "micro"
+)
+
+// HealthRegion groups municipalities into a macrorregião or microrregião de saúde.
+// Microrregiões point to the macrorregião that contains them.
+type HealthRegion struct {
+	ID        int              `json:"id" db:"id"`
+	Name      string           `json:"name" db:"name"`
+	Type      HealthRegionType `json:"type" db:"type"`
+	ParentID  *int             `json:"parent_id" db:"parent_id"`
+	State     string           `json:"state" db:"state"`
+	CreatedAt time.Time        `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at" db:"updated_at"`
+
+	// Relations
+	MunicipalityIDs []int `json:"municipality_ids,omitempty"`
+}
+
+func (t HealthRegionType) IsValid() bool {
+	return t == HealthRegionMacro || t == HealthRegionMicro
+}
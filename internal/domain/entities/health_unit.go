@@ -0,0 +1,41 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/joaopanucci/apsdigital/internal/domain/apperrors"
+)
+
+// ErrHealthUnitNotFound is returned for unknown health units
+var ErrHealthUnitNotFound = apperrors.NotFound("health_unit_not_found")
+
+// HealthUnit is an establishment registered in the CNES (Cadastro Nacional de Estabelecimentos de Saúde)
+type HealthUnit struct {
+	ID             int       `json:"id" db:"id"`
+	CNES           string    `json:"cnes" db:"cnes"`
+	Name           string    `json:"name" db:"name"`
+	Type           string    `json:"type" db:"type"`
+	MunicipalityID int       `json:"municipality_id" db:"municipality_id"`
+	Active         bool      `json:"active" db:"active"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// CNESEstablishment is a row of a CNES establishments export
+type CNESEstablishment struct {
+	CNES string
+	Name string
+	Type string
+	// IBGECode has 6 digits in DATASUS files and 7 in IBGE ones
+	IBGECode string
+	Active   bool
+}
+
+// HealthUnitImportReport summarizes a CNES import
+type HealthUnitImportReport struct {
+	Total        int      `json:"total"`
+	Created      int      `json:"created"`
+	Updated      int      `json:"updated"`
+	Skipped      []string `json:"skipped"`
+	MatchedUsers int      `json:"matched_users"`
+}
@@ -0,0 +1,77 @@
+package entities
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type JobStatus string
+
+const (
+	JobPending   JobStatus = "pending"
+	JobRunning   JobStatus = "running"
+	JobSucceeded JobStatus = "succeeded"
+	JobFailed    JobStatus = "failed" // Out of attempts
+)
+
+// Background job types
+const (
+	JobPaymentPublished    = "payment.published"
+	JobResolutionPublished = "resolution.published"
+	JobTabletStolen        = "tablet.stolen"
+	JobRefreshTokenCleanup = "refresh_tokens.cleanup"
+	JobLiveEventCleanup    = "live_events.cleanup"
+	JobPrune               = "jobs.prune"
+)
+
+// DefaultJobMaxAttempts is how many times a job runs before it is marked failed
+const DefaultJobMaxAttempts = 5
+
+// Job is a unit of background work. Payload is the JSON its handler decodes.
+type Job struct {
+	ID          int64           `json:"id" db:"id"`
+	Type        string          `json:"type" db:"type"`
+	Payload     json.RawMessage `json:"payload" db:"payload"`
+	Status      JobStatus       `json:"status" db:"status"`
+	Attempts    int             `json:"attempts" db:"attempts"`
+	MaxAttempts int             `json:"max_attempts" db:"max_attempts"`
+	RunAt       time.Time       `json:"run_at" db:"run_at"`
+	LockedUntil *time.Time      `json:"locked_until" db:"locked_until"`
+	LastError   string          `json:"last_error" db:"last_error"`
+	// UniqueKey, when set, keeps the job from being queued again while a row with the same key exists
+	UniqueKey  string     `json:"unique_key" db:"unique_key"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	FinishedAt *time.Time `json:"finished_at" db:"finished_at"`
+}
+
+// NewJob builds a job of the given type that runs as soon as a worker is free
+func NewJob(jobType string, payload interface{}) (*Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Job{
+		Type:        jobType,
+		Payload:     data,
+		MaxAttempts: DefaultJobMaxAttempts,
+	}, nil
+}
+
+// PaymentPublishedJob announces a new payment file once its transaction commits
+type PaymentPublishedJob struct {
+	PaymentID string `json:"payment_id"`
+}
+
+// ResolutionPublishedJob announces a new resolution once its transaction commits
+type ResolutionPublishedJob struct {
+	ResolutionID string `json:"resolution_id"`
+}
+
+// TabletStolenJob announces a tablet reported stolen once its transaction commits
+type TabletStolenJob struct {
+	TabletID  int       `json:"tablet_id"`
+	RequestID uuid.UUID `json:"request_id"`
+}
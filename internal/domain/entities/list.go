@@ -0,0 +1,135 @@
+package entities
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	"github.com/joaopanucci/apsdigital/internal/domain/apperrors"
+)
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// SortField orders a list by one of the fields its endpoint allows
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// ListQuery is the pagination, sorting and filtering of a list request; F is the entity's filter struct.
+// Pages are addressed either by number or by the cursor returned in the previous page. A cursor
+// holds the sort key of the last row seen, so rows added or removed meanwhile don't shift the
+// next page.
+type ListQuery[F any] struct {
+	Page    int
+	Limit   int
+	Cursor  string
+	Sort    []SortField
+	Filters F
+}
+
+// Page is the response envelope of list endpoints
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	Total      int    `json:"total"`
+	Page       int    `json:"page,omitempty"`
+	Limit      int    `json:"limit"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// Normalize applies the default and maximum limit and validates the cursor
+func (q *ListQuery[F]) Normalize() error {
+	if q.Limit <= 0 {
+		q.Limit = DefaultPageLimit
+	}
+	if q.Limit > MaxPageLimit {
+		q.Limit = MaxPageLimit
+	}
+	if q.Page <= 0 {
+		q.Page = 1
+	}
+
+	if q.Cursor != "" {
+		if _, err := q.After(); err != nil {
+			return err
+		}
+		q.Page = 0
+	}
+
+	return nil
+}
+
+// Offset is the number of rows skipped before a numbered page. Cursor pages skip none:
+// they start right after the cursor.
+func (q ListQuery[F]) Offset() int {
+	if q.Cursor != "" || q.Page <= 1 {
+		return 0
+	}
+	return (q.Page - 1) * q.Limit
+}
+
+// After is the sort key the cursor points past: the text of each sort field of the last row
+// of the previous page, then its id. It is nil for numbered pages. A cursor is only valid
+// with the sort it was issued for.
+func (q ListQuery[F]) After() ([]*string, error) {
+	if q.Cursor == "" {
+		return nil, nil
+	}
+
+	c, err := decodeCursor(q.Cursor)
+	if err != nil || c.Sort != sortSpec(q.Sort) || len(c.Key) == 0 {
+		return nil, apperrors.Invalid("cursor", "cursor_invalid")
+	}
+
+	return c.Key, nil
+}
+
+// NewPage wraps the items of a query whose filters match total rows. next is the sort key of
+// the last item when more rows follow it, nil on the last page.
+func NewPage[T any, F any](items []T, total int, q ListQuery[F], next []*string) *Page[T] {
+	if items == nil {
+		items = []T{}
+	}
+
+	page := &Page[T]{Items: items, Total: total, Page: q.Page, Limit: q.Limit}
+	if next != nil {
+		page.NextCursor = encodeCursor(cursor{Sort: sortSpec(q.Sort), Key: next})
+	}
+
+	return page
+}
+
+type cursor struct {
+	Sort string    `json:"s"`
+	Key  []*string `json:"k"`
+}
+
+// sortSpec writes sort as clients send it, to tie cursors to their sort
+func sortSpec(sort []SortField) string {
+	fields := make([]string, len(sort))
+	for i, field := range sort {
+		fields[i] = field.Field
+		if field.Desc {
+			fields[i] = "-" + field.Field
+		}
+	}
+	return strings.Join(fields, ",")
+}
+
+// Cursors are opaque to clients so the encoding can change without breaking them
+func encodeCursor(c cursor) string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(value string) (cursor, error) {
+	var c cursor
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return c, err
+	}
+	return c, json.Unmarshal(raw, &c)
+}
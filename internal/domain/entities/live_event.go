@@ -0,0 +1,57 @@
+package entities
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/joaopanucci/apsdigital/internal/domain/apperrors"
+
+	"github.com/google/uuid"
+)
+
+// ErrLiveEventNotFound is returned for unknown live events
+var ErrLiveEventNotFound = apperrors.NotFound("live_event_not_found")
+
+type LiveEventKind string
+
+const (
+	LiveEventNotification LiveEventKind = "notification"
+	LiveEventEntity       LiveEventKind = "entity" // A record was created or changed
+)
+
+// LiveEvent is pushed to connected clients. It goes either to one user or to the users
+// holding Permission whose scope covers any of MunicipalityIDs.
+type LiveEvent struct {
+	ID              int64           `json:"id" db:"id"`
+	Kind            LiveEventKind   `json:"kind" db:"kind"`
+	Type            string          `json:"type" db:"type"`
+	UserID          *uuid.UUID      `json:"-" db:"user_id"`
+	MunicipalityIDs []int           `json:"-" db:"municipality_ids"`
+	Permission      string          `json:"-" db:"permission"`
+	Payload         json.RawMessage `json:"payload" db:"payload"`
+	CreatedAt       time.Time       `json:"created_at" db:"created_at"`
+}
+
+// LiveEventAudience is the user a stream belongs to, as of when it was opened
+type LiveEventAudience struct {
+	UserID      uuid.UUID
+	Scope       *MunicipalityScope
+	Permissions map[string]bool
+}
+
+// VisibleTo reports whether the event is for the audience
+func (e *LiveEvent) VisibleTo(a LiveEventAudience) bool {
+	if e.UserID != nil {
+		return *e.UserID == a.UserID
+	}
+	if e.Permission != "" && !a.Permissions[e.Permission] {
+		return false
+	}
+
+	for _, id := range e.MunicipalityIDs {
+		if a.Scope.Contains(&id) {
+			return true
+		}
+	}
+	return false
+}
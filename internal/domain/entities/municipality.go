@@ -2,14 +2,25 @@ package entities
 
 import (
 	"time"
+
+	"github.com/joaopanucci/apsdigital/internal/domain/apperrors"
 )
 
+// ErrMunicipalityNotFound is returned for unknown municipalities
+var ErrMunicipalityNotFound = apperrors.NotFound("municipality_not_found")
+
+// ErrMunicipalityNameTaken is returned when another municipality already has the name
+var ErrMunicipalityNameTaken = apperrors.Conflict("name_taken").WithMessage("municipality_name_taken").
+	WithFields(apperrors.FieldError{Field: "name", Code: "taken", Key: "municipality_name_taken"})
+
 type Municipality struct {
-	ID        int       `json:"id" db:"id"`
-	Name      string    `json:"name" db:"name"`
-	IBGECode  string    `json:"ibge_code" db:"ibge_code"`
-	State     string    `json:"state" db:"state"`
-	Active    bool      `json:"active" db:"active"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	ID             int       `json:"id" db:"id"`
+	Name           string    `json:"name" db:"name"`
+	IBGECode       string    `json:"ibge_code" db:"ibge_code"`
+	State          string    `json:"state" db:"state"`
+	Microregion    string    `json:"microregion" db:"microregion"`
+	HealthRegionID *int      `json:"health_region_id" db:"health_region_id"`
+	Active         bool      `json:"active" db:"active"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
 }
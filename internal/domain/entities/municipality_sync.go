@@ -0,0 +1,35 @@
+package entities
+
+// IBGEMunicipality is a municipality as published in the IBGE localities dataset
+type IBGEMunicipality struct {
+	IBGECode    string
+	Name        string
+	State       string // UF abbreviation, e.g. MS
+	Microregion string
+}
+
+// MunicipalityChange describes one municipality touched by a sync
+type MunicipalityChange struct {
+	ID       int                    `json:"id,omitempty"`
+	IBGECode string                 `json:"ibge_code"`
+	Name     string                 `json:"name"`
+	Changes  map[string]FieldChange `json:"changes,omitempty"`
+}
+
+// FieldChange is the old and new value of an updated field
+type FieldChange struct {
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+// MunicipalitySyncReport is the diff between the database and an IBGE dataset
+type MunicipalitySyncReport struct {
+	Source      string               `json:"source"`
+	DryRun      bool                 `json:"dry_run"`
+	Total       int                  `json:"total"`
+	Created     []MunicipalityChange `json:"created"`
+	Updated     []MunicipalityChange `json:"updated"`
+	Reactivated []MunicipalityChange `json:"reactivated"`
+	Deactivated []MunicipalityChange `json:"deactivated"`
+	Unchanged   int                  `json:"unchanged"`
+}
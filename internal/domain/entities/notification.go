@@ -0,0 +1,42 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type NotificationType string
+
+const (
+	NotificationUserPendingAuthorization NotificationType = "user_pending_authorization"
+	NotificationUserAuthorized           NotificationType = "user_authorized"
+	NotificationUserRejected             NotificationType = "user_rejected"
+	NotificationTabletRequestCreated     NotificationType = "tablet_request_created"
+	NotificationTabletRequestApproved    NotificationType = "tablet_request_approved"
+	NotificationTabletRequestRejected    NotificationType = "tablet_request_rejected"
+	NotificationPaymentPublished         NotificationType = "payment_published"
+	NotificationResolutionPublished      NotificationType = "resolution_published"
+)
+
+type Notification struct {
+	ID        int64            `json:"id" db:"id"`
+	UserID    uuid.UUID        `json:"user_id" db:"user_id"`
+	Type      NotificationType `json:"type" db:"type"`
+	Title     string           `json:"title" db:"title"`
+	Body      string           `json:"body" db:"body"`
+	Link      string           `json:"link" db:"link"` // API path of the related record
+	ReadAt    *time.Time       `json:"read_at" db:"read_at"`
+	CreatedAt time.Time        `json:"created_at" db:"created_at"`
+}
+
+// NotificationAudience selects who receives a notification: the listed users plus, when
+// Permission is set, the active users holding it whose scope covers MunicipalityID (any
+// municipality when nil) and whose role level is at most MaxLevel (any level when 0)
+type NotificationAudience struct {
+	UserIDs        []uuid.UUID
+	Permission     string
+	MunicipalityID *int
+	MaxLevel       int
+	Except         *uuid.UUID // Usually whoever caused the event
+}
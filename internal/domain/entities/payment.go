@@ -3,9 +3,14 @@ package entities
 import (
 	"time"
 
+	"github.com/joaopanucci/apsdigital/internal/domain/apperrors"
+
 	"github.com/google/uuid"
 )
 
+// ErrPaymentNotFound is returned for unknown payments
+var ErrPaymentNotFound = apperrors.NotFound("payment_not_found")
+
 type Payment struct {
 	ID               uuid.UUID  `json:"id" db:"id"`
 	FileURL          string     `json:"file_url" db:"file_url"`
@@ -0,0 +1,44 @@
+package entities
+
+import (
+	"time"
+)
+
+type Permission struct {
+	ID          int       `json:"id" db:"id"`
+	Name        string    `json:"name" db:"name"`
+	Description string    `json:"description" db:"description"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// Named permissions granted to roles through role_permissions
+const (
+	PermissionPaymentsView           = "payments.view"
+	PermissionPaymentsUpload         = "payments.upload"
+	PermissionPaymentsDelete         = "payments.delete"
+	PermissionResolutionsView        = "resolutions.view"
+	PermissionResolutionsUpload      = "resolutions.upload"
+	PermissionResolutionsDelete      = "resolutions.delete"
+	PermissionTabletsView            = "tablets.view"
+	PermissionTabletsManage          = "tablets.manage"
+	PermissionTabletsRequest         = "tablets.request"
+	PermissionTabletsApproveRequest  = "tablets.approve_request"
+	PermissionTabletsExport          = "tablets.export"
+	PermissionTabletsStats           = "tablets.stats"
+	PermissionTabletsAllocate        = "tablets.allocate"
+	PermissionTabletsTransferPropose = "tablets.transfer_propose"
+	PermissionTabletsTransferApprove = "tablets.transfer_approve"
+	PermissionTabletsTransferReceive = "tablets.transfer_receive"
+	PermissionUsersView              = "users.view"
+	PermissionUsersAuthorize         = "users.authorize"
+	PermissionUsersManageScope       = "users.manage_scope"
+	PermissionProfessionsManage      = "professions.manage"
+	PermissionRolesView              = "roles.view"
+	PermissionRolesManage            = "roles.manage"
+	PermissionHealthRegionsManage    = "health_regions.manage"
+	PermissionMunicipalitiesSync     = "municipalities.sync"
+	PermissionHealthUnitsManage      = "health_units.manage"
+	PermissionWebhooksManage         = "webhooks.manage"
+	// Access data of every municipality regardless of the user's scope
+	PermissionMunicipalitiesViewAll = "municipalities.view_all"
+)
@@ -2,8 +2,17 @@ package entities
 
 import (
 	"time"
+
+	"github.com/joaopanucci/apsdigital/internal/domain/apperrors"
 )
 
+// ErrProfessionNotFound is returned for unknown professions
+var ErrProfessionNotFound = apperrors.NotFound("profession_not_found")
+
+// ErrProfessionNameTaken is returned when another profession already has the name
+var ErrProfessionNameTaken = apperrors.Conflict("name_taken").WithMessage("profession_name_taken").
+	WithFields(apperrors.FieldError{Field: "name", Code: "taken", Key: "profession_name_taken"})
+
 type Profession struct {
 	ID        int       `json:"id" db:"id"`
 	Name      string    `json:"name" db:"name"`
@@ -3,9 +3,14 @@ package entities
 import (
 	"time"
 
+	"github.com/joaopanucci/apsdigital/internal/domain/apperrors"
+
 	"github.com/google/uuid"
 )
 
+// ErrResolutionNotFound is returned for unknown resolutions
+var ErrResolutionNotFound = apperrors.NotFound("resolution_not_found")
+
 type ResolutionType string
 
 const (
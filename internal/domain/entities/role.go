@@ -3,9 +3,17 @@ package entities
 import (
 	"time"
 
+	"github.com/joaopanucci/apsdigital/internal/domain/apperrors"
+
 	"github.com/google/uuid"
 )
 
+// ErrRoleNotFound is returned for unknown roles
+var ErrRoleNotFound = apperrors.NotFound("role_not_found")
+
+// ErrLevelNotAllowed is returned when a user reviews users above their own level
+var ErrLevelNotAllowed = apperrors.Forbidden("level_not_allowed")
+
 type Role struct {
 	ID          uuid.UUID `json:"id" db:"id"`
 	Name        string    `json:"name" db:"name"`
@@ -28,3 +36,18 @@ const (
 	LevelGerente     = 3
 	LevelACS         = 4
 )
+
+// CanAuthorizeLevel reports whether a user of actorLevel may authorize a user of targetLevel:
+// ADM authorizes anyone, Coordenador authorizes Gerente and ACS, Gerente authorizes ACS
+func CanAuthorizeLevel(actorLevel, targetLevel int) bool {
+	switch actorLevel {
+	case LevelAdmin:
+		return true
+	case LevelCoordenador:
+		return targetLevel >= LevelGerente
+	case LevelGerente:
+		return targetLevel == LevelACS
+	default:
+		return false
+	}
+}
@@ -3,9 +3,14 @@ package entities
 import (
 	"time"
 
+	"github.com/joaopanucci/apsdigital/internal/domain/apperrors"
+
 	"github.com/google/uuid"
 )
 
+// ErrTabletNotFound is returned for unknown tablets
+var ErrTabletNotFound = apperrors.NotFound("tablet_not_found")
+
 type TabletStatus string
 
 const (
@@ -16,6 +21,7 @@ const (
 	TabletStatusMaintenance TabletStatus = "manutencao"
 	TabletStatusAvailable   TabletStatus = "disponivel"
 	TabletStatusAssigned    TabletStatus = "atribuido"
+	TabletStatusInTransit   TabletStatus = "em_transito"
 )
 
 type Tablet struct {
@@ -36,4 +42,64 @@ type Tablet struct {
 	// Relations
 	AssignedUser *User         `json:"assigned_user,omitempty"`
 	Municipality *Municipality `json:"municipality,omitempty"`
+	LastEvent    *TabletEvent  `json:"last_event,omitempty"`
+}
+
+type TabletEventType string
+
+const (
+	TabletEventCreated       TabletEventType = "cadastro"
+	TabletEventAssigned      TabletEventType = "atribuicao"
+	TabletEventReturned      TabletEventType = "devolucao"
+	TabletEventStatusChanged TabletEventType = "status"
+	TabletEventTransferred   TabletEventType = "transferencia"
+)
+
+// TabletEvent is an entry of a tablet's history
+type TabletEvent struct {
+	ID         int64           `json:"id" db:"id"`
+	TabletID   int             `json:"tablet_id" db:"tablet_id"`
+	Type       TabletEventType `json:"type" db:"type"`
+	FromStatus TabletStatus    `json:"from_status" db:"from_status"`
+	ToStatus   TabletStatus    `json:"to_status" db:"to_status"`
+	UserID     *uuid.UUID      `json:"user_id" db:"user_id"` // Agent holding the tablet; for transfers, the user who took the step
+	Note       string          `json:"note" db:"note"`
+	CreatedAt  time.Time       `json:"created_at" db:"created_at"`
+
+	// Set on transfers between municipalities
+	FromMunicipalityID *int `json:"from_municipality_id,omitempty" db:"from_municipality_id"`
+	ToMunicipalityID   *int `json:"to_municipality_id,omitempty" db:"to_municipality_id"`
+}
+
+// TabletManifestRow is one device of a supplier delivery manifest
+type TabletManifestRow struct {
+	Line         int
+	SerialNumber string
+	Model        string
+	AssetCode    string
+	Municipality string // IBGE code or name
+}
+
+type TabletImportStatus string
+
+const (
+	TabletImportCreated TabletImportStatus = "created"
+	TabletImportFailed  TabletImportStatus = "failed"
+)
+
+// TabletImportResult is the outcome of one manifest row
+type TabletImportResult struct {
+	Line         int                `json:"line"`
+	SerialNumber string             `json:"serial_number"`
+	AssetCode    string             `json:"asset_code"`
+	Status       TabletImportStatus `json:"status"`
+	TabletID     *int               `json:"tablet_id,omitempty"`
+	Error        string             `json:"error,omitempty"`
+}
+
+type TabletImportReport struct {
+	Total   int                   `json:"total"`
+	Created int                   `json:"created"`
+	Failed  int                   `json:"failed"`
+	Rows    []*TabletImportResult `json:"rows"`
 }
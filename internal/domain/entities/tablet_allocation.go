@@ -0,0 +1,62 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/joaopanucci/apsdigital/internal/domain/apperrors"
+
+	"github.com/google/uuid"
+)
+
+// ErrAllocationConflict means an accepted plan no longer matches the data, because a
+// tablet or request it refers to changed since the plan was generated
+var ErrAllocationConflict = apperrors.Conflict("allocation_conflict")
+
+// AllocationRequest is a pending request for a new tablet from a user who holds none
+type AllocationRequest struct {
+	RequestID      uuid.UUID `json:"request_id"`
+	UserID         uuid.UUID `json:"user_id"`
+	UserName       string    `json:"user_name"`
+	MunicipalityID int       `json:"municipality_id"`
+	RequestedAt    time.Time `json:"requested_at"`
+}
+
+// MunicipalityAllocation is the tablet coverage of a municipality before and after a plan
+type MunicipalityAllocation struct {
+	MunicipalityID   int     `json:"municipality_id"`
+	MunicipalityName string  `json:"municipality_name"`
+	HealthRegionID   *int    `json:"health_region_id"`
+	ActiveACS        int     `json:"active_acs"`
+	ACSWithTablet    int     `json:"acs_with_tablet"`
+	Available        int     `json:"available"`
+	PendingRequests  int     `json:"pending_requests"`
+	CoverageBefore   float64 `json:"coverage_before"` // Percentage of active ACS with a tablet
+	CoverageAfter    float64 `json:"coverage_after"`
+}
+
+// PlannedAssignment gives a tablet to the user of a request, moving it first when it
+// comes from another municipality
+type PlannedAssignment struct {
+	RequestID          uuid.UUID `json:"request_id"`
+	TabletID           int       `json:"tablet_id"`
+	AssetCode          string    `json:"asset_code"`
+	SerialNumber       string    `json:"serial_number"`
+	UserID             uuid.UUID `json:"user_id"`
+	UserName           string    `json:"user_name"`
+	MunicipalityID     int       `json:"municipality_id"`
+	FromMunicipalityID *int      `json:"from_municipality_id,omitempty"`
+}
+
+type TabletAllocationPlan struct {
+	Assignments    []*PlannedAssignment      `json:"assignments"`
+	Transfers      int                       `json:"transfers"`
+	Unserved       []*AllocationRequest      `json:"unserved"`
+	Municipalities []*MunicipalityAllocation `json:"municipalities"`
+	GeneratedAt    time.Time                 `json:"generated_at"`
+}
+
+// AllocationDecision is one accepted assignment of a plan
+type AllocationDecision struct {
+	RequestID uuid.UUID `json:"request_id" binding:"required"`
+	TabletID  int       `json:"tablet_id" binding:"required"`
+}
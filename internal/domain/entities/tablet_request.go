@@ -3,9 +3,17 @@ package entities
 import (
 	"time"
 
+	"github.com/joaopanucci/apsdigital/internal/domain/apperrors"
+
 	"github.com/google/uuid"
 )
 
+// ErrTabletRequestNotFound is returned for unknown tablet requests
+var ErrTabletRequestNotFound = apperrors.NotFound("tablet_request_not_found")
+
+// ErrTabletRequestNotPending is returned when changing a request that was already decided
+var ErrTabletRequestNotPending = apperrors.Conflict("tablet_request_not_pending")
+
 type TabletRequestType string
 
 const (
@@ -24,6 +32,13 @@ const (
 	TabletRequestStatusCompleted  TabletRequestStatus = "completed"
 )
 
+// MaxTabletRequestPhotos is how many photos can be attached to one request
+const MaxTabletRequestPhotos = 5
+
+// ErrPoliceReportRequired means a theft request cannot be approved before the police
+// report (BO) is attached
+var ErrPoliceReportRequired = apperrors.Unprocessable("police_report_required")
+
 type TabletRequest struct {
 	ID             uuid.UUID           `json:"id" db:"id"`
 	UserID         uuid.UUID           `json:"user_id" db:"user_id"`
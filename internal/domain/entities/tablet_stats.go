@@ -0,0 +1,52 @@
+package entities
+
+import "time"
+
+// TabletStatsFilter narrows the tablet statistics. The date range applies to the
+// time based figures (maintenance periods and incidents); inventory counts are
+// always the current snapshot.
+type TabletStatsFilter struct {
+	MunicipalityID  *int
+	MunicipalityIDs []int // nil for every municipality
+	HealthRegionID  *int
+	From            *time.Time
+	To              *time.Time // exclusive
+}
+
+type TabletStats struct {
+	Municipalities     []*MunicipalityTabletStats `json:"municipalities"`
+	ACSCoverage        ACSTabletCoverage          `json:"acs_coverage"`
+	Maintenance        TabletMaintenanceStats     `json:"maintenance"`
+	IncidentsByQuarter []*TabletQuarterIncidents  `json:"incidents_by_quarter"`
+	GeneratedAt        time.Time                  `json:"generated_at"`
+}
+
+// MunicipalityTabletStats counts the tablets of a municipality by status
+type MunicipalityTabletStats struct {
+	MunicipalityID   int                  `json:"municipality_id"`
+	MunicipalityName string               `json:"municipality_name"`
+	ByStatus         map[TabletStatus]int `json:"by_status"`
+	Total            int                  `json:"total"`
+}
+
+// ACSTabletCoverage is how many active community health agents hold no tablet
+type ACSTabletCoverage struct {
+	ActiveACS         int     `json:"active_acs"`
+	WithoutTablet     int     `json:"without_tablet"`
+	WithoutTabletRate float64 `json:"without_tablet_rate"` // Percentage
+}
+
+type TabletMaintenanceStats struct {
+	Periods     int     `json:"periods"`
+	AverageDays float64 `json:"average_days"`
+}
+
+// TabletQuarterIncidents are the theft and breakage reports of a quarter against the
+// tablets registered by its end
+type TabletQuarterIncidents struct {
+	Quarter   string  `json:"quarter"` // e.g. 2026-Q1
+	Thefts    int     `json:"thefts"`
+	Breakages int     `json:"breakages"`
+	Inventory int     `json:"inventory"`
+	Rate      float64 `json:"rate"` // Incidents per 100 tablets
+}
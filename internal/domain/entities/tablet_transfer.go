@@ -0,0 +1,47 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/joaopanucci/apsdigital/internal/domain/apperrors"
+
+	"github.com/google/uuid"
+)
+
+// ErrTabletTransferNotFound is returned for unknown tablet transfers
+var ErrTabletTransferNotFound = apperrors.NotFound("tablet_transfer_not_found")
+
+type TabletTransferStatus string
+
+const (
+	TabletTransferPending  TabletTransferStatus = "pending"
+	TabletTransferApproved TabletTransferStatus = "approved" // Tablet in transit
+	TabletTransferReceived TabletTransferStatus = "received"
+	TabletTransferRejected TabletTransferStatus = "rejected"
+)
+
+// ErrTransferState means the transfer or its tablet is not in the state the step requires
+var ErrTransferState = apperrors.Conflict("transfer_state")
+
+// TabletTransfer moves a tablet between municipalities. Each step keeps who took it and when.
+type TabletTransfer struct {
+	ID                 int64                `json:"id" db:"id"`
+	TabletID           int                  `json:"tablet_id" db:"tablet_id"`
+	FromMunicipalityID int                  `json:"from_municipality_id" db:"from_municipality_id"`
+	ToMunicipalityID   int                  `json:"to_municipality_id" db:"to_municipality_id"`
+	Status             TabletTransferStatus `json:"status" db:"status"`
+	Reason             string               `json:"reason" db:"reason"`
+	ProposedBy         uuid.UUID            `json:"proposed_by" db:"proposed_by"`
+	ProposedAt         time.Time            `json:"proposed_at" db:"proposed_at"`
+	ApprovedBy         *uuid.UUID           `json:"approved_by" db:"approved_by"`
+	ApprovedAt         *time.Time           `json:"approved_at" db:"approved_at"`
+	ReceivedBy         *uuid.UUID           `json:"received_by" db:"received_by"`
+	ReceivedAt         *time.Time           `json:"received_at" db:"received_at"`
+	RejectedBy         *uuid.UUID           `json:"rejected_by" db:"rejected_by"`
+	RejectedAt         *time.Time           `json:"rejected_at" db:"rejected_at"`
+	RejectionReason    string               `json:"rejection_reason" db:"rejection_reason"`
+	UpdatedAt          time.Time            `json:"updated_at" db:"updated_at"`
+
+	// Relations
+	Tablet *Tablet `json:"tablet,omitempty"`
+}
@@ -3,9 +3,28 @@ package entities
 import (
 	"time"
 
+	"github.com/joaopanucci/apsdigital/internal/domain/apperrors"
+
 	"github.com/google/uuid"
 )
 
+// ErrUserNotFound is returned for unknown users
+var ErrUserNotFound = apperrors.NotFound("user_not_found")
+
+// ErrSessionNotFound is returned for unknown or revoked sessions
+var ErrSessionNotFound = apperrors.NotFound("session_not_found")
+
+// ErrAccountInactive is returned when a user that isn't active logs in or uses a session
+var ErrAccountInactive = apperrors.Forbidden("account_inactive")
+
+// ErrEmailTaken and ErrCPFTaken reject a registration reusing another user's e-mail or CPF
+var (
+	ErrEmailTaken = apperrors.Conflict("email_taken").
+			WithFields(apperrors.FieldError{Field: "email", Code: "taken", Key: "email_taken"})
+	ErrCPFTaken = apperrors.Conflict("cpf_taken").
+			WithFields(apperrors.FieldError{Field: "cpf", Code: "taken", Key: "cpf_taken"})
+)
+
 type UserStatus string
 
 const (
@@ -26,9 +45,12 @@ type User struct {
 	ProfessionID   *int       `json:"profession_id" db:"profession_id"`
 	Municipality   string     `json:"municipality" db:"municipality"` // Keep for backward compatibility
 	MunicipalityID *int       `json:"municipality_id" db:"municipality_id"`
-	Unit           string     `json:"unit" db:"unit"`
+	UnitID         *int       `json:"unit_id" db:"unit_id"`
 	Status         UserStatus `json:"status" db:"status"`
 	IsAuthorized   bool       `json:"is_authorized" db:"is_authorized"`
+	ScopeType      ScopeType  `json:"scope_type" db:"scope_type"`
+	HealthRegionID *int       `json:"health_region_id" db:"health_region_id"`
+	ScopeState     string     `json:"scope_state" db:"scope_state"`
 	ProfilePhoto   string     `json:"profile_photo" db:"profile_photo"`
 	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
@@ -40,10 +62,30 @@ type User struct {
 }
 
 type RefreshToken struct {
-	ID        uuid.UUID `json:"id" db:"id"`
-	UserID    uuid.UUID `json:"user_id" db:"user_id"`
-	Token     string    `json:"token" db:"token"`
-	ExpiresAt time.Time `json:"expires_at" db:"expires_at"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	IsRevoked bool      `json:"is_revoked" db:"is_revoked"`
+	ID         uuid.UUID  `json:"id" db:"id"`
+	UserID     uuid.UUID  `json:"user_id" db:"user_id"`
+	Token      string     `json:"-" db:"-"`          // Plaintext value, only set when the token is issued
+	TokenHash  string     `json:"-" db:"token_hash"` // SHA-256 of the plaintext token
+	FamilyID   uuid.UUID  `json:"family_id" db:"family_id"`
+	ReplacedBy *uuid.UUID `json:"replaced_by" db:"replaced_by"`
+	DeviceName string     `json:"device_name" db:"device_name"`
+	UserAgent  string     `json:"user_agent" db:"user_agent"`
+	IPAddress  string     `json:"ip_address" db:"ip_address"`
+	ExpiresAt  time.Time  `json:"expires_at" db:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at" db:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at" db:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	IsRevoked  bool       `json:"is_revoked" db:"is_revoked"`
+}
+
+// Session is a logged-in device, i.e. a refresh token family
+type Session struct {
+	ID         uuid.UUID `json:"id"` // Refresh token family ID
+	DeviceName string    `json:"device_name"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
 }
@@ -0,0 +1,68 @@
+package entities
+
+type ScopeType string
+
+const (
+	ScopeMunicipality ScopeType = "municipality"
+	ScopeRegion       ScopeType = "region"
+	ScopeState        ScopeType = "state"
+)
+
+// UserScope is the area a user works in: one municipality, a health region or a whole state
+type UserScope struct {
+	Type           ScopeType `json:"type"`
+	MunicipalityID *int      `json:"municipality_id,omitempty"`
+	HealthRegionID *int      `json:"health_region_id,omitempty"`
+	State          string    `json:"state,omitempty"`
+}
+
+// Scope returns the user's assigned scope
+func (u *User) Scope() UserScope {
+	scope := UserScope{Type: u.ScopeType}
+
+	switch u.ScopeType {
+	case ScopeRegion:
+		scope.HealthRegionID = u.HealthRegionID
+	case ScopeState:
+		scope.State = u.ScopeState
+	default:
+		scope.Type = ScopeMunicipality
+		scope.MunicipalityID = u.MunicipalityID
+	}
+
+	return scope
+}
+
+// MunicipalityScope is the set of municipalities whose data a user may see
+type MunicipalityScope struct {
+	All             bool
+	MunicipalityIDs []int
+}
+
+// Contains reports whether records of the given municipality are visible
+func (s *MunicipalityScope) Contains(municipalityID *int) bool {
+	if s.All {
+		return true
+	}
+	if municipalityID == nil {
+		return false
+	}
+
+	for _, id := range s.MunicipalityIDs {
+		if id == *municipalityID {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter returns the municipality IDs to restrict queries to, or nil when unrestricted
+func (s *MunicipalityScope) Filter() []int {
+	if s.All {
+		return nil
+	}
+	if s.MunicipalityIDs == nil {
+		return []int{}
+	}
+	return s.MunicipalityIDs
+}
@@ -0,0 +1,118 @@
+package entities
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/joaopanucci/apsdigital/internal/domain/apperrors"
+
+	"github.com/google/uuid"
+)
+
+// Events sent to webhooks
+const (
+	WebhookEventPaymentPublished = "payment.published"
+	WebhookEventTabletStolen     = "tablet.stolen"
+	// Sent on demand to check a subscription's endpoint
+	WebhookEventPing = "ping"
+)
+
+// WebhookEventTypes are the events a subscription may ask for
+var WebhookEventTypes = []string{
+	WebhookEventPaymentPublished,
+	WebhookEventTabletStolen,
+}
+
+// MaxWebhookAttempts is how many times a delivery is tried before it is marked failed
+const MaxWebhookAttempts = 10
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliveryDelivered WebhookDeliveryStatus = "delivered"
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "failed"
+)
+
+// ErrWebhookNotFound is returned for unknown subscriptions and deliveries
+var ErrWebhookNotFound = apperrors.NotFound("webhook_not_found")
+
+// WebhookSubscription sends the chosen events of a municipality to an external URL. The
+// secret signs every payload and is only shown when created or rotated.
+type WebhookSubscription struct {
+	ID             uuid.UUID  `json:"id" db:"id"`
+	MunicipalityID int        `json:"municipality_id" db:"municipality_id"`
+	Name           string     `json:"name" db:"name"`
+	URL            string     `json:"url" db:"url"`
+	Secret         string     `json:"-" db:"secret"`
+	EventTypes     []string   `json:"event_types" db:"event_types"`
+	Active         bool       `json:"active" db:"active"`
+	CreatedBy      *uuid.UUID `json:"created_by" db:"created_by"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Wants reports whether the subscription receives the event type
+func (s *WebhookSubscription) Wants(eventType string) bool {
+	for _, t := range s.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookEvent is the JSON body sent to subscribers. ID is the same for every
+// subscription and attempt, so receivers can discard duplicates.
+type WebhookEvent struct {
+	ID             uuid.UUID   `json:"id"`
+	Type           string      `json:"type"`
+	MunicipalityID int         `json:"municipality_id"`
+	CreatedAt      time.Time   `json:"created_at"`
+	Data           interface{} `json:"data"`
+}
+
+// PaymentPublishedData is the data of a payment.published event. Webhooks reach systems
+// outside the state, so they carry the file's identification and nothing about who sent it.
+type PaymentPublishedData struct {
+	ID               uuid.UUID `json:"id"`
+	MunicipalityID   int       `json:"municipality_id"`
+	MunicipalityName string    `json:"municipality_name"`
+	Competence       string    `json:"competence"` // YYYY-MM format
+	CompetenceYear   int       `json:"competence_year"`
+	FileName         string    `json:"file_name"`
+	PublishedAt      time.Time `json:"published_at"`
+}
+
+// TabletStolenData is the data of a tablet.stolen event. The agent who held the tablet is
+// left out, as the payment data leaves out the uploader.
+type TabletStolenData struct {
+	TabletID       int       `json:"tablet_id"`
+	AssetCode      string    `json:"asset_code"`
+	Model          string    `json:"model"`
+	SerialNumber   string    `json:"serial_number"`
+	MunicipalityID int       `json:"municipality_id"`
+	RequestID      uuid.UUID `json:"request_id"`
+	ReportedAt     time.Time `json:"reported_at"`
+}
+
+// WebhookDelivery is one event queued for one subscription, with the result of its last attempt
+type WebhookDelivery struct {
+	ID             int64                 `json:"id" db:"id"`
+	SubscriptionID uuid.UUID             `json:"subscription_id" db:"subscription_id"`
+	EventID        uuid.UUID             `json:"event_id" db:"event_id"`
+	EventType      string                `json:"event_type" db:"event_type"`
+	Payload        json.RawMessage       `json:"payload" db:"payload"`
+	Status         WebhookDeliveryStatus `json:"status" db:"status"`
+	Attempts       int                   `json:"attempts" db:"attempts"`
+	NextAttemptAt  *time.Time            `json:"next_attempt_at" db:"next_attempt_at"`
+	LastAttemptAt  *time.Time            `json:"last_attempt_at" db:"last_attempt_at"`
+	ResponseStatus *int                  `json:"response_status" db:"response_status"`
+	LastError      string                `json:"last_error" db:"last_error"`
+	DeliveredAt    *time.Time            `json:"delivered_at" db:"delivered_at"`
+	ReplayOf       *int64                `json:"replay_of" db:"replay_of"`
+	CreatedAt      time.Time             `json:"created_at" db:"created_at"`
+
+	// Relations, loaded when the delivery is claimed for sending
+	Subscription *WebhookSubscription `json:"-"`
+}
@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/joaopanucci/apsdigital/internal/domain/entities"
@@ -14,28 +15,92 @@ type UserRepository interface {
 	GetByCPF(ctx context.Context, cpf string) (*entities.User, error)
 	Update(ctx context.Context, user *entities.User) error
 	Delete(ctx context.Context, id uuid.UUID) error
-	List(ctx context.Context, filters map[string]interface{}) ([]*entities.User, error)
+	List(ctx context.Context, q entities.ListQuery[entities.UserFilter]) (*entities.Page[*entities.User], error)
 	GetPendingAuthorization(ctx context.Context) ([]*entities.User, error)
+	CountPendingAuthorization(ctx context.Context) (int, error)
 	AuthorizeUser(ctx context.Context, userID uuid.UUID) error
+	UpdateScope(ctx context.Context, userID uuid.UUID, scope entities.UserScope) error
 }
 
 type MunicipalityRepository interface {
 	Create(ctx context.Context, municipality *entities.Municipality) error
 	GetByID(ctx context.Context, id int) (*entities.Municipality, error)
 	GetByName(ctx context.Context, name string) (*entities.Municipality, error)
-	List(ctx context.Context) ([]*entities.Municipality, error)
+	List(ctx context.Context, q entities.ListQuery[entities.MunicipalityFilter]) (*entities.Page[*entities.Municipality], error)
 	Update(ctx context.Context, municipality *entities.Municipality) error
 	Delete(ctx context.Context, id int) error
+	ListIDsByState(ctx context.Context, state string) ([]int, error)
+	// ListAll includes inactive municipalities
+	ListAll(ctx context.Context) ([]*entities.Municipality, error)
+	// ApplySync upserts by IBGE code and deactivates the given IDs atomically
+	ApplySync(ctx context.Context, upserts []*entities.Municipality, deactivateIDs []int) error
+}
+
+type HealthRegionRepository interface {
+	Create(ctx context.Context, region *entities.HealthRegion) error
+	GetByID(ctx context.Context, id int) (*entities.HealthRegion, error)
+	List(ctx context.Context) ([]*entities.HealthRegion, error)
+	Update(ctx context.Context, region *entities.HealthRegion) error
+	Delete(ctx context.Context, id int) error
+	// ListMunicipalityIDs returns the municipalities of the region and of its sub-regions
+	ListMunicipalityIDs(ctx context.Context, regionID int) ([]int, error)
+	SetMunicipalities(ctx context.Context, regionID int, municipalityIDs []int) error
+}
+
+type HealthUnitRepository interface {
+	Create(ctx context.Context, unit *entities.HealthUnit) error
+	GetByID(ctx context.Context, id int) (*entities.HealthUnit, error)
+	List(ctx context.Context, q entities.ListQuery[entities.HealthUnitFilter]) (*entities.Page[*entities.HealthUnit], error)
+	Update(ctx context.Context, unit *entities.HealthUnit) error
+	Delete(ctx context.Context, id int) error
+	// UpsertByCNES creates or updates units by CNES code and returns how many were created and updated
+	UpsertByCNES(ctx context.Context, units []*entities.HealthUnit) (created int, updated int, err error)
+	// MatchUserUnits links users whose legacy free-text unit matches a unit and returns how many were linked
+	MatchUserUnits(ctx context.Context) (int, error)
 }
 
 type TabletRepository interface {
 	Create(ctx context.Context, tablet *entities.Tablet) error
+	// CreateBatch returns one error per tablet, nil for the ones created
+	CreateBatch(ctx context.Context, tablets []*entities.Tablet) ([]error, error)
 	GetByID(ctx context.Context, id int) (*entities.Tablet, error)
+	GetByAssetCode(ctx context.Context, assetCode string) (*entities.Tablet, error)
 	GetByUserCPF(ctx context.Context, cpf string) ([]*entities.Tablet, error)
 	GetByAssignedUser(ctx context.Context, userID uuid.UUID) ([]*entities.Tablet, error)
-	List(ctx context.Context, filters map[string]interface{}) ([]*entities.Tablet, error)
+	List(ctx context.Context, q entities.ListQuery[entities.TabletFilter]) (*entities.Page[*entities.Tablet], error)
 	Update(ctx context.Context, tablet *entities.Tablet) error
 	Delete(ctx context.Context, id int) error
+	AddEvent(ctx context.Context, event *entities.TabletEvent) error
+}
+
+// TabletTransferRepository stores transfers; every step updates the transfer and its
+// tablet in one transaction and fails with ErrTransferState when the step is not allowed
+type TabletTransferRepository interface {
+	Create(ctx context.Context, transfer *entities.TabletTransfer) error
+	GetByID(ctx context.Context, id int64) (*entities.TabletTransfer, error)
+	List(ctx context.Context, q entities.ListQuery[entities.TabletTransferFilter]) (*entities.Page[*entities.TabletTransfer], error)
+	Approve(ctx context.Context, id int64, approvedBy uuid.UUID) error
+	Reject(ctx context.Context, id int64, rejectedBy uuid.UUID, reason string) error
+	Receive(ctx context.Context, id int64, receivedBy uuid.UUID) error
+}
+
+// TabletAllocationRepository reads the demand and stock of tablets and applies allocation plans.
+// A nil municipalityIDs means every municipality.
+type TabletAllocationRepository interface {
+	PendingNewRequests(ctx context.Context, municipalityIDs []int) ([]*entities.AllocationRequest, error)
+	MunicipalityCoverage(ctx context.Context, municipalityIDs []int) ([]*entities.MunicipalityAllocation, error)
+	AvailableTablets(ctx context.Context, municipalityIDs []int) ([]*entities.Tablet, error)
+	// ApplyAllocation assigns and transfers the tablets and completes the requests in one
+	// transaction, failing with ErrAllocationConflict when any decision is stale
+	ApplyAllocation(ctx context.Context, decisions []entities.AllocationDecision, municipalityIDs []int, approvedBy uuid.UUID) error
+}
+
+// TabletStatsRepository computes the aggregate figures of the tablet dashboard
+type TabletStatsRepository interface {
+	CountByMunicipalityStatus(ctx context.Context, f entities.TabletStatsFilter) ([]*entities.MunicipalityTabletStats, error)
+	ACSCoverage(ctx context.Context, f entities.TabletStatsFilter) (*entities.ACSTabletCoverage, error)
+	MaintenanceDurations(ctx context.Context, f entities.TabletStatsFilter) (*entities.TabletMaintenanceStats, error)
+	IncidentsByQuarter(ctx context.Context, f entities.TabletStatsFilter) ([]*entities.TabletQuarterIncidents, error)
 }
 
 type RoleRepository interface {
@@ -45,13 +110,17 @@ type RoleRepository interface {
 	List(ctx context.Context) ([]*entities.Role, error)
 	Update(ctx context.Context, role *entities.Role) error
 	Delete(ctx context.Context, id uuid.UUID) error
+	ListPermissions(ctx context.Context) ([]*entities.Permission, error)
+	GetPermissions(ctx context.Context, roleID uuid.UUID) ([]string, error)
+	// SetPermissions replaces the role's permissions with the given names
+	SetPermissions(ctx context.Context, roleID uuid.UUID, permissions []string) error
 }
 
 type ProfessionRepository interface {
 	Create(ctx context.Context, profession *entities.Profession) error
 	GetByID(ctx context.Context, id int) (*entities.Profession, error)
 	GetByName(ctx context.Context, name string) (*entities.Profession, error)
-	GetAll(ctx context.Context) ([]*entities.Profession, error)
+	GetAll(ctx context.Context, q entities.ListQuery[entities.ProfessionFilter]) (*entities.Page[*entities.Profession], error)
 	Update(ctx context.Context, profession *entities.Profession) error
 	Delete(ctx context.Context, id int) error
 }
@@ -59,9 +128,78 @@ type ProfessionRepository interface {
 type TabletRequestRepository interface {
 	Create(ctx context.Context, request *entities.TabletRequest) error
 	GetByID(ctx context.Context, id uuid.UUID) (*entities.TabletRequest, error)
-	List(ctx context.Context, filters map[string]interface{}) ([]*entities.TabletRequest, error)
+	List(ctx context.Context, q entities.ListQuery[entities.TabletRequestFilter]) (*entities.Page[*entities.TabletRequest], error)
 	Update(ctx context.Context, request *entities.TabletRequest) error
 	GetByUserID(ctx context.Context, userID uuid.UUID) ([]*entities.TabletRequest, error)
+	AddPhoto(ctx context.Context, id uuid.UUID, url string) error
+	SetDocument(ctx context.Context, id uuid.UUID, url string) error
+	CountPending(ctx context.Context) (int, error)
+}
+
+// NotificationRepository stores notifications; recipients are resolved from the users'
+// roles, permissions and municipality scope
+type NotificationRepository interface {
+	ResolveRecipients(ctx context.Context, audience entities.NotificationAudience) ([]uuid.UUID, error)
+	CreateForUsers(ctx context.Context, notification *entities.Notification, userIDs []uuid.UUID) error
+	List(ctx context.Context, q entities.ListQuery[entities.NotificationFilter]) (*entities.Page[*entities.Notification], error)
+	CountUnread(ctx context.Context, userID uuid.UUID) (int, error)
+	MarkRead(ctx context.Context, userID uuid.UUID, ids []int64) (int, error)
+	MarkAllRead(ctx context.Context, userID uuid.UUID) (int, error)
+}
+
+// LiveEventRepository reads the events pushed to connected clients
+type LiveEventRepository interface {
+	GetByID(ctx context.Context, id int64) (*entities.LiveEvent, error)
+	// ListAfter returns up to limit events after afterID, oldest first
+	ListAfter(ctx context.Context, afterID int64, limit int) ([]*entities.LiveEvent, error)
+	// ListVisibleAfter is ListAfter restricted to the events of the audience
+	ListVisibleAfter(ctx context.Context, afterID int64, audience entities.LiveEventAudience, limit int) ([]*entities.LiveEvent, error)
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// WebhookRepository stores webhook subscriptions and their outbox of deliveries
+type WebhookRepository interface {
+	Create(ctx context.Context, subscription *entities.WebhookSubscription) error
+	GetByID(ctx context.Context, id uuid.UUID) (*entities.WebhookSubscription, error)
+	List(ctx context.Context, filter entities.WebhookFilter) ([]*entities.WebhookSubscription, error)
+	Update(ctx context.Context, subscription *entities.WebhookSubscription) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	// Enqueue queues the event for every active subscription of its municipality that wants
+	// it and returns how many deliveries were queued
+	Enqueue(ctx context.Context, event *entities.WebhookEvent, payload []byte) (int, error)
+	// EnqueueFor queues the event for one subscription, whatever its event types
+	EnqueueFor(ctx context.Context, subscriptionID uuid.UUID, event *entities.WebhookEvent, payload []byte) (*entities.WebhookDelivery, error)
+	GetDelivery(ctx context.Context, id int64) (*entities.WebhookDelivery, error)
+	ListDeliveries(ctx context.Context, q entities.ListQuery[entities.WebhookDeliveryFilter]) (*entities.Page[*entities.WebhookDelivery], error)
+	// Replay queues a copy of a delivery to be sent again
+	Replay(ctx context.Context, id int64) (*entities.WebhookDelivery, error)
+	// ClaimDue locks up to limit pending deliveries that are due, for lease, so other
+	// workers skip them while they are being sent
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*entities.WebhookDelivery, error)
+	MarkDelivered(ctx context.Context, id int64, responseStatus int) error
+	// MarkAttemptFailed records a failed attempt; a nil retryAt marks the delivery failed
+	MarkAttemptFailed(ctx context.Context, id int64, responseStatus *int, message string, retryAt *time.Time) error
+}
+
+// Transactor runs fn in a transaction that the repositories called with its ctx join
+type Transactor interface {
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// JobRepository is the background job queue. Enqueue joins the caller's transaction, so
+// a job is only queued if the writes it follows commit.
+type JobRepository interface {
+	// Enqueue queues the job; with a UniqueKey already taken it does nothing and returns false
+	Enqueue(ctx context.Context, job *entities.Job) (bool, error)
+	// Claim locks up to limit due jobs of the given types until lease passes
+	Claim(ctx context.Context, types []string, limit int, lease time.Duration) ([]*entities.Job, error)
+	Complete(ctx context.Context, id int64) error
+	// Fail records a failed run; a nil retryAt marks the job failed for good
+	Fail(ctx context.Context, id int64, message string, retryAt *time.Time) error
+	// Release hands back a job interrupted by shutdown to run again now, giving back the
+	// attempt its claim counted
+	Release(ctx context.Context, id int64, message string) error
+	DeleteFinishedBefore(ctx context.Context, status entities.JobStatus, before time.Time) (int64, error)
 }
 
 type AuthorizationRepository interface {
@@ -75,7 +213,7 @@ type AuthorizationRepository interface {
 type PaymentRepositoryInterface interface {
 	Create(ctx context.Context, payment *entities.Payment) error
 	GetByID(ctx context.Context, id uint) (*entities.Payment, error)
-	GetAll(ctx context.Context, filters map[string]interface{}) ([]*entities.Payment, error)
+	GetAll(ctx context.Context, q entities.ListQuery[entities.PaymentFilter]) (*entities.Page[*entities.Payment], error)
 	Update(ctx context.Context, payment *entities.Payment) error
 	Delete(ctx context.Context, id uint) error
 	GetCompetences(ctx context.Context, municipalityID *uint) ([]string, error)
@@ -85,7 +223,7 @@ type PaymentRepositoryInterface interface {
 type ResolutionRepositoryInterface interface {
 	Create(ctx context.Context, resolution *entities.Resolution) error
 	GetByID(ctx context.Context, id uint) (*entities.Resolution, error)
-	GetAll(ctx context.Context, filters map[string]interface{}) ([]*entities.Resolution, error)
+	GetAll(ctx context.Context, q entities.ListQuery[entities.ResolutionFilter]) (*entities.Page[*entities.Resolution], error)
 	Update(ctx context.Context, resolution *entities.Resolution) error
 	Delete(ctx context.Context, id uint) error
 	GetTypes(ctx context.Context, municipalityID *uint) ([]string, error)
@@ -95,8 +233,15 @@ type ResolutionRepositoryInterface interface {
 
 type RefreshTokenRepository interface {
 	Create(ctx context.Context, token *entities.RefreshToken) error
-	GetByToken(ctx context.Context, token string) (*entities.RefreshToken, error)
+	// GetByTokenHash returns the token even when revoked or expired so callers can detect reuse
+	GetByTokenHash(ctx context.Context, tokenHash string) (*entities.RefreshToken, error)
+	// Rotate revokes the old token and stores its replacement atomically. It fails if the old token was already revoked.
+	Rotate(ctx context.Context, oldID uuid.UUID, newToken *entities.RefreshToken) error
+	RevokeFamily(ctx context.Context, familyID uuid.UUID) error
+	RevokeUserFamily(ctx context.Context, userID, familyID uuid.UUID) error
 	RevokeByUserID(ctx context.Context, userID uuid.UUID) error
-	RevokeToken(ctx context.Context, token string) error
-	CleanupExpired(ctx context.Context) error
+	// IsFamilyActive reports whether a session has a refresh token that is neither revoked nor expired
+	IsFamilyActive(ctx context.Context, familyID uuid.UUID) (bool, error)
+	ListSessions(ctx context.Context, userID uuid.UUID) ([]*entities.Session, error)
+	CleanupExpired(ctx context.Context) (int64, error)
 }
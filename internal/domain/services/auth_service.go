@@ -3,11 +3,15 @@ package services
 import (
 	"context"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/joaopanucci/apsdigital/internal/config"
+	"github.com/joaopanucci/apsdigital/internal/domain/apperrors"
 	"github.com/joaopanucci/apsdigital/internal/domain/entities"
 	"github.com/joaopanucci/apsdigital/internal/domain/repositories"
 	"github.com/joaopanucci/apsdigital/internal/utils"
@@ -17,11 +21,31 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+// sessionCacheTTL bounds how long an access token keeps working after its session is
+// revoked on another replica
+const sessionCacheTTL = 30 * time.Second
+
+type cachedSession struct {
+	active   bool
+	loadedAt time.Time
+}
+
 type AuthService struct {
 	userRepo         repositories.UserRepository
 	refreshTokenRepo repositories.RefreshTokenRepository
 	roleRepo         repositories.RoleRepository
+	tokenSigner      TokenSigner
 	config           *config.Config
+	notifications    *NotificationService
+
+	mu       sync.RWMutex
+	sessions map[uuid.UUID]cachedSession // by session (token family) id
+	sweptAt  time.Time
+}
+
+// TokenSigner signs access token claims with the active key
+type TokenSigner interface {
+	Sign(claims jwt.Claims) (string, error)
 }
 
 type Claims struct {
@@ -30,12 +54,21 @@ type Claims struct {
 	Role         string    `json:"role"`
 	Level        int       `json:"level"`
 	IsAuthorized bool      `json:"is_authorized"`
+	SessionID    uuid.UUID `json:"sid"`
 	jwt.RegisteredClaims
 }
 
 type LoginRequest struct {
-	CPF      string `json:"cpf" binding:"required"`
-	Password string `json:"password" binding:"required"`
+	CPF        string `json:"cpf" binding:"required"`
+	Password   string `json:"password" binding:"required"`
+	DeviceName string `json:"device_name"`
+}
+
+// DeviceInfo describes the client a session was opened from
+type DeviceInfo struct {
+	Name      string
+	UserAgent string
+	IPAddress string
 }
 
 type LoginResponse struct {
@@ -53,22 +86,28 @@ type RegisterRequest struct {
 	RoleID         uuid.UUID `json:"role_id" binding:"required"`
 	ProfessionID   *int      `json:"profession_id"`
 	MunicipalityID *int      `json:"municipality_id" binding:"required"`
-	Unit           string    `json:"unit"`
+	UnitID         *int      `json:"unit_id"`
 }
 
-func NewAuthService(userRepo repositories.UserRepository, refreshTokenRepo repositories.RefreshTokenRepository, roleRepo repositories.RoleRepository, config *config.Config) *AuthService {
+func NewAuthService(userRepo repositories.UserRepository, refreshTokenRepo repositories.RefreshTokenRepository, roleRepo repositories.RoleRepository, tokenSigner TokenSigner, config *config.Config, notifications *NotificationService) *AuthService {
 	return &AuthService{
 		userRepo:         userRepo,
 		refreshTokenRepo: refreshTokenRepo,
 		roleRepo:         roleRepo,
+		tokenSigner:      tokenSigner,
 		config:           config,
+		notifications:    notifications,
+		sessions:         make(map[uuid.UUID]cachedSession),
 	}
 }
 
 func (s *AuthService) Register(ctx context.Context, req *RegisterRequest) (*entities.User, error) {
+	ctx, span := tracer.Start(ctx, "AuthService.Register")
+	defer span.End()
+
 	// Validate CPF
 	if !utils.ValidateCPF(req.CPF) {
-		return nil, fmt.Errorf("CPF inválido")
+		return nil, apperrors.Invalid("cpf", "cpf_invalid")
 	}
 
 	// Clean CPF for storage
@@ -76,24 +115,27 @@ func (s *AuthService) Register(ctx context.Context, req *RegisterRequest) (*enti
 
 	// Validate role - prevent ADM registration
 	role, err := s.roleRepo.GetByID(ctx, req.RoleID)
+	if errors.Is(err, entities.ErrRoleNotFound) {
+		return nil, apperrors.Invalid("role_id", "role_invalid")
+	}
 	if err != nil {
-		return nil, fmt.Errorf("invalid role")
+		return nil, fmt.Errorf("failed to get role: %w", err)
 	}
 
 	// Block ADM role registration (level 1 is ADM)
 	if role.Level == 1 || role.Name == "ADM" {
-		return nil, fmt.Errorf("ADM role registration is not allowed")
+		return nil, apperrors.Invalid("role_id", "role_admin_not_allowed")
 	}
 
 	// Check if user already exists
 	existingUser, _ := s.userRepo.GetByEmail(ctx, req.Email)
 	if existingUser != nil {
-		return nil, fmt.Errorf("user with this email already exists")
+		return nil, entities.ErrEmailTaken
 	}
 
 	existingUserCPF, _ := s.userRepo.GetByCPF(ctx, req.CPF)
 	if existingUserCPF != nil {
-		return nil, fmt.Errorf("user with this CPF already exists")
+		return nil, entities.ErrCPFTaken
 	}
 
 	// Hash password
@@ -112,7 +154,7 @@ func (s *AuthService) Register(ctx context.Context, req *RegisterRequest) (*enti
 		RoleID:         req.RoleID,
 		ProfessionID:   req.ProfessionID,
 		MunicipalityID: req.MunicipalityID,
-		Unit:           req.Unit,
+		UnitID:         req.UnitID,
 		Status:         entities.UserStatusPendingAuthorization,
 		IsAuthorized:   false, // Requer autorização por padrão
 	}
@@ -121,89 +163,142 @@ func (s *AuthService) Register(ctx context.Context, req *RegisterRequest) (*enti
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
 
+	s.notifications.UserPendingAuthorization(ctx, user, role.Level)
+
 	return user, nil
 }
 
-func (s *AuthService) Login(ctx context.Context, req *LoginRequest) (*LoginResponse, error) {
+func (s *AuthService) Login(ctx context.Context, req *LoginRequest, device DeviceInfo) (*LoginResponse, error) {
+	ctx, span := tracer.Start(ctx, "AuthService.Login")
+	defer span.End()
+
 	// Validate and clean CPF
 	if !utils.ValidateCPF(req.CPF) {
-		return nil, fmt.Errorf("credenciais inválidas")
+		return nil, ErrInvalidCredentials
 	}
 	req.CPF = utils.CleanCPF(req.CPF)
 
 	// Get user by CPF
 	user, err := s.userRepo.GetByCPF(ctx, req.CPF)
+	if errors.Is(err, entities.ErrUserNotFound) {
+		return nil, ErrInvalidCredentials
+	}
 	if err != nil {
-		return nil, fmt.Errorf("credenciais inválidas")
+		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
 	// Check if user is active
 	if user.Status != entities.UserStatusActive {
-		return nil, fmt.Errorf("conta de usuário não está ativa")
+		return nil, entities.ErrAccountInactive
 	}
 
 	// Check if user is authorized (new requirement)
 	if !user.IsAuthorized {
-		return nil, fmt.Errorf("seu cadastro ainda não foi autorizado")
+		return nil, ErrAccountUnauthorized
 	}
 
 	// Verify password
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
-		return nil, fmt.Errorf("invalid credentials")
+		return nil, ErrInvalidCredentials
 	}
 
-	// Generate tokens
-	accessToken, err := s.generateAccessToken(user)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate access token: %w", err)
+	if device.Name == "" {
+		device.Name = req.DeviceName
 	}
 
-	refreshToken, err := s.generateRefreshToken(ctx, user.ID)
+	// Every login starts a new session (token family), other devices stay logged in
+	refreshToken, err := s.newRefreshToken(user.ID, uuid.New(), device)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
 	}
 
+	if err := s.refreshTokenRepo.Create(ctx, refreshToken); err != nil {
+		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	accessToken, err := s.generateAccessToken(user, refreshToken.FamilyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate access token: %w", err)
+	}
+
 	// Clear password from response
 	user.Password = ""
 
 	return &LoginResponse{
 		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
+		RefreshToken: refreshToken.Token,
 		User:         user,
 	}, nil
 }
 
-func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string) (*LoginResponse, error) {
+func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string, device DeviceInfo) (*LoginResponse, error) {
+	ctx, span := tracer.Start(ctx, "AuthService.RefreshToken")
+	defer span.End()
+
 	// Validate refresh token
-	token, err := s.refreshTokenRepo.GetByToken(ctx, refreshToken)
+	token, err := s.refreshTokenRepo.GetByTokenHash(ctx, hashRefreshToken(refreshToken))
+	if errors.Is(err, entities.ErrSessionNotFound) {
+		return nil, ErrInvalidRefreshToken
+	}
 	if err != nil {
-		return nil, fmt.Errorf("invalid refresh token")
+		return nil, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+
+	if token.IsRevoked {
+		// A token that was already rotated is being presented again: it was
+		// stolen or replayed, so the whole session is no longer trustworthy
+		if token.ReplacedBy != nil {
+			if err := s.refreshTokenRepo.RevokeFamily(ctx, token.FamilyID); err != nil {
+				return nil, fmt.Errorf("failed to revoke session: %w", err)
+			}
+			s.cacheSession(token.FamilyID, false)
+			return nil, ErrRefreshTokenReused
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+
+	if time.Now().After(token.ExpiresAt) {
+		return nil, ErrRefreshTokenExpired
 	}
 
 	// Get user
 	user, err := s.userRepo.GetByID(ctx, token.UserID)
 	if err != nil {
-		return nil, fmt.Errorf("user not found")
+		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
 	if user.Status != entities.UserStatusActive {
-		return nil, fmt.Errorf("user account is not active")
+		return nil, entities.ErrAccountInactive
+	}
+
+	// Keep the device metadata from login unless the client reports new values
+	if device.Name == "" {
+		device.Name = token.DeviceName
+	}
+	if device.UserAgent == "" {
+		device.UserAgent = token.UserAgent
+	}
+	if device.IPAddress == "" {
+		device.IPAddress = token.IPAddress
 	}
 
-	// Revoke old refresh token
-	if err := s.refreshTokenRepo.RevokeToken(ctx, refreshToken); err != nil {
-		return nil, fmt.Errorf("failed to revoke old token: %w", err)
+	newRefreshToken, err := s.newRefreshToken(user.ID, token.FamilyID, device)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
 	}
 
-	// Generate new tokens
-	accessToken, err := s.generateAccessToken(user)
+	// Losing the race against a concurrent refresh of the same token
+	err = s.refreshTokenRepo.Rotate(ctx, token.ID, newRefreshToken)
+	if errors.Is(err, entities.ErrSessionNotFound) {
+		return nil, ErrInvalidRefreshToken
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate access token: %w", err)
+		return nil, fmt.Errorf("failed to rotate refresh token: %w", err)
 	}
 
-	newRefreshToken, err := s.generateRefreshToken(ctx, user.ID)
+	accessToken, err := s.generateAccessToken(user, token.FamilyID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+		return nil, fmt.Errorf("failed to generate access token: %w", err)
 	}
 
 	// Clear password from response
@@ -211,16 +306,106 @@ func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string) (*L
 
 	return &LoginResponse{
 		AccessToken:  accessToken,
-		RefreshToken: newRefreshToken,
+		RefreshToken: newRefreshToken.Token,
 		User:         user,
 	}, nil
 }
 
+// Logout ends the session the refresh token belongs to
 func (s *AuthService) Logout(ctx context.Context, refreshToken string) error {
-	return s.refreshTokenRepo.RevokeToken(ctx, refreshToken)
+	ctx, span := tracer.Start(ctx, "AuthService.Logout")
+	defer span.End()
+
+	token, err := s.refreshTokenRepo.GetByTokenHash(ctx, hashRefreshToken(refreshToken))
+	if errors.Is(err, entities.ErrSessionNotFound) {
+		return ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get refresh token: %w", err)
+	}
+
+	if err := s.refreshTokenRepo.RevokeFamily(ctx, token.FamilyID); err != nil {
+		return err
+	}
+	s.cacheSession(token.FamilyID, false)
+
+	return nil
+}
+
+// ListSessions returns the active sessions of a user, flagging the one the request came from
+func (s *AuthService) ListSessions(ctx context.Context, userID, currentSessionID uuid.UUID) ([]*entities.Session, error) {
+	ctx, span := tracer.Start(ctx, "AuthService.ListSessions")
+	defer span.End()
+
+	sessions, err := s.refreshTokenRepo.ListSessions(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	for _, session := range sessions {
+		session.Current = session.ID == currentSessionID
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession logs a single device out
+func (s *AuthService) RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "AuthService.RevokeSession")
+	defer span.End()
+
+	if err := s.refreshTokenRepo.RevokeUserFamily(ctx, userID, sessionID); err != nil {
+		return err
+	}
+	s.cacheSession(sessionID, false)
+
+	return nil
+}
+
+// IsSessionActive reports whether the session an access token was issued for is still
+// open, so logging a device out also stops its access token and not only its refreshes
+func (s *AuthService) IsSessionActive(ctx context.Context, sessionID uuid.UUID) (bool, error) {
+	s.mu.RLock()
+	cached, ok := s.sessions[sessionID]
+	s.mu.RUnlock()
+
+	if ok && time.Since(cached.loadedAt) < sessionCacheTTL {
+		return cached.active, nil
+	}
+
+	ctx, span := tracer.Start(ctx, "AuthService.IsSessionActive")
+	defer span.End()
+
+	active, err := s.refreshTokenRepo.IsFamilyActive(ctx, sessionID)
+	if err != nil {
+		return false, fmt.Errorf("failed to check session: %w", err)
+	}
+
+	s.cacheSession(sessionID, active)
+
+	return active, nil
+}
+
+func (s *AuthService) cacheSession(sessionID uuid.UUID, active bool) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Drop stale entries once per TTL so the cache holds the sessions in use instead of
+	// every session ever seen
+	if now.Sub(s.sweptAt) >= sessionCacheTTL {
+		for id, cached := range s.sessions {
+			if now.Sub(cached.loadedAt) >= sessionCacheTTL {
+				delete(s.sessions, id)
+			}
+		}
+		s.sweptAt = now
+	}
+	s.sessions[sessionID] = cachedSession{active: active, loadedAt: now}
 }
 
-func (s *AuthService) generateAccessToken(user *entities.User) (string, error) {
+func (s *AuthService) generateAccessToken(user *entities.User, sessionID uuid.UUID) (string, error) {
 	expirationTime, err := time.ParseDuration(s.config.JWT.Expiration)
 	if err != nil {
 		expirationTime = 24 * time.Hour
@@ -232,21 +417,22 @@ func (s *AuthService) generateAccessToken(user *entities.User) (string, error) {
 		Role:         user.Role.Name,
 		Level:        user.Role.Level,
 		IsAuthorized: user.IsAuthorized,
+		SessionID:    sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expirationTime)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(s.config.JWT.Secret))
+	return s.tokenSigner.Sign(claims)
 }
 
-func (s *AuthService) generateRefreshToken(ctx context.Context, userID uuid.UUID) (string, error) {
+// newRefreshToken builds a random refresh token for the given family; only its hash is persisted
+func (s *AuthService) newRefreshToken(userID, familyID uuid.UUID, device DeviceInfo) (*entities.RefreshToken, error) {
 	// Generate random token
 	bytes := make([]byte, 32)
 	if _, err := rand.Read(bytes); err != nil {
-		return "", err
+		return nil, err
 	}
 	tokenString := hex.EncodeToString(bytes)
 
@@ -255,21 +441,19 @@ func (s *AuthService) generateRefreshToken(ctx context.Context, userID uuid.UUID
 		expirationTime = 7 * 24 * time.Hour
 	}
 
-	// Revoke existing refresh tokens for this user
-	if err := s.refreshTokenRepo.RevokeByUserID(ctx, userID); err != nil {
-		return "", err
-	}
-
-	// Save refresh token
-	refreshToken := &entities.RefreshToken{
-		UserID:    userID,
-		Token:     tokenString,
-		ExpiresAt: time.Now().Add(expirationTime),
-	}
-
-	if err := s.refreshTokenRepo.Create(ctx, refreshToken); err != nil {
-		return "", err
-	}
+	return &entities.RefreshToken{
+		UserID:     userID,
+		Token:      tokenString,
+		TokenHash:  hashRefreshToken(tokenString),
+		FamilyID:   familyID,
+		DeviceName: device.Name,
+		UserAgent:  device.UserAgent,
+		IPAddress:  device.IPAddress,
+		ExpiresAt:  time.Now().Add(expirationTime),
+	}, nil
+}
 
-	return tokenString, nil
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
@@ -0,0 +1,18 @@
+package services
+
+import "github.com/joaopanucci/apsdigital/internal/domain/apperrors"
+
+// Authentication errors. Login doesn't tell an unknown CPF from a wrong password.
+var (
+	ErrInvalidCredentials  = apperrors.Unauthorized("invalid_credentials")
+	ErrAccountUnauthorized = apperrors.Forbidden("account_not_authorized")
+	ErrInvalidRefreshToken = apperrors.Unauthorized("invalid_refresh_token")
+	ErrRefreshTokenExpired = apperrors.Unauthorized("session_expired")
+	ErrRefreshTokenReused  = apperrors.Unauthorized("session_revoked")
+)
+
+// ErrUserNotPending is returned when authorizing or rejecting a user that was already reviewed
+var ErrUserNotPending = apperrors.Conflict("user_not_pending")
+
+// ErrAgentNotFound is returned when no user has the CPF a tablet request was opened for
+var ErrAgentNotFound = apperrors.NotFound("agent_not_found")
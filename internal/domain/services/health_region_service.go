@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/joaopanucci/apsdigital/internal/domain/apperrors"
+	"github.com/joaopanucci/apsdigital/internal/domain/entities"
+	"github.com/joaopanucci/apsdigital/internal/domain/repositories"
+)
+
+type HealthRegionService struct {
+	regionRepo repositories.HealthRegionRepository
+}
+
+func NewHealthRegionService(regionRepo repositories.HealthRegionRepository) *HealthRegionService {
+	return &HealthRegionService{
+		regionRepo: regionRepo,
+	}
+}
+
+func (s *HealthRegionService) GetAll(ctx context.Context) ([]*entities.HealthRegion, error) {
+	ctx, span := tracer.Start(ctx, "HealthRegionService.GetAll")
+	defer span.End()
+
+	regions, err := s.regionRepo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get health regions: %w", err)
+	}
+
+	return regions, nil
+}
+
+func (s *HealthRegionService) GetByID(ctx context.Context, id int) (*entities.HealthRegion, error) {
+	ctx, span := tracer.Start(ctx, "HealthRegionService.GetByID")
+	defer span.End()
+
+	region, err := s.regionRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := s.regionRepo.ListMunicipalityIDs(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get region municipalities: %w", err)
+	}
+	region.MunicipalityIDs = ids
+
+	return region, nil
+}
+
+func (s *HealthRegionService) Create(ctx context.Context, region *entities.HealthRegion) error {
+	ctx, span := tracer.Start(ctx, "HealthRegionService.Create")
+	defer span.End()
+
+	if err := s.validate(ctx, region); err != nil {
+		return err
+	}
+
+	return s.regionRepo.Create(ctx, region)
+}
+
+func (s *HealthRegionService) Update(ctx context.Context, region *entities.HealthRegion) error {
+	ctx, span := tracer.Start(ctx, "HealthRegionService.Update")
+	defer span.End()
+
+	if _, err := s.regionRepo.GetByID(ctx, region.ID); err != nil {
+		return err
+	}
+
+	if err := s.validate(ctx, region); err != nil {
+		return err
+	}
+
+	return s.regionRepo.Update(ctx, region)
+}
+
+func (s *HealthRegionService) Delete(ctx context.Context, id int) error {
+	ctx, span := tracer.Start(ctx, "HealthRegionService.Delete")
+	defer span.End()
+
+	return s.regionRepo.Delete(ctx, id)
+}
+
+// SetMunicipalities replaces the municipalities that belong directly to a region
+func (s *HealthRegionService) SetMunicipalities(ctx context.Context, regionID int, municipalityIDs []int) error {
+	ctx, span := tracer.Start(ctx, "HealthRegionService.SetMunicipalities")
+	defer span.End()
+
+	if _, err := s.regionRepo.GetByID(ctx, regionID); err != nil {
+		return err
+	}
+
+	unique := make(map[int]bool, len(municipalityIDs))
+	ids := make([]int, 0, len(municipalityIDs))
+	for _, id := range municipalityIDs {
+		if !unique[id] {
+			unique[id] = true
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+
+	return s.regionRepo.SetMunicipalities(ctx, regionID, ids)
+}
+
+func (s *HealthRegionService) validate(ctx context.Context, region *entities.HealthRegion) error {
+	region.Name = strings.TrimSpace(region.Name)
+	region.State = strings.ToUpper(strings.TrimSpace(region.State))
+
+	if region.Name == "" {
+		return apperrors.Required("name")
+	}
+	if !region.Type.IsValid() {
+		return apperrors.Invalid("type", "region_type_invalid")
+	}
+	if len(region.State) != 2 {
+		return apperrors.Invalid("state", "state_invalid")
+	}
+
+	if region.ParentID == nil {
+		return nil
+	}
+
+	// Only microrregiões nest, and only inside a macrorregião of the same state
+	if region.Type != entities.HealthRegionMicro {
+		return apperrors.Invalid("parent_id", "parent_region_not_allowed")
+	}
+
+	parent, err := s.regionRepo.GetByID(ctx, *region.ParentID)
+	if errors.Is(err, entities.ErrHealthRegionNotFound) {
+		return apperrors.Invalid("parent_id", "parent_region_not_found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get parent region: %w", err)
+	}
+	if parent.Type != entities.HealthRegionMacro || parent.State != region.State {
+		return apperrors.Invalid("parent_id", "parent_region_invalid")
+	}
+
+	return nil
+}
@@ -0,0 +1,180 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/joaopanucci/apsdigital/internal/domain/apperrors"
+	"github.com/joaopanucci/apsdigital/internal/domain/entities"
+	"github.com/joaopanucci/apsdigital/internal/domain/repositories"
+)
+
+// CNESParser reads a CNES establishments export
+type CNESParser interface {
+	Parse(r io.Reader) ([]entities.CNESEstablishment, error)
+}
+
+type HealthUnitService struct {
+	unitRepo         repositories.HealthUnitRepository
+	municipalityRepo repositories.MunicipalityRepository
+	parser           CNESParser
+}
+
+func NewHealthUnitService(unitRepo repositories.HealthUnitRepository, municipalityRepo repositories.MunicipalityRepository, parser CNESParser) *HealthUnitService {
+	return &HealthUnitService{
+		unitRepo:         unitRepo,
+		municipalityRepo: municipalityRepo,
+		parser:           parser,
+	}
+}
+
+func (s *HealthUnitService) GetAll(ctx context.Context, q entities.ListQuery[entities.HealthUnitFilter]) (*entities.Page[*entities.HealthUnit], error) {
+	ctx, span := tracer.Start(ctx, "HealthUnitService.GetAll")
+	defer span.End()
+
+	page, err := s.unitRepo.List(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get health units: %w", err)
+	}
+
+	return page, nil
+}
+
+func (s *HealthUnitService) GetByID(ctx context.Context, id int) (*entities.HealthUnit, error) {
+	ctx, span := tracer.Start(ctx, "HealthUnitService.GetByID")
+	defer span.End()
+
+	return s.unitRepo.GetByID(ctx, id)
+}
+
+func (s *HealthUnitService) Create(ctx context.Context, unit *entities.HealthUnit) error {
+	ctx, span := tracer.Start(ctx, "HealthUnitService.Create")
+	defer span.End()
+
+	if err := s.validate(ctx, unit); err != nil {
+		return err
+	}
+
+	return s.unitRepo.Create(ctx, unit)
+}
+
+func (s *HealthUnitService) Update(ctx context.Context, unit *entities.HealthUnit) error {
+	ctx, span := tracer.Start(ctx, "HealthUnitService.Update")
+	defer span.End()
+
+	if err := s.validate(ctx, unit); err != nil {
+		return err
+	}
+
+	return s.unitRepo.Update(ctx, unit)
+}
+
+func (s *HealthUnitService) Delete(ctx context.Context, id int) error {
+	ctx, span := tracer.Start(ctx, "HealthUnitService.Delete")
+	defer span.End()
+
+	return s.unitRepo.Delete(ctx, id)
+}
+
+// ImportCNES upserts the establishments of a CNES export by CNES code and links users whose
+// legacy unit text matches one of them. Rows of municipalities that are unknown or outside
+// the scope are skipped.
+func (s *HealthUnitService) ImportCNES(ctx context.Context, r io.Reader, scope *entities.MunicipalityScope) (*entities.HealthUnitImportReport, error) {
+	ctx, span := tracer.Start(ctx, "HealthUnitService.ImportCNES")
+	defer span.End()
+
+	establishments, err := s.parser.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(establishments) == 0 {
+		return nil, apperrors.InvalidFile("file_no_establishments")
+	}
+
+	municipalities, err := s.municipalityRepo.ListAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get municipalities: %w", err)
+	}
+
+	// DATASUS uses the IBGE code without the check digit
+	byCode := make(map[string]int, len(municipalities))
+	for _, municipality := range municipalities {
+		if len(municipality.IBGECode) == 7 {
+			byCode[municipality.IBGECode[:6]] = municipality.ID
+		}
+	}
+
+	report := &entities.HealthUnitImportReport{Total: len(establishments), Skipped: []string{}}
+
+	// A CNES listed twice keeps its last row
+	position := make(map[string]int, len(establishments))
+	var units []*entities.HealthUnit
+	for _, e := range establishments {
+		municipalityID, ok := byCode[e.IBGECode[:6]]
+		if !ok {
+			report.Skipped = append(report.Skipped, fmt.Sprintf("%s: municipality %s not found", e.CNES, e.IBGECode))
+			continue
+		}
+		if !scope.Contains(&municipalityID) {
+			report.Skipped = append(report.Skipped, fmt.Sprintf("%s: municipality %s is outside your scope", e.CNES, e.IBGECode))
+			continue
+		}
+
+		unit := &entities.HealthUnit{
+			CNES:           e.CNES,
+			Name:           e.Name,
+			Type:           e.Type,
+			MunicipalityID: municipalityID,
+			Active:         e.Active,
+		}
+		if i, ok := position[e.CNES]; ok {
+			units[i] = unit
+			continue
+		}
+		position[e.CNES] = len(units)
+		units = append(units, unit)
+	}
+
+	if len(units) == 0 {
+		return report, nil
+	}
+
+	report.Created, report.Updated, err = s.unitRepo.UpsertByCNES(ctx, units)
+	if err != nil {
+		return nil, fmt.Errorf("failed to import health units: %w", err)
+	}
+
+	report.MatchedUsers, err = s.unitRepo.MatchUserUnits(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to link users to health units: %w", err)
+	}
+
+	return report, nil
+}
+
+func (s *HealthUnitService) validate(ctx context.Context, unit *entities.HealthUnit) error {
+	unit.CNES = strings.TrimSpace(unit.CNES)
+	unit.Name = strings.TrimSpace(unit.Name)
+	unit.Type = strings.TrimSpace(unit.Type)
+
+	if _, err := strconv.Atoi(unit.CNES); err != nil || len(unit.CNES) != 7 {
+		return apperrors.Invalid("cnes", "cnes_length")
+	}
+	if unit.Name == "" {
+		return apperrors.Required("name")
+	}
+
+	_, err := s.municipalityRepo.GetByID(ctx, unit.MunicipalityID)
+	if errors.Is(err, entities.ErrMunicipalityNotFound) {
+		return apperrors.Invalid("municipality_id", "municipality_not_found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get municipality: %w", err)
+	}
+
+	return nil
+}
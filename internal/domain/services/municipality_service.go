@@ -3,31 +3,55 @@ package services
 import (
 	"context"
 	"fmt"
+	"io"
+	"sort"
+	"strings"
 
+	"github.com/joaopanucci/apsdigital/internal/domain/apperrors"
 	"github.com/joaopanucci/apsdigital/internal/domain/entities"
 	"github.com/joaopanucci/apsdigital/internal/domain/repositories"
 )
 
+// LocalityLoader reads the IBGE localities dataset
+type LocalityLoader interface {
+	Load(ctx context.Context, source string) ([]entities.IBGEMunicipality, error)
+	Parse(r io.Reader, format string) ([]entities.IBGEMunicipality, error)
+}
+
+type MunicipalitySyncOptions struct {
+	// State limits the sync to one UF
+	State  string
+	DryRun bool
+}
+
 type MunicipalityService struct {
 	municipalityRepo repositories.MunicipalityRepository
+	localities       LocalityLoader
 }
 
-func NewMunicipalityService(municipalityRepo repositories.MunicipalityRepository) *MunicipalityService {
+func NewMunicipalityService(municipalityRepo repositories.MunicipalityRepository, localities LocalityLoader) *MunicipalityService {
 	return &MunicipalityService{
 		municipalityRepo: municipalityRepo,
+		localities:       localities,
 	}
 }
 
-func (s *MunicipalityService) GetAll(ctx context.Context) ([]*entities.Municipality, error) {
-	municipalities, err := s.municipalityRepo.List(ctx)
+func (s *MunicipalityService) GetAll(ctx context.Context, q entities.ListQuery[entities.MunicipalityFilter]) (*entities.Page[*entities.Municipality], error) {
+	ctx, span := tracer.Start(ctx, "MunicipalityService.GetAll")
+	defer span.End()
+
+	page, err := s.municipalityRepo.List(ctx, q)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get municipalities: %w", err)
 	}
 	
-	return municipalities, nil
+	return page, nil
 }
 
 func (s *MunicipalityService) GetByID(ctx context.Context, id int) (*entities.Municipality, error) {
+	ctx, span := tracer.Start(ctx, "MunicipalityService.GetByID")
+	defer span.End()
+
 	municipality, err := s.municipalityRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get municipality: %w", err)
@@ -37,6 +61,9 @@ func (s *MunicipalityService) GetByID(ctx context.Context, id int) (*entities.Mu
 }
 
 func (s *MunicipalityService) GetByName(ctx context.Context, name string) (*entities.Municipality, error) {
+	ctx, span := tracer.Start(ctx, "MunicipalityService.GetByName")
+	defer span.End()
+
 	municipality, err := s.municipalityRepo.GetByName(ctx, name)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get municipality by name: %w", err)
@@ -46,10 +73,13 @@ func (s *MunicipalityService) GetByName(ctx context.Context, name string) (*enti
 }
 
 func (s *MunicipalityService) Create(ctx context.Context, municipality *entities.Municipality) error {
+	ctx, span := tracer.Start(ctx, "MunicipalityService.Create")
+	defer span.End()
+
 	// Check if municipality with this name already exists
 	existing, _ := s.municipalityRepo.GetByName(ctx, municipality.Name)
 	if existing != nil {
-		return fmt.Errorf("municipality with name '%s' already exists", municipality.Name)
+		return entities.ErrMunicipalityNameTaken
 	}
 	
 	if err := s.municipalityRepo.Create(ctx, municipality); err != nil {
@@ -60,17 +90,20 @@ func (s *MunicipalityService) Create(ctx context.Context, municipality *entities
 }
 
 func (s *MunicipalityService) Update(ctx context.Context, municipality *entities.Municipality) error {
+	ctx, span := tracer.Start(ctx, "MunicipalityService.Update")
+	defer span.End()
+
 	// Check if municipality exists
 	existing, err := s.municipalityRepo.GetByID(ctx, municipality.ID)
 	if err != nil {
-		return fmt.Errorf("municipality not found")
+		return err
 	}
 	
 	// Check if another municipality with this name exists (excluding current one)
 	if existing.Name != municipality.Name {
 		nameCheck, _ := s.municipalityRepo.GetByName(ctx, municipality.Name)
 		if nameCheck != nil && nameCheck.ID != municipality.ID {
-			return fmt.Errorf("municipality with name '%s' already exists", municipality.Name)
+			return entities.ErrMunicipalityNameTaken
 		}
 	}
 	
@@ -82,10 +115,13 @@ func (s *MunicipalityService) Update(ctx context.Context, municipality *entities
 }
 
 func (s *MunicipalityService) Delete(ctx context.Context, id int) error {
+	ctx, span := tracer.Start(ctx, "MunicipalityService.Delete")
+	defer span.End()
+
 	// Check if municipality exists
 	_, err := s.municipalityRepo.GetByID(ctx, id)
 	if err != nil {
-		return fmt.Errorf("municipality not found")
+		return err
 	}
 	
 	if err := s.municipalityRepo.Delete(ctx, id); err != nil {
@@ -94,3 +130,162 @@ func (s *MunicipalityService) Delete(ctx context.Context, id int) error {
 	
 	return nil
 }
+
+// SyncFromIBGE imports the IBGE localities dataset from a file path or URL
+func (s *MunicipalityService) SyncFromIBGE(ctx context.Context, source string, opts MunicipalitySyncOptions) (*entities.MunicipalitySyncReport, error) {
+	ctx, span := tracer.Start(ctx, "MunicipalityService.SyncFromIBGE")
+	defer span.End()
+
+	records, err := s.localities.Load(ctx, source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load IBGE data: %w", err)
+	}
+
+	return s.syncMunicipalities(ctx, source, records, opts)
+}
+
+// SyncFromIBGEData imports an already opened IBGE dataset, e.g. an uploaded file
+func (s *MunicipalityService) SyncFromIBGEData(ctx context.Context, name string, r io.Reader, format string, opts MunicipalitySyncOptions) (*entities.MunicipalitySyncReport, error) {
+	ctx, span := tracer.Start(ctx, "MunicipalityService.SyncFromIBGEData")
+	defer span.End()
+
+	records, err := s.localities.Parse(r, format)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load IBGE data: %w", err)
+	}
+
+	return s.syncMunicipalities(ctx, name, records, opts)
+}
+
+// syncMunicipalities upserts the dataset by IBGE code and deactivates municipalities missing from it.
+// Rows are never deleted, so users, payments and tablets keep their references.
+func (s *MunicipalityService) syncMunicipalities(ctx context.Context, source string, records []entities.IBGEMunicipality, opts MunicipalitySyncOptions) (*entities.MunicipalitySyncReport, error) {
+	state := strings.ToUpper(strings.TrimSpace(opts.State))
+
+	incoming := make(map[string]entities.IBGEMunicipality, len(records))
+	// Only municipalities of the states present in the dataset can be missing from it
+	coveredStates := make(map[string]bool)
+	coveredPrefixes := make(map[string]bool)
+	for _, record := range records {
+		if state != "" && record.State != state {
+			continue
+		}
+		if _, exists := incoming[record.IBGECode]; exists {
+			return nil, apperrors.InvalidFile("file_ibge_code_repeated", record.IBGECode)
+		}
+		incoming[record.IBGECode] = record
+		coveredStates[record.State] = true
+		coveredPrefixes[record.IBGECode[:2]] = true
+	}
+
+	if len(incoming) == 0 {
+		return nil, apperrors.InvalidFile("file_no_municipalities")
+	}
+
+	existing, err := s.municipalityRepo.ListAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get municipalities: %w", err)
+	}
+
+	report := &entities.MunicipalitySyncReport{
+		Source:      source,
+		DryRun:      opts.DryRun,
+		Total:       len(incoming),
+		Created:     []entities.MunicipalityChange{},
+		Updated:     []entities.MunicipalityChange{},
+		Reactivated: []entities.MunicipalityChange{},
+		Deactivated: []entities.MunicipalityChange{},
+	}
+
+	var upserts []*entities.Municipality
+	var deactivateIDs []int
+	seen := make(map[string]bool, len(incoming))
+
+	for _, municipality := range existing {
+		record, found := incoming[municipality.IBGECode]
+		if !found {
+			inScope := coveredStates[municipality.State] ||
+				(municipality.State == "" && len(municipality.IBGECode) >= 2 && coveredPrefixes[municipality.IBGECode[:2]])
+			if municipality.Active && inScope {
+				deactivateIDs = append(deactivateIDs, municipality.ID)
+				report.Deactivated = append(report.Deactivated, entities.MunicipalityChange{
+					ID: municipality.ID, IBGECode: municipality.IBGECode, Name: municipality.Name,
+				})
+			}
+			continue
+		}
+		seen[record.IBGECode] = true
+
+		change := entities.MunicipalityChange{ID: municipality.ID, IBGECode: record.IBGECode, Name: record.Name}
+		change.Changes = diffMunicipality(municipality, record)
+
+		switch {
+		case !municipality.Active:
+			report.Reactivated = append(report.Reactivated, change)
+		case len(change.Changes) > 0:
+			report.Updated = append(report.Updated, change)
+		default:
+			report.Unchanged++
+			continue
+		}
+
+		upserts = append(upserts, municipalityFromIBGE(record))
+	}
+
+	for code, record := range incoming {
+		if seen[code] {
+			continue
+		}
+		upserts = append(upserts, municipalityFromIBGE(record))
+		report.Created = append(report.Created, entities.MunicipalityChange{IBGECode: record.IBGECode, Name: record.Name})
+	}
+
+	if !opts.DryRun && (len(upserts) > 0 || len(deactivateIDs) > 0) {
+		if err := s.municipalityRepo.ApplySync(ctx, upserts, deactivateIDs); err != nil {
+			return nil, fmt.Errorf("failed to apply IBGE sync: %w", err)
+		}
+
+		ids := make(map[string]int, len(upserts))
+		for _, municipality := range upserts {
+			ids[municipality.IBGECode] = municipality.ID
+		}
+		for i := range report.Created {
+			report.Created[i].ID = ids[report.Created[i].IBGECode]
+		}
+	}
+
+	for _, changes := range [][]entities.MunicipalityChange{report.Created, report.Updated, report.Reactivated, report.Deactivated} {
+		sort.Slice(changes, func(i, j int) bool { return changes[i].IBGECode < changes[j].IBGECode })
+	}
+
+	return report, nil
+}
+
+func municipalityFromIBGE(record entities.IBGEMunicipality) *entities.Municipality {
+	return &entities.Municipality{
+		Name:        record.Name,
+		IBGECode:    record.IBGECode,
+		State:       record.State,
+		Microregion: record.Microregion,
+		Active:      true,
+	}
+}
+
+func diffMunicipality(municipality *entities.Municipality, record entities.IBGEMunicipality) map[string]entities.FieldChange {
+	changes := make(map[string]entities.FieldChange)
+
+	if municipality.Name != record.Name {
+		changes["name"] = entities.FieldChange{Old: municipality.Name, New: record.Name}
+	}
+	if municipality.State != record.State {
+		changes["state"] = entities.FieldChange{Old: municipality.State, New: record.State}
+	}
+	if municipality.Microregion != record.Microregion {
+		changes["microregion"] = entities.FieldChange{Old: municipality.Microregion, New: record.Microregion}
+	}
+
+	if len(changes) == 0 {
+		return nil
+	}
+	return changes
+}
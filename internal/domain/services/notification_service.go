@@ -0,0 +1,233 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/joaopanucci/apsdigital/internal/domain/apperrors"
+	"github.com/joaopanucci/apsdigital/internal/domain/entities"
+	"github.com/joaopanucci/apsdigital/internal/domain/repositories"
+
+	"github.com/google/uuid"
+)
+
+// NotificationService delivers in-app notifications. Other services call it when
+// something happens; a failed notification is logged and never fails the caller.
+type NotificationService struct {
+	notificationRepo repositories.NotificationRepository
+}
+
+func NewNotificationService(notificationRepo repositories.NotificationRepository) *NotificationService {
+	return &NotificationService{
+		notificationRepo: notificationRepo,
+	}
+}
+
+// Notify sends the notification to everyone in the audience, once each
+func (s *NotificationService) Notify(ctx context.Context, audience entities.NotificationAudience, notification *entities.Notification) {
+	ctx, span := tracer.Start(ctx, "NotificationService.Notify")
+	defer span.End()
+
+	recipients, err := s.notificationRepo.ResolveRecipients(ctx, audience)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error resolving notification recipients", "type", notification.Type, "error", err)
+		return
+	}
+
+	seen := make(map[uuid.UUID]bool, len(recipients)+len(audience.UserIDs))
+	var userIDs []uuid.UUID
+	for _, id := range append(audience.UserIDs, recipients...) {
+		if seen[id] || (audience.Except != nil && id == *audience.Except) {
+			continue
+		}
+		seen[id] = true
+		userIDs = append(userIDs, id)
+	}
+	if len(userIDs) == 0 {
+		return
+	}
+
+	if err := s.notificationRepo.CreateForUsers(ctx, notification, userIDs); err != nil {
+		slog.ErrorContext(ctx, "Error creating notification", "type", notification.Type, "error", err)
+	}
+}
+
+// UserPendingAuthorization tells the users who may authorize a new registration about it
+func (s *NotificationService) UserPendingAuthorization(ctx context.Context, user *entities.User, roleLevel int) {
+	ctx, span := tracer.Start(ctx, "NotificationService.UserPendingAuthorization")
+	defer span.End()
+
+	s.Notify(ctx, entities.NotificationAudience{
+		Permission:     entities.PermissionUsersAuthorize,
+		MunicipalityID: user.MunicipalityID,
+		// Only higher roles authorize, see entities.CanAuthorizeLevel
+		MaxLevel: roleLevel - 1,
+	}, &entities.Notification{
+		Type:  entities.NotificationUserPendingAuthorization,
+		Title: "Novo cadastro aguardando autorização",
+		Body:  fmt.Sprintf("%s se cadastrou e aguarda autorização.", user.Name),
+		Link:  "/users?status=" + string(entities.UserStatusPendingAuthorization),
+	})
+}
+
+func (s *NotificationService) UserAuthorized(ctx context.Context, user *entities.User) {
+	ctx, span := tracer.Start(ctx, "NotificationService.UserAuthorized")
+	defer span.End()
+
+	s.Notify(ctx, entities.NotificationAudience{UserIDs: []uuid.UUID{user.ID}}, &entities.Notification{
+		Type:  entities.NotificationUserAuthorized,
+		Title: "Cadastro autorizado",
+		Body:  "Seu cadastro foi autorizado. Você já pode usar o sistema.",
+	})
+}
+
+func (s *NotificationService) UserRejected(ctx context.Context, user *entities.User) {
+	ctx, span := tracer.Start(ctx, "NotificationService.UserRejected")
+	defer span.End()
+
+	s.Notify(ctx, entities.NotificationAudience{UserIDs: []uuid.UUID{user.ID}}, &entities.Notification{
+		Type:  entities.NotificationUserRejected,
+		Title: "Cadastro não autorizado",
+		Body:  "Seu cadastro não foi autorizado.",
+	})
+}
+
+// tabletRequestTypeNames are how request types read in notifications
+var tabletRequestTypeNames = map[entities.TabletRequestType]string{
+	entities.TabletRequestTypeNew:      "novo tablet",
+	entities.TabletRequestTypeReturn:   "devolução de tablet",
+	entities.TabletRequestTypeBreakage: "tablet quebrado",
+	entities.TabletRequestTypeTheft:    "furto de tablet",
+}
+
+// TabletRequestCreated tells the approvers of the agent's municipality about a new request
+func (s *NotificationService) TabletRequestCreated(ctx context.Context, request *entities.TabletRequest, createdBy uuid.UUID) {
+	ctx, span := tracer.Start(ctx, "NotificationService.TabletRequestCreated")
+	defer span.End()
+
+	var municipalityID *int
+	name := ""
+	if request.User != nil {
+		municipalityID = request.User.MunicipalityID
+		name = request.User.Name
+	}
+
+	s.Notify(ctx, entities.NotificationAudience{
+		Permission:     entities.PermissionTabletsApproveRequest,
+		MunicipalityID: municipalityID,
+		Except:         &createdBy,
+	}, &entities.Notification{
+		Type:  entities.NotificationTabletRequestCreated,
+		Title: "Nova solicitação de tablet",
+		Body:  fmt.Sprintf("Solicitação de %s para %s.", tabletRequestTypeNames[request.Type], name),
+		Link:  "/tablets/requests?status=" + string(entities.TabletRequestStatusPending),
+	})
+}
+
+// TabletRequestDecided tells the agent a request was approved or rejected
+func (s *NotificationService) TabletRequestDecided(ctx context.Context, request *entities.TabletRequest) {
+	ctx, span := tracer.Start(ctx, "NotificationService.TabletRequestDecided")
+	defer span.End()
+
+	notification := &entities.Notification{
+		Type:  entities.NotificationTabletRequestApproved,
+		Title: "Solicitação de tablet aprovada",
+		Body:  fmt.Sprintf("Sua solicitação de %s foi aprovada.", tabletRequestTypeNames[request.Type]),
+		Link:  "/tablets/requests",
+	}
+	if request.Status == entities.TabletRequestStatusRejected {
+		notification.Type = entities.NotificationTabletRequestRejected
+		notification.Title = "Solicitação de tablet rejeitada"
+		notification.Body = fmt.Sprintf("Sua solicitação de %s foi rejeitada: %s", tabletRequestTypeNames[request.Type], request.RejectionReason)
+	}
+
+	s.Notify(ctx, entities.NotificationAudience{UserIDs: []uuid.UUID{request.UserID}}, notification)
+}
+
+// PaymentPublished tells the users of the payment's municipality about it
+func (s *NotificationService) PaymentPublished(ctx context.Context, payment *entities.Payment) {
+	ctx, span := tracer.Start(ctx, "NotificationService.PaymentPublished")
+	defer span.End()
+
+	s.Notify(ctx, entities.NotificationAudience{
+		Permission:     entities.PermissionPaymentsView,
+		MunicipalityID: payment.MunicipalityID,
+		Except:         &payment.UploadedBy,
+	}, &entities.Notification{
+		Type:  entities.NotificationPaymentPublished,
+		Title: "Novo arquivo de pagamento",
+		Body:  fmt.Sprintf("Pagamento da competência %s disponível.", payment.Competence),
+		Link:  "/payments/" + payment.ID.String(),
+	})
+}
+
+// ResolutionPublished tells the users of the resolution's municipality about it, or
+// everyone who sees resolutions when it is not tied to a municipality
+func (s *NotificationService) ResolutionPublished(ctx context.Context, resolution *entities.Resolution) {
+	ctx, span := tracer.Start(ctx, "NotificationService.ResolutionPublished")
+	defer span.End()
+
+	s.Notify(ctx, entities.NotificationAudience{
+		Permission:     entities.PermissionResolutionsView,
+		MunicipalityID: resolution.MunicipalityID,
+		Except:         &resolution.UploadedBy,
+	}, &entities.Notification{
+		Type:  entities.NotificationResolutionPublished,
+		Title: "Nova resolução publicada",
+		Body:  fmt.Sprintf("%s %s: %s", resolution.Type, resolution.Number, resolution.Title),
+		Link:  "/resolutions/" + resolution.ID.String(),
+	})
+}
+
+func (s *NotificationService) GetAll(ctx context.Context, q entities.ListQuery[entities.NotificationFilter]) (*entities.Page[*entities.Notification], error) {
+	ctx, span := tracer.Start(ctx, "NotificationService.GetAll")
+	defer span.End()
+
+	page, err := s.notificationRepo.List(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notifications: %w", err)
+	}
+
+	return page, nil
+}
+
+func (s *NotificationService) CountUnread(ctx context.Context, userID uuid.UUID) (int, error) {
+	ctx, span := tracer.Start(ctx, "NotificationService.CountUnread")
+	defer span.End()
+
+	count, err := s.notificationRepo.CountUnread(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count notifications: %w", err)
+	}
+
+	return count, nil
+}
+
+func (s *NotificationService) MarkRead(ctx context.Context, userID uuid.UUID, ids []int64) (int, error) {
+	ctx, span := tracer.Start(ctx, "NotificationService.MarkRead")
+	defer span.End()
+
+	if len(ids) == 0 {
+		return 0, apperrors.Required("ids")
+	}
+
+	updated, err := s.notificationRepo.MarkRead(ctx, userID, ids)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark notifications as read: %w", err)
+	}
+
+	return updated, nil
+}
+
+func (s *NotificationService) MarkAllRead(ctx context.Context, userID uuid.UUID) (int, error) {
+	ctx, span := tracer.Start(ctx, "NotificationService.MarkAllRead")
+	defer span.End()
+
+	updated, err := s.notificationRepo.MarkAllRead(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark notifications as read: %w", err)
+	}
+
+	return updated, nil
+}
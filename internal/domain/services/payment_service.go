@@ -3,112 +3,162 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	"github.com/google/uuid"
+	"github.com/joaopanucci/apsdigital/internal/domain/apperrors"
 	"github.com/joaopanucci/apsdigital/internal/domain/entities"
+	domainrepos "github.com/joaopanucci/apsdigital/internal/domain/repositories"
 	"github.com/joaopanucci/apsdigital/internal/infra/repositories"
 )
 
 type PaymentService struct {
-	paymentRepo *repositories.PaymentRepository
+	paymentRepo   *repositories.PaymentRepository
+	jobRepo       domainrepos.JobRepository
+	tx            domainrepos.Transactor
+	notifications *NotificationService
+	webhooks      *WebhookService
 }
 
-func NewPaymentService(paymentRepo *repositories.PaymentRepository) *PaymentService {
+func NewPaymentService(paymentRepo *repositories.PaymentRepository, jobRepo domainrepos.JobRepository, tx domainrepos.Transactor, notifications *NotificationService, webhooks *WebhookService) *PaymentService {
 	return &PaymentService{
-		paymentRepo: paymentRepo,
+		paymentRepo:   paymentRepo,
+		jobRepo:       jobRepo,
+		tx:            tx,
+		notifications: notifications,
+		webhooks:      webhooks,
 	}
 }
 
 func (s *PaymentService) CreatePayment(ctx context.Context, payment *entities.Payment) error {
+	ctx, span := tracer.Start(ctx, "PaymentService.CreatePayment")
+	defer span.End()
+
 	if payment.FileURL == "" {
-		return errors.New("file URL is required")
+		return apperrors.Required("file")
 	}
 
 	if payment.Competence == "" {
-		return errors.New("competence is required")
+		return apperrors.Required("competence")
 	}
 
 	if payment.UploadedBy == uuid.Nil {
-		return errors.New("uploaded by user is required")
+		return apperrors.Required("uploaded_by")
 	}
 
-	return s.paymentRepo.Create(ctx, payment)
+	// The announcement is queued with the payment, so it goes out only if the payment is saved
+	return s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.paymentRepo.Create(ctx, payment); err != nil {
+			return err
+		}
+
+		job, err := entities.NewJob(entities.JobPaymentPublished, entities.PaymentPublishedJob{PaymentID: payment.ID.String()})
+		if err != nil {
+			return err
+		}
+		if _, err := s.jobRepo.Enqueue(ctx, job); err != nil {
+			return fmt.Errorf("failed to queue payment announcement: %w", err)
+		}
+		return nil
+	})
+}
+
+// AnnouncePublished notifies the users and webhooks of a new payment's municipality; it
+// runs as the payment.published job
+func (s *PaymentService) AnnouncePublished(ctx context.Context, job entities.PaymentPublishedJob) error {
+	ctx, span := tracer.Start(ctx, "PaymentService.AnnouncePublished")
+	defer span.End()
+
+	payment, err := s.paymentRepo.GetByID(ctx, job.PaymentID)
+	if errors.Is(err, entities.ErrPaymentNotFound) {
+		// Deleted before the job ran
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load payment: %w", err)
+	}
+
+	s.notifications.PaymentPublished(ctx, payment)
+	s.webhooks.PaymentPublished(ctx, payment)
+
+	return nil
 }
 
 func (s *PaymentService) GetPaymentByID(ctx context.Context, id string) (*entities.Payment, error) {
+	ctx, span := tracer.Start(ctx, "PaymentService.GetPaymentByID")
+	defer span.End()
+
 	if id == "" {
-		return nil, errors.New("invalid payment ID")
+		return nil, apperrors.Invalid("id", "id_invalid")
 	}
 
 	return s.paymentRepo.GetByID(ctx, id)
 }
 
-func (s *PaymentService) GetAllPayments(ctx context.Context, filters map[string]interface{}) ([]*entities.Payment, error) {
-	return s.paymentRepo.GetAll(ctx, filters)
-}
+func (s *PaymentService) GetAllPayments(ctx context.Context, q entities.ListQuery[entities.PaymentFilter]) (*entities.Page[*entities.Payment], error) {
+	ctx, span := tracer.Start(ctx, "PaymentService.GetAllPayments")
+	defer span.End()
 
-func (s *PaymentService) GetPaymentsByMunicipality(ctx context.Context, municipalityID uint, filters map[string]interface{}) ([]*entities.Payment, error) {
-	if filters == nil {
-		filters = make(map[string]interface{})
+	page, err := s.paymentRepo.GetAll(ctx, q)
+	if err != nil {
+		return nil, err
 	}
 
-	filters["municipality_id"] = municipalityID
-	return s.paymentRepo.GetAll(ctx, filters)
+	return page, nil
+}
+
+func (s *PaymentService) GetPaymentsByMunicipality(ctx context.Context, municipalityID int, q entities.ListQuery[entities.PaymentFilter]) (*entities.Page[*entities.Payment], error) {
+	ctx, span := tracer.Start(ctx, "PaymentService.GetPaymentsByMunicipality")
+	defer span.End()
+
+	q.Filters.MunicipalityID = &municipalityID
+	return s.GetAllPayments(ctx, q)
 }
 
 func (s *PaymentService) UpdatePayment(ctx context.Context, payment *entities.Payment) error {
+	ctx, span := tracer.Start(ctx, "PaymentService.UpdatePayment")
+	defer span.End()
+
 	if payment.ID == uuid.Nil {
-		return errors.New("invalid payment ID")
+		return apperrors.Invalid("id", "id_invalid")
 	}
 
 	// Check if payment exists
 	_, err := s.paymentRepo.GetByID(ctx, payment.ID.String())
 	if err != nil {
-		return errors.New("payment not found")
+		return err
 	}
 
 	return s.paymentRepo.Update(ctx, payment)
 }
 
 func (s *PaymentService) DeletePayment(ctx context.Context, id string) error {
+	ctx, span := tracer.Start(ctx, "PaymentService.DeletePayment")
+	defer span.End()
+
 	if id == "" {
-		return errors.New("invalid payment ID")
+		return apperrors.Invalid("id", "id_invalid")
 	}
 
 	// Check if payment exists
 	_, err := s.paymentRepo.GetByID(ctx, id)
 	if err != nil {
-		return errors.New("payment not found")
+		return err
 	}
 
 	return s.paymentRepo.Delete(ctx, id)
 }
 
-func (s *PaymentService) GetCompetences(ctx context.Context, municipalityID *uint) ([]string, error) {
-	return s.paymentRepo.GetCompetences(ctx, municipalityID)
-}
+func (s *PaymentService) GetCompetences(ctx context.Context, municipalityIDs []int) ([]string, error) {
+	ctx, span := tracer.Start(ctx, "PaymentService.GetCompetences")
+	defer span.End()
 
-func (s *PaymentService) GetYears(ctx context.Context, municipalityID *uint) ([]int, error) {
-	return s.paymentRepo.GetYears(ctx, municipalityID)
+	return s.paymentRepo.GetCompetences(ctx, municipalityIDs)
 }
 
-func (s *PaymentService) GetPaymentsWithPagination(ctx context.Context, filters map[string]interface{}, page, limit int) ([]*entities.Payment, error) {
-	if page <= 0 {
-		page = 1
-	}
-
-	if limit <= 0 {
-		limit = 10
-	}
-
-	offset := (page - 1) * limit
-
-	if filters == nil {
-		filters = make(map[string]interface{})
-	}
-
-	filters["limit"] = limit
-	filters["offset"] = offset
+func (s *PaymentService) GetYears(ctx context.Context, municipalityIDs []int) ([]int, error) {
+	ctx, span := tracer.Start(ctx, "PaymentService.GetYears")
+	defer span.End()
 
-	return s.paymentRepo.GetAll(ctx, filters)
+	return s.paymentRepo.GetYears(ctx, municipalityIDs)
 }
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/joaopanucci/apsdigital/internal/domain/apperrors"
+	"github.com/joaopanucci/apsdigital/internal/domain/entities"
+	"github.com/joaopanucci/apsdigital/internal/domain/repositories"
+
+	"github.com/google/uuid"
+)
+
+// permissionCacheTTL bounds how long a permission change made on another replica takes to apply
+const permissionCacheTTL = time.Minute
+
+type cachedPermissions struct {
+	permissions map[string]bool
+	loadedAt    time.Time
+}
+
+type PermissionService struct {
+	roleRepo repositories.RoleRepository
+
+	mu    sync.RWMutex
+	cache map[string]cachedPermissions // by role name
+}
+
+func NewPermissionService(roleRepo repositories.RoleRepository) *PermissionService {
+	return &PermissionService{
+		roleRepo: roleRepo,
+		cache:    make(map[string]cachedPermissions),
+	}
+}
+
+// GetRolePermissions returns the permission set of a role, as carried in the access token
+func (s *PermissionService) GetRolePermissions(ctx context.Context, roleName string) (map[string]bool, error) {
+	ctx, span := tracer.Start(ctx, "PermissionService.GetRolePermissions")
+	defer span.End()
+
+	s.mu.RLock()
+	cached, ok := s.cache[roleName]
+	s.mu.RUnlock()
+
+	if ok && time.Since(cached.loadedAt) < permissionCacheTTL {
+		return cached.permissions, nil
+	}
+
+	role, err := s.roleRepo.GetByName(ctx, roleName)
+	if err != nil {
+		return nil, err
+	}
+
+	names, err := s.roleRepo.GetPermissions(ctx, role.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get role permissions: %w", err)
+	}
+
+	permissions := make(map[string]bool, len(names))
+	for _, name := range names {
+		permissions[name] = true
+	}
+
+	s.mu.Lock()
+	s.cache[roleName] = cachedPermissions{permissions: permissions, loadedAt: time.Now()}
+	s.mu.Unlock()
+
+	return permissions, nil
+}
+
+func (s *PermissionService) ListRoles(ctx context.Context) ([]*entities.Role, error) {
+	ctx, span := tracer.Start(ctx, "PermissionService.ListRoles")
+	defer span.End()
+
+	roles, err := s.roleRepo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list roles: %w", err)
+	}
+
+	return roles, nil
+}
+
+func (s *PermissionService) ListPermissions(ctx context.Context) ([]*entities.Permission, error) {
+	ctx, span := tracer.Start(ctx, "PermissionService.ListPermissions")
+	defer span.End()
+
+	permissions, err := s.roleRepo.ListPermissions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list permissions: %w", err)
+	}
+
+	return permissions, nil
+}
+
+func (s *PermissionService) GetPermissionsByRoleID(ctx context.Context, roleID uuid.UUID) ([]string, error) {
+	ctx, span := tracer.Start(ctx, "PermissionService.GetPermissionsByRoleID")
+	defer span.End()
+
+	if _, err := s.roleRepo.GetByID(ctx, roleID); err != nil {
+		return nil, err
+	}
+
+	permissions, err := s.roleRepo.GetPermissions(ctx, roleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get role permissions: %w", err)
+	}
+
+	return permissions, nil
+}
+
+// SetRolePermissions replaces the permissions granted to a role
+func (s *PermissionService) SetRolePermissions(ctx context.Context, roleID uuid.UUID, permissions []string) error {
+	ctx, span := tracer.Start(ctx, "PermissionService.SetRolePermissions")
+	defer span.End()
+
+	role, err := s.roleRepo.GetByID(ctx, roleID)
+	if err != nil {
+		return err
+	}
+
+	unique := make(map[string]bool, len(permissions))
+	for _, name := range permissions {
+		unique[name] = true
+	}
+
+	// Prevent locking every administrator out of permission management
+	if role.Name == entities.RoleAdmin && !unique[entities.PermissionRolesManage] {
+		return apperrors.Invalid("permissions", "permission_locked", entities.PermissionRolesManage, entities.RoleAdmin)
+	}
+
+	names := make([]string, 0, len(unique))
+	for name := range unique {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if err := s.roleRepo.SetPermissions(ctx, roleID, names); err != nil {
+		return fmt.Errorf("failed to set role permissions: %w", err)
+	}
+
+	s.mu.Lock()
+	delete(s.cache, role.Name)
+	s.mu.Unlock()
+
+	return nil
+}
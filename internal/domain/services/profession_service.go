@@ -2,7 +2,6 @@ package services
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/joaopanucci/apsdigital/internal/domain/entities"
 	"github.com/joaopanucci/apsdigital/internal/domain/repositories"
@@ -19,35 +18,52 @@ func NewProfessionService(professionRepo repositories.ProfessionRepository) *Pro
 }
 
 func (s *ProfessionService) CreateProfession(ctx context.Context, profession *entities.Profession) error {
+	ctx, span := tracer.Start(ctx, "ProfessionService.CreateProfession")
+	defer span.End()
+
 	// Check if profession already exists
 	existing, _ := s.professionRepo.GetByName(ctx, profession.Name)
 	if existing != nil {
-		return fmt.Errorf("profession with name '%s' already exists", profession.Name)
+		return entities.ErrProfessionNameTaken
 	}
 
 	return s.professionRepo.Create(ctx, profession)
 }
 
 func (s *ProfessionService) GetProfessionByID(ctx context.Context, id int) (*entities.Profession, error) {
+	ctx, span := tracer.Start(ctx, "ProfessionService.GetProfessionByID")
+	defer span.End()
+
 	return s.professionRepo.GetByID(ctx, id)
 }
 
-func (s *ProfessionService) GetAllProfessions(ctx context.Context) ([]*entities.Profession, error) {
-	return s.professionRepo.GetAll(ctx)
+func (s *ProfessionService) GetAllProfessions(ctx context.Context, q entities.ListQuery[entities.ProfessionFilter]) (*entities.Page[*entities.Profession], error) {
+	ctx, span := tracer.Start(ctx, "ProfessionService.GetAllProfessions")
+	defer span.End()
+
+	page, err := s.professionRepo.GetAll(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	return page, nil
 }
 
 func (s *ProfessionService) UpdateProfession(ctx context.Context, profession *entities.Profession) error {
+	ctx, span := tracer.Start(ctx, "ProfessionService.UpdateProfession")
+	defer span.End()
+
 	// Check if profession exists
 	existing, err := s.professionRepo.GetByID(ctx, profession.ID)
 	if err != nil {
-		return fmt.Errorf("profession not found")
+		return err
 	}
 
 	// Check if name is being changed to an existing one
 	if existing.Name != profession.Name {
 		nameExists, _ := s.professionRepo.GetByName(ctx, profession.Name)
 		if nameExists != nil {
-			return fmt.Errorf("profession with name '%s' already exists", profession.Name)
+			return entities.ErrProfessionNameTaken
 		}
 	}
 
@@ -55,10 +71,13 @@ func (s *ProfessionService) UpdateProfession(ctx context.Context, profession *en
 }
 
 func (s *ProfessionService) DeleteProfession(ctx context.Context, id int) error {
+	ctx, span := tracer.Start(ctx, "ProfessionService.DeleteProfession")
+	defer span.End()
+
 	// Check if profession exists
 	_, err := s.professionRepo.GetByID(ctx, id)
 	if err != nil {
-		return fmt.Errorf("profession not found")
+		return err
 	}
 
 	return s.professionRepo.Delete(ctx, id)
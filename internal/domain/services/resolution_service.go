@@ -3,128 +3,177 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	"github.com/google/uuid"
+	"github.com/joaopanucci/apsdigital/internal/domain/apperrors"
 	"github.com/joaopanucci/apsdigital/internal/domain/entities"
+	domainrepos "github.com/joaopanucci/apsdigital/internal/domain/repositories"
 	"github.com/joaopanucci/apsdigital/internal/infra/repositories"
 )
 
 type ResolutionService struct {
 	resolutionRepo *repositories.ResolutionRepository
+	jobRepo        domainrepos.JobRepository
+	tx             domainrepos.Transactor
+	notifications  *NotificationService
 }
 
-func NewResolutionService(resolutionRepo *repositories.ResolutionRepository) *ResolutionService {
+func NewResolutionService(resolutionRepo *repositories.ResolutionRepository, jobRepo domainrepos.JobRepository, tx domainrepos.Transactor, notifications *NotificationService) *ResolutionService {
 	return &ResolutionService{
 		resolutionRepo: resolutionRepo,
+		jobRepo:        jobRepo,
+		tx:             tx,
+		notifications:  notifications,
 	}
 }
 
 func (s *ResolutionService) CreateResolution(ctx context.Context, resolution *entities.Resolution) error {
+	ctx, span := tracer.Start(ctx, "ResolutionService.CreateResolution")
+	defer span.End()
+
 	if resolution.Title == "" {
-		return errors.New("title is required")
+		return apperrors.Required("title")
 	}
 
 	if resolution.FileURL == "" {
-		return errors.New("file URL is required")
+		return apperrors.Required("file")
 	}
 
 	if resolution.Type == "" {
-		return errors.New("type is required")
+		return apperrors.Required("type")
 	}
 
 	if resolution.Type != "MS" && resolution.Type != "SES" {
-		return errors.New("type must be MS or SES")
+		return apperrors.Invalid("type", "resolution_type_invalid")
 	}
 
 	if resolution.UploadedBy == uuid.Nil {
-		return errors.New("uploaded by user is required")
+		return apperrors.Required("uploaded_by")
+	}
+
+	return s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.resolutionRepo.Create(ctx, resolution); err != nil {
+			return err
+		}
+
+		job, err := entities.NewJob(entities.JobResolutionPublished, entities.ResolutionPublishedJob{ResolutionID: resolution.ID.String()})
+		if err != nil {
+			return err
+		}
+		if _, err := s.jobRepo.Enqueue(ctx, job); err != nil {
+			return fmt.Errorf("failed to queue resolution announcement: %w", err)
+		}
+		return nil
+	})
+}
+
+// AnnouncePublished notifies the users who see a new resolution; it runs as the
+// resolution.published job
+func (s *ResolutionService) AnnouncePublished(ctx context.Context, job entities.ResolutionPublishedJob) error {
+	ctx, span := tracer.Start(ctx, "ResolutionService.AnnouncePublished")
+	defer span.End()
+
+	resolution, err := s.resolutionRepo.GetByID(ctx, job.ResolutionID)
+	if errors.Is(err, entities.ErrResolutionNotFound) {
+		// Deleted before the job ran
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load resolution: %w", err)
 	}
 
-	return s.resolutionRepo.Create(ctx, resolution)
+	s.notifications.ResolutionPublished(ctx, resolution)
+
+	return nil
 }
 
 func (s *ResolutionService) GetResolutionByID(ctx context.Context, id string) (*entities.Resolution, error) {
+	ctx, span := tracer.Start(ctx, "ResolutionService.GetResolutionByID")
+	defer span.End()
+
 	if id == "" {
-		return nil, errors.New("invalid resolution ID")
+		return nil, apperrors.Invalid("id", "id_invalid")
 	}
 
 	return s.resolutionRepo.GetByID(ctx, id)
 }
 
-func (s *ResolutionService) GetAllResolutions(ctx context.Context, filters map[string]interface{}) ([]*entities.Resolution, error) {
-	return s.resolutionRepo.GetAll(ctx, filters)
-}
+func (s *ResolutionService) GetAllResolutions(ctx context.Context, q entities.ListQuery[entities.ResolutionFilter]) (*entities.Page[*entities.Resolution], error) {
+	ctx, span := tracer.Start(ctx, "ResolutionService.GetAllResolutions")
+	defer span.End()
 
-func (s *ResolutionService) GetResolutionsByMunicipality(ctx context.Context, municipalityID uint, filters map[string]interface{}) ([]*entities.Resolution, error) {
-	if filters == nil {
-		filters = make(map[string]interface{})
+	page, err := s.resolutionRepo.GetAll(ctx, q)
+	if err != nil {
+		return nil, err
 	}
 
-	filters["municipality_id"] = municipalityID
-	return s.resolutionRepo.GetAll(ctx, filters)
+	return page, nil
+}
+
+func (s *ResolutionService) GetResolutionsByMunicipality(ctx context.Context, municipalityID int, q entities.ListQuery[entities.ResolutionFilter]) (*entities.Page[*entities.Resolution], error) {
+	ctx, span := tracer.Start(ctx, "ResolutionService.GetResolutionsByMunicipality")
+	defer span.End()
+
+	q.Filters.MunicipalityID = &municipalityID
+	return s.GetAllResolutions(ctx, q)
 }
 
 func (s *ResolutionService) UpdateResolution(ctx context.Context, resolution *entities.Resolution) error {
+	ctx, span := tracer.Start(ctx, "ResolutionService.UpdateResolution")
+	defer span.End()
+
 	if resolution.ID == uuid.Nil {
-		return errors.New("invalid resolution ID")
+		return apperrors.Invalid("id", "id_invalid")
 	}
 
 	// Check if resolution exists
 	_, err := s.resolutionRepo.GetByID(ctx, resolution.ID.String())
 	if err != nil {
-		return errors.New("resolution not found")
+		return err
 	}
 
 	return s.resolutionRepo.Update(ctx, resolution)
 }
 
 func (s *ResolutionService) DeleteResolution(ctx context.Context, id string) error {
+	ctx, span := tracer.Start(ctx, "ResolutionService.DeleteResolution")
+	defer span.End()
+
 	if id == "" {
-		return errors.New("invalid resolution ID")
+		return apperrors.Invalid("id", "id_invalid")
 	}
 
 	// Check if resolution exists
 	_, err := s.resolutionRepo.GetByID(ctx, id)
 	if err != nil {
-		return errors.New("resolution not found")
+		return err
 	}
 
 	return s.resolutionRepo.Delete(ctx, id)
 }
 
-func (s *ResolutionService) GetTypes(ctx context.Context, municipalityID *uint) ([]string, error) {
-	return s.resolutionRepo.GetTypes(ctx, municipalityID)
-}
+func (s *ResolutionService) GetTypes(ctx context.Context, municipalityIDs []int) ([]string, error) {
+	ctx, span := tracer.Start(ctx, "ResolutionService.GetTypes")
+	defer span.End()
 
-func (s *ResolutionService) GetYears(ctx context.Context, municipalityID *uint) ([]int, error) {
-	return s.resolutionRepo.GetYears(ctx, municipalityID)
+	return s.resolutionRepo.GetTypes(ctx, municipalityIDs)
 }
 
-func (s *ResolutionService) GetRecentResolutions(ctx context.Context, municipalityID *uint, limit int) ([]*entities.Resolution, error) {
-	if limit <= 0 {
-		limit = 10
-	}
+func (s *ResolutionService) GetYears(ctx context.Context, municipalityIDs []int) ([]int, error) {
+	ctx, span := tracer.Start(ctx, "ResolutionService.GetYears")
+	defer span.End()
 
-	return s.resolutionRepo.GetRecent(ctx, municipalityID, limit)
+	return s.resolutionRepo.GetYears(ctx, municipalityIDs)
 }
 
-func (s *ResolutionService) GetResolutionsWithPagination(ctx context.Context, filters map[string]interface{}, page, limit int) ([]*entities.Resolution, error) {
-	if page <= 0 {
-		page = 1
-	}
+func (s *ResolutionService) GetRecentResolutions(ctx context.Context, municipalityIDs []int, limit int) ([]*entities.Resolution, error) {
+	ctx, span := tracer.Start(ctx, "ResolutionService.GetRecentResolutions")
+	defer span.End()
 
 	if limit <= 0 {
 		limit = 10
 	}
 
-	offset := (page - 1) * limit
-
-	if filters == nil {
-		filters = make(map[string]interface{})
-	}
-
-	filters["limit"] = limit
-	filters["offset"] = offset
-
-	return s.resolutionRepo.GetAll(ctx, filters)
+	return s.resolutionRepo.GetRecent(ctx, municipalityIDs, limit)
 }
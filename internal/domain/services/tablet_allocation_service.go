@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/joaopanucci/apsdigital/internal/domain/apperrors"
+	"github.com/joaopanucci/apsdigital/internal/domain/entities"
+	"github.com/joaopanucci/apsdigital/internal/domain/repositories"
+
+	"github.com/google/uuid"
+)
+
+type TabletAllocationService struct {
+	allocationRepo repositories.TabletAllocationRepository
+}
+
+func NewTabletAllocationService(allocationRepo repositories.TabletAllocationRepository) *TabletAllocationService {
+	return &TabletAllocationService{
+		allocationRepo: allocationRepo,
+	}
+}
+
+// allocationState is a municipality while a plan is being built
+type allocationState struct {
+	*entities.MunicipalityAllocation
+	stock   []*entities.Tablet
+	queue   []*entities.AllocationRequest
+	covered int
+}
+
+// coverage is the share of active ACS holding a tablet; municipalities without ACS on
+// record count as covered so they are served last
+func (m *allocationState) coverage() float64 {
+	if m.ActiveACS == 0 {
+		return 1
+	}
+	return float64(m.covered) / float64(m.ActiveACS)
+}
+
+// surplus is the stock left after the municipality's own requests
+func (m *allocationState) surplus() int {
+	return len(m.stock) - len(m.queue)
+}
+
+// BuildPlan proposes who gets each available tablet. Every assignment covers one more
+// requester, so the plan serves as many requests as there is stock; when stock is short
+// it goes first to the municipalities with the lowest coverage. Requests are served from
+// local stock when possible, otherwise from the municipality with the largest surplus,
+// preferring one in the same health region.
+func (s *TabletAllocationService) BuildPlan(ctx context.Context, scope *entities.MunicipalityScope) (*entities.TabletAllocationPlan, error) {
+	ctx, span := tracer.Start(ctx, "TabletAllocationService.BuildPlan")
+	defer span.End()
+
+	ids := scope.Filter()
+
+	requests, err := s.allocationRepo.PendingNewRequests(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build allocation plan: %w", err)
+	}
+	coverage, err := s.allocationRepo.MunicipalityCoverage(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build allocation plan: %w", err)
+	}
+	tablets, err := s.allocationRepo.AvailableTablets(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build allocation plan: %w", err)
+	}
+
+	states := make(map[int]*allocationState, len(coverage))
+	for _, m := range coverage {
+		states[m.MunicipalityID] = &allocationState{MunicipalityAllocation: m, covered: m.ACSWithTablet}
+	}
+	for _, tablet := range tablets {
+		if state, ok := states[tablet.MunicipalityID]; ok {
+			state.stock = append(state.stock, tablet)
+		}
+	}
+
+	plan := &entities.TabletAllocationPlan{
+		Assignments: []*entities.PlannedAssignment{},
+		Unserved:    []*entities.AllocationRequest{},
+		GeneratedAt: time.Now(),
+	}
+	for _, request := range requests {
+		state, ok := states[request.MunicipalityID]
+		if !ok {
+			// Inactive municipality, nothing can be sent there
+			plan.Unserved = append(plan.Unserved, request)
+			continue
+		}
+		state.queue = append(state.queue, request)
+		state.PendingRequests++
+	}
+
+	for {
+		receiver := lowestCoverage(states)
+		if receiver == nil {
+			break
+		}
+
+		source := receiver
+		if len(receiver.stock) == 0 {
+			source = largestSurplus(states, receiver.HealthRegionID)
+		}
+		if source == nil {
+			// No stock anywhere for this municipality
+			plan.Unserved = append(plan.Unserved, receiver.queue...)
+			receiver.queue = nil
+			continue
+		}
+
+		tablet := source.stock[0]
+		source.stock = source.stock[1:]
+		request := receiver.queue[0]
+		receiver.queue = receiver.queue[1:]
+		receiver.covered++
+
+		assignment := &entities.PlannedAssignment{
+			RequestID:      request.RequestID,
+			TabletID:       tablet.ID,
+			AssetCode:      tablet.AssetCode,
+			SerialNumber:   tablet.SerialNumber,
+			UserID:         request.UserID,
+			UserName:       request.UserName,
+			MunicipalityID: receiver.MunicipalityID,
+		}
+		if source != receiver {
+			from := source.MunicipalityID
+			assignment.FromMunicipalityID = &from
+			plan.Transfers++
+		}
+		plan.Assignments = append(plan.Assignments, assignment)
+	}
+
+	for _, m := range coverage {
+		state := states[m.MunicipalityID]
+		if m.ActiveACS > 0 {
+			m.CoverageBefore = float64(m.ACSWithTablet) * 100 / float64(m.ActiveACS)
+			m.CoverageAfter = float64(state.covered) * 100 / float64(m.ActiveACS)
+		}
+	}
+	plan.Municipalities = coverage
+
+	sort.Slice(plan.Unserved, func(i, j int) bool {
+		return plan.Unserved[i].RequestedAt.Before(plan.Unserved[j].RequestedAt)
+	})
+
+	return plan, nil
+}
+
+// lowestCoverage picks the municipality with requests left and the lowest coverage,
+// breaking ties by the oldest request
+func lowestCoverage(states map[int]*allocationState) *allocationState {
+	var best *allocationState
+	for _, state := range states {
+		if len(state.queue) == 0 {
+			continue
+		}
+		if best == nil || state.coverage() < best.coverage() ||
+			(state.coverage() == best.coverage() && state.queue[0].RequestedAt.Before(best.queue[0].RequestedAt)) {
+			best = state
+		}
+	}
+	return best
+}
+
+// largestSurplus picks the municipality with the most stock to spare, preferring the
+// given health region
+func largestSurplus(states map[int]*allocationState, regionID *int) *allocationState {
+	sameRegion := func(state *allocationState) bool {
+		return regionID != nil && state.HealthRegionID != nil && *state.HealthRegionID == *regionID
+	}
+
+	var best *allocationState
+	for _, state := range states {
+		if state.surplus() <= 0 {
+			continue
+		}
+		switch {
+		case best == nil:
+			best = state
+		case sameRegion(state) != sameRegion(best):
+			if sameRegion(state) {
+				best = state
+			}
+		case state.surplus() > best.surplus() ||
+			(state.surplus() == best.surplus() && state.MunicipalityID < best.MunicipalityID):
+			best = state
+		}
+	}
+	return best
+}
+
+// AcceptPlan applies the accepted assignments of a plan at once; nothing changes if any
+// of them is out of date
+func (s *TabletAllocationService) AcceptPlan(ctx context.Context, decisions []entities.AllocationDecision, scope *entities.MunicipalityScope, approvedBy uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "TabletAllocationService.AcceptPlan")
+	defer span.End()
+
+	if len(decisions) == 0 {
+		return apperrors.Required("assignments")
+	}
+
+	if err := s.allocationRepo.ApplyAllocation(ctx, decisions, scope.Filter(), approvedBy); err != nil {
+		return fmt.Errorf("failed to apply allocation plan: %w", err)
+	}
+
+	return nil
+}
@@ -2,37 +2,101 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"time"
 
+	"github.com/joaopanucci/apsdigital/internal/domain/apperrors"
 	"github.com/joaopanucci/apsdigital/internal/domain/entities"
 	"github.com/joaopanucci/apsdigital/internal/domain/repositories"
+	"github.com/joaopanucci/apsdigital/internal/utils"
 
 	"github.com/google/uuid"
 )
 
+// TabletManifestParser reads a supplier delivery manifest
+type TabletManifestParser interface {
+	Parse(r io.Reader) ([]entities.TabletManifestRow, error)
+}
+
 type TabletService struct {
-	tabletRepo repositories.TabletRepository
-	userRepo   repositories.UserRepository
+	tabletRepo       repositories.TabletRepository
+	requestRepo      repositories.TabletRequestRepository
+	userRepo         repositories.UserRepository
+	municipalityRepo repositories.MunicipalityRepository
+	manifestParser   TabletManifestParser
+	jobRepo          repositories.JobRepository
+	tx               repositories.Transactor
+	notifications    *NotificationService
+	webhooks         *WebhookService
 }
 
-func NewTabletService(tabletRepo repositories.TabletRepository, userRepo repositories.UserRepository) *TabletService {
+func NewTabletService(tabletRepo repositories.TabletRepository, requestRepo repositories.TabletRequestRepository, userRepo repositories.UserRepository, municipalityRepo repositories.MunicipalityRepository, manifestParser TabletManifestParser, jobRepo repositories.JobRepository, tx repositories.Transactor, notifications *NotificationService, webhooks *WebhookService) *TabletService {
 	return &TabletService{
-		tabletRepo: tabletRepo,
-		userRepo:   userRepo,
+		tabletRepo:       tabletRepo,
+		requestRepo:      requestRepo,
+		userRepo:         userRepo,
+		municipalityRepo: municipalityRepo,
+		manifestParser:   manifestParser,
+		jobRepo:          jobRepo,
+		tx:               tx,
+		notifications:    notifications,
+		webhooks:         webhooks,
 	}
 }
 
-func (s *TabletService) GetAll(ctx context.Context, filters map[string]interface{}) ([]*entities.Tablet, error) {
-	tablets, err := s.tabletRepo.List(ctx, filters)
+func (s *TabletService) GetAll(ctx context.Context, q entities.ListQuery[entities.TabletFilter]) (*entities.Page[*entities.Tablet], error) {
+	ctx, span := tracer.Start(ctx, "TabletService.GetAll")
+	defer span.End()
+
+	page, err := s.tabletRepo.List(ctx, q)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get tablets: %w", err)
 	}
 
-	return tablets, nil
+	return page, nil
+}
+
+// tabletExportBatch is how many tablets Export reads per query
+const tabletExportBatch = 500
+
+// Export walks every tablet matching the filter in serial order, one batch at
+// a time, so callers can stream large inventories without holding them in memory
+func (s *TabletService) Export(ctx context.Context, filter entities.TabletFilter, fn func(*entities.Tablet) error) error {
+	ctx, span := tracer.Start(ctx, "TabletService.Export")
+	defer span.End()
+
+	q := entities.ListQuery[entities.TabletFilter]{
+		Page:    1,
+		Limit:   tabletExportBatch,
+		Sort:    []entities.SortField{{Field: "serial_number"}},
+		Filters: filter,
+	}
+
+	for {
+		page, err := s.tabletRepo.List(ctx, q)
+		if err != nil {
+			return fmt.Errorf("failed to export tablets: %w", err)
+		}
+
+		for _, tablet := range page.Items {
+			if err := fn(tablet); err != nil {
+				return err
+			}
+		}
+
+		if page.NextCursor == "" {
+			return nil
+		}
+		q.Page, q.Cursor = 0, page.NextCursor
+	}
 }
 
 func (s *TabletService) GetByID(ctx context.Context, id int) (*entities.Tablet, error) {
+	ctx, span := tracer.Start(ctx, "TabletService.GetByID")
+	defer span.End()
+
 	tablet, err := s.tabletRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get tablet: %w", err)
@@ -41,7 +105,31 @@ func (s *TabletService) GetByID(ctx context.Context, id int) (*entities.Tablet,
 	return tablet, nil
 }
 
+// GetByAssetCode finds the tablet of a scanned label, with the agent holding it
+func (s *TabletService) GetByAssetCode(ctx context.Context, assetCode string) (*entities.Tablet, error) {
+	ctx, span := tracer.Start(ctx, "TabletService.GetByAssetCode")
+	defer span.End()
+
+	tablet, err := s.tabletRepo.GetByAssetCode(ctx, assetCode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tablet: %w", err)
+	}
+
+	if tablet.AssignedUserID != nil {
+		agent, err := s.userRepo.GetByID(ctx, *tablet.AssignedUserID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get assigned agent: %w", err)
+		}
+		tablet.AssignedUser = agent
+	}
+
+	return tablet, nil
+}
+
 func (s *TabletService) GetByUserCPF(ctx context.Context, cpf string) ([]*entities.Tablet, error) {
+	ctx, span := tracer.Start(ctx, "TabletService.GetByUserCPF")
+	defer span.End()
+
 	tablets, err := s.tabletRepo.GetByUserCPF(ctx, cpf)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get tablets by CPF: %w", err)
@@ -51,6 +139,9 @@ func (s *TabletService) GetByUserCPF(ctx context.Context, cpf string) ([]*entiti
 }
 
 func (s *TabletService) GetByAssignedUser(ctx context.Context, userID uuid.UUID) ([]*entities.Tablet, error) {
+	ctx, span := tracer.Start(ctx, "TabletService.GetByAssignedUser")
+	defer span.End()
+
 	tablets, err := s.tabletRepo.GetByAssignedUser(ctx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get tablets by user: %w", err)
@@ -59,48 +150,165 @@ func (s *TabletService) GetByAssignedUser(ctx context.Context, userID uuid.UUID)
 	return tablets, nil
 }
 
+// Create registers a tablet; duplicate serial numbers and asset codes are rejected by the database
 func (s *TabletService) Create(ctx context.Context, tablet *entities.Tablet) error {
-	// Validate that the tablet doesn't already exist with the same serial number
-	existing, _ := s.tabletRepo.List(ctx, map[string]interface{}{
-		"serial_number": tablet.SerialNumber,
-	})
+	ctx, span := tracer.Start(ctx, "TabletService.Create")
+	defer span.End()
 
-	for _, existingTablet := range existing {
-		if existingTablet.SerialNumber == tablet.SerialNumber {
-			return fmt.Errorf("tablet with serial number '%s' already exists", tablet.SerialNumber)
+	if err := s.tabletRepo.Create(ctx, tablet); err != nil {
+		return fmt.Errorf("failed to create tablet: %w", err)
+	}
+
+	return s.recordEvent(ctx, tablet, entities.TabletEventCreated, "", "")
+}
+
+// ImportManifest registers the devices of a supplier delivery manifest as available
+// tablets. Rows are checked one by one and the valid ones are inserted in a single
+// transaction; the report tells what happened to every row.
+func (s *TabletService) ImportManifest(ctx context.Context, r io.Reader, scope *entities.MunicipalityScope) (*entities.TabletImportReport, error) {
+	ctx, span := tracer.Start(ctx, "TabletService.ImportManifest")
+	defer span.End()
+
+	rows, err := s.manifestParser.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, apperrors.InvalidFile("file_no_tablets")
+	}
+
+	municipalities, err := s.municipalityRepo.ListAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get municipalities: %w", err)
+	}
+
+	// Municipalities are referenced by IBGE code, with or without the check digit, or by
+	// name; names shared by municipalities of different states are ambiguous
+	const ambiguous = -1
+	byKey := make(map[string]int, 3*len(municipalities))
+	for _, municipality := range municipalities {
+		if len(municipality.IBGECode) == 7 {
+			byKey[municipality.IBGECode] = municipality.ID
+			byKey[municipality.IBGECode[:6]] = municipality.ID
+		}
+		name := utils.FoldName(municipality.Name)
+		if _, ok := byKey[name]; ok {
+			byKey[name] = ambiguous
+		} else {
+			byKey[name] = municipality.ID
 		}
 	}
 
-	if err := s.tabletRepo.Create(ctx, tablet); err != nil {
-		return fmt.Errorf("failed to create tablet: %w", err)
+	report := &entities.TabletImportReport{Total: len(rows)}
+	serialLines := make(map[string]int, len(rows))
+	assetLines := make(map[string]int, len(rows))
+	var tablets []*entities.Tablet
+	var pending []*entities.TabletImportResult
+	for _, row := range rows {
+		result := &entities.TabletImportResult{
+			Line:         row.Line,
+			SerialNumber: row.SerialNumber,
+			AssetCode:    row.AssetCode,
+			Status:       entities.TabletImportFailed,
+		}
+		report.Rows = append(report.Rows, result)
+
+		municipalityID, ok := byKey[row.Municipality]
+		if !ok {
+			municipalityID, ok = byKey[utils.FoldName(row.Municipality)]
+		}
+
+		switch {
+		case row.SerialNumber == "":
+			result.Error = "serial number is required"
+		case row.Municipality == "":
+			result.Error = "municipality is required"
+		case !ok:
+			result.Error = fmt.Sprintf("municipality '%s' not found", row.Municipality)
+		case municipalityID == ambiguous:
+			result.Error = fmt.Sprintf("municipality '%s' is ambiguous, use its IBGE code", row.Municipality)
+		case !scope.Contains(&municipalityID):
+			result.Error = fmt.Sprintf("municipality '%s' is outside your scope", row.Municipality)
+		case serialLines[row.SerialNumber] != 0:
+			result.Error = fmt.Sprintf("serial number repeats line %d", serialLines[row.SerialNumber])
+		case row.AssetCode != "" && assetLines[row.AssetCode] != 0:
+			result.Error = fmt.Sprintf("asset code repeats line %d", assetLines[row.AssetCode])
+		}
+		if result.Error != "" {
+			continue
+		}
+
+		serialLines[row.SerialNumber] = row.Line
+		if row.AssetCode != "" {
+			assetLines[row.AssetCode] = row.Line
+		}
+		tablets = append(tablets, &entities.Tablet{
+			SerialNumber:   row.SerialNumber,
+			Model:          row.Model,
+			AssetCode:      row.AssetCode,
+			MunicipalityID: municipalityID,
+			Status:         entities.TabletStatusAvailable,
+		})
+		pending = append(pending, result)
 	}
 
-	return nil
+	if len(tablets) > 0 {
+		rowErrors, err := s.tabletRepo.CreateBatch(ctx, tablets)
+		if err != nil {
+			return nil, fmt.Errorf("failed to import tablets: %w", err)
+		}
+
+		for i, result := range pending {
+			if rowErrors[i] != nil {
+				result.Error = rowErrors[i].Error()
+				continue
+			}
+			result.Status = entities.TabletImportCreated
+			result.TabletID = &tablets[i].ID
+		}
+	}
+
+	for _, result := range report.Rows {
+		if result.Status == entities.TabletImportCreated {
+			report.Created++
+		} else {
+			report.Failed++
+		}
+	}
+
+	return report, nil
 }
 
 func (s *TabletService) AssignToUser(ctx context.Context, tabletID int, userID uuid.UUID, userCPF string) error {
+	ctx, span := tracer.Start(ctx, "TabletService.AssignToUser")
+	defer span.End()
+
 	// Get tablet
 	tablet, err := s.tabletRepo.GetByID(ctx, tabletID)
 	if err != nil {
-		return fmt.Errorf("tablet not found")
+		return err
 	}
 
 	// Check if tablet is available
 	if tablet.Status != entities.TabletStatusAvailable {
-		return fmt.Errorf("tablet is not available for assignment")
+		return apperrors.Conflict("tablet_not_available")
 	}
 
 	// Verify user exists and is active
 	user, err := s.userRepo.GetByID(ctx, userID)
+	if errors.Is(err, entities.ErrUserNotFound) {
+		return apperrors.Invalid("user_id", "user_not_found")
+	}
 	if err != nil {
-		return fmt.Errorf("user not found")
+		return fmt.Errorf("failed to get user: %w", err)
 	}
 
 	if user.Status != entities.UserStatusActive {
-		return fmt.Errorf("user is not active")
+		return apperrors.Invalid("user_id", "user_inactive")
 	}
 
 	// Assign tablet to user
+	previous := tablet.Status
 	tablet.Status = entities.TabletStatusAssigned
 	tablet.AssignedUserID = &userID
 	tablet.UserCPF = &userCPF
@@ -111,22 +319,26 @@ func (s *TabletService) AssignToUser(ctx context.Context, tabletID int, userID u
 		return fmt.Errorf("failed to assign tablet: %w", err)
 	}
 
-	return nil
+	return s.recordEvent(ctx, tablet, entities.TabletEventAssigned, previous, "")
 }
 
 func (s *TabletService) ReturnTablet(ctx context.Context, tabletID int) error {
+	ctx, span := tracer.Start(ctx, "TabletService.ReturnTablet")
+	defer span.End()
+
 	// Get tablet
 	tablet, err := s.tabletRepo.GetByID(ctx, tabletID)
 	if err != nil {
-		return fmt.Errorf("tablet not found")
+		return err
 	}
 
 	// Check if tablet is assigned
 	if tablet.Status != entities.TabletStatusAssigned {
-		return fmt.Errorf("tablet is not currently assigned")
+		return apperrors.Conflict("tablet_not_assigned")
 	}
 
 	// Return tablet (make it available)
+	returnedBy := tablet.AssignedUserID
 	tablet.Status = entities.TabletStatusAvailable
 	tablet.AssignedUserID = nil
 	tablet.UserCPF = nil
@@ -136,18 +348,31 @@ func (s *TabletService) ReturnTablet(ctx context.Context, tabletID int) error {
 		return fmt.Errorf("failed to return tablet: %w", err)
 	}
 
+	event := &entities.TabletEvent{
+		TabletID:   tablet.ID,
+		Type:       entities.TabletEventReturned,
+		FromStatus: entities.TabletStatusAssigned,
+		ToStatus:   tablet.Status,
+		UserID:     returnedBy,
+	}
+	if err := s.tabletRepo.AddEvent(ctx, event); err != nil {
+		return fmt.Errorf("failed to record tablet event: %w", err)
+	}
+
 	return nil
 }
 
 func (s *TabletService) MarkAsMaintenance(ctx context.Context, tabletID int) error {
+	ctx, span := tracer.Start(ctx, "TabletService.MarkAsMaintenance")
+	defer span.End()
+
 	// Get tablet
 	tablet, err := s.tabletRepo.GetByID(ctx, tabletID)
 	if err != nil {
-		return fmt.Errorf("tablet not found")
+		return err
 	}
 
-	// Mark as maintenance
-	tablet.Status = entities.TabletStatusMaintenance
+	previous := tablet.Status
 
 	// If it was assigned, unassign it
 	if tablet.Status == entities.TabletStatusAssigned {
@@ -156,32 +381,53 @@ func (s *TabletService) MarkAsMaintenance(ctx context.Context, tabletID int) err
 		tablet.AssignedAt = nil
 	}
 
+	// Mark as maintenance
+	tablet.Status = entities.TabletStatusMaintenance
+
 	if err := s.tabletRepo.Update(ctx, tablet); err != nil {
 		return fmt.Errorf("failed to mark tablet as maintenance: %w", err)
 	}
 
-	return nil
+	return s.recordEvent(ctx, tablet, entities.TabletEventStatusChanged, previous, "")
 }
 
 func (s *TabletService) Update(ctx context.Context, tablet *entities.Tablet) error {
+	ctx, span := tracer.Start(ctx, "TabletService.Update")
+	defer span.End()
+
 	// Check if tablet exists
-	_, err := s.tabletRepo.GetByID(ctx, tablet.ID)
+	existing, err := s.tabletRepo.GetByID(ctx, tablet.ID)
 	if err != nil {
-		return fmt.Errorf("tablet not found")
+		return err
+	}
+
+	// Moving between municipalities goes through the transfer workflow
+	if tablet.MunicipalityID != existing.MunicipalityID {
+		return apperrors.Invalid("municipality_id", "tablet_move_requires_transfer")
+	}
+	if (tablet.Status == entities.TabletStatusInTransit) != (existing.Status == entities.TabletStatusInTransit) {
+		return apperrors.Invalid("status", "tablet_in_transit_status")
 	}
 
 	if err := s.tabletRepo.Update(ctx, tablet); err != nil {
 		return fmt.Errorf("failed to update tablet: %w", err)
 	}
 
+	if tablet.Status != existing.Status {
+		return s.recordEvent(ctx, tablet, entities.TabletEventStatusChanged, existing.Status, "")
+	}
+
 	return nil
 }
 
 func (s *TabletService) Delete(ctx context.Context, id int) error {
+	ctx, span := tracer.Start(ctx, "TabletService.Delete")
+	defer span.End()
+
 	// Check if tablet exists
 	_, err := s.tabletRepo.GetByID(ctx, id)
 	if err != nil {
-		return fmt.Errorf("tablet not found")
+		return err
 	}
 
 	if err := s.tabletRepo.Delete(ctx, id); err != nil {
@@ -191,87 +437,313 @@ func (s *TabletService) Delete(ctx context.Context, id int) error {
 	return nil
 }
 
+// recordEvent appends a status change to the tablet's history
+func (s *TabletService) recordEvent(ctx context.Context, tablet *entities.Tablet, eventType entities.TabletEventType, from entities.TabletStatus, note string) error {
+	event := &entities.TabletEvent{
+		TabletID:   tablet.ID,
+		Type:       eventType,
+		FromStatus: from,
+		ToStatus:   tablet.Status,
+		UserID:     tablet.AssignedUserID,
+		Note:       note,
+	}
+
+	if err := s.tabletRepo.AddEvent(ctx, event); err != nil {
+		return fmt.Errorf("failed to record tablet event: %w", err)
+	}
+
+	return nil
+}
+
 // SearchAgentByCPF searches for a user agent by CPF
 func (s *TabletService) SearchAgentByCPF(ctx context.Context, cpf string) (*entities.User, error) {
+	ctx, span := tracer.Start(ctx, "TabletService.SearchAgentByCPF")
+	defer span.End()
+
 	user, err := s.userRepo.GetByCPF(ctx, cpf)
+	if errors.Is(err, entities.ErrUserNotFound) {
+		return nil, ErrAgentNotFound
+	}
 	if err != nil {
-		return nil, fmt.Errorf("agent not found: %w", err)
+		return nil, fmt.Errorf("failed to get agent: %w", err)
 	}
 	return user, nil
 }
 
+// openRequest records a request on behalf of the agent. Callers let the approvers know
+// once it is saved, outside of any transaction it is part of.
+func (s *TabletService) openRequest(ctx context.Context, agentCPF string, requestType entities.TabletRequestType, description string) (*entities.TabletRequest, error) {
+	agent, err := s.userRepo.GetByCPF(ctx, agentCPF)
+	if errors.Is(err, entities.ErrUserNotFound) {
+		return nil, ErrAgentNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get agent: %w", err)
+	}
+
+	request := &entities.TabletRequest{
+		UserID:      agent.ID,
+		Type:        requestType,
+		Status:      entities.TabletRequestStatusPending,
+		Description: description,
+	}
+
+	if err := s.requestRepo.Create(ctx, request); err != nil {
+		return nil, fmt.Errorf("failed to create tablet request: %w", err)
+	}
+	request.User = agent
+
+	return request, nil
+}
+
 // RequestNewTablet creates a new tablet request
-func (s *TabletService) RequestNewTablet(ctx context.Context, agentCPF string, requestedBy uuid.UUID) error {
-	// This would typically create a tablet request record
-	// For now, we'll just return success
-	return nil
+func (s *TabletService) RequestNewTablet(ctx context.Context, agentCPF string, requestedBy uuid.UUID) (*entities.TabletRequest, error) {
+	ctx, span := tracer.Start(ctx, "TabletService.RequestNewTablet")
+	defer span.End()
+
+	request, err := s.openRequest(ctx, agentCPF, entities.TabletRequestTypeNew, "")
+	if err != nil {
+		return nil, err
+	}
+
+	s.notifications.TabletRequestCreated(ctx, request, requestedBy)
+
+	return request, nil
 }
 
 // RequestTabletReturn creates a tablet return request
-func (s *TabletService) RequestTabletReturn(ctx context.Context, agentCPF string) error {
+func (s *TabletService) RequestTabletReturn(ctx context.Context, agentCPF string, requestedBy uuid.UUID) (*entities.TabletRequest, error) {
+	ctx, span := tracer.Start(ctx, "TabletService.RequestTabletReturn")
+	defer span.End()
+
+	request, err := s.openRequest(ctx, agentCPF, entities.TabletRequestTypeReturn, "")
+	if err != nil {
+		return nil, err
+	}
+
+	s.notifications.TabletRequestCreated(ctx, request, requestedBy)
+
 	// Find tablets by CPF and mark for return
 	tablets, err := s.GetByUserCPF(ctx, agentCPF)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	for _, tablet := range tablets {
 		if err := s.ReturnTablet(ctx, tablet.ID); err != nil {
-			return err
+			return nil, err
 		}
 	}
-	return nil
+	return request, nil
 }
 
 // ReportTabletBroken reports a tablet as broken
-func (s *TabletService) ReportTabletBroken(ctx context.Context, agentCPF string, description string) error {
+func (s *TabletService) ReportTabletBroken(ctx context.Context, agentCPF string, description string, requestedBy uuid.UUID) (*entities.TabletRequest, error) {
+	ctx, span := tracer.Start(ctx, "TabletService.ReportTabletBroken")
+	defer span.End()
+
+	request, err := s.openRequest(ctx, agentCPF, entities.TabletRequestTypeBreakage, description)
+	if err != nil {
+		return nil, err
+	}
+
+	s.notifications.TabletRequestCreated(ctx, request, requestedBy)
+
 	tablets, err := s.GetByUserCPF(ctx, agentCPF)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	for _, tablet := range tablets {
 		if err := s.MarkAsMaintenance(ctx, tablet.ID); err != nil {
+			return nil, err
+		}
+	}
+	return request, nil
+}
+
+// ReportTabletStolen reports a tablet as stolen; the police report (BO) is attached to
+// the request afterwards and is required to approve it
+func (s *TabletService) ReportTabletStolen(ctx context.Context, agentCPF string, description string, requestedBy uuid.UUID) (*entities.TabletRequest, error) {
+	ctx, span := tracer.Start(ctx, "TabletService.ReportTabletStolen")
+	defer span.End()
+
+	// The request, the tablets' status and the webhook announcements are saved together
+	var request *entities.TabletRequest
+	err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		request, err = s.openRequest(ctx, agentCPF, entities.TabletRequestTypeTheft, description)
+		if err != nil {
+			return err
+		}
+
+		tablets, err := s.GetByUserCPF(ctx, agentCPF)
+		if err != nil {
 			return err
 		}
+
+		for _, tablet := range tablets {
+			tablet.Status = entities.TabletStatusStolen
+			tablet.UpdatedAt = time.Now()
+			if err := s.Update(ctx, tablet); err != nil {
+				return err
+			}
+
+			job, err := entities.NewJob(entities.JobTabletStolen, entities.TabletStolenJob{TabletID: tablet.ID, RequestID: request.ID})
+			if err != nil {
+				return err
+			}
+			if _, err := s.jobRepo.Enqueue(ctx, job); err != nil {
+				return fmt.Errorf("failed to queue stolen tablet announcement: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Notified after the commit: a failed notification must not abort the report
+	s.notifications.TabletRequestCreated(ctx, request, requestedBy)
+
+	return request, nil
+}
+
+// AnnounceStolen sends the tablet.stolen webhook of a tablet reported stolen; it runs as
+// the tablet.stolen job
+func (s *TabletService) AnnounceStolen(ctx context.Context, job entities.TabletStolenJob) error {
+	ctx, span := tracer.Start(ctx, "TabletService.AnnounceStolen")
+	defer span.End()
+
+	tablet, err := s.tabletRepo.GetByID(ctx, job.TabletID)
+	if errors.Is(err, entities.ErrTabletNotFound) {
+		// Deleted before the job ran
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load tablet: %w", err)
+	}
+
+	request, err := s.requestRepo.GetByID(ctx, job.RequestID)
+	if errors.Is(err, entities.ErrTabletRequestNotFound) {
+		return nil
 	}
+	if err != nil {
+		return fmt.Errorf("failed to load tablet request: %w", err)
+	}
+
+	s.webhooks.TabletStolen(ctx, tablet, request)
+
 	return nil
 }
 
-// ReportTabletStolen reports a tablet as stolen
-func (s *TabletService) ReportTabletStolen(ctx context.Context, agentCPF string, description string) error {
-	tablets, err := s.GetByUserCPF(ctx, agentCPF)
+// GetTabletRequests returns a page of tablet requests
+func (s *TabletService) GetTabletRequests(ctx context.Context, q entities.ListQuery[entities.TabletRequestFilter]) (*entities.Page[*entities.TabletRequest], error) {
+	ctx, span := tracer.Start(ctx, "TabletService.GetTabletRequests")
+	defer span.End()
+
+	page, err := s.requestRepo.List(ctx, q)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to get tablet requests: %w", err)
 	}
 
-	for _, tablet := range tablets {
-		tablet.Status = entities.TabletStatusMaintenance
-		tablet.UpdatedAt = time.Now()
-		if err := s.Update(ctx, tablet); err != nil {
-			return err
-		}
+	return page, nil
+}
+
+func (s *TabletService) GetTabletRequest(ctx context.Context, id uuid.UUID) (*entities.TabletRequest, error) {
+	ctx, span := tracer.Start(ctx, "TabletService.GetTabletRequest")
+	defer span.End()
+
+	return s.requestRepo.GetByID(ctx, id)
+}
+
+// AddRequestPhoto attaches a stored photo to a pending request
+func (s *TabletService) AddRequestPhoto(ctx context.Context, requestID uuid.UUID, url string) error {
+	ctx, span := tracer.Start(ctx, "TabletService.AddRequestPhoto")
+	defer span.End()
+
+	if err := s.requestRepo.AddPhoto(ctx, requestID, url); err != nil {
+		return fmt.Errorf("failed to attach photo: %w", err)
+	}
+
+	return nil
+}
+
+// SetRequestDocument attaches the stored police report (BO) to a pending request
+func (s *TabletService) SetRequestDocument(ctx context.Context, requestID uuid.UUID, url string) error {
+	ctx, span := tracer.Start(ctx, "TabletService.SetRequestDocument")
+	defer span.End()
+
+	if err := s.requestRepo.SetDocument(ctx, requestID, url); err != nil {
+		return fmt.Errorf("failed to attach document: %w", err)
 	}
+
 	return nil
 }
 
-// GetTabletRequests gets all tablet requests
-func (s *TabletService) GetTabletRequests(ctx context.Context, filters map[string]interface{}) ([]map[string]interface{}, error) {
-	// This would typically return tablet request records
-	// For now, we'll return an empty slice
-	return []map[string]interface{}{}, nil
+// pendingRequest loads a request that can still be approved or rejected
+func (s *TabletService) pendingRequest(ctx context.Context, requestID uuid.UUID) (*entities.TabletRequest, error) {
+	request, err := s.requestRepo.GetByID(ctx, requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.Status != entities.TabletRequestStatusPending {
+		return nil, entities.ErrTabletRequestNotPending
+	}
+
+	return request, nil
 }
 
 // ApproveTabletRequest approves a tablet request
-func (s *TabletService) ApproveTabletRequest(ctx context.Context, requestID uint, approvedBy uuid.UUID) error {
-	// This would typically update a tablet request record
-	// For now, we'll just return success
+func (s *TabletService) ApproveTabletRequest(ctx context.Context, requestID uuid.UUID, approvedBy uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "TabletService.ApproveTabletRequest")
+	defer span.End()
+
+	request, err := s.pendingRequest(ctx, requestID)
+	if err != nil {
+		return err
+	}
+
+	if request.Type == entities.TabletRequestTypeTheft && request.DocumentURL == "" {
+		return entities.ErrPoliceReportRequired
+	}
+
+	now := time.Now()
+	request.Status = entities.TabletRequestStatusApproved
+	request.ApprovedBy = &approvedBy
+	request.ApprovedAt = &now
+
+	if err := s.requestRepo.Update(ctx, request); err != nil {
+		return fmt.Errorf("failed to approve tablet request: %w", err)
+	}
+
+	s.notifications.TabletRequestDecided(ctx, request)
+
 	return nil
 }
 
 // RejectTabletRequest rejects a tablet request
-func (s *TabletService) RejectTabletRequest(ctx context.Context, requestID uint, rejectedBy uuid.UUID, reason string) error {
-	// This would typically update a tablet request record
-	// For now, we'll just return success
+func (s *TabletService) RejectTabletRequest(ctx context.Context, requestID uuid.UUID, rejectedBy uuid.UUID, reason string) error {
+	ctx, span := tracer.Start(ctx, "TabletService.RejectTabletRequest")
+	defer span.End()
+
+	request, err := s.pendingRequest(ctx, requestID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	request.Status = entities.TabletRequestStatusRejected
+	request.RejectedBy = &rejectedBy
+	request.RejectedAt = &now
+	request.RejectionReason = reason
+
+	if err := s.requestRepo.Update(ctx, request); err != nil {
+		return fmt.Errorf("failed to reject tablet request: %w", err)
+	}
+
+	s.notifications.TabletRequestDecided(ctx, request)
+
 	return nil
 }
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/joaopanucci/apsdigital/internal/domain/entities"
+	"github.com/joaopanucci/apsdigital/internal/domain/repositories"
+)
+
+// tabletStatsCacheTTL is how long a dashboard result is served before it is recomputed
+const tabletStatsCacheTTL = 5 * time.Minute
+
+type cachedTabletStats struct {
+	stats    *entities.TabletStats
+	loadedAt time.Time
+}
+
+type TabletStatsService struct {
+	statsRepo repositories.TabletStatsRepository
+
+	mu    sync.Mutex
+	cache map[string]cachedTabletStats // by filter key
+}
+
+func NewTabletStatsService(statsRepo repositories.TabletStatsRepository) *TabletStatsService {
+	return &TabletStatsService{
+		statsRepo: statsRepo,
+		cache:     make(map[string]cachedTabletStats),
+	}
+}
+
+// GetStats returns the tablet dashboard figures, reusing a result computed for the same
+// filter within the cache TTL
+func (s *TabletStatsService) GetStats(ctx context.Context, f entities.TabletStatsFilter) (*entities.TabletStats, error) {
+	ctx, span := tracer.Start(ctx, "TabletStatsService.GetStats")
+	defer span.End()
+
+	key := tabletStatsKey(f)
+
+	s.mu.Lock()
+	cached, ok := s.cache[key]
+	s.mu.Unlock()
+
+	if ok && time.Since(cached.loadedAt) < tabletStatsCacheTTL {
+		return cached.stats, nil
+	}
+
+	stats := &entities.TabletStats{GeneratedAt: time.Now()}
+
+	municipalities, err := s.statsRepo.CountByMunicipalityStatus(ctx, f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tablet stats: %w", err)
+	}
+	stats.Municipalities = municipalities
+
+	coverage, err := s.statsRepo.ACSCoverage(ctx, f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tablet stats: %w", err)
+	}
+	stats.ACSCoverage = *coverage
+
+	maintenance, err := s.statsRepo.MaintenanceDurations(ctx, f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tablet stats: %w", err)
+	}
+	stats.Maintenance = *maintenance
+
+	incidents, err := s.statsRepo.IncidentsByQuarter(ctx, f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tablet stats: %w", err)
+	}
+	stats.IncidentsByQuarter = incidents
+
+	s.mu.Lock()
+	// Drop expired entries so filters that are not requested again do not pile up
+	for k, entry := range s.cache {
+		if time.Since(entry.loadedAt) >= tabletStatsCacheTTL {
+			delete(s.cache, k)
+		}
+	}
+	s.cache[key] = cachedTabletStats{stats: stats, loadedAt: stats.GeneratedAt}
+	s.mu.Unlock()
+
+	return stats, nil
+}
+
+// tabletStatsKey identifies a filter by value, so equal filters share a cache entry
+func tabletStatsKey(f entities.TabletStatsFilter) string {
+	parts := make([]string, 5)
+	if f.MunicipalityID != nil {
+		parts[0] = strconv.Itoa(*f.MunicipalityID)
+	}
+	if f.MunicipalityIDs != nil {
+		ids := append([]int(nil), f.MunicipalityIDs...)
+		sort.Ints(ids)
+		scope := make([]string, len(ids))
+		for i, id := range ids {
+			scope[i] = strconv.Itoa(id)
+		}
+		parts[1] = "[" + strings.Join(scope, ",") + "]"
+	}
+	if f.HealthRegionID != nil {
+		parts[2] = strconv.Itoa(*f.HealthRegionID)
+	}
+	if f.From != nil {
+		parts[3] = f.From.Format(time.RFC3339)
+	}
+	if f.To != nil {
+		parts[4] = f.To.Format(time.RFC3339)
+	}
+	return strings.Join(parts, "|")
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/joaopanucci/apsdigital/internal/domain/apperrors"
+	"github.com/joaopanucci/apsdigital/internal/domain/entities"
+	"github.com/joaopanucci/apsdigital/internal/domain/repositories"
+
+	"github.com/google/uuid"
+)
+
+// TabletTransferService moves tablets between municipalities: the source proposes,
+// a coordinator approves and the tablet stays in transit until the destination
+// confirms it was received
+type TabletTransferService struct {
+	transferRepo     repositories.TabletTransferRepository
+	tabletRepo       repositories.TabletRepository
+	municipalityRepo repositories.MunicipalityRepository
+}
+
+func NewTabletTransferService(transferRepo repositories.TabletTransferRepository, tabletRepo repositories.TabletRepository, municipalityRepo repositories.MunicipalityRepository) *TabletTransferService {
+	return &TabletTransferService{
+		transferRepo:     transferRepo,
+		tabletRepo:       tabletRepo,
+		municipalityRepo: municipalityRepo,
+	}
+}
+
+func (s *TabletTransferService) GetAll(ctx context.Context, q entities.ListQuery[entities.TabletTransferFilter]) (*entities.Page[*entities.TabletTransfer], error) {
+	ctx, span := tracer.Start(ctx, "TabletTransferService.GetAll")
+	defer span.End()
+
+	page, err := s.transferRepo.List(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tablet transfers: %w", err)
+	}
+
+	return page, nil
+}
+
+func (s *TabletTransferService) GetByID(ctx context.Context, id int64) (*entities.TabletTransfer, error) {
+	ctx, span := tracer.Start(ctx, "TabletTransferService.GetByID")
+	defer span.End()
+
+	return s.transferRepo.GetByID(ctx, id)
+}
+
+// Propose opens a transfer of an available tablet to another active municipality
+func (s *TabletTransferService) Propose(ctx context.Context, tablet *entities.Tablet, toMunicipalityID int, reason string, proposedBy uuid.UUID) (*entities.TabletTransfer, error) {
+	ctx, span := tracer.Start(ctx, "TabletTransferService.Propose")
+	defer span.End()
+
+	if toMunicipalityID == tablet.MunicipalityID {
+		return nil, apperrors.Invalid("to_municipality_id", "transfer_same_municipality")
+	}
+
+	destination, err := s.municipalityRepo.GetByID(ctx, toMunicipalityID)
+	if errors.Is(err, entities.ErrMunicipalityNotFound) {
+		return nil, apperrors.Invalid("to_municipality_id", "destination_not_found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get destination municipality: %w", err)
+	}
+	if !destination.Active {
+		return nil, apperrors.Invalid("to_municipality_id", "destination_inactive")
+	}
+
+	transfer := &entities.TabletTransfer{
+		TabletID:           tablet.ID,
+		FromMunicipalityID: tablet.MunicipalityID,
+		ToMunicipalityID:   toMunicipalityID,
+		Reason:             reason,
+		ProposedBy:         proposedBy,
+	}
+
+	if err := s.transferRepo.Create(ctx, transfer); err != nil {
+		return nil, fmt.Errorf("failed to propose tablet transfer: %w", err)
+	}
+
+	return transfer, nil
+}
+
+func (s *TabletTransferService) Approve(ctx context.Context, id int64, approvedBy uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "TabletTransferService.Approve")
+	defer span.End()
+
+	if err := s.transferRepo.Approve(ctx, id, approvedBy); err != nil {
+		return fmt.Errorf("failed to approve tablet transfer: %w", err)
+	}
+
+	return nil
+}
+
+func (s *TabletTransferService) Reject(ctx context.Context, id int64, rejectedBy uuid.UUID, reason string) error {
+	ctx, span := tracer.Start(ctx, "TabletTransferService.Reject")
+	defer span.End()
+
+	if reason == "" {
+		return apperrors.Required("reason")
+	}
+
+	if err := s.transferRepo.Reject(ctx, id, rejectedBy, reason); err != nil {
+		return fmt.Errorf("failed to reject tablet transfer: %w", err)
+	}
+
+	return nil
+}
+
+func (s *TabletTransferService) Receive(ctx context.Context, id int64, receivedBy uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "TabletTransferService.Receive")
+	defer span.End()
+
+	if err := s.transferRepo.Receive(ctx, id, receivedBy); err != nil {
+		return fmt.Errorf("failed to receive tablet transfer: %w", err)
+	}
+
+	return nil
+}
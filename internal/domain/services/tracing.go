@@ -0,0 +1,6 @@
+package services
+
+import "go.opentelemetry.io/otel"
+
+// tracer starts a span for each service method, between the request and its queries
+var tracer = otel.Tracer("github.com/joaopanucci/apsdigital/internal/domain/services")
@@ -11,16 +11,28 @@ import (
 )
 
 type UserAuthorizationService struct {
-	userRepo repositories.UserRepository
+	userRepo      repositories.UserRepository
+	notifications *NotificationService
 }
 
-func NewUserAuthorizationService(userRepo repositories.UserRepository) *UserAuthorizationService {
+func NewUserAuthorizationService(userRepo repositories.UserRepository, notifications *NotificationService) *UserAuthorizationService {
 	return &UserAuthorizationService{
-		userRepo: userRepo,
+		userRepo:      userRepo,
+		notifications: notifications,
 	}
 }
 
+func (s *UserAuthorizationService) GetByID(ctx context.Context, userID uuid.UUID) (*entities.User, error) {
+	ctx, span := tracer.Start(ctx, "UserAuthorizationService.GetByID")
+	defer span.End()
+
+	return s.userRepo.GetByID(ctx, userID)
+}
+
 func (s *UserAuthorizationService) GetPendingUsers(ctx context.Context) ([]*entities.User, error) {
+	ctx, span := tracer.Start(ctx, "UserAuthorizationService.GetPendingUsers")
+	defer span.End()
+
 	users, err := s.userRepo.GetPendingAuthorization(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get pending authorization users: %w", err)
@@ -30,33 +42,41 @@ func (s *UserAuthorizationService) GetPendingUsers(ctx context.Context) ([]*enti
 }
 
 func (s *UserAuthorizationService) AuthorizeUser(ctx context.Context, userID uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "UserAuthorizationService.AuthorizeUser")
+	defer span.End()
+
 	// Check if user exists and is pending authorization
 	user, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
-		return fmt.Errorf("user not found")
+		return err
 	}
 	
 	if user.Status != entities.UserStatusPendingAuthorization {
-		return fmt.Errorf("user is not pending authorization")
+		return ErrUserNotPending
 	}
 	
 	// Authorize user
 	if err := s.userRepo.AuthorizeUser(ctx, userID); err != nil {
 		return fmt.Errorf("failed to authorize user: %w", err)
 	}
-	
+
+	s.notifications.UserAuthorized(ctx, user)
+
 	return nil
 }
 
 func (s *UserAuthorizationService) RejectUser(ctx context.Context, userID uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "UserAuthorizationService.RejectUser")
+	defer span.End()
+
 	// Check if user exists
 	user, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
-		return fmt.Errorf("user not found")
+		return err
 	}
 	
 	if user.Status != entities.UserStatusPendingAuthorization {
-		return fmt.Errorf("user is not pending authorization")
+		return ErrUserNotPending
 	}
 	
 	// Set user as inactive (rejection)
@@ -64,20 +84,35 @@ func (s *UserAuthorizationService) RejectUser(ctx context.Context, userID uuid.U
 	if err := s.userRepo.Update(ctx, user); err != nil {
 		return fmt.Errorf("failed to reject user: %w", err)
 	}
-	
+
+	s.notifications.UserRejected(ctx, user)
+
 	return nil
 }
 
-func (s *UserAuthorizationService) GetUsersByMunicipality(ctx context.Context, municipalityID int) ([]*entities.User, error) {
-	filters := map[string]interface{}{
-		"municipality_id": municipalityID,
-		"status": entities.UserStatusActive,
-	}
+func (s *UserAuthorizationService) GetUsersByMunicipality(ctx context.Context, municipalityID int, q entities.ListQuery[entities.UserFilter]) (*entities.Page[*entities.User], error) {
+	ctx, span := tracer.Start(ctx, "UserAuthorizationService.GetUsersByMunicipality")
+	defer span.End()
+
+	q.Filters.MunicipalityID = &municipalityID
+	q.Filters.Status = entities.UserStatusActive
 	
-	users, err := s.userRepo.List(ctx, filters)
+	page, err := s.userRepo.List(ctx, q)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get users by municipality: %w", err)
 	}
 	
-	return users, nil
+	return page, nil
+}
+
+func (s *UserAuthorizationService) ListUsers(ctx context.Context, q entities.ListQuery[entities.UserFilter]) (*entities.Page[*entities.User], error) {
+	ctx, span := tracer.Start(ctx, "UserAuthorizationService.ListUsers")
+	defer span.End()
+
+	page, err := s.userRepo.List(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	
+	return page, nil
 }
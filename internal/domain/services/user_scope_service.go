@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/joaopanucci/apsdigital/internal/domain/apperrors"
+	"github.com/joaopanucci/apsdigital/internal/domain/entities"
+	"github.com/joaopanucci/apsdigital/internal/domain/repositories"
+
+	"github.com/google/uuid"
+)
+
+type UserScopeService struct {
+	userRepo         repositories.UserRepository
+	municipalityRepo repositories.MunicipalityRepository
+	regionRepo       repositories.HealthRegionRepository
+}
+
+func NewUserScopeService(userRepo repositories.UserRepository, municipalityRepo repositories.MunicipalityRepository, regionRepo repositories.HealthRegionRepository) *UserScopeService {
+	return &UserScopeService{
+		userRepo:         userRepo,
+		municipalityRepo: municipalityRepo,
+		regionRepo:       regionRepo,
+	}
+}
+
+// ResolveMunicipalityScope expands the user's scope into the municipalities it covers
+func (s *UserScopeService) ResolveMunicipalityScope(ctx context.Context, user *entities.User) (*entities.MunicipalityScope, error) {
+	ctx, span := tracer.Start(ctx, "UserScopeService.ResolveMunicipalityScope")
+	defer span.End()
+
+	scope := user.Scope()
+
+	switch scope.Type {
+	case entities.ScopeRegion:
+		if scope.HealthRegionID == nil {
+			return &entities.MunicipalityScope{}, nil
+		}
+
+		ids, err := s.regionRepo.ListMunicipalityIDs(ctx, *scope.HealthRegionID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve region scope: %w", err)
+		}
+		return &entities.MunicipalityScope{MunicipalityIDs: ids}, nil
+
+	case entities.ScopeState:
+		ids, err := s.municipalityRepo.ListIDsByState(ctx, scope.State)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve state scope: %w", err)
+		}
+		return &entities.MunicipalityScope{MunicipalityIDs: ids}, nil
+
+	default:
+		if scope.MunicipalityID == nil {
+			return &entities.MunicipalityScope{}, nil
+		}
+		return &entities.MunicipalityScope{MunicipalityIDs: []int{*scope.MunicipalityID}}, nil
+	}
+}
+
+// SetUserScope assigns a user to a municipality, a health region or a whole state
+func (s *UserScopeService) SetUserScope(ctx context.Context, userID uuid.UUID, scope entities.UserScope) error {
+	ctx, span := tracer.Start(ctx, "UserScopeService.SetUserScope")
+	defer span.End()
+
+	if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+		return err
+	}
+
+	switch scope.Type {
+	case entities.ScopeMunicipality:
+		if scope.MunicipalityID == nil {
+			return apperrors.Required("municipality_id")
+		}
+		if _, err := s.municipalityRepo.GetByID(ctx, *scope.MunicipalityID); err != nil {
+			return err
+		}
+		scope.HealthRegionID = nil
+		scope.State = ""
+
+	case entities.ScopeRegion:
+		if scope.HealthRegionID == nil {
+			return apperrors.Required("health_region_id")
+		}
+		if _, err := s.regionRepo.GetByID(ctx, *scope.HealthRegionID); err != nil {
+			return err
+		}
+		scope.State = ""
+
+	case entities.ScopeState:
+		scope.State = strings.ToUpper(strings.TrimSpace(scope.State))
+		if len(scope.State) != 2 {
+			return apperrors.Invalid("state", "state_invalid")
+		}
+		scope.HealthRegionID = nil
+
+	default:
+		return apperrors.Invalid("type", "scope_type_invalid")
+	}
+
+	return s.userRepo.UpdateScope(ctx, userID, scope)
+}
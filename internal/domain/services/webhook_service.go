@@ -0,0 +1,290 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joaopanucci/apsdigital/internal/domain/apperrors"
+	"github.com/joaopanucci/apsdigital/internal/domain/entities"
+	"github.com/joaopanucci/apsdigital/internal/domain/repositories"
+
+	"github.com/google/uuid"
+)
+
+// WebhookService manages webhook subscriptions and queues events for them. Like
+// notifications, a failure to queue an event is logged and never fails the caller.
+type WebhookService struct {
+	webhookRepo      repositories.WebhookRepository
+	municipalityRepo repositories.MunicipalityRepository
+}
+
+func NewWebhookService(webhookRepo repositories.WebhookRepository, municipalityRepo repositories.MunicipalityRepository) *WebhookService {
+	return &WebhookService{
+		webhookRepo:      webhookRepo,
+		municipalityRepo: municipalityRepo,
+	}
+}
+
+// Publish queues the event for the subscriptions of the municipality that want it
+func (s *WebhookService) Publish(ctx context.Context, eventType string, municipalityID int, data interface{}) {
+	ctx, span := tracer.Start(ctx, "WebhookService.Publish")
+	defer span.End()
+
+	event := newWebhookEvent(eventType, municipalityID, data)
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error encoding webhook event", "event_type", eventType, "error", err)
+		return
+	}
+
+	if _, err := s.webhookRepo.Enqueue(ctx, event, payload); err != nil {
+		slog.ErrorContext(ctx, "Error queueing webhook event", "event_type", eventType, "error", err)
+	}
+}
+
+func newWebhookEvent(eventType string, municipalityID int, data interface{}) *entities.WebhookEvent {
+	return &entities.WebhookEvent{
+		ID:             uuid.New(),
+		Type:           eventType,
+		MunicipalityID: municipalityID,
+		CreatedAt:      time.Now().UTC(),
+		Data:           data,
+	}
+}
+
+// PaymentPublished sends a new payment file to its municipality's systems
+func (s *WebhookService) PaymentPublished(ctx context.Context, payment *entities.Payment) {
+	ctx, span := tracer.Start(ctx, "WebhookService.PaymentPublished")
+	defer span.End()
+
+	if payment.MunicipalityID == nil {
+		return
+	}
+
+	s.Publish(ctx, entities.WebhookEventPaymentPublished, *payment.MunicipalityID, entities.PaymentPublishedData{
+		ID:               payment.ID,
+		MunicipalityID:   *payment.MunicipalityID,
+		MunicipalityName: payment.MunicipalityName,
+		Competence:       payment.Competence,
+		CompetenceYear:   competenceYear(payment.Competence),
+		FileName:         path.Base(payment.FileURL),
+		PublishedAt:      payment.CreatedAt,
+	})
+}
+
+// competenceYear returns the year of a YYYY-MM competence, or 0 when it has none
+func competenceYear(competence string) int {
+	year, _, _ := strings.Cut(competence, "-")
+	n, err := strconv.Atoi(year)
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// TabletStolen sends a tablet reported stolen, with the request that reported it
+func (s *WebhookService) TabletStolen(ctx context.Context, tablet *entities.Tablet, request *entities.TabletRequest) {
+	ctx, span := tracer.Start(ctx, "WebhookService.TabletStolen")
+	defer span.End()
+
+	s.Publish(ctx, entities.WebhookEventTabletStolen, tablet.MunicipalityID, entities.TabletStolenData{
+		TabletID:       tablet.ID,
+		AssetCode:      tablet.AssetCode,
+		Model:          tablet.Model,
+		SerialNumber:   tablet.SerialNumber,
+		MunicipalityID: tablet.MunicipalityID,
+		RequestID:      request.ID,
+		ReportedAt:     request.CreatedAt,
+	})
+}
+
+func (s *WebhookService) GetAll(ctx context.Context, filter entities.WebhookFilter) ([]*entities.WebhookSubscription, error) {
+	ctx, span := tracer.Start(ctx, "WebhookService.GetAll")
+	defer span.End()
+
+	subscriptions, err := s.webhookRepo.List(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhooks: %w", err)
+	}
+
+	return subscriptions, nil
+}
+
+func (s *WebhookService) GetByID(ctx context.Context, id uuid.UUID) (*entities.WebhookSubscription, error) {
+	ctx, span := tracer.Start(ctx, "WebhookService.GetByID")
+	defer span.End()
+
+	return s.webhookRepo.GetByID(ctx, id)
+}
+
+// Create validates the subscription and gives it a new secret
+func (s *WebhookService) Create(ctx context.Context, subscription *entities.WebhookSubscription) error {
+	ctx, span := tracer.Start(ctx, "WebhookService.Create")
+	defer span.End()
+
+	municipality, err := s.municipalityRepo.GetByID(ctx, subscription.MunicipalityID)
+	if errors.Is(err, entities.ErrMunicipalityNotFound) {
+		return apperrors.Invalid("municipality_id", "municipality_not_found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get municipality: %w", err)
+	}
+	if !municipality.Active {
+		return apperrors.Invalid("municipality_id", "municipality_inactive")
+	}
+
+	if err := validateWebhook(subscription); err != nil {
+		return err
+	}
+
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return err
+	}
+	subscription.Secret = secret
+
+	return s.webhookRepo.Create(ctx, subscription)
+}
+
+// Update changes the name, URL, events and active flag; the municipality and secret stay
+func (s *WebhookService) Update(ctx context.Context, subscription *entities.WebhookSubscription) error {
+	ctx, span := tracer.Start(ctx, "WebhookService.Update")
+	defer span.End()
+
+	if err := validateWebhook(subscription); err != nil {
+		return err
+	}
+
+	return s.webhookRepo.Update(ctx, subscription)
+}
+
+// RotateSecret replaces the signing secret; deliveries still queued are signed with the new one
+func (s *WebhookService) RotateSecret(ctx context.Context, subscription *entities.WebhookSubscription) error {
+	ctx, span := tracer.Start(ctx, "WebhookService.RotateSecret")
+	defer span.End()
+
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return err
+	}
+	subscription.Secret = secret
+
+	return s.webhookRepo.Update(ctx, subscription)
+}
+
+func (s *WebhookService) Delete(ctx context.Context, id uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "WebhookService.Delete")
+	defer span.End()
+
+	return s.webhookRepo.Delete(ctx, id)
+}
+
+// Ping queues a ping event for the subscription so its endpoint can be checked
+func (s *WebhookService) Ping(ctx context.Context, subscription *entities.WebhookSubscription) (*entities.WebhookDelivery, error) {
+	ctx, span := tracer.Start(ctx, "WebhookService.Ping")
+	defer span.End()
+
+	event := newWebhookEvent(entities.WebhookEventPing, subscription.MunicipalityID, map[string]interface{}{
+		"webhook_id": subscription.ID,
+		"name":       subscription.Name,
+	})
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode ping: %w", err)
+	}
+
+	delivery, err := s.webhookRepo.EnqueueFor(ctx, subscription.ID, event, payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to queue ping: %w", err)
+	}
+
+	return delivery, nil
+}
+
+func (s *WebhookService) GetDeliveries(ctx context.Context, q entities.ListQuery[entities.WebhookDeliveryFilter]) (*entities.Page[*entities.WebhookDelivery], error) {
+	ctx, span := tracer.Start(ctx, "WebhookService.GetDeliveries")
+	defer span.End()
+
+	page, err := s.webhookRepo.ListDeliveries(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook deliveries: %w", err)
+	}
+
+	return page, nil
+}
+
+func (s *WebhookService) GetDelivery(ctx context.Context, id int64) (*entities.WebhookDelivery, error) {
+	ctx, span := tracer.Start(ctx, "WebhookService.GetDelivery")
+	defer span.End()
+
+	return s.webhookRepo.GetDelivery(ctx, id)
+}
+
+// Replay queues a delivery to be sent again as a new entry of the log
+func (s *WebhookService) Replay(ctx context.Context, id int64) (*entities.WebhookDelivery, error) {
+	ctx, span := tracer.Start(ctx, "WebhookService.Replay")
+	defer span.End()
+
+	return s.webhookRepo.Replay(ctx, id)
+}
+
+func validateWebhook(subscription *entities.WebhookSubscription) error {
+	subscription.Name = strings.TrimSpace(subscription.Name)
+	if subscription.Name == "" {
+		return apperrors.Required("name")
+	}
+
+	target, err := url.Parse(subscription.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return apperrors.Invalid("url", "webhook_url_invalid")
+	}
+
+	if len(subscription.EventTypes) == 0 {
+		return apperrors.Required("event_types")
+	}
+
+	seen := make(map[string]bool, len(subscription.EventTypes))
+	eventTypes := make([]string, 0, len(subscription.EventTypes))
+	for _, eventType := range subscription.EventTypes {
+		if !isWebhookEventType(eventType) {
+			return apperrors.Invalid("event_types", "webhook_event_unknown", eventType)
+		}
+		if !seen[eventType] {
+			seen[eventType] = true
+			eventTypes = append(eventTypes, eventType)
+		}
+	}
+	subscription.EventTypes = eventTypes
+
+	return nil
+}
+
+func isWebhookEventType(eventType string) bool {
+	for _, t := range entities.WebhookEventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+
+	return "whsec_" + hex.EncodeToString(b), nil
+}
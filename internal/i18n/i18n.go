@@ -0,0 +1,73 @@
+// Package i18n holds the messages the API shows to users, in Brazilian Portuguese and
+// English. Messages are looked up by key, usually an error code, in the language
+// negotiated from the request's Accept-Language header.
+package i18n
+
+import (
+	"fmt"
+
+	"golang.org/x/text/language"
+)
+
+// Supported languages, as sent back in Content-Language
+const (
+	PortugueseBR = "pt-BR"
+	English      = "en"
+	// Default answers clients that accept none of the supported languages, and is the
+	// language of logs
+	Default = PortugueseBR
+)
+
+var catalogs = map[string]map[string]string{
+	PortugueseBR: ptBR,
+	English:      en,
+}
+
+// supported lists the languages in the order matcher was built with, default first
+var supported = []string{PortugueseBR, English}
+
+var matcher = language.NewMatcher([]language.Tag{language.BrazilianPortuguese, language.English})
+
+// Negotiate picks the supported language that best matches an Accept-Language header
+func Negotiate(acceptLanguage string) string {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return Default
+	}
+
+	_, index, confidence := matcher.Match(tags...)
+	if confidence == language.No {
+		return Default
+	}
+	return supported[index]
+}
+
+// Localizer is a message argument that is a message itself, such as the error a
+// parser reports for one line of a file
+type Localizer interface {
+	Localize(lang string) string
+}
+
+// Message is the message for key in lang, filled with args. Keys missing in lang fall
+// back to the default language; unknown keys are returned as they are.
+func Message(lang, key string, args ...any) string {
+	message, ok := catalogs[lang][key]
+	if !ok {
+		message, ok = catalogs[Default][key]
+	}
+	if !ok {
+		return key
+	}
+	if len(args) == 0 {
+		return message
+	}
+
+	localized := make([]any, len(args))
+	for i, arg := range args {
+		if l, ok := arg.(Localizer); ok {
+			arg = l.Localize(lang)
+		}
+		localized[i] = arg
+	}
+	return fmt.Sprintf(message, localized...)
+}
@@ -0,0 +1,177 @@
+package i18n
+
+var en = map[string]string{
+	// Problem titles, by error kind
+	"title_internal":      "Internal error",
+	"title_validation":    "Invalid request",
+	"title_unauthorized":  "Unauthenticated",
+	"title_forbidden":     "Access denied",
+	"title_not_found":     "Not found",
+	"title_conflict":      "Conflict",
+	"title_unprocessable": "Operation not allowed",
+	"title_too_large":     "File too large",
+
+	// Errors shared across the API
+	"internal_error":    "Internal server error",
+	"not_found":         "Record not found",
+	"already_exists":    "Record already exists",
+	"in_use":            "Record is in use by other data",
+	"unauthenticated":   "User not authenticated",
+	"access_denied":     "Access denied",
+	"invalid_input":     "Invalid data",
+	"invalid_body":      "The request body could not be read",
+	"invalid_reference": "Reference to a record that does not exist",
+	"invalid_value":     "Invalid value",
+	"route_not_found":   "Route not found",
+	"invalid_token":     "Invalid or expired access token",
+
+	// Field validation
+	"required":           "This field is required",
+	"email_invalid":      "Invalid e-mail address",
+	"min_length":         "Must be at least %s characters long",
+	"min_value":          "Must be at least %s",
+	"max_length":         "Must be at most %s characters long",
+	"max_value":          "Must be at most %s",
+	"one_of":             "Must be one of: %s",
+	"id_invalid":         "Invalid identifier",
+	"cursor_invalid":     "Invalid cursor",
+	"page_invalid":       "Page must be a number greater than zero",
+	"limit_invalid":      "Limit must be a number greater than zero",
+	"sort_field_invalid": "Cannot sort by %q, use one of: %s",
+	"sort_field_unknown": "Cannot sort by %q",
+	"boolean_invalid":    "Use true or false",
+	"date_invalid":       "Invalid date, use YYYY-MM-DD",
+	"date_range_invalid": "The start date cannot be after the end date",
+	"year_invalid":       "Invalid year",
+	"state_invalid":      "Enter the two-letter state code",
+	"event_id_invalid":   "Invalid event identifier",
+
+	// Uploaded files
+	"file_unreadable":           "The uploaded file could not be read",
+	"file_too_large":            "The file exceeds %d MB",
+	"file_pdf_only":             "Only PDF files are allowed",
+	"file_format_unsupported":   "Unsupported file format: %s",
+	"file_csv_header":           "The CSV header could not be read",
+	"file_csv_column_missing":   "Required column missing from the CSV: %s",
+	"file_csv_line_unreadable":  "CSV line %d could not be read",
+	"file_csv_line_invalid":     "CSV line %d: %s",
+	"file_ibge_json":            "The file is not a valid IBGE JSON",
+	"file_ibge_item_unreadable": "Item %d of the IBGE JSON is invalid",
+	"file_ibge_item_invalid":    "Item %d of the IBGE JSON: %s",
+	"file_ibge_code_invalid":    "invalid IBGE code %q",
+	"file_municipality_name":    "municipality %s has no name",
+	"file_municipality_state":   "municipality %s has an invalid state %q",
+	"file_ibge_code_repeated":   "IBGE code %s appears more than once in the file",
+	"file_no_municipalities":    "The file has no municipalities to import",
+	"file_cnes_invalid":         "invalid CNES %q",
+	"file_establishment_name":   "establishment %s has no name",
+	"file_establishment_ibge":   "establishment %s has an invalid municipality code %q",
+	"file_no_establishments":    "The file has no establishments",
+	"file_no_tablets":           "The file has no tablets",
+
+	// Authentication and users
+	"invalid_credentials":    "Invalid CPF or password",
+	"account_not_authorized": "Your registration has not been authorized yet",
+	"invalid_refresh_token":  "Invalid session, please log in again",
+	"session_expired":        "Session expired, please log in again",
+	"session_revoked":        "Session ended for security reasons, please log in again",
+	"cpf_invalid":            "Invalid CPF",
+	"role_invalid":           "Invalid role",
+	"role_admin_not_allowed": "Signing up as an administrator is not allowed",
+	"user_not_found":         "User not found",
+	"user_inactive":          "The user is not active",
+	"user_not_pending":       "The user is not awaiting authorization",
+	"session_not_found":      "Session not found",
+	"account_inactive":       "The user account is not active",
+	"email_taken":            "E-mail already registered",
+	"cpf_taken":              "CPF already registered",
+	"scope_type_invalid":     "Invalid scope type",
+
+	// Roles, permissions and professions
+	"role_not_found":        "Role not found",
+	"role_name_taken":       "A role with this name already exists",
+	"level_not_allowed":     "You cannot authorize users of this level",
+	"permission_unknown":    "The list contains an unknown permission",
+	"permission_locked":     "The %s permission cannot be removed from the %s role",
+	"profession_not_found":  "Profession not found",
+	"profession_name_taken": "A profession with this name already exists",
+
+	// Municipalities, health regions and health units
+	"municipality_not_found":    "Municipality not found",
+	"municipality_inactive":     "The municipality is not active",
+	"municipality_name_taken":   "A municipality with this name already exists",
+	"municipality_unknown":      "The list contains an unknown municipality",
+	"ibge_code_taken":           "IBGE code already registered",
+	"health_region_not_found":   "Health region not found",
+	"health_region_name_taken":  "A region with this name already exists",
+	"region_type_invalid":       "Invalid region type",
+	"parent_region_not_allowed": "Only micro-regions can have a parent region",
+	"parent_region_not_found":   "Parent region not found",
+	"parent_region_invalid":     "The parent region must be a macro-region in the same state",
+	"health_unit_not_found":     "Health unit not found",
+	"cnes_length":               "The CNES must have 7 digits",
+	"cnes_taken":                "CNES already registered",
+
+	// Tablets, requests, transfers and allocation
+	"tablet_not_found":              "Tablet not found",
+	"tablet_not_available":          "The tablet is not available for assignment",
+	"tablet_not_assigned":           "The tablet is not assigned to anyone",
+	"tablet_without_asset_code":     "The tablet has no asset code",
+	"tablet_move_requires_transfer": "Use a transfer to move the tablet to another municipality",
+	"tablet_in_transit_status":      "The in transit status is managed by transfers",
+	"asset_code_taken":              "Asset code already registered",
+	"serial_number_taken":           "Serial number already registered",
+	"tablet_asset_code_taken":       "A tablet with asset code '%s' already exists",
+	"tablet_serial_number_taken":    "A tablet with serial number '%s' already exists",
+	"agent_lookup_required":         "Provide the CPF or the QR code",
+	"agent_not_found":               "No agent found with this CPF",
+	"qr_invalid":                    "Invalid QR code",
+	"qr_signature_invalid":          "The QR code is not from a valid label",
+	"tablet_request_type_invalid":   "Invalid request type",
+	"tablet_request_not_found":      "Request not found",
+	"tablet_request_not_pending":    "The request is no longer pending",
+	"tablet_request_photo_limit":    "The request already has %d photos",
+	"police_report_required":        "Attach the police report to approve a theft request",
+	"tablet_transfer_not_found":     "Transfer not found",
+	"transfer_state":                "The transfer cannot move to this step",
+	"transfer_same_municipality":    "The tablet is already in this municipality",
+	"destination_not_found":         "Destination municipality not found",
+	"destination_inactive":          "The destination municipality is not active",
+	"allocation_conflict":           "The allocation plan is out of date; generate a new plan",
+
+	// Payments, resolutions, webhooks and live events
+	"payment_not_found":       "Payment not found",
+	"resolution_not_found":    "Resolution not found",
+	"resolution_type_invalid": "The type must be MS or SES",
+	"webhook_not_found":       "Webhook not found",
+	"webhook_url_invalid":     "Enter a full URL, with http or https",
+	"webhook_event_unknown":   "Unknown event type: %s",
+	"live_event_not_found":    "Event not found",
+
+	// Successful operations
+	"user_registered":                      "User registered successfully. Awaiting authorization.",
+	"logged_out":                           "Logged out successfully",
+	"session_ended":                        "Session revoked successfully",
+	"user_scope_updated":                   "User scope updated successfully",
+	"user_authorized":                      "User authorized successfully",
+	"user_rejected":                        "User rejected successfully",
+	"role_permissions_updated":             "Role permissions updated successfully",
+	"profession_deleted":                   "Profession deleted successfully",
+	"health_region_deleted":                "Health region deleted successfully",
+	"health_region_municipalities_updated": "Health region municipalities updated successfully",
+	"health_unit_deleted":                  "Health unit deleted successfully",
+	"tablet_request_submitted":             "Tablet request submitted successfully",
+	"tablet_request_approved":              "Request approved successfully",
+	"tablet_request_rejected":              "Request rejected successfully",
+	"photo_attached":                       "Photo attached successfully",
+	"document_attached":                    "Document attached successfully",
+	"transfer_approved":                    "Transfer approved successfully",
+	"transfer_rejected":                    "Transfer rejected successfully",
+	"transfer_received":                    "Transfer received successfully",
+	"allocation_applied":                   "Allocation plan applied successfully",
+	"payment_uploaded":                     "Payment file uploaded successfully",
+	"payment_deleted":                      "Payment deleted successfully",
+	"resolution_uploaded":                  "Resolution file uploaded successfully",
+	"resolution_deleted":                   "Resolution deleted successfully",
+	"webhook_deleted":                      "Webhook deleted successfully",
+}
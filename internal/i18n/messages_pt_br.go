@@ -0,0 +1,178 @@
+package i18n
+
+// ptBR is the default catalog: every key must be here
+var ptBR = map[string]string{
+	// Problem titles, by error kind
+	"title_internal":      "Erro interno",
+	"title_validation":    "Requisição inválida",
+	"title_unauthorized":  "Não autenticado",
+	"title_forbidden":     "Acesso negado",
+	"title_not_found":     "Não encontrado",
+	"title_conflict":      "Conflito",
+	"title_unprocessable": "Operação não permitida",
+	"title_too_large":     "Arquivo muito grande",
+
+	// Errors shared across the API
+	"internal_error":    "Erro interno do servidor",
+	"not_found":         "Registro não encontrado",
+	"already_exists":    "Registro já existe",
+	"in_use":            "Registro em uso por outros dados",
+	"unauthenticated":   "Usuário não autenticado",
+	"access_denied":     "Acesso negado",
+	"invalid_input":     "Dados inválidos",
+	"invalid_body":      "Não foi possível ler os dados enviados",
+	"invalid_reference": "Referência a um registro inexistente",
+	"invalid_value":     "Valor inválido",
+	"route_not_found":   "Rota não encontrada",
+	"invalid_token":     "Token de acesso inválido ou expirado",
+
+	// Field validation
+	"required":           "Campo obrigatório",
+	"email_invalid":      "E-mail inválido",
+	"min_length":         "Deve ter no mínimo %s caracteres",
+	"min_value":          "Deve ser no mínimo %s",
+	"max_length":         "Deve ter no máximo %s caracteres",
+	"max_value":          "Deve ser no máximo %s",
+	"one_of":             "Deve ser um de: %s",
+	"id_invalid":         "Identificador inválido",
+	"cursor_invalid":     "Cursor inválido",
+	"page_invalid":       "A página deve ser um número maior que zero",
+	"limit_invalid":      "O limite deve ser um número maior que zero",
+	"sort_field_invalid": "Não é possível ordenar por %q, use um de: %s",
+	"sort_field_unknown": "Não é possível ordenar por %q",
+	"boolean_invalid":    "Use true ou false",
+	"date_invalid":       "Data inválida, use AAAA-MM-DD",
+	"date_range_invalid": "A data inicial não pode ser posterior à final",
+	"year_invalid":       "Ano inválido",
+	"state_invalid":      "Informe a sigla do estado com duas letras",
+	"event_id_invalid":   "Identificador de evento inválido",
+
+	// Uploaded files
+	"file_unreadable":           "Não foi possível ler o arquivo enviado",
+	"file_too_large":            "O arquivo excede %d MB",
+	"file_pdf_only":             "Apenas arquivos PDF são permitidos",
+	"file_format_unsupported":   "Formato de arquivo não suportado: %s",
+	"file_csv_header":           "Não foi possível ler o cabeçalho do CSV",
+	"file_csv_column_missing":   "Coluna obrigatória ausente no CSV: %s",
+	"file_csv_line_unreadable":  "Não foi possível ler a linha %d do CSV",
+	"file_csv_line_invalid":     "Linha %d do CSV: %s",
+	"file_ibge_json":            "O arquivo não é um JSON do IBGE válido",
+	"file_ibge_item_unreadable": "Item %d do JSON do IBGE é inválido",
+	"file_ibge_item_invalid":    "Item %d do JSON do IBGE: %s",
+	"file_ibge_code_invalid":    "código IBGE inválido %q",
+	"file_municipality_name":    "município %s sem nome",
+	"file_municipality_state":   "município %s com UF inválida %q",
+	"file_ibge_code_repeated":   "Código IBGE %s repetido no arquivo",
+	"file_no_municipalities":    "O arquivo não contém nenhum município para importar",
+	"file_cnes_invalid":         "CNES inválido %q",
+	"file_establishment_name":   "estabelecimento %s sem nome",
+	"file_establishment_ibge":   "estabelecimento %s com código de município inválido %q",
+	"file_no_establishments":    "O arquivo não contém nenhum estabelecimento",
+	"file_no_tablets":           "O arquivo não contém nenhum tablet",
+
+	// Authentication and users
+	"invalid_credentials":    "CPF ou senha inválidos",
+	"account_not_authorized": "Seu cadastro ainda não foi autorizado",
+	"invalid_refresh_token":  "Sessão inválida, faça login novamente",
+	"session_expired":        "Sessão expirada, faça login novamente",
+	"session_revoked":        "Sessão encerrada por segurança, faça login novamente",
+	"cpf_invalid":            "CPF inválido",
+	"role_invalid":           "Perfil inválido",
+	"role_admin_not_allowed": "Não é permitido se cadastrar como administrador",
+	"user_not_found":         "Usuário não encontrado",
+	"user_inactive":          "O usuário não está ativo",
+	"user_not_pending":       "O usuário não está aguardando autorização",
+	"session_not_found":      "Sessão não encontrada",
+	"account_inactive":       "A conta de usuário não está ativa",
+	"email_taken":            "E-mail já cadastrado",
+	"cpf_taken":              "CPF já cadastrado",
+	"scope_type_invalid":     "Tipo de abrangência inválido",
+
+	// Roles, permissions and professions
+	"role_not_found":        "Perfil não encontrado",
+	"role_name_taken":       "Já existe um perfil com este nome",
+	"level_not_allowed":     "Você não pode autorizar usuários deste nível",
+	"permission_unknown":    "A lista contém uma permissão desconhecida",
+	"permission_locked":     "A permissão %s não pode ser removida do perfil %s",
+	"profession_not_found":  "Profissão não encontrada",
+	"profession_name_taken": "Já existe uma profissão com este nome",
+
+	// Municipalities, health regions and health units
+	"municipality_not_found":    "Município não encontrado",
+	"municipality_inactive":     "O município não está ativo",
+	"municipality_name_taken":   "Já existe um município com este nome",
+	"municipality_unknown":      "A lista contém um município desconhecido",
+	"ibge_code_taken":           "Código IBGE já cadastrado",
+	"health_region_not_found":   "Região de saúde não encontrada",
+	"health_region_name_taken":  "Já existe uma região com este nome",
+	"region_type_invalid":       "Tipo de região inválido",
+	"parent_region_not_allowed": "Apenas microrregiões podem ter uma região superior",
+	"parent_region_not_found":   "Região superior não encontrada",
+	"parent_region_invalid":     "A região superior deve ser uma macrorregião do mesmo estado",
+	"health_unit_not_found":     "Unidade de saúde não encontrada",
+	"cnes_length":               "O CNES deve ter 7 dígitos",
+	"cnes_taken":                "CNES já cadastrado",
+
+	// Tablets, requests, transfers and allocation
+	"tablet_not_found":              "Tablet não encontrado",
+	"tablet_not_available":          "O tablet não está disponível para atribuição",
+	"tablet_not_assigned":           "O tablet não está atribuído a ninguém",
+	"tablet_without_asset_code":     "O tablet não tem número de patrimônio",
+	"tablet_move_requires_transfer": "Use uma transferência para mover o tablet para outro município",
+	"tablet_in_transit_status":      "O status em trânsito é controlado pelas transferências",
+	"asset_code_taken":              "Patrimônio já cadastrado",
+	"serial_number_taken":           "Número de série já cadastrado",
+	"tablet_asset_code_taken":       "Já existe um tablet com o patrimônio '%s'",
+	"tablet_serial_number_taken":    "Já existe um tablet com o número de série '%s'",
+	"agent_lookup_required":         "Informe o CPF ou o QR code",
+	"agent_not_found":               "Nenhum agente encontrado com este CPF",
+	"qr_invalid":                    "QR code inválido",
+	"qr_signature_invalid":          "O QR code não é de uma etiqueta válida",
+	"tablet_request_type_invalid":   "Tipo de solicitação inválido",
+	"tablet_request_not_found":      "Solicitação não encontrada",
+	"tablet_request_not_pending":    "A solicitação não está mais pendente",
+	"tablet_request_photo_limit":    "A solicitação já tem %d fotos",
+	"police_report_required":        "Anexe o boletim de ocorrência (BO) para aprovar uma solicitação de furto",
+	"tablet_transfer_not_found":     "Transferência não encontrada",
+	"transfer_state":                "A transferência não pode avançar para esta etapa",
+	"transfer_same_municipality":    "O tablet já está neste município",
+	"destination_not_found":         "Município de destino não encontrado",
+	"destination_inactive":          "O município de destino não está ativo",
+	"allocation_conflict":           "O plano de alocação está desatualizado; gere um novo plano",
+
+	// Payments, resolutions, webhooks and live events
+	"payment_not_found":       "Pagamento não encontrado",
+	"resolution_not_found":    "Resolução não encontrada",
+	"resolution_type_invalid": "O tipo deve ser MS ou SES",
+	"webhook_not_found":       "Webhook não encontrado",
+	"webhook_url_invalid":     "Informe uma URL completa, com http ou https",
+	"webhook_event_unknown":   "Tipo de evento desconhecido: %s",
+	"live_event_not_found":    "Evento não encontrado",
+
+	// Successful operations
+	"user_registered":                      "Cadastro realizado com sucesso. Aguardando autorização.",
+	"logged_out":                           "Sessão encerrada com sucesso",
+	"session_ended":                        "Sessão revogada com sucesso",
+	"user_scope_updated":                   "Abrangência do usuário atualizada com sucesso",
+	"user_authorized":                      "Usuário autorizado com sucesso",
+	"user_rejected":                        "Usuário rejeitado com sucesso",
+	"role_permissions_updated":             "Permissões do perfil atualizadas com sucesso",
+	"profession_deleted":                   "Profissão excluída com sucesso",
+	"health_region_deleted":                "Região de saúde excluída com sucesso",
+	"health_region_municipalities_updated": "Municípios da região de saúde atualizados com sucesso",
+	"health_unit_deleted":                  "Unidade de saúde excluída com sucesso",
+	"tablet_request_submitted":             "Solicitação enviada com sucesso",
+	"tablet_request_approved":              "Solicitação aprovada com sucesso",
+	"tablet_request_rejected":              "Solicitação rejeitada com sucesso",
+	"photo_attached":                       "Foto anexada com sucesso",
+	"document_attached":                    "Documento anexado com sucesso",
+	"transfer_approved":                    "Transferência aprovada com sucesso",
+	"transfer_rejected":                    "Transferência rejeitada com sucesso",
+	"transfer_received":                    "Transferência recebida com sucesso",
+	"allocation_applied":                   "Plano de alocação aplicado com sucesso",
+	"payment_uploaded":                     "Arquivo de pagamento enviado com sucesso",
+	"payment_deleted":                      "Pagamento excluído com sucesso",
+	"resolution_uploaded":                  "Arquivo da resolução enviado com sucesso",
+	"resolution_deleted":                   "Resolução excluída com sucesso",
+	"webhook_deleted":                      "Webhook excluído com sucesso",
+}
@@ -0,0 +1,220 @@
+package cnes
+
+import (
+	"bufio"
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/joaopanucci/apsdigital/internal/domain/apperrors"
+	"github.com/joaopanucci/apsdigital/internal/domain/entities"
+)
+
+// unitTypes maps the CNES TP_UNIDADE codes to their description
+var unitTypes = map[string]string{
+	"01": "POSTO DE SAUDE",
+	"02": "CENTRO DE SAUDE/UNIDADE BASICA",
+	"04": "POLICLINICA",
+	"05": "HOSPITAL GERAL",
+	"07": "HOSPITAL ESPECIALIZADO",
+	"15": "UNIDADE MISTA",
+	"20": "PRONTO SOCORRO GERAL",
+	"21": "PRONTO SOCORRO ESPECIALIZADO",
+	"22": "CONSULTORIO ISOLADO",
+	"32": "UNIDADE MOVEL FLUVIAL",
+	"36": "CLINICA/CENTRO DE ESPECIALIDADE",
+	"39": "UNIDADE DE APOIO DIAGNOSE E TERAPIA (SADT ISOLADO)",
+	"40": "UNIDADE MOVEL TERRESTRE",
+	"42": "UNIDADE MOVEL DE NIVEL PRE-HOSPITALAR NA AREA DE URGENCIA",
+	"43": "FARMACIA",
+	"50": "UNIDADE DE VIGILANCIA EM SAUDE",
+	"61": "CENTRO DE PARTO NORMAL - ISOLADO",
+	"62": "HOSPITAL/DIA - ISOLADO",
+	"64": "CENTRAL DE REGULACAO DE SERVICOS DE SAUDE",
+	"68": "CENTRAL DE GESTAO EM SAUDE",
+	"69": "CENTRO DE ATENCAO HEMOTERAPIA E OU HEMATOLOGICA",
+	"70": "CENTRO DE ATENCAO PSICOSSOCIAL",
+	"71": "CENTRO DE APOIO A SAUDE DA FAMILIA",
+	"72": "UNIDADE DE ATENCAO A SAUDE INDIGENA",
+	"73": "PRONTO ATENDIMENTO",
+	"74": "POLO ACADEMIA DA SAUDE",
+	"75": "TELESSAUDE",
+	"76": "CENTRAL DE REGULACAO MEDICA DAS URGENCIAS",
+	"77": "SERVICO DE ATENCAO DOMICILIAR ISOLADO (HOME CARE)",
+	"78": "UNIDADE DE ATENCAO EM REGIME RESIDENCIAL",
+	"79": "OFICINA ORTOPEDICA",
+	"80": "LABORATORIO DE SAUDE PUBLICA",
+	"81": "CENTRAL DE REGULACAO DO ACESSO",
+	"83": "POLO DE PREVENCAO DE DOENCAS E AGRAVOS E PROMOCAO DA SAUDE",
+	"84": "CENTRAL DE ABASTECIMENTO",
+	"85": "CENTRO DE IMUNIZACAO",
+}
+
+// columns maps the accepted header names to fields. The upper-case names are the ones of the
+// DATASUS tbEstabelecimento export.
+var columns = map[string]string{
+	"co_cnes":             "cnes",
+	"cnes":                "cnes",
+	"no_fantasia":         "name",
+	"nome":                "name",
+	"name":                "name",
+	"no_razao_social":     "legal_name",
+	"tp_unidade":          "type",
+	"tipo":                "type",
+	"type":                "type",
+	"co_municipio_gestor": "municipality",
+	"co_municipio":        "municipality",
+	"codigo_municipio":    "municipality",
+	"ibge_code":           "municipality",
+	"co_motivo_desab":     "disabled_reason",
+	"ativo":               "active",
+	"active":              "active",
+}
+
+// Parser reads CNES establishment exports in CSV
+type Parser struct{}
+
+func NewParser() *Parser {
+	return &Parser{}
+}
+
+// Parse decodes a CSV with a header row, separated by ';' or ','. DATASUS files are ISO-8859-1,
+// so fields that are not valid UTF-8 are converted from Latin-1.
+func (p *Parser) Parse(r io.Reader) ([]entities.CNESEstablishment, error) {
+	buffered := bufio.NewReader(r)
+	head, _ := buffered.Peek(4096)
+	line := string(head)
+	if i := strings.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+
+	reader := csv.NewReader(buffered)
+	if strings.Count(line, ";") > strings.Count(line, ",") {
+		reader.Comma = ';'
+	}
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, apperrors.InvalidFile("file_csv_header").Wrap(err)
+	}
+
+	index := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.Trim(strings.TrimPrefix(name, "\ufeff"), " \""))
+		if field, ok := columns[name]; ok {
+			if _, seen := index[field]; !seen {
+				index[field] = i
+			}
+		}
+	}
+	for _, field := range []string{"cnes", "municipality"} {
+		if _, ok := index[field]; !ok {
+			return nil, apperrors.InvalidFile("file_csv_column_missing", field)
+		}
+	}
+	if _, ok := index["name"]; !ok {
+		if _, ok := index["legal_name"]; !ok {
+			return nil, apperrors.InvalidFile("file_csv_column_missing", "name")
+		}
+	}
+
+	value := func(record []string, field string) string {
+		i, ok := index[field]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(toUTF8(record[i]))
+	}
+
+	var establishments []entities.CNESEstablishment
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, apperrors.InvalidFile("file_csv_line_unreadable", line).Wrap(err)
+		}
+
+		e := entities.CNESEstablishment{
+			CNES:     value(record, "cnes"),
+			Name:     value(record, "name"),
+			Type:     unitType(value(record, "type")),
+			IBGECode: value(record, "municipality"),
+			Active:   value(record, "disabled_reason") == "",
+		}
+		if e.Name == "" {
+			e.Name = value(record, "legal_name")
+		}
+		if active := value(record, "active"); active != "" {
+			e.Active = parseBool(active)
+		}
+
+		if err := normalize(&e); err != nil {
+			return nil, apperrors.InvalidFile("file_csv_line_invalid", line, err)
+		}
+		establishments = append(establishments, e)
+	}
+
+	return establishments, nil
+}
+
+func normalize(e *entities.CNESEstablishment) error {
+	// Spreadsheets drop the leading zeros of the CNES
+	if len(e.CNES) < 7 && isDigits(e.CNES) {
+		e.CNES = strings.Repeat("0", 7-len(e.CNES)) + e.CNES
+	}
+	if len(e.CNES) != 7 || !isDigits(e.CNES) {
+		return apperrors.InvalidFile("file_cnes_invalid", e.CNES)
+	}
+	if e.Name == "" {
+		return apperrors.InvalidFile("file_establishment_name", e.CNES)
+	}
+	if (len(e.IBGECode) != 6 && len(e.IBGECode) != 7) || !isDigits(e.IBGECode) {
+		return apperrors.InvalidFile("file_establishment_ibge", e.CNES, e.IBGECode)
+	}
+
+	return nil
+}
+
+func unitType(code string) string {
+	if len(code) == 1 {
+		code = "0" + code
+	}
+	if description, ok := unitTypes[code]; ok {
+		return description
+	}
+	return code
+}
+
+func parseBool(value string) bool {
+	switch strings.ToLower(value) {
+	case "1", "s", "sim", "true", "t", "y", "yes":
+		return true
+	}
+	return false
+}
+
+func isDigits(value string) bool {
+	if value == "" {
+		return false
+	}
+	_, err := strconv.ParseUint(value, 10, 64)
+	return err == nil
+}
+
+func toUTF8(value string) string {
+	if utf8.ValidString(value) {
+		return value
+	}
+
+	runes := make([]rune, len(value))
+	for i := 0; i < len(value); i++ {
+		runes[i] = rune(value[i])
+	}
+	return string(runes)
+}
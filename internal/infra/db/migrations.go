@@ -0,0 +1,84 @@
+package db
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// migrationFiles are the goose migrations this build expects to be applied
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationVersions lists the distinct versions of the embedded migrations, from their
+// "<version>_<name>.sql" file names; a few early versions are shared by two files
+func migrationVersions() ([]int64, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[int64]bool, len(entries))
+	var versions []int64
+	for _, entry := range entries {
+		prefix, _, _ := strings.Cut(entry.Name(), "_")
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration file name %s", entry.Name())
+		}
+		if !seen[version] {
+			seen[version] = true
+			versions = append(versions, version)
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+
+	return versions, nil
+}
+
+// PendingMigrations returns the versions of the embedded migrations the database has not
+// applied, going by goose's version table
+func (db *PostgresDB) PendingMigrations(ctx context.Context) ([]int64, error) {
+	versions, err := migrationVersions()
+	if err != nil {
+		return nil, err
+	}
+
+	// The newest row of each version says whether it is applied
+	rows, err := db.Pool.Query(ctx, `
+		SELECT DISTINCT ON (version_id) version_id, is_applied
+		FROM goose_db_version
+		ORDER BY version_id, id DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("error reading migration status: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]bool)
+	for rows.Next() {
+		var version int64
+		var isApplied bool
+		if err := rows.Scan(&version, &isApplied); err != nil {
+			return nil, fmt.Errorf("error reading migration status: %w", err)
+		}
+		applied[version] = isApplied
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading migration status: %w", err)
+	}
+
+	var pending []int64
+	for _, version := range versions {
+		if !applied[version] {
+			pending = append(pending, version)
+		}
+	}
+
+	return pending, nil
+}
@@ -0,0 +1,35 @@
+-- +goose Up
+-- Refresh tokens are stored hashed and grouped into rotation families (one family per device session).
+-- Plaintext tokens cannot be converted, so existing sessions are dropped and users log in again.
+DELETE FROM refresh_tokens;
+
+DROP INDEX IF EXISTS idx_refresh_tokens_token;
+ALTER TABLE refresh_tokens DROP COLUMN token;
+
+ALTER TABLE refresh_tokens ADD COLUMN token_hash VARCHAR(64) NOT NULL UNIQUE;
+ALTER TABLE refresh_tokens ADD COLUMN family_id UUID NOT NULL;
+ALTER TABLE refresh_tokens ADD COLUMN replaced_by UUID REFERENCES refresh_tokens(id) ON DELETE SET NULL;
+ALTER TABLE refresh_tokens ADD COLUMN device_name VARCHAR(255);
+ALTER TABLE refresh_tokens ADD COLUMN user_agent TEXT;
+ALTER TABLE refresh_tokens ADD COLUMN ip_address VARCHAR(45);
+ALTER TABLE refresh_tokens ADD COLUMN last_used_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE refresh_tokens ADD COLUMN revoked_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX idx_refresh_tokens_family_id ON refresh_tokens(family_id);
+
+-- +goose Down
+DELETE FROM refresh_tokens;
+
+DROP INDEX IF EXISTS idx_refresh_tokens_family_id;
+
+ALTER TABLE refresh_tokens DROP COLUMN revoked_at;
+ALTER TABLE refresh_tokens DROP COLUMN last_used_at;
+ALTER TABLE refresh_tokens DROP COLUMN ip_address;
+ALTER TABLE refresh_tokens DROP COLUMN user_agent;
+ALTER TABLE refresh_tokens DROP COLUMN device_name;
+ALTER TABLE refresh_tokens DROP COLUMN replaced_by;
+ALTER TABLE refresh_tokens DROP COLUMN family_id;
+ALTER TABLE refresh_tokens DROP COLUMN token_hash;
+
+ALTER TABLE refresh_tokens ADD COLUMN token VARCHAR(255) NOT NULL UNIQUE;
+CREATE INDEX idx_refresh_tokens_token ON refresh_tokens(token);
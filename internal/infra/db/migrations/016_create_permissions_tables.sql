@@ -0,0 +1,74 @@
+-- +goose Up
+CREATE TABLE permissions (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL UNIQUE,
+    description TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE role_permissions (
+    role_id UUID NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    permission_id INTEGER NOT NULL REFERENCES permissions(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (role_id, permission_id)
+);
+
+CREATE INDEX idx_role_permissions_permission_id ON role_permissions(permission_id);
+
+INSERT INTO permissions (name, description) VALUES
+('payments.view', 'Visualizar arquivos de pagamento'),
+('payments.upload', 'Enviar e editar arquivos de pagamento'),
+('payments.delete', 'Excluir arquivos de pagamento'),
+('resolutions.view', 'Visualizar resoluções'),
+('resolutions.upload', 'Enviar e editar resoluções'),
+('resolutions.delete', 'Excluir resoluções'),
+('tablets.view', 'Visualizar tablets e solicitações'),
+('tablets.manage', 'Cadastrar, atribuir e devolver tablets'),
+('tablets.request', 'Abrir solicitações de tablet'),
+('tablets.approve_request', 'Aprovar ou rejeitar solicitações de tablet'),
+('users.view', 'Visualizar usuários'),
+('users.authorize', 'Autorizar ou rejeitar cadastros de usuários'),
+('professions.manage', 'Gerenciar profissões'),
+('roles.view', 'Visualizar perfis e permissões'),
+('roles.manage', 'Alterar permissões dos perfis'),
+('municipalities.view_all', 'Acessar dados de todos os municípios');
+
+-- Seed the rights each role already had. Payments and resolutions were readable without
+-- logging in, so every role keeps payments.view and resolutions.view.
+-- ADM: everything
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r CROSS JOIN permissions p
+WHERE r.name = 'ADM';
+
+-- Coordenador: manages resolutions and professions, sees roles, and has every Gerente right
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r CROSS JOIN permissions p
+WHERE r.name = 'Coordenador' AND p.name IN (
+    'payments.view', 'payments.upload', 'payments.delete',
+    'resolutions.view', 'resolutions.upload', 'resolutions.delete',
+    'tablets.view', 'tablets.manage', 'tablets.request', 'tablets.approve_request',
+    'users.view', 'users.authorize',
+    'professions.manage', 'roles.view'
+);
+
+-- Gerente: manages payments, tablets and user authorizations
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r CROSS JOIN permissions p
+WHERE r.name = 'Gerente' AND p.name IN (
+    'payments.view', 'payments.upload', 'payments.delete',
+    'resolutions.view',
+    'tablets.view', 'tablets.manage', 'tablets.request', 'tablets.approve_request',
+    'users.view', 'users.authorize'
+);
+
+-- ACS: reads payments and resolutions and requests tablets
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r CROSS JOIN permissions p
+WHERE r.name = 'ACS' AND p.name IN (
+    'payments.view', 'resolutions.view',
+    'tablets.view', 'tablets.request'
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS permissions;
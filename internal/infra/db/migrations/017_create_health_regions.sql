@@ -0,0 +1,49 @@
+-- +goose Up
+CREATE TABLE health_regions (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    type VARCHAR(10) NOT NULL CHECK (type IN ('macro', 'micro')),
+    parent_id INTEGER REFERENCES health_regions(id) ON DELETE SET NULL,
+    state VARCHAR(2) NOT NULL DEFAULT 'MS',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (state, type, name)
+);
+
+CREATE INDEX idx_health_regions_parent_id ON health_regions(parent_id);
+
+ALTER TABLE municipalities ADD COLUMN health_region_id INTEGER REFERENCES health_regions(id) ON DELETE SET NULL;
+CREATE INDEX idx_municipalities_health_region_id ON municipalities(health_region_id);
+
+-- State scopes list municipalities by state. Earlier migrations never created these
+-- columns, though some databases have them already.
+ALTER TABLE municipalities ADD COLUMN IF NOT EXISTS state VARCHAR(2);
+ALTER TABLE municipalities ADD COLUMN IF NOT EXISTS active BOOLEAN NOT NULL DEFAULT true;
+
+-- The hand-seeded rows are all from Mato Grosso do Sul (IBGE state code 50)
+UPDATE municipalities SET state = 'MS' WHERE state IS NULL AND ibge_code LIKE '50%';
+CREATE INDEX IF NOT EXISTS idx_municipalities_state ON municipalities(state);
+
+-- A user sees one municipality (municipality_id), a health region or a whole state
+ALTER TABLE users ADD COLUMN scope_type VARCHAR(20) NOT NULL DEFAULT 'municipality'
+    CHECK (scope_type IN ('municipality', 'region', 'state'));
+ALTER TABLE users ADD COLUMN health_region_id INTEGER REFERENCES health_regions(id) ON DELETE SET NULL;
+ALTER TABLE users ADD COLUMN scope_state VARCHAR(2);
+
+INSERT INTO permissions (name, description) VALUES
+('health_regions.manage', 'Gerenciar regiões de saúde'),
+('users.manage_scope', 'Definir município, região ou estado de atuação dos usuários');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r CROSS JOIN permissions p
+WHERE r.name = 'ADM' AND p.name IN ('health_regions.manage', 'users.manage_scope');
+
+-- +goose Down
+DELETE FROM permissions WHERE name IN ('health_regions.manage', 'users.manage_scope');
+ALTER TABLE users DROP COLUMN IF EXISTS scope_state;
+ALTER TABLE users DROP COLUMN IF EXISTS health_region_id;
+ALTER TABLE users DROP COLUMN IF EXISTS scope_type;
+DROP INDEX IF EXISTS idx_municipalities_state;
+DROP INDEX IF EXISTS idx_municipalities_health_region_id;
+ALTER TABLE municipalities DROP COLUMN IF EXISTS health_region_id;
+DROP TABLE IF EXISTS health_regions;
@@ -0,0 +1,22 @@
+-- +goose Up
+-- state and active come from 017; repeated for databases migrated before it added them
+ALTER TABLE municipalities ADD COLUMN IF NOT EXISTS state VARCHAR(2);
+ALTER TABLE municipalities ADD COLUMN IF NOT EXISTS active BOOLEAN NOT NULL DEFAULT true;
+ALTER TABLE municipalities ADD COLUMN microregion VARCHAR(255);
+
+UPDATE municipalities SET state = 'MS' WHERE state IS NULL AND ibge_code LIKE '50%';
+
+-- IBGE sync upserts by code
+CREATE UNIQUE INDEX IF NOT EXISTS idx_municipalities_ibge_code_unique ON municipalities(ibge_code);
+
+INSERT INTO permissions (name, description) VALUES
+('municipalities.sync', 'Sincronizar municípios com a base de localidades do IBGE');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r CROSS JOIN permissions p
+WHERE r.name = 'ADM' AND p.name = 'municipalities.sync';
+
+-- +goose Down
+DELETE FROM permissions WHERE name = 'municipalities.sync';
+DROP INDEX IF EXISTS idx_municipalities_ibge_code_unique;
+ALTER TABLE municipalities DROP COLUMN IF EXISTS microregion;
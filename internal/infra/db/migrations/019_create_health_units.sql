@@ -0,0 +1,86 @@
+-- +goose Up
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE EXTENSION IF NOT EXISTS unaccent;
+
+CREATE TABLE health_units (
+    id SERIAL PRIMARY KEY,
+    cnes VARCHAR(7) NOT NULL UNIQUE,
+    name VARCHAR(255) NOT NULL,
+    type VARCHAR(100) NOT NULL DEFAULT '',
+    municipality_id INTEGER NOT NULL REFERENCES municipalities(id),
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_health_units_municipality_id ON health_units(municipality_id);
+
+-- users.unit was free text; the original value is kept in unit_legacy until it is matched
+ALTER TABLE users RENAME COLUMN unit TO unit_legacy;
+ALTER TABLE users ADD COLUMN unit_id INTEGER REFERENCES health_units(id) ON DELETE SET NULL;
+CREATE INDEX idx_users_unit_id ON users(unit_id);
+
+-- Strips accents, punctuation and the usual prefixes (UBS, ESF, ...) so "UBS Vila Nova" matches "UNIDADE BASICA DE SAUDE VILA NOVA"
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION normalize_unit_name(value TEXT) RETURNS TEXT AS $$
+    SELECT trim(regexp_replace(
+        regexp_replace(
+            regexp_replace(lower(unaccent(COALESCE(value, ''))), '[^a-z0-9]+', ' ', 'g'),
+            '^\s*(unidade basica de saude|unidade de saude da familia|estrategia saude da familia|centro de saude|posto de saude|ubsf|ubs|usf|esf|psf|cs)\s+', ''),
+        '\s+', ' ', 'g'))
+$$ LANGUAGE sql STABLE;
+-- +goose StatementEnd
+
+-- Links users without a unit to the unit of their municipality whose CNES equals the digits of the
+-- free text or whose name is the most similar one; returns the number of linked users
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION match_user_units(min_similarity REAL DEFAULT 0.6) RETURNS INTEGER AS $$
+DECLARE
+    matched INTEGER;
+BEGIN
+    WITH scored AS (
+        SELECT u.id AS user_id, hu.id AS unit_id,
+               CASE WHEN hu.cnes = regexp_replace(u.unit_legacy, '\D', '', 'g') THEN 1
+                    ELSE similarity(normalize_unit_name(u.unit_legacy), normalize_unit_name(hu.name))
+               END AS score
+        FROM users u
+        JOIN health_units hu ON hu.active AND (u.municipality_id IS NULL OR hu.municipality_id = u.municipality_id)
+        WHERE u.unit_id IS NULL AND COALESCE(trim(u.unit_legacy), '') <> ''
+    ), ranked AS (
+        SELECT user_id, unit_id, score,
+               rank() OVER (PARTITION BY user_id ORDER BY score DESC) AS position,
+               count(*) OVER (PARTITION BY user_id, score) AS ties
+        FROM scored
+        WHERE score >= min_similarity
+    )
+    UPDATE users SET unit_id = ranked.unit_id, updated_at = NOW()
+    FROM ranked
+    -- Ambiguous matches are left for manual review
+    WHERE users.id = ranked.user_id AND ranked.position = 1 AND ranked.ties = 1;
+
+    GET DIAGNOSTICS matched = ROW_COUNT;
+    RETURN matched;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+SELECT match_user_units();
+
+INSERT INTO permissions (name, description) VALUES
+('health_units.manage', 'Gerenciar e importar unidades de saúde (CNES)');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r CROSS JOIN permissions p
+WHERE r.name = 'ADM' AND p.name = 'health_units.manage';
+
+-- +goose Down
+DELETE FROM permissions WHERE name = 'health_units.manage';
+DROP FUNCTION IF EXISTS match_user_units(REAL);
+DROP FUNCTION IF EXISTS normalize_unit_name(TEXT);
+UPDATE users SET unit_legacy = hu.name
+FROM health_units hu
+WHERE users.unit_id = hu.id AND COALESCE(users.unit_legacy, '') = '';
+DROP INDEX IF EXISTS idx_users_unit_id;
+ALTER TABLE users DROP COLUMN IF EXISTS unit_id;
+ALTER TABLE users RENAME COLUMN unit_legacy TO unit;
+DROP TABLE IF EXISTS health_units;
@@ -0,0 +1,48 @@
+-- +goose Up
+-- The tablet code works with the assigned user's UUID and CPF, serial/model and the
+-- disponivel/atribuido statuses, which the original tables never had
+ALTER TABLE tablets ADD COLUMN IF NOT EXISTS assigned_user_id UUID REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE tablets ADD COLUMN IF NOT EXISTS user_cpf VARCHAR(14);
+ALTER TABLE tablets ADD COLUMN IF NOT EXISTS model VARCHAR(255);
+ALTER TABLE tablets ADD COLUMN IF NOT EXISTS serial_number VARCHAR(255);
+ALTER TABLE tablets ADD COLUMN IF NOT EXISTS assigned_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE tablets ADD COLUMN IF NOT EXISTS returned_at TIMESTAMP WITH TIME ZONE;
+CREATE INDEX IF NOT EXISTS idx_tablets_assigned_user_id ON tablets(assigned_user_id);
+
+ALTER TABLE tablets DROP CONSTRAINT IF EXISTS check_tablet_status;
+ALTER TABLE tablets ADD CONSTRAINT check_tablet_status
+    CHECK (status IN ('ativo', 'devolvido', 'quebrado', 'furtado', 'manutencao', 'disponivel', 'atribuido'));
+
+-- History of each tablet: registration, assignments, returns and status changes
+CREATE TABLE tablet_events (
+    id BIGSERIAL PRIMARY KEY,
+    tablet_id INTEGER NOT NULL REFERENCES tablets(id) ON DELETE CASCADE,
+    type VARCHAR(30) NOT NULL,
+    from_status VARCHAR(50),
+    to_status VARCHAR(50) NOT NULL,
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    note TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_tablet_events_tablet_id ON tablet_events(tablet_id, created_at DESC);
+CREATE INDEX idx_tablet_events_type ON tablet_events(type, created_at);
+
+-- Existing tablets start their history with their current status
+INSERT INTO tablet_events (tablet_id, type, to_status, user_id, created_at)
+SELECT id, 'cadastro', status, assigned_user_id, COALESCE(created_at, NOW()) FROM tablets;
+
+INSERT INTO permissions (name, description) VALUES
+('tablets.export', 'Exportar o inventário de tablets');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r CROSS JOIN permissions p
+WHERE r.name IN ('ADM', 'Coordenador', 'Gerente') AND p.name = 'tablets.export';
+
+-- +goose Down
+DELETE FROM permissions WHERE name = 'tablets.export';
+DROP TABLE IF EXISTS tablet_events;
+ALTER TABLE tablets DROP CONSTRAINT IF EXISTS check_tablet_status;
+ALTER TABLE tablets ADD CONSTRAINT check_tablet_status
+    CHECK (status IN ('ativo', 'devolvido', 'quebrado', 'furtado', 'manutencao'));
+DROP INDEX IF EXISTS idx_tablets_assigned_user_id;
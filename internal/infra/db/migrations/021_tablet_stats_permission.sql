@@ -0,0 +1,14 @@
+-- +goose Up
+INSERT INTO permissions (name, description) VALUES
+('tablets.stats', 'Visualizar as estatísticas de tablets');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r CROSS JOIN permissions p
+WHERE r.name IN ('ADM', 'Coordenador', 'Gerente') AND p.name = 'tablets.stats';
+
+-- Theft and breakage rates group the requests by type and quarter
+CREATE INDEX IF NOT EXISTS idx_tablet_requests_type_created_at ON tablet_requests(type, created_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_tablet_requests_type_created_at;
+DELETE FROM permissions WHERE name = 'tablets.stats';
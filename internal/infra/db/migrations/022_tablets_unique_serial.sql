@@ -0,0 +1,33 @@
+-- +goose Up
+-- Serial numbers identify devices, so duplicates are rejected by the database instead
+-- of being looked up before every insert
+
+-- Blank serials mean the serial is unknown
+UPDATE tablets SET serial_number = NULL WHERE TRIM(serial_number) = '';
+
+-- Tablets registered before the check may repeat a serial: the oldest keeps it, the others
+-- lose it and record why in their history so they can be corrected by hand
+CREATE TEMPORARY TABLE duplicate_serials ON COMMIT DROP AS
+SELECT id, serial_number, status, first_id
+FROM (
+    SELECT id, serial_number, status,
+           FIRST_VALUE(id) OVER (PARTITION BY serial_number ORDER BY created_at, id) AS first_id
+    FROM tablets
+    WHERE serial_number IS NOT NULL
+) t
+WHERE id <> first_id;
+
+INSERT INTO tablet_events (tablet_id, type, from_status, to_status, note)
+SELECT id, 'status', status, status,
+       'Número de série ' || serial_number || ' removido: repetido do tablet ' || first_id
+FROM duplicate_serials;
+
+UPDATE tablets SET serial_number = NULL
+WHERE id IN (SELECT id FROM duplicate_serials);
+
+CREATE UNIQUE INDEX idx_tablets_serial_number_unique ON tablets(serial_number)
+    WHERE serial_number IS NOT NULL AND serial_number <> '';
+
+-- +goose Down
+-- The removed serials stay in the notes of tablet_events
+DROP INDEX IF EXISTS idx_tablets_serial_number_unique;
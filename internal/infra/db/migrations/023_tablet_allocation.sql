@@ -0,0 +1,19 @@
+-- +goose Up
+-- Transfers move a tablet between municipalities; the event keeps both ends
+ALTER TABLE tablet_events ADD COLUMN from_municipality_id INTEGER REFERENCES municipalities(id) ON DELETE SET NULL;
+ALTER TABLE tablet_events ADD COLUMN to_municipality_id INTEGER REFERENCES municipalities(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_tablet_requests_type_status ON tablet_requests(type, status);
+
+INSERT INTO permissions (name, description) VALUES
+('tablets.allocate', 'Planejar e aplicar a distribuição de tablets entre municípios');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r CROSS JOIN permissions p
+WHERE r.name = 'ADM' AND p.name = 'tablets.allocate';
+
+-- +goose Down
+DELETE FROM permissions WHERE name = 'tablets.allocate';
+DROP INDEX IF EXISTS idx_tablet_requests_type_status;
+ALTER TABLE tablet_events DROP COLUMN IF EXISTS to_municipality_id;
+ALTER TABLE tablet_events DROP COLUMN IF EXISTS from_municipality_id;
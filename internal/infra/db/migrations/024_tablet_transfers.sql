@@ -0,0 +1,52 @@
+-- +goose Up
+ALTER TABLE tablets DROP CONSTRAINT IF EXISTS check_tablet_status;
+ALTER TABLE tablets ADD CONSTRAINT check_tablet_status
+    CHECK (status IN ('ativo', 'devolvido', 'quebrado', 'furtado', 'manutencao', 'disponivel', 'atribuido', 'em_transito'));
+
+-- Moving a tablet to another municipality: proposed by the source, approved by a
+-- coordinator (the tablet is then in transit) and confirmed by the destination
+CREATE TABLE tablet_transfers (
+    id BIGSERIAL PRIMARY KEY,
+    tablet_id INTEGER NOT NULL REFERENCES tablets(id) ON DELETE CASCADE,
+    from_municipality_id INTEGER NOT NULL REFERENCES municipalities(id),
+    to_municipality_id INTEGER NOT NULL REFERENCES municipalities(id),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    reason TEXT,
+    proposed_by UUID NOT NULL REFERENCES users(id),
+    proposed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    approved_by UUID REFERENCES users(id),
+    approved_at TIMESTAMP WITH TIME ZONE,
+    received_by UUID REFERENCES users(id),
+    received_at TIMESTAMP WITH TIME ZONE,
+    rejected_by UUID REFERENCES users(id),
+    rejected_at TIMESTAMP WITH TIME ZONE,
+    rejection_reason TEXT,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    CONSTRAINT check_transfer_status CHECK (status IN ('pending', 'approved', 'received', 'rejected')),
+    CONSTRAINT check_transfer_municipalities CHECK (from_municipality_id <> to_municipality_id)
+);
+
+-- A tablet has at most one open transfer
+CREATE UNIQUE INDEX idx_tablet_transfers_open ON tablet_transfers(tablet_id) WHERE status IN ('pending', 'approved');
+CREATE INDEX idx_tablet_transfers_from ON tablet_transfers(from_municipality_id, status);
+CREATE INDEX idx_tablet_transfers_to ON tablet_transfers(to_municipality_id, status);
+
+INSERT INTO permissions (name, description) VALUES
+('tablets.transfer_propose', 'Propor a transferência de tablets para outro município'),
+('tablets.transfer_approve', 'Aprovar ou rejeitar transferências de tablets'),
+('tablets.transfer_receive', 'Confirmar o recebimento de tablets transferidos');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r CROSS JOIN permissions p
+WHERE (r.name = 'ADM' AND p.name IN ('tablets.transfer_propose', 'tablets.transfer_approve', 'tablets.transfer_receive'))
+   OR (r.name = 'Coordenador' AND p.name = 'tablets.transfer_approve')
+   OR (r.name = 'Gerente' AND p.name IN ('tablets.transfer_propose', 'tablets.transfer_receive'));
+
+-- +goose Down
+DELETE FROM permissions WHERE name IN ('tablets.transfer_propose', 'tablets.transfer_approve', 'tablets.transfer_receive');
+DROP TABLE IF EXISTS tablet_transfers;
+UPDATE tablets SET status = 'disponivel' WHERE status = 'em_transito';
+ALTER TABLE tablets DROP CONSTRAINT IF EXISTS check_tablet_status;
+ALTER TABLE tablets ADD CONSTRAINT check_tablet_status
+    CHECK (status IN ('ativo', 'devolvido', 'quebrado', 'furtado', 'manutencao', 'disponivel', 'atribuido'));
@@ -0,0 +1,117 @@
+-- +goose Up
+-- Log of the events pushed to connected clients. Rows are written by triggers, so every
+-- replica sees the same events, and NOTIFY on live_events wakes the brokers listening.
+-- An event goes either to one user (user_id) or to the users holding permission whose
+-- scope covers any of municipality_ids.
+CREATE TABLE live_events (
+    id BIGSERIAL PRIMARY KEY,
+    kind VARCHAR(20) NOT NULL,
+    type VARCHAR(50) NOT NULL,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    municipality_ids INTEGER[],
+    permission VARCHAR(100),
+    payload JSONB NOT NULL DEFAULT '{}'::jsonb,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    CONSTRAINT check_live_event_kind CHECK (kind IN ('notification', 'entity'))
+);
+
+CREATE INDEX idx_live_events_created_at ON live_events(created_at);
+CREATE INDEX idx_live_events_user_id ON live_events(user_id) WHERE user_id IS NOT NULL;
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION notify_live_event() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM pg_notify('live_events', NEW.id::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER live_events_notify AFTER INSERT ON live_events
+FOR EACH ROW EXECUTE FUNCTION notify_live_event();
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION notification_live_event() RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO live_events (kind, type, user_id, payload)
+    VALUES ('notification', NEW.type, NEW.user_id, to_jsonb(NEW));
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER notifications_live_event AFTER INSERT ON notifications
+FOR EACH ROW EXECUTE FUNCTION notification_live_event();
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION tablet_live_event() RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO live_events (kind, type, municipality_ids, permission, payload)
+    VALUES (
+        'entity',
+        CASE TG_OP WHEN 'INSERT' THEN 'tablet.created' ELSE 'tablet.updated' END,
+        CASE WHEN TG_OP = 'UPDATE' AND OLD.municipality_id IS DISTINCT FROM NEW.municipality_id
+             THEN ARRAY[OLD.municipality_id, NEW.municipality_id]
+             ELSE ARRAY[NEW.municipality_id] END,
+        'tablets.view',
+        jsonb_build_object('id', NEW.id, 'status', NEW.status, 'municipality_id', NEW.municipality_id)
+    );
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER tablets_live_event AFTER INSERT OR UPDATE ON tablets
+FOR EACH ROW EXECUTE FUNCTION tablet_live_event();
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION tablet_request_live_event() RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO live_events (kind, type, municipality_ids, permission, payload)
+    SELECT
+        'entity',
+        CASE TG_OP WHEN 'INSERT' THEN 'tablet_request.created' ELSE 'tablet_request.updated' END,
+        ARRAY[u.municipality_id],
+        'tablets.view',
+        jsonb_build_object('id', NEW.id, 'type', NEW.type, 'status', NEW.status, 'user_id', NEW.user_id)
+    FROM users u WHERE u.id = NEW.user_id;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER tablet_requests_live_event AFTER INSERT OR UPDATE ON tablet_requests
+FOR EACH ROW EXECUTE FUNCTION tablet_request_live_event();
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION tablet_transfer_live_event() RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO live_events (kind, type, municipality_ids, permission, payload)
+    VALUES (
+        'entity',
+        CASE TG_OP WHEN 'INSERT' THEN 'tablet_transfer.created' ELSE 'tablet_transfer.updated' END,
+        ARRAY[NEW.from_municipality_id, NEW.to_municipality_id],
+        'tablets.view',
+        jsonb_build_object('id', NEW.id, 'tablet_id', NEW.tablet_id, 'status', NEW.status,
+                           'from_municipality_id', NEW.from_municipality_id, 'to_municipality_id', NEW.to_municipality_id)
+    );
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER tablet_transfers_live_event AFTER INSERT OR UPDATE ON tablet_transfers
+FOR EACH ROW EXECUTE FUNCTION tablet_transfer_live_event();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS tablet_transfers_live_event ON tablet_transfers;
+DROP TRIGGER IF EXISTS tablet_requests_live_event ON tablet_requests;
+DROP TRIGGER IF EXISTS tablets_live_event ON tablets;
+DROP TRIGGER IF EXISTS notifications_live_event ON notifications;
+DROP FUNCTION IF EXISTS tablet_transfer_live_event();
+DROP FUNCTION IF EXISTS tablet_request_live_event();
+DROP FUNCTION IF EXISTS tablet_live_event();
+DROP FUNCTION IF EXISTS notification_live_event();
+DROP TABLE IF EXISTS live_events;
+DROP FUNCTION IF EXISTS notify_live_event();
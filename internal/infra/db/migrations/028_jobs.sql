@@ -0,0 +1,28 @@
+-- +goose Up
+-- Background job queue. Workers claim due jobs with FOR UPDATE SKIP LOCKED and hold them
+-- until locked_until; a job whose worker died is claimed again once the lock expires.
+-- unique_key keeps a job from being queued twice, e.g. a cron run by several replicas.
+CREATE TABLE jobs (
+    id BIGSERIAL PRIMARY KEY,
+    type VARCHAR(100) NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}'::jsonb,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL DEFAULT 5,
+    run_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    locked_until TIMESTAMP WITH TIME ZONE,
+    last_error TEXT,
+    unique_key VARCHAR(200),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    finished_at TIMESTAMP WITH TIME ZONE,
+
+    CONSTRAINT check_job_status CHECK (status IN ('pending', 'running', 'succeeded', 'failed'))
+);
+
+CREATE INDEX idx_jobs_due ON jobs(run_at) WHERE status = 'pending';
+CREATE INDEX idx_jobs_running ON jobs(locked_until) WHERE status = 'running';
+CREATE INDEX idx_jobs_finished_at ON jobs(finished_at) WHERE finished_at IS NOT NULL;
+CREATE UNIQUE INDEX idx_jobs_unique_key ON jobs(unique_key);
+
+-- +goose Down
+DROP TABLE IF EXISTS jobs;
@@ -3,7 +3,8 @@ package db
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -12,11 +13,19 @@ type PostgresDB struct {
 	Pool *pgxpool.Pool
 }
 
-func NewPostgresConnection(host, port, user, password, dbname string) (*PostgresDB, error) {
+// NewPostgresConnection connects to the database. Failed queries, and those taking longer
+// than slowQuery when it is set, are logged.
+func NewPostgresConnection(host, port, user, password, dbname string, slowQuery time.Duration) (*PostgresDB, error) {
 	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
 		host, port, user, password, dbname)
 
-	pool, err := pgxpool.New(context.Background(), dsn)
+	config, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse connection config: %w", err)
+	}
+	config.ConnConfig.Tracer = &queryTracer{slowQuery: slowQuery}
+
+	pool, err := pgxpool.NewWithConfig(context.Background(), config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create connection pool: %w", err)
 	}
@@ -25,7 +34,7 @@ func NewPostgresConnection(host, port, user, password, dbname string) (*Postgres
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	log.Println("Successfully connected to PostgreSQL!")
+	slog.Info("Connected to PostgreSQL", "host", host, "database", dbname)
 
 	return &PostgresDB{Pool: pool}, nil
 }
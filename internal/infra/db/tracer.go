@@ -0,0 +1,89 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/joaopanucci/apsdigital/internal/infra/db")
+
+// queryTracer traces each query and logs failed and slow ones with the context of the
+// caller, so repository logs carry the request or job that ran them. Arguments are
+// never recorded.
+type queryTracer struct {
+	slowQuery time.Duration
+}
+
+type queryStartKey struct{}
+
+type queryStart struct {
+	sql string
+	at  time.Time
+}
+
+func (t *queryTracer) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	sql := compactSQL(data.SQL)
+	operation := sqlOperation(sql)
+
+	ctx, _ = tracer.Start(ctx, operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBNamespace(conn.Config().Database),
+			semconv.DBOperationName(operation),
+			semconv.DBQueryText(sql),
+		),
+	)
+
+	return context.WithValue(ctx, queryStartKey{}, queryStart{sql: sql, at: time.Now()})
+}
+
+func (t *queryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	span := trace.SpanFromContext(ctx)
+	defer span.End()
+
+	start, ok := ctx.Value(queryStartKey{}).(queryStart)
+	if !ok {
+		return
+	}
+	elapsed := time.Since(start.at)
+
+	switch {
+	case data.Err != nil && !errors.Is(data.Err, context.Canceled):
+		span.RecordError(data.Err)
+		span.SetStatus(codes.Error, data.Err.Error())
+		slog.WarnContext(ctx, "Query failed",
+			"sql", start.sql,
+			"duration_ms", elapsed.Milliseconds(),
+			"error", data.Err,
+		)
+	case t.slowQuery > 0 && elapsed >= t.slowQuery:
+		slog.WarnContext(ctx, "Slow query",
+			"sql", start.sql,
+			"duration_ms", elapsed.Milliseconds(),
+		)
+	}
+}
+
+// compactSQL puts a query on one line
+func compactSQL(sql string) string {
+	return strings.Join(strings.Fields(sql), " ")
+}
+
+// sqlOperation is the first keyword of a query, such as SELECT, naming its span
+func sqlOperation(sql string) string {
+	operation, _, _ := strings.Cut(sql, " ")
+	if operation == "" {
+		return "query"
+	}
+	return strings.ToUpper(operation)
+}
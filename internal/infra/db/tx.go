@@ -0,0 +1,56 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Querier is implemented by both the pool and a transaction
+type Querier interface {
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+type txKey struct{}
+
+// Conn returns the transaction InTx put in ctx, or the pool outside one. Repositories
+// query through it, so their writes join any larger unit of work.
+func (db *PostgresDB) Conn(ctx context.Context) Querier {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx
+	}
+	return db.Pool
+}
+
+// Begin starts a transaction for repositories that need their own, or a savepoint within
+// the transaction InTx put in ctx, so rolling it back leaves the outer one usable
+func (db *PostgresDB) Begin(ctx context.Context) (pgx.Tx, error) {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx.Begin(ctx)
+	}
+	return db.Pool.Begin(ctx)
+}
+
+// InTx runs fn in a transaction, committed when fn returns nil. Repositories called with
+// the ctx given to fn join the transaction through Conn; nested calls join the outer one.
+func (db *PostgresDB) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,52 @@
+package db
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Where collects the conditions of a WHERE clause with their arguments. Conditions use "?"
+// placeholders, numbered $1, $2, ... in the order they are added.
+type Where struct {
+	conditions []string
+	args       []interface{}
+}
+
+// Add appends a condition; every "?" in it consumes one of args
+func (w *Where) Add(condition string, args ...interface{}) *Where {
+	var b strings.Builder
+	next := 0
+	for _, r := range condition {
+		if r == '?' && next < len(args) {
+			w.args = append(w.args, args[next])
+			next++
+			b.WriteString("$" + strconv.Itoa(len(w.args)))
+			continue
+		}
+		b.WriteRune(r)
+	}
+
+	w.conditions = append(w.conditions, b.String())
+	return w
+}
+
+// AddIf appends the condition only when ok is true
+func (w *Where) AddIf(ok bool, condition string, args ...interface{}) *Where {
+	if ok {
+		w.Add(condition, args...)
+	}
+	return w
+}
+
+// SQL returns " WHERE ..." joined with AND, or "" without conditions
+func (w *Where) SQL() string {
+	if len(w.conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(w.conditions, " AND ")
+}
+
+// Args returns the arguments in placeholder order
+func (w *Where) Args() []interface{} {
+	return w.args
+}
@@ -0,0 +1,80 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWhere(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func(w *Where)
+		sql   string
+		args  []interface{}
+	}{
+		{
+			name:  "empty",
+			build: func(w *Where) {},
+			sql:   "",
+		},
+		{
+			name: "numbers placeholders across conditions",
+			build: func(w *Where) {
+				w.Add("a = ?", 1)
+				w.Add("(b ILIKE ? OR c = ?)", "%x%", "x")
+				w.Add("d = ANY(?)", []int{4, 5})
+			},
+			sql:  " WHERE a = $1 AND (b ILIKE $2 OR c = $3) AND d = ANY($4)",
+			args: []interface{}{1, "%x%", "x", []int{4, 5}},
+		},
+		{
+			name: "condition without placeholders",
+			build: func(w *Where) {
+				w.Add("active = true")
+				w.Add("id = ?", 7)
+			},
+			sql:  " WHERE active = true AND id = $1",
+			args: []interface{}{7},
+		},
+		{
+			name: "extra placeholders are kept as written",
+			build: func(w *Where) {
+				w.Add("a = ? OR b = ?", 1)
+			},
+			sql:  " WHERE a = $1 OR b = ?",
+			args: []interface{}{1},
+		},
+		{
+			name: "AddIf skips false conditions without consuming numbers",
+			build: func(w *Where) {
+				w.AddIf(false, "a = ?", 1)
+				w.AddIf(true, "b = ?", 2)
+				w.AddIf(false, "c = ?", 3)
+				w.AddIf(true, "d = ?", 4)
+			},
+			sql:  " WHERE b = $1 AND d = $2",
+			args: []interface{}{2, 4},
+		},
+		{
+			name: "AddIf with every condition skipped",
+			build: func(w *Where) {
+				w.AddIf(false, "a = ?", 1)
+			},
+			sql: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &Where{}
+			tt.build(w)
+
+			if got := w.SQL(); got != tt.sql {
+				t.Errorf("SQL() = %q, want %q", got, tt.sql)
+			}
+			if got := w.Args(); !reflect.DeepEqual(got, tt.args) {
+				t.Errorf("Args() = %#v, want %#v", got, tt.args)
+			}
+		})
+	}
+}
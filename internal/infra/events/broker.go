@@ -0,0 +1,217 @@
+// Package events fans live events out to the clients connected to this replica.
+package events
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/joaopanucci/apsdigital/internal/domain/entities"
+	"github.com/joaopanucci/apsdigital/internal/domain/repositories"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Channel is the Postgres channel live_events rows are announced on
+const Channel = "live_events"
+
+const (
+	// subscriptionBuffer is how many events a slow client may fall behind before it is
+	// dropped; it reconnects with Last-Event-ID and catches up from the database
+	subscriptionBuffer = 64
+	catchUpBatch       = 500
+	reconnectDelay     = 5 * time.Second
+	// catchUpLookback is how far below the newest event delivered catching up starts, for
+	// events that committed after newer ones; those already delivered are skipped
+	catchUpLookback = 1000
+	// recentEvents is how many delivered ids are remembered to skip duplicates
+	recentEvents = 4 * catchUpLookback
+)
+
+// Subscription receives the events visible to its audience. Events is closed when the
+// subscription is dropped for falling behind.
+type Subscription struct {
+	audience entities.LiveEventAudience
+	events   chan *entities.LiveEvent
+}
+
+func (s *Subscription) Events() <-chan *entities.LiveEvent {
+	return s.events
+}
+
+// Broker listens on the live_events channel and delivers each event to the matching
+// subscriptions. Every replica runs its own broker, so all of them see every event.
+type Broker struct {
+	pool       *pgxpool.Pool
+	eventsRepo repositories.LiveEventRepository
+
+	mu            sync.Mutex
+	subscriptions map[*Subscription]struct{}
+	closed        bool
+
+	// lastID is the newest event delivered, used to catch up after a lost connection
+	lastID    int64
+	delivered *RecentIDs
+}
+
+func NewBroker(pool *pgxpool.Pool, eventsRepo repositories.LiveEventRepository) *Broker {
+	return &Broker{
+		pool:          pool,
+		eventsRepo:    eventsRepo,
+		subscriptions: make(map[*Subscription]struct{}),
+		delivered:     NewRecentIDs(recentEvents),
+	}
+}
+
+func (b *Broker) Subscribe(audience entities.LiveEventAudience) *Subscription {
+	sub := &Subscription{
+		audience: audience,
+		events:   make(chan *entities.LiveEvent, subscriptionBuffer),
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		close(sub.events)
+		return sub
+	}
+	b.subscriptions[sub] = struct{}{}
+
+	return sub
+}
+
+// Close ends every subscription, and those made afterwards, so open streams finish and
+// the server can shut down; clients reconnect to another replica and resume
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for sub := range b.subscriptions {
+		delete(b.subscriptions, sub)
+		close(sub.events)
+	}
+}
+
+func (b *Broker) Unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.subscriptions[sub]; ok {
+		delete(b.subscriptions, sub)
+		close(sub.events)
+	}
+}
+
+// Replay returns every event of the audience after afterID, for clients resuming a stream.
+// Events are kept for a limited time, so the replay is bounded.
+func (b *Broker) Replay(ctx context.Context, audience entities.LiveEventAudience, afterID int64) ([]*entities.LiveEvent, error) {
+	var missed []*entities.LiveEvent
+	for {
+		batch, err := b.eventsRepo.ListVisibleAfter(ctx, afterID, audience, catchUpBatch)
+		if err != nil {
+			return nil, err
+		}
+		missed = append(missed, batch...)
+		if len(batch) < catchUpBatch {
+			return missed, nil
+		}
+		afterID = batch[len(batch)-1].ID
+	}
+}
+
+// Run listens for events until ctx is cancelled, reconnecting when the connection drops
+func (b *Broker) Run(ctx context.Context) {
+	for {
+		err := b.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		slog.WarnContext(ctx, "Live event listener stopped", "error", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(reconnectDelay):
+		}
+	}
+}
+
+func (b *Broker) listen(ctx context.Context) error {
+	pooled, err := b.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	// A listening connection must not go back to the pool
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+Channel); err != nil {
+		return err
+	}
+
+	// Deliver what was missed while disconnected
+	if b.lastID > 0 {
+		afterID := max(b.lastID-catchUpLookback, 0)
+		for {
+			missed, err := b.eventsRepo.ListAfter(ctx, afterID, catchUpBatch)
+			if err != nil {
+				return err
+			}
+			for _, event := range missed {
+				b.publish(event)
+			}
+			if len(missed) < catchUpBatch {
+				break
+			}
+			afterID = missed[len(missed)-1].ID
+		}
+	}
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		id, err := strconv.ParseInt(notification.Payload, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		event, err := b.eventsRepo.GetByID(ctx, id)
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to load live event", "event_id", id, "error", err)
+			continue
+		}
+		b.publish(event)
+	}
+}
+
+func (b *Broker) publish(event *entities.LiveEvent) {
+	if !b.delivered.Add(event.ID) {
+		return
+	}
+	if event.ID > b.lastID {
+		b.lastID = event.ID
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for sub := range b.subscriptions {
+		if !event.VisibleTo(sub.audience) {
+			continue
+		}
+
+		select {
+		case sub.events <- event:
+		default:
+			delete(b.subscriptions, sub)
+			close(sub.events)
+		}
+	}
+}
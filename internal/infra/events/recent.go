@@ -0,0 +1,36 @@
+package events
+
+// RecentIDs remembers the last events delivered. Event ids come from a sequence but
+// transactions commit in any order, so an event may arrive after one with a higher id;
+// deliveries are deduplicated by id instead of being cut at the highest id seen.
+type RecentIDs struct {
+	ids  map[int64]struct{}
+	ring []int64
+	next int
+}
+
+// NewRecentIDs remembers up to size ids, forgetting the oldest first
+func NewRecentIDs(size int) *RecentIDs {
+	return &RecentIDs{
+		ids:  make(map[int64]struct{}, size),
+		ring: make([]int64, 0, size),
+	}
+}
+
+// Add records id and reports whether it is new
+func (r *RecentIDs) Add(id int64) bool {
+	if _, ok := r.ids[id]; ok {
+		return false
+	}
+
+	if len(r.ring) < cap(r.ring) {
+		r.ring = append(r.ring, id)
+	} else {
+		delete(r.ids, r.ring[r.next])
+		r.ring[r.next] = id
+		r.next = (r.next + 1) % len(r.ring)
+	}
+	r.ids[id] = struct{}{}
+
+	return true
+}
@@ -0,0 +1,28 @@
+package events
+
+import "testing"
+
+func TestRecentIDs(t *testing.T) {
+	recent := NewRecentIDs(3)
+
+	steps := []struct {
+		id  int64
+		new bool
+	}{
+		{id: 5, new: true},
+		{id: 3, new: true}, // Committed after 5
+		{id: 5, new: false},
+		{id: 7, new: true},
+		{id: 3, new: false},
+		{id: 8, new: true}, // Forgets 5, the oldest
+		{id: 5, new: true},
+		{id: 7, new: false},
+		{id: 3, new: true},
+	}
+
+	for i, step := range steps {
+		if got := recent.Add(step.id); got != step.new {
+			t.Errorf("step %d: Add(%d) = %v, want %v", i, step.id, got, step.new)
+		}
+	}
+}
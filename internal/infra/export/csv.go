@@ -0,0 +1,61 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+)
+
+// CSVWriter writes semicolon separated values with a UTF-8 BOM, which is what Excel
+// expects in pt-BR locales. CSV has no sheets, so AddSheet appends the extra rows
+// after a blank line and the sheet name.
+type CSVWriter struct {
+	w *csv.Writer
+}
+
+// NewCSVWriter starts a CSV document on w
+func NewCSVWriter(w io.Writer) (*CSVWriter, error) {
+	if _, err := io.WriteString(w, "\ufeff"); err != nil {
+		return nil, err
+	}
+
+	writer := csv.NewWriter(w)
+	writer.Comma = ';'
+	return &CSVWriter{w: writer}, nil
+}
+
+func (c *CSVWriter) WriteRow(cells ...string) error {
+	record := make([]string, len(cells))
+	for i, cell := range cells {
+		record[i] = escapeFormula(cell)
+	}
+	return c.w.Write(record)
+}
+
+func (c *CSVWriter) AddSheet(name string, rows [][]string) error {
+	if err := c.w.Write([]string{}); err != nil {
+		return err
+	}
+	if err := c.WriteRow(name); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err := c.WriteRow(row...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *CSVWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// escapeFormula prefixes cells that spreadsheets would evaluate as formulas
+func escapeFormula(cell string) string {
+	if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
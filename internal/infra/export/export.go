@@ -0,0 +1,49 @@
+// Package export writes tabular reports as CSV or XLSX, one row at a time so large
+// listings can be streamed straight to the response.
+package export
+
+import (
+	"fmt"
+	"io"
+)
+
+// Format is a spreadsheet format a report can be exported to
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatXLSX Format = "xlsx"
+)
+
+// Writer streams the rows of a report. The first sheet is written row by row with
+// WriteRow; small extra sheets such as summaries are added whole with AddSheet.
+type Writer interface {
+	WriteRow(cells ...string) error
+	AddSheet(name string, rows [][]string) error
+	Close() error
+}
+
+// ParseFormat validates the format requested by the client
+func ParseFormat(value string) (Format, error) {
+	switch Format(value) {
+	case FormatCSV, FormatXLSX:
+		return Format(value), nil
+	}
+	return "", fmt.Errorf("unsupported export format %q", value)
+}
+
+// ContentType is the MIME type of the format
+func (f Format) ContentType() string {
+	if f == FormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// NewWriter creates a writer of the format whose first sheet is named sheet
+func NewWriter(w io.Writer, format Format, sheet string) (Writer, error) {
+	if format == FormatXLSX {
+		return NewXLSXWriter(w, sheet)
+	}
+	return NewCSVWriter(w)
+}
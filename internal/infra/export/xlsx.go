@@ -0,0 +1,130 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	xlsxSheetHeader = xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+	xlsxSheetFooter = `</sheetData></worksheet>`
+)
+
+// XLSXWriter writes an Office Open XML workbook using inline strings, so rows can be
+// written to the zip as they come instead of being collected in a shared string table.
+type XLSXWriter struct {
+	zip    *zip.Writer
+	sheet  *bufio.Writer
+	row    int
+	sheets []string
+}
+
+// NewXLSXWriter starts a workbook on w whose first sheet is named sheet
+func NewXLSXWriter(w io.Writer, sheet string) (*XLSXWriter, error) {
+	x := &XLSXWriter{zip: zip.NewWriter(w)}
+	if err := x.startSheet(sheet); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+func (x *XLSXWriter) WriteRow(cells ...string) error {
+	x.row++
+	fmt.Fprintf(x.sheet, `<row r="%d">`, x.row)
+	for i, cell := range cells {
+		fmt.Fprintf(x.sheet, `<c r="%s%d" t="inlineStr"><is><t xml:space="preserve">`, columnName(i), x.row)
+		if err := xml.EscapeText(x.sheet, []byte(cell)); err != nil {
+			return err
+		}
+		x.sheet.WriteString(`</t></is></c>`)
+	}
+	_, err := x.sheet.WriteString(`</row>`)
+	return err
+}
+
+func (x *XLSXWriter) AddSheet(name string, rows [][]string) error {
+	if err := x.endSheet(); err != nil {
+		return err
+	}
+	if err := x.startSheet(name); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err := x.WriteRow(row...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close finishes the last sheet and writes the workbook parts that reference the sheets
+func (x *XLSXWriter) Close() error {
+	if err := x.endSheet(); err != nil {
+		return err
+	}
+
+	var sheets, rels, overrides string
+	for i, name := range x.sheets {
+		n := strconv.Itoa(i + 1)
+		sheets += `<sheet name="` + escapeAttr(name) + `" sheetId="` + n + `" r:id="rId` + n + `"/>`
+		rels += `<Relationship Id="rId` + n + `" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet` + n + `.xml"/>`
+		overrides += `<Override PartName="/xl/worksheets/sheet` + n + `.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`
+	}
+
+	parts := []struct{ name, content string }{
+		{"xl/workbook.xml", xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>` + sheets + `</sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` + rels + `</Relationships>`},
+		{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+		{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` + overrides + `</Types>`},
+	}
+	for _, part := range parts {
+		w, err := x.zip.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, part.content); err != nil {
+			return err
+		}
+	}
+
+	return x.zip.Close()
+}
+
+func (x *XLSXWriter) startSheet(name string) error {
+	x.sheets = append(x.sheets, name)
+	w, err := x.zip.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", len(x.sheets)))
+	if err != nil {
+		return err
+	}
+	x.sheet = bufio.NewWriter(w)
+	x.row = 0
+	_, err = x.sheet.WriteString(xlsxSheetHeader)
+	return err
+}
+
+func (x *XLSXWriter) endSheet() error {
+	if _, err := x.sheet.WriteString(xlsxSheetFooter); err != nil {
+		return err
+	}
+	return x.sheet.Flush()
+}
+
+// columnName converts a zero-based column index to its letters (0 = A, 26 = AA)
+func columnName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+func escapeAttr(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
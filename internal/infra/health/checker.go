@@ -0,0 +1,85 @@
+// Package health answers the liveness and readiness probes.
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Check reports why a dependency is unusable, or nil
+type Check func(ctx context.Context) error
+
+// checkTimeout bounds each check so a hung dependency fails the probe instead of stalling it
+const checkTimeout = 3 * time.Second
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Checker runs the readiness checks. Once draining it reports not ready, so load
+// balancers stop sending requests while the server shuts down.
+type Checker struct {
+	checks   []namedCheck
+	draining atomic.Bool
+}
+
+func NewChecker() *Checker {
+	return &Checker{}
+}
+
+// Add registers a check; add every check before serving
+func (c *Checker) Add(name string, check Check) {
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+// Drain makes every following readiness check fail
+func (c *Checker) Drain() {
+	c.draining.Store(true)
+}
+
+// Report is the result of each check, "ok" or the error
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+// Ready runs the checks concurrently
+func (c *Checker) Ready(ctx context.Context) (*Report, bool) {
+	report := &Report{Status: "ready", Checks: make(map[string]string, len(c.checks))}
+	if c.draining.Load() {
+		report.Status = "draining"
+		return report, false
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	ready := true
+	for _, nc := range c.checks {
+		wg.Add(1)
+		go func(nc namedCheck) {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+			defer cancel()
+			err := nc.check(checkCtx)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				report.Checks[nc.name] = err.Error()
+				ready = false
+				return
+			}
+			report.Checks[nc.name] = "ok"
+		}(nc)
+	}
+	wg.Wait()
+
+	if !ready {
+		report.Status = "not ready"
+	}
+	return report, ready
+}
@@ -3,9 +3,12 @@ package controllers
 import (
 	"net/http"
 
+	"github.com/joaopanucci/apsdigital/internal/domain/apperrors"
 	"github.com/joaopanucci/apsdigital/internal/domain/services"
+	"github.com/joaopanucci/apsdigital/internal/infra/metrics"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 type AuthController struct {
@@ -21,18 +24,18 @@ func NewAuthController(authService *services.AuthService) *AuthController {
 func (ac *AuthController) Register(c *gin.Context) {
 	var req services.RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(bindingError(err))
 		return
 	}
 
 	user, err := ac.authService.Register(c.Request.Context(), &req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusCreated, gin.H{
-		"message": "User registered successfully. Awaiting authorization.",
+		"message": message(c, "user_registered"),
 		"user":    user,
 	})
 }
@@ -40,13 +43,14 @@ func (ac *AuthController) Register(c *gin.Context) {
 func (ac *AuthController) Login(c *gin.Context) {
 	var req services.LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(bindingError(err))
 		return
 	}
 
-	response, err := ac.authService.Login(c.Request.Context(), &req)
+	response, err := ac.authService.Login(c.Request.Context(), &req, deviceInfo(c, req.DeviceName))
+	metrics.ObserveLogin(err)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -56,16 +60,17 @@ func (ac *AuthController) Login(c *gin.Context) {
 func (ac *AuthController) RefreshToken(c *gin.Context) {
 	var req struct {
 		RefreshToken string `json:"refresh_token" binding:"required"`
+		DeviceName   string `json:"device_name"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(bindingError(err))
 		return
 	}
 
-	response, err := ac.authService.RefreshToken(c.Request.Context(), req.RefreshToken)
+	response, err := ac.authService.RefreshToken(c.Request.Context(), req.RefreshToken, deviceInfo(c, req.DeviceName))
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -78,22 +83,22 @@ func (ac *AuthController) Logout(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(bindingError(err))
 		return
 	}
 
 	if err := ac.authService.Logout(c.Request.Context(), req.RefreshToken); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": message(c, "logged_out")})
 }
 
 func (ac *AuthController) Me(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		c.Error(apperrors.ErrUnauthenticated)
 		return
 	}
 
@@ -104,3 +109,52 @@ func (ac *AuthController) Me(c *gin.Context) {
 		"user_level": c.GetInt("user_level"),
 	})
 }
+
+func (ac *AuthController) ListSessions(c *gin.Context) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		c.Error(apperrors.ErrUnauthenticated)
+		return
+	}
+
+	sessionID, _ := c.Get("session_id")
+	currentSessionID, _ := sessionID.(uuid.UUID)
+
+	sessions, err := ac.authService.ListSessions(c.Request.Context(), userID.(uuid.UUID), currentSessionID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sessions": sessions})
+}
+
+func (ac *AuthController) RevokeSession(c *gin.Context) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		c.Error(apperrors.ErrUnauthenticated)
+		return
+	}
+
+	sessionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(invalidID("id"))
+		return
+	}
+
+	if err := ac.authService.RevokeSession(c.Request.Context(), userID.(uuid.UUID), sessionID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": message(c, "session_ended")})
+}
+
+// deviceInfo collects the metadata recorded with a session
+func deviceInfo(c *gin.Context, deviceName string) services.DeviceInfo {
+	return services.DeviceInfo{
+		Name:      deviceName,
+		UserAgent: c.Request.UserAgent(),
+		IPAddress: c.ClientIP(),
+	}
+}
@@ -0,0 +1,85 @@
+package controllers
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/joaopanucci/apsdigital/internal/domain/apperrors"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// errPDFOnly rejects uploads of documents that must be PDFs
+var errPDFOnly = apperrors.InvalidFile("file_pdf_only")
+
+func init() {
+	// Report fields by the names clients send, not the Go struct fields
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(inputFieldName)
+	}
+}
+
+func inputFieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "form", "uri"} {
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// bindingError turns a failed ShouldBind into a validation error naming each rejected field
+func bindingError(err error) error {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return apperrors.Validation(apperrors.CodeInvalidBody).Wrap(err)
+	}
+
+	fields := make([]apperrors.FieldError, 0, len(validationErrors))
+	for _, fieldErr := range validationErrors {
+		key, args := validationMessage(fieldErr)
+		fields = append(fields, apperrors.FieldError{
+			Field: fieldErr.Field(),
+			Code:  fieldErr.Tag(),
+			Key:   key,
+			Args:  args,
+		})
+	}
+
+	return apperrors.Validation(apperrors.CodeInvalidInput, fields...)
+}
+
+// validationMessage picks the catalog message for a failed validator tag
+func validationMessage(fieldErr validator.FieldError) (string, []any) {
+	switch fieldErr.Tag() {
+	case "required":
+		return "required", nil
+	case "email":
+		return "email_invalid", nil
+	case "min":
+		if fieldErr.Kind() == reflect.String {
+			return "min_length", []any{fieldErr.Param()}
+		}
+		return "min_value", []any{fieldErr.Param()}
+	case "max":
+		if fieldErr.Kind() == reflect.String {
+			return "max_length", []any{fieldErr.Param()}
+		}
+		return "max_value", []any{fieldErr.Param()}
+	case "oneof":
+		return "one_of", []any{fieldErr.Param()}
+	default:
+		return "invalid_value", nil
+	}
+}
+
+// invalidID rejects an identifier in the path or query string that can't be parsed
+func invalidID(field string) error {
+	return apperrors.Invalid(field, "id_invalid")
+}
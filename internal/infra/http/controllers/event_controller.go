@@ -0,0 +1,145 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/joaopanucci/apsdigital/internal/domain/apperrors"
+	"github.com/joaopanucci/apsdigital/internal/domain/entities"
+	"github.com/joaopanucci/apsdigital/internal/infra/events"
+	"github.com/joaopanucci/apsdigital/internal/infra/http/middlewares"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// heartbeatInterval keeps proxies from closing idle streams
+	heartbeatInterval = 25 * time.Second
+	// reconnectDelay is how long EventSource waits before reconnecting, in milliseconds
+	reconnectDelay = 5000
+	// recentLiveEvents is how many sent event ids a stream remembers to skip duplicates
+	recentLiveEvents = 1024
+)
+
+type EventController struct {
+	broker *events.Broker
+}
+
+func NewEventController(broker *events.Broker) *EventController {
+	return &EventController{
+		broker: broker,
+	}
+}
+
+// Stream pushes the current user's notifications and the changes to records in their
+// scope as Server-Sent Events. Each event carries its id, so a reconnecting client that
+// sends Last-Event-ID (or ?last_event_id=) receives what it missed first.
+func (c *EventController) Stream(ctx *gin.Context) {
+	userEntity, ok := currentUser(ctx)
+	if !ok {
+		return
+	}
+
+	audience := entities.LiveEventAudience{
+		UserID:      userEntity.ID,
+		Scope:       middlewares.MunicipalityScope(ctx),
+		Permissions: middlewares.Permissions(ctx),
+	}
+
+	lastID, err := lastEventID(ctx)
+	if err != nil {
+		ctx.Error(apperrors.Invalid("Last-Event-ID", "event_id_invalid"))
+		return
+	}
+
+	// Subscribe before replaying so nothing falls between the two
+	sub := c.broker.Subscribe(audience)
+	defer c.broker.Unsubscribe(sub)
+
+	var missed []*entities.LiveEvent
+	if lastID > 0 {
+		missed, err = c.broker.Replay(ctx.Request.Context(), audience, lastID)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+	}
+
+	// The stream outlives the server's write timeout
+	if err := http.NewResponseController(ctx.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		ctx.Error(fmt.Errorf("streaming not supported: %w", err))
+		return
+	}
+
+	header := ctx.Writer.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+
+	if err := sse.Encode(ctx.Writer, sse.Event{Retry: reconnectDelay}); err != nil {
+		return
+	}
+	// Replayed events may also come through the subscription
+	sent := events.NewRecentIDs(recentLiveEvents)
+	for _, event := range missed {
+		sent.Add(event.ID)
+		if err := writeLiveEvent(ctx, event); err != nil {
+			return
+		}
+	}
+	ctx.Writer.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case event, open := <-sub.Events():
+			if !open {
+				// Dropped for falling behind or shutting down; the client resumes from its last event
+				return
+			}
+			if !sent.Add(event.ID) {
+				continue
+			}
+			if err := writeLiveEvent(ctx, event); err != nil {
+				return
+			}
+			ctx.Writer.Flush()
+		case <-heartbeat.C:
+			if _, err := ctx.Writer.WriteString(": heartbeat\n\n"); err != nil {
+				return
+			}
+			ctx.Writer.Flush()
+		}
+	}
+}
+
+// writeLiveEvent sends the event named after its kind, so clients listen for
+// "notification" and "entity"
+func writeLiveEvent(ctx *gin.Context, event *entities.LiveEvent) error {
+	return sse.Encode(ctx.Writer, sse.Event{
+		Id:    strconv.FormatInt(event.ID, 10),
+		Event: string(event.Kind),
+		Data:  event,
+	})
+}
+
+func lastEventID(ctx *gin.Context) (int64, error) {
+	value := ctx.GetHeader("Last-Event-ID")
+	if value == "" {
+		value = ctx.Query("last_event_id")
+	}
+	if value == "" {
+		return 0, nil
+	}
+
+	return strconv.ParseInt(value, 10, 64)
+}
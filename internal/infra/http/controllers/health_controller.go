@@ -0,0 +1,35 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joaopanucci/apsdigital/internal/infra/health"
+)
+
+type HealthController struct {
+	checker *health.Checker
+}
+
+func NewHealthController(checker *health.Checker) *HealthController {
+	return &HealthController{
+		checker: checker,
+	}
+}
+
+// Livez answers while the process can serve requests; it checks no dependency, so a
+// database outage does not get every replica restarted
+func (c *HealthController) Livez(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Readyz answers 503 while a dependency is unusable or the server is shutting down
+func (c *HealthController) Readyz(ctx *gin.Context) {
+	report, ready := c.checker.Ready(ctx.Request.Context())
+	if !ready {
+		ctx.JSON(http.StatusServiceUnavailable, report)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, report)
+}
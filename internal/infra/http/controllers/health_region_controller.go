@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joaopanucci/apsdigital/internal/domain/entities"
+	"github.com/joaopanucci/apsdigital/internal/domain/services"
+)
+
+type HealthRegionController struct {
+	regionService *services.HealthRegionService
+}
+
+func NewHealthRegionController(regionService *services.HealthRegionService) *HealthRegionController {
+	return &HealthRegionController{
+		regionService: regionService,
+	}
+}
+
+type HealthRegionRequest struct {
+	Name     string `json:"name" binding:"required"`
+	Type     string `json:"type" binding:"required"`
+	ParentID *int   `json:"parent_id"`
+	State    string `json:"state" binding:"required"`
+}
+
+type SetRegionMunicipalitiesRequest struct {
+	MunicipalityIDs []int `json:"municipality_ids" binding:"required"`
+}
+
+func (c *HealthRegionController) GetHealthRegions(ctx *gin.Context) {
+	regions, err := c.regionService.GetAll(ctx.Request.Context())
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, regions)
+}
+
+func (c *HealthRegionController) GetHealthRegionByID(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.Error(invalidID("id"))
+		return
+	}
+
+	region, err := c.regionService.GetByID(ctx.Request.Context(), id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, region)
+}
+
+func (c *HealthRegionController) CreateHealthRegion(ctx *gin.Context) {
+	var req HealthRegionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(bindingError(err))
+		return
+	}
+
+	region := &entities.HealthRegion{
+		Name:     req.Name,
+		Type:     entities.HealthRegionType(req.Type),
+		ParentID: req.ParentID,
+		State:    req.State,
+	}
+
+	if err := c.regionService.Create(ctx.Request.Context(), region); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, region)
+}
+
+func (c *HealthRegionController) UpdateHealthRegion(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.Error(invalidID("id"))
+		return
+	}
+
+	var req HealthRegionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(bindingError(err))
+		return
+	}
+
+	region := &entities.HealthRegion{
+		ID:       id,
+		Name:     req.Name,
+		Type:     entities.HealthRegionType(req.Type),
+		ParentID: req.ParentID,
+		State:    req.State,
+	}
+
+	if err := c.regionService.Update(ctx.Request.Context(), region); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, region)
+}
+
+func (c *HealthRegionController) DeleteHealthRegion(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.Error(invalidID("id"))
+		return
+	}
+
+	if err := c.regionService.Delete(ctx.Request.Context(), id); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": message(ctx, "health_region_deleted")})
+}
+
+func (c *HealthRegionController) SetMunicipalities(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.Error(invalidID("id"))
+		return
+	}
+
+	var req SetRegionMunicipalitiesRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(bindingError(err))
+		return
+	}
+
+	if err := c.regionService.SetMunicipalities(ctx.Request.Context(), id, req.MunicipalityIDs); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": message(ctx, "health_region_municipalities_updated")})
+}
@@ -0,0 +1,232 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joaopanucci/apsdigital/internal/domain/apperrors"
+	"github.com/joaopanucci/apsdigital/internal/domain/entities"
+	"github.com/joaopanucci/apsdigital/internal/domain/services"
+	"github.com/joaopanucci/apsdigital/internal/infra/http/middlewares"
+	"github.com/joaopanucci/apsdigital/internal/infra/metrics"
+)
+
+type HealthUnitController struct {
+	unitService *services.HealthUnitService
+}
+
+func NewHealthUnitController(unitService *services.HealthUnitService) *HealthUnitController {
+	return &HealthUnitController{
+		unitService: unitService,
+	}
+}
+
+type HealthUnitFilters struct {
+	MunicipalityID string `form:"municipality_id"`
+	Type           string `form:"type"`
+	Active         string `form:"active"`
+	Search         string `form:"q"`
+}
+
+type HealthUnitRequest struct {
+	CNES           string `json:"cnes" binding:"required"`
+	Name           string `json:"name" binding:"required"`
+	Type           string `json:"type"`
+	MunicipalityID int    `json:"municipality_id" binding:"required"`
+	Active         *bool  `json:"active"`
+}
+
+func (c *HealthUnitController) GetHealthUnits(ctx *gin.Context) {
+	var filters HealthUnitFilters
+	if err := ctx.ShouldBindQuery(&filters); err != nil {
+		ctx.Error(bindingError(err))
+		return
+	}
+
+	q, err := bindListQuery[entities.HealthUnitFilter](ctx, "name", "cnes", "type", "created_at")
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	// Restrict to the user's scope, optionally narrowed to one municipality
+	scope := middlewares.MunicipalityScope(ctx)
+	if filters.MunicipalityID != "" {
+		municipalityID, err := strconv.Atoi(filters.MunicipalityID)
+		if err != nil {
+			ctx.Error(invalidID("municipality_id"))
+			return
+		}
+		if !scope.Contains(&municipalityID) {
+			ctx.Error(apperrors.ErrAccessDenied)
+			return
+		}
+		q.Filters.MunicipalityID = &municipalityID
+	} else if ids := scope.Filter(); ids != nil {
+		q.Filters.MunicipalityIDs = ids
+	}
+
+	if filters.Type != "" {
+		q.Filters.Type = filters.Type
+	}
+
+	if filters.Active != "" {
+		active, err := strconv.ParseBool(filters.Active)
+		if err != nil {
+			ctx.Error(apperrors.Invalid("active", "boolean_invalid"))
+			return
+		}
+		q.Filters.Active = &active
+	}
+
+	if filters.Search != "" {
+		q.Filters.Search = filters.Search
+	}
+
+	page, err := c.unitService.GetAll(ctx.Request.Context(), q)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, page)
+}
+
+func (c *HealthUnitController) GetHealthUnitByID(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.Error(invalidID("id"))
+		return
+	}
+
+	unit, err := c.unitService.GetByID(ctx.Request.Context(), id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if !canAccessMunicipality(ctx, &unit.MunicipalityID) {
+		ctx.Error(apperrors.ErrAccessDenied)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, unit)
+}
+
+func (c *HealthUnitController) CreateHealthUnit(ctx *gin.Context) {
+	var req HealthUnitRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(bindingError(err))
+		return
+	}
+
+	if !canAccessMunicipality(ctx, &req.MunicipalityID) {
+		ctx.Error(apperrors.ErrAccessDenied)
+		return
+	}
+
+	unit := &entities.HealthUnit{
+		CNES:           req.CNES,
+		Name:           req.Name,
+		Type:           req.Type,
+		MunicipalityID: req.MunicipalityID,
+		Active:         req.Active == nil || *req.Active,
+	}
+
+	if err := c.unitService.Create(ctx.Request.Context(), unit); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, unit)
+}
+
+func (c *HealthUnitController) UpdateHealthUnit(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.Error(invalidID("id"))
+		return
+	}
+
+	var req HealthUnitRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(bindingError(err))
+		return
+	}
+
+	existing, err := c.unitService.GetByID(ctx.Request.Context(), id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if !canAccessMunicipality(ctx, &existing.MunicipalityID) || !canAccessMunicipality(ctx, &req.MunicipalityID) {
+		ctx.Error(apperrors.ErrAccessDenied)
+		return
+	}
+
+	unit := &entities.HealthUnit{
+		ID:             id,
+		CNES:           req.CNES,
+		Name:           req.Name,
+		Type:           req.Type,
+		MunicipalityID: req.MunicipalityID,
+		Active:         existing.Active,
+	}
+	if req.Active != nil {
+		unit.Active = *req.Active
+	}
+
+	if err := c.unitService.Update(ctx.Request.Context(), unit); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, unit)
+}
+
+func (c *HealthUnitController) DeleteHealthUnit(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.Error(invalidID("id"))
+		return
+	}
+
+	unit, err := c.unitService.GetByID(ctx.Request.Context(), id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if !canAccessMunicipality(ctx, &unit.MunicipalityID) {
+		ctx.Error(apperrors.ErrAccessDenied)
+		return
+	}
+
+	if err := c.unitService.Delete(ctx.Request.Context(), id); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": message(ctx, "health_unit_deleted")})
+}
+
+// ImportCNES upserts the establishments of an uploaded CNES CSV export
+func (c *HealthUnitController) ImportCNES(ctx *gin.Context) {
+	file, header, err := ctx.Request.FormFile("file")
+	if err != nil {
+		ctx.Error(apperrors.Required("file"))
+		return
+	}
+	defer file.Close()
+	metrics.ObserveUpload(metrics.UploadHealthUnitImport, header.Size)
+
+	report, err := c.unitService.ImportCNES(ctx.Request.Context(), file, middlewares.MunicipalityScope(ctx))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, report)
+}
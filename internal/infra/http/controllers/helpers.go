@@ -0,0 +1,30 @@
+package controllers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/joaopanucci/apsdigital/internal/domain/apperrors"
+	"github.com/joaopanucci/apsdigital/internal/domain/entities"
+	"github.com/joaopanucci/apsdigital/internal/i18n"
+	"github.com/joaopanucci/apsdigital/internal/infra/http/middlewares"
+)
+
+// canAccessMunicipality reports whether the user's scope covers the given municipality
+func canAccessMunicipality(ctx *gin.Context, municipalityID *int) bool {
+	return middlewares.MunicipalityScope(ctx).Contains(municipalityID)
+}
+
+// currentUser returns the authenticated user, answering 401 when there is none
+func currentUser(ctx *gin.Context) (*entities.User, bool) {
+	user, exists := ctx.Get("user")
+	if !exists {
+		ctx.Error(apperrors.ErrUnauthenticated)
+		return nil, false
+	}
+
+	return user.(*entities.User), true
+}
+
+// message is the catalog message for key in the language negotiated for the request
+func message(ctx *gin.Context, key string, args ...any) string {
+	return i18n.Message(middlewares.Language(ctx), key, args...)
+}
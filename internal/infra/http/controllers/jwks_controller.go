@@ -0,0 +1,24 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joaopanucci/apsdigital/internal/infra/jwtkeys"
+)
+
+type JWKSController struct {
+	keys *jwtkeys.Manager
+}
+
+func NewJWKSController(keys *jwtkeys.Manager) *JWKSController {
+	return &JWKSController{
+		keys: keys,
+	}
+}
+
+// GetJWKS publishes the public signing keys so other services can verify access tokens
+func (c *JWKSController) GetJWKS(ctx *gin.Context) {
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, c.keys.JWKS())
+}
@@ -0,0 +1,62 @@
+package controllers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joaopanucci/apsdigital/internal/domain/apperrors"
+	"github.com/joaopanucci/apsdigital/internal/domain/entities"
+)
+
+// bindListQuery reads page, limit, cursor and sort from the query string. sort is a comma
+// separated list of fields, each prefixed with "-" for descending order, and must only use
+// sortFields.
+func bindListQuery[F any](ctx *gin.Context, sortFields ...string) (entities.ListQuery[F], error) {
+	q := entities.ListQuery[F]{
+		Cursor: ctx.Query("cursor"),
+	}
+
+	if value := ctx.Query("page"); value != "" {
+		page, err := strconv.Atoi(value)
+		if err != nil || page < 1 {
+			return q, apperrors.Invalid("page", "page_invalid")
+		}
+		q.Page = page
+	}
+
+	if value := ctx.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			return q, apperrors.Invalid("limit", "limit_invalid")
+		}
+		q.Limit = limit
+	}
+
+	if value := ctx.Query("sort"); value != "" {
+		for _, field := range strings.Split(value, ",") {
+			field = strings.TrimSpace(field)
+			desc := strings.HasPrefix(field, "-")
+			field = strings.TrimPrefix(field, "-")
+
+			allowed := false
+			for _, sortField := range sortFields {
+				if field == sortField {
+					allowed = true
+					break
+				}
+			}
+			if !allowed {
+				return q, apperrors.Invalid("sort", "sort_field_invalid", field, strings.Join(sortFields, ", "))
+			}
+
+			q.Sort = append(q.Sort, entities.SortField{Field: field, Desc: desc})
+		}
+	}
+
+	if err := q.Normalize(); err != nil {
+		return q, err
+	}
+
+	return q, nil
+}
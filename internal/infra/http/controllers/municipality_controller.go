@@ -2,27 +2,108 @@ package controllers
 
 import (
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/joaopanucci/apsdigital/internal/domain/apperrors"
+	"github.com/joaopanucci/apsdigital/internal/domain/entities"
 	"github.com/joaopanucci/apsdigital/internal/domain/services"
+	"github.com/joaopanucci/apsdigital/internal/infra/ibge"
+	"github.com/joaopanucci/apsdigital/internal/infra/metrics"
 )
 
 type MunicipalityController struct {
 	municipalityService *services.MunicipalityService
+	// ibgeSource is synced when no file is uploaded
+	ibgeSource string
 }
 
-func NewMunicipalityController(municipalityService *services.MunicipalityService) *MunicipalityController {
+func NewMunicipalityController(municipalityService *services.MunicipalityService, ibgeSource string) *MunicipalityController {
 	return &MunicipalityController{
 		municipalityService: municipalityService,
+		ibgeSource:          ibgeSource,
 	}
 }
 
+type MunicipalityFilters struct {
+	State          string `form:"state"`
+	HealthRegionID string `form:"health_region_id"`
+	Search         string `form:"q"`
+}
+
+type SyncMunicipalitiesRequest struct {
+	State  string `form:"state"`
+	DryRun bool   `form:"dry_run"`
+}
+
 func (c *MunicipalityController) GetMunicipalities(ctx *gin.Context) {
-	municipalities, err := c.municipalityService.GetAll(ctx.Request.Context())
+	var filters MunicipalityFilters
+	if err := ctx.ShouldBindQuery(&filters); err != nil {
+		ctx.Error(bindingError(err))
+		return
+	}
+
+	q, err := bindListQuery[entities.MunicipalityFilter](ctx, "name", "state", "ibge_code")
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if filters.State != "" {
+		q.Filters.State = filters.State
+	}
+
+	if filters.HealthRegionID != "" {
+		healthRegionID, err := strconv.Atoi(filters.HealthRegionID)
+		if err != nil {
+			ctx.Error(invalidID("health_region_id"))
+			return
+		}
+		q.Filters.HealthRegionID = &healthRegionID
+	}
+
+	if filters.Search != "" {
+		q.Filters.Search = filters.Search
+	}
+
+	page, err := c.municipalityService.GetAll(ctx.Request.Context(), q)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, page)
+}
+
+// SyncFromIBGE imports an uploaded IBGE localities file, or the configured source when none is sent
+func (c *MunicipalityController) SyncFromIBGE(ctx *gin.Context) {
+	var req SyncMunicipalitiesRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.Error(bindingError(err))
+		return
+	}
+
+	opts := services.MunicipalitySyncOptions{State: req.State, DryRun: req.DryRun}
+
+	file, header, err := ctx.Request.FormFile("file")
+	if err != nil && err != http.ErrMissingFile && err != http.ErrNotMultipart {
+		ctx.Error(apperrors.InvalidFile("file_unreadable").Wrap(err))
+		return
+	}
+
+	var report *entities.MunicipalitySyncReport
+	if file != nil {
+		defer file.Close()
+		metrics.ObserveUpload(metrics.UploadMunicipalityImport, header.Size)
+		report, err = c.municipalityService.SyncFromIBGEData(ctx.Request.Context(), header.Filename, file, ibge.FormatFromName(header.Filename), opts)
+	} else {
+		report, err = c.municipalityService.SyncFromIBGE(ctx.Request.Context(), c.ibgeSource, opts)
+	}
+
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.Error(err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, municipalities)
+	ctx.JSON(http.StatusOK, report)
 }
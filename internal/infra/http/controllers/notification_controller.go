@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joaopanucci/apsdigital/internal/domain/entities"
+	"github.com/joaopanucci/apsdigital/internal/domain/services"
+)
+
+type NotificationController struct {
+	notificationService *services.NotificationService
+}
+
+func NewNotificationController(notificationService *services.NotificationService) *NotificationController {
+	return &NotificationController{
+		notificationService: notificationService,
+	}
+}
+
+type MarkNotificationsReadRequest struct {
+	IDs []int64 `json:"ids" binding:"required"`
+}
+
+// NotificationPage is a page of notifications with the user's unread total
+type NotificationPage struct {
+	*entities.Page[*entities.Notification]
+	UnreadCount int `json:"unread_count"`
+}
+
+// GetNotifications lists the current user's notifications, newest first; ?unread=true
+// keeps only the unread ones
+func (c *NotificationController) GetNotifications(ctx *gin.Context) {
+	userEntity, ok := currentUser(ctx)
+	if !ok {
+		return
+	}
+
+	q, err := bindListQuery[entities.NotificationFilter](ctx, "created_at")
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	q.Filters.UserID = userEntity.ID
+	q.Filters.UnreadOnly = ctx.Query("unread") == "true"
+
+	page, err := c.notificationService.GetAll(ctx.Request.Context(), q)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	unread, err := c.notificationService.CountUnread(ctx.Request.Context(), userEntity.ID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, NotificationPage{Page: page, UnreadCount: unread})
+}
+
+func (c *NotificationController) GetUnreadCount(ctx *gin.Context) {
+	userEntity, ok := currentUser(ctx)
+	if !ok {
+		return
+	}
+
+	unread, err := c.notificationService.CountUnread(ctx.Request.Context(), userEntity.ID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"unread_count": unread})
+}
+
+// MarkRead marks the given notifications of the current user as read
+func (c *NotificationController) MarkRead(ctx *gin.Context) {
+	userEntity, ok := currentUser(ctx)
+	if !ok {
+		return
+	}
+
+	var req MarkNotificationsReadRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(bindingError(err))
+		return
+	}
+
+	updated, err := c.notificationService.MarkRead(ctx.Request.Context(), userEntity.ID, req.IDs)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"updated": updated})
+}
+
+func (c *NotificationController) MarkAllRead(ctx *gin.Context) {
+	userEntity, ok := currentUser(ctx)
+	if !ok {
+		return
+	}
+
+	updated, err := c.notificationService.MarkAllRead(ctx.Request.Context(), userEntity.ID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"updated": updated})
+}
@@ -2,9 +2,7 @@ package controllers
 
 import (
 	"fmt"
-	"io"
 	"net/http"
-	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -12,17 +10,23 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/joaopanucci/apsdigital/internal/domain/apperrors"
 	"github.com/joaopanucci/apsdigital/internal/domain/entities"
 	"github.com/joaopanucci/apsdigital/internal/domain/services"
+	"github.com/joaopanucci/apsdigital/internal/infra/http/middlewares"
+	"github.com/joaopanucci/apsdigital/internal/infra/metrics"
+	"github.com/joaopanucci/apsdigital/internal/infra/storage"
 )
 
 type PaymentController struct {
 	paymentService *services.PaymentService
+	files          *storage.Local
 }
 
-func NewPaymentController(paymentService *services.PaymentService) *PaymentController {
+func NewPaymentController(paymentService *services.PaymentService, files *storage.Local) *PaymentController {
 	return &PaymentController{
 		paymentService: paymentService,
+		files:          files,
 	}
 }
 
@@ -37,21 +41,19 @@ type PaymentFilters struct {
 	Month          string `form:"month"`
 	Competence     string `form:"competence"`
 	MunicipalityID string `form:"municipality_id"`
-	Page           int    `form:"page"`
-	Limit          int    `form:"limit"`
 }
 
 func (c *PaymentController) CreatePayment(ctx *gin.Context) {
 	var req CreatePaymentRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		ctx.Error(bindingError(err))
 		return
 	}
 
 	// Get user from context (set by auth middleware)
 	user, exists := ctx.Get("user")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		ctx.Error(apperrors.ErrUnauthenticated)
 		return
 	}
 
@@ -64,8 +66,13 @@ func (c *PaymentController) CreatePayment(ctx *gin.Context) {
 		MunicipalityID: func() *int { i := int(req.MunicipalityID); return &i }(),
 	}
 
+	if !canAccessMunicipality(ctx, payment.MunicipalityID) {
+		ctx.Error(apperrors.ErrAccessDenied)
+		return
+	}
+
 	if err := c.paymentService.CreatePayment(ctx.Request.Context(), payment); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.Error(err)
 		return
 	}
 
@@ -75,93 +82,75 @@ func (c *PaymentController) CreatePayment(ctx *gin.Context) {
 func (c *PaymentController) GetPayments(ctx *gin.Context) {
 	var filters PaymentFilters
 	if err := ctx.ShouldBindQuery(&filters); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		ctx.Error(bindingError(err))
 		return
 	}
 
-	// Get user from context
-	user, exists := ctx.Get("user")
-	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+	q, err := bindListQuery[entities.PaymentFilter](ctx, "created_at", "competence", "municipality")
+	if err != nil {
+		ctx.Error(err)
 		return
 	}
 
-	userEntity := user.(*entities.User)
-
-	// Build filters map
-	filterMap := make(map[string]interface{})
-
-	// Add municipality filter for non-admin users
-	if userEntity.Role.Name != "ADM" {
-		filterMap["municipality_id"] = userEntity.MunicipalityID
-	} else if filters.MunicipalityID != "" {
-		municipalityID, err := strconv.ParseUint(filters.MunicipalityID, 10, 32)
-		if err == nil {
-			filterMap["municipality_id"] = uint(municipalityID)
+	// Restrict to the user's scope, optionally narrowed to one municipality
+	scope := middlewares.MunicipalityScope(ctx)
+	if filters.MunicipalityID != "" {
+		municipalityID, err := strconv.Atoi(filters.MunicipalityID)
+		if err != nil {
+			ctx.Error(invalidID("municipality_id"))
+			return
+		}
+		if !scope.Contains(&municipalityID) {
+			ctx.Error(apperrors.ErrAccessDenied)
+			return
 		}
+		q.Filters.MunicipalityID = &municipalityID
+	} else if ids := scope.Filter(); ids != nil {
+		q.Filters.MunicipalityIDs = ids
 	}
 
 	if filters.Year != "" {
 		year, err := strconv.Atoi(filters.Year)
 		if err == nil {
-			filterMap["year"] = year
+			q.Filters.Year = year
 		}
 	}
 
 	if filters.Month != "" {
 		month, err := strconv.Atoi(filters.Month)
 		if err == nil {
-			filterMap["month"] = month
+			q.Filters.Month = month
 		}
 	}
 
 	if filters.Competence != "" {
-		filterMap["competence"] = filters.Competence
-	}
-
-	var payments []*entities.Payment
-	var err error
-
-	if filters.Page > 0 && filters.Limit > 0 {
-		payments, err = c.paymentService.GetPaymentsWithPagination(ctx.Request.Context(), filterMap, filters.Page, filters.Limit)
-	} else {
-		payments, err = c.paymentService.GetAllPayments(ctx.Request.Context(), filterMap)
+		q.Filters.Competence = filters.Competence
 	}
 
+	page, err := c.paymentService.GetAllPayments(ctx.Request.Context(), q)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.Error(err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, payments)
+	ctx.JSON(http.StatusOK, page)
 }
 
 func (c *PaymentController) GetPaymentByID(ctx *gin.Context) {
-	idStr := ctx.Param("id")
-	_, err := strconv.ParseUint(idStr, 10, 32)
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payment ID"})
+	if _, err := uuid.Parse(ctx.Param("id")); err != nil {
+		ctx.Error(invalidID("id"))
 		return
 	}
 
 	payment, err := c.paymentService.GetPaymentByID(ctx.Request.Context(), ctx.Param("id"))
 	if err != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"error": "Payment not found"})
+		ctx.Error(err)
 		return
 	}
 
-	// Check access permissions
-	user, exists := ctx.Get("user")
-	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
-		return
-	}
-
-	userEntity := user.(*entities.User)
-
-	// Admin can access all, others only their municipality
-	if userEntity.Role.Name != "ADM" && payment.MunicipalityID != userEntity.MunicipalityID {
-		ctx.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+	// Users only access municipalities within their scope
+	if !canAccessMunicipality(ctx, payment.MunicipalityID) {
+		ctx.Error(apperrors.ErrAccessDenied)
 		return
 	}
 
@@ -169,16 +158,14 @@ func (c *PaymentController) GetPaymentByID(ctx *gin.Context) {
 }
 
 func (c *PaymentController) UpdatePayment(ctx *gin.Context) {
-	idStr := ctx.Param("id")
-	_, err := strconv.ParseUint(idStr, 10, 32)
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payment ID"})
+	if _, err := uuid.Parse(ctx.Param("id")); err != nil {
+		ctx.Error(invalidID("id"))
 		return
 	}
 
 	var req CreatePaymentRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		ctx.Error(bindingError(err))
 		return
 	}
 
@@ -189,8 +176,20 @@ func (c *PaymentController) UpdatePayment(ctx *gin.Context) {
 		Competence: req.Competence,
 	}
 
+	existing, err := c.paymentService.GetPaymentByID(ctx.Request.Context(), ctx.Param("id"))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	// Users only modify municipalities within their scope
+	if !canAccessMunicipality(ctx, existing.MunicipalityID) {
+		ctx.Error(apperrors.ErrAccessDenied)
+		return
+	}
+
 	if err := c.paymentService.UpdatePayment(ctx.Request.Context(), payment); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.Error(err)
 		return
 	}
 
@@ -198,41 +197,35 @@ func (c *PaymentController) UpdatePayment(ctx *gin.Context) {
 }
 
 func (c *PaymentController) DeletePayment(ctx *gin.Context) {
-	idStr := ctx.Param("id")
-	_, err := strconv.ParseUint(idStr, 10, 32)
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payment ID"})
+	if _, err := uuid.Parse(ctx.Param("id")); err != nil {
+		ctx.Error(invalidID("id"))
 		return
 	}
 
-	if err := c.paymentService.DeletePayment(ctx.Request.Context(), ctx.Param("id")); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	existing, err := c.paymentService.GetPaymentByID(ctx.Request.Context(), ctx.Param("id"))
+	if err != nil {
+		ctx.Error(err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"message": "Payment deleted successfully"})
-}
-
-func (c *PaymentController) GetCompetences(ctx *gin.Context) {
-	user, exists := ctx.Get("user")
-	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+	// Users only modify municipalities within their scope
+	if !canAccessMunicipality(ctx, existing.MunicipalityID) {
+		ctx.Error(apperrors.ErrAccessDenied)
 		return
 	}
 
-	userEntity := user.(*entities.User)
-
-	var municipalityID *uint
-	if userEntity.Role.Name != "ADM" {
-		if userEntity.MunicipalityID != nil {
-			id := uint(*userEntity.MunicipalityID)
-			municipalityID = &id
-		}
+	if err := c.paymentService.DeletePayment(ctx.Request.Context(), ctx.Param("id")); err != nil {
+		ctx.Error(err)
+		return
 	}
 
-	competences, err := c.paymentService.GetCompetences(ctx.Request.Context(), municipalityID)
+	ctx.JSON(http.StatusOK, gin.H{"message": message(ctx, "payment_deleted")})
+}
+
+func (c *PaymentController) GetCompetences(ctx *gin.Context) {
+	competences, err := c.paymentService.GetCompetences(ctx.Request.Context(), middlewares.MunicipalityScope(ctx).Filter())
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.Error(err)
 		return
 	}
 
@@ -240,25 +233,9 @@ func (c *PaymentController) GetCompetences(ctx *gin.Context) {
 }
 
 func (c *PaymentController) GetYears(ctx *gin.Context) {
-	user, exists := ctx.Get("user")
-	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
-		return
-	}
-
-	userEntity := user.(*entities.User)
-
-	var municipalityID *uint
-	if userEntity.Role.Name != "ADM" {
-		if userEntity.MunicipalityID != nil {
-			id := uint(*userEntity.MunicipalityID)
-			municipalityID = &id
-		}
-	}
-
-	years, err := c.paymentService.GetYears(ctx.Request.Context(), municipalityID)
+	years, err := c.paymentService.GetYears(ctx.Request.Context(), middlewares.MunicipalityScope(ctx).Filter())
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.Error(err)
 		return
 	}
 
@@ -266,31 +243,20 @@ func (c *PaymentController) GetYears(ctx *gin.Context) {
 }
 
 func (c *PaymentController) ViewPDF(ctx *gin.Context) {
-	idStr := ctx.Param("id")
-	_, err := strconv.ParseUint(idStr, 10, 32)
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payment ID"})
+	if _, err := uuid.Parse(ctx.Param("id")); err != nil {
+		ctx.Error(invalidID("id"))
 		return
 	}
 
 	payment, err := c.paymentService.GetPaymentByID(ctx.Request.Context(), ctx.Param("id"))
 	if err != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"error": "Payment not found"})
+		ctx.Error(err)
 		return
 	}
 
-	// Check access permissions
-	user, exists := ctx.Get("user")
-	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
-		return
-	}
-
-	userEntity := user.(*entities.User)
-
-	// Admin can access all, others only their municipality
-	if userEntity.Role.Name != "ADM" && payment.MunicipalityID != userEntity.MunicipalityID {
-		ctx.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+	// Users only access municipalities within their scope
+	if !canAccessMunicipality(ctx, payment.MunicipalityID) {
+		ctx.Error(apperrors.ErrAccessDenied)
 		return
 	}
 
@@ -302,31 +268,20 @@ func (c *PaymentController) ViewPDF(ctx *gin.Context) {
 }
 
 func (c *PaymentController) DownloadPDF(ctx *gin.Context) {
-	idStr := ctx.Param("id")
-	_, err := strconv.ParseUint(idStr, 10, 32)
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payment ID"})
+	if _, err := uuid.Parse(ctx.Param("id")); err != nil {
+		ctx.Error(invalidID("id"))
 		return
 	}
 
 	payment, err := c.paymentService.GetPaymentByID(ctx.Request.Context(), ctx.Param("id"))
 	if err != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"error": "Payment not found"})
+		ctx.Error(err)
 		return
 	}
 
-	// Check access permissions
-	user, exists := ctx.Get("user")
-	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
-		return
-	}
-
-	userEntity := user.(*entities.User)
-
-	// Admin can access all, others only their municipality
-	if userEntity.Role.Name != "ADM" && payment.MunicipalityID != userEntity.MunicipalityID {
-		ctx.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+	// Users only access municipalities within their scope
+	if !canAccessMunicipality(ctx, payment.MunicipalityID) {
+		ctx.Error(apperrors.ErrAccessDenied)
 		return
 	}
 
@@ -337,80 +292,12 @@ func (c *PaymentController) DownloadPDF(ctx *gin.Context) {
 	})
 }
 
-// GetPaymentsPublic - Public version for testing (no auth required)
-func (c *PaymentController) GetPaymentsPublic(ctx *gin.Context) {
-	var filters PaymentFilters
-	if err := ctx.ShouldBindQuery(&filters); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	// Build filters map (without user restrictions for testing)
-	filterMap := make(map[string]interface{})
-
-	if filters.Year != "" {
-		year, err := strconv.Atoi(filters.Year)
-		if err == nil {
-			filterMap["year"] = year
-		}
-	}
-
-	if filters.Month != "" {
-		month, err := strconv.Atoi(filters.Month)
-		if err == nil {
-			filterMap["month"] = month
-		}
-	}
-
-	if filters.Competence != "" {
-		filterMap["competence"] = filters.Competence
-	}
-
-	var payments []*entities.Payment
-	var err error
-
-	if filters.Page > 0 && filters.Limit > 0 {
-		payments, err = c.paymentService.GetPaymentsWithPagination(ctx.Request.Context(), filterMap, filters.Page, filters.Limit)
-	} else {
-		payments, err = c.paymentService.GetAllPayments(ctx.Request.Context(), filterMap)
-	}
-
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get payments: " + err.Error()})
-		return
-	}
-
-	ctx.JSON(http.StatusOK, payments)
-}
-
-// GetCompetencesPublic - Public version for testing
-func (c *PaymentController) GetCompetencesPublic(ctx *gin.Context) {
-	competences, err := c.paymentService.GetCompetences(ctx.Request.Context(), nil)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get competences: " + err.Error()})
-		return
-	}
-
-	ctx.JSON(http.StatusOK, competences)
-}
-
-// GetYearsPublic - Public version for testing
-func (c *PaymentController) GetYearsPublic(ctx *gin.Context) {
-	years, err := c.paymentService.GetYears(ctx.Request.Context(), nil)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get years: " + err.Error()})
-		return
-	}
-
-	ctx.JSON(http.StatusOK, years)
-}
-
 // UploadPaymentFile handles PDF file upload for payments
 func (c *PaymentController) UploadPaymentFile(ctx *gin.Context) {
 	// Get the multipart form file
 	file, header, err := ctx.Request.FormFile("file")
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "No file provided"})
+		ctx.Error(apperrors.Required("file"))
 		return
 	}
 	defer file.Close()
@@ -420,70 +307,70 @@ func (c *PaymentController) UploadPaymentFile(ctx *gin.Context) {
 	municipalityIDStr := ctx.PostForm("municipality_id")
 
 	if competence == "" {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Competence is required"})
+		ctx.Error(apperrors.Required("competence"))
 		return
 	}
 
 	if municipalityIDStr == "" {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Municipality ID is required"})
+		ctx.Error(apperrors.Required("municipality_id"))
 		return
 	}
 
 	municipalityID, err := strconv.ParseUint(municipalityIDStr, 10, 32)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid municipality ID"})
+		ctx.Error(invalidID("municipality_id"))
 		return
 	}
 
-	// Validate file type
-	if !strings.HasSuffix(strings.ToLower(header.Filename), ".pdf") {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Only PDF files are allowed"})
+	// Get user from context (set by auth middleware)
+	user, exists := ctx.Get("user")
+	if !exists {
+		ctx.Error(apperrors.ErrUnauthenticated)
+		return
+	}
+
+	userEntity := user.(*entities.User)
+
+	uploadMunicipalityID := int(municipalityID)
+	if !canAccessMunicipality(ctx, &uploadMunicipalityID) {
+		ctx.Error(apperrors.ErrAccessDenied)
 		return
 	}
 
-	// Create uploads directory if it doesn't exist
-	uploadsDir := "uploads/payments"
-	if err := os.MkdirAll(uploadsDir, os.ModePerm); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create uploads directory"})
+	// Validate file type
+	if !strings.HasSuffix(strings.ToLower(header.Filename), ".pdf") {
+		ctx.Error(errPDFOnly)
 		return
 	}
 
 	// Generate unique filename
 	timestamp := time.Now().Format("20060102_150405")
-	filename := fmt.Sprintf("payment_%s_%s_%s", competence, timestamp, header.Filename)
-	filePath := filepath.Join(uploadsDir, filename)
+	filename := fmt.Sprintf("payment_%s_%s_%s", competence, timestamp, filepath.Base(header.Filename))
 
-	// Create the file on disk
-	dst, err := os.Create(filePath)
+	fileURL, err := c.files.Save("payments", filename, file)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create file"})
-		return
-	}
-	defer dst.Close()
-
-	// Copy the uploaded file to destination
-	if _, err := io.Copy(dst, file); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save file"})
+		ctx.Error(fmt.Errorf("failed to save file: %w", err))
 		return
 	}
+	metrics.ObserveUpload(metrics.UploadPayment, header.Size)
 
 	// Create payment record in database
 	payment := &entities.Payment{
-		FileURL:        "/" + filePath, // Store relative path
+		FileURL:        fileURL,
 		Competence:     competence,
-		MunicipalityID: func() *int { i := int(municipalityID); return &i }(),
-		// Note: UploadedBy will need authentication middleware to set user
+		MunicipalityID: &uploadMunicipalityID,
+		UploadedBy:     userEntity.ID,
 	}
 
 	if err := c.paymentService.CreatePayment(ctx.Request.Context(), payment); err != nil {
 		// If database save fails, clean up the file
-		os.Remove(filePath)
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save payment record"})
+		c.files.Remove(fileURL)
+		ctx.Error(err)
 		return
 	}
 
 	ctx.JSON(http.StatusCreated, gin.H{
-		"message":  "Payment file uploaded successfully",
+		"message":  message(ctx, "payment_uploaded"),
 		"payment":  payment,
 		"filename": filename,
 	})
@@ -26,7 +26,7 @@ type CreateProfessionRequest struct {
 func (c *ProfessionController) CreateProfession(ctx *gin.Context) {
 	var req CreateProfessionRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		ctx.Error(bindingError(err))
 		return
 	}
 
@@ -35,7 +35,7 @@ func (c *ProfessionController) CreateProfession(ctx *gin.Context) {
 	}
 
 	if err := c.professionService.CreateProfession(ctx.Request.Context(), profession); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.Error(err)
 		return
 	}
 
@@ -43,26 +43,36 @@ func (c *ProfessionController) CreateProfession(ctx *gin.Context) {
 }
 
 func (c *ProfessionController) GetProfessions(ctx *gin.Context) {
-	professions, err := c.professionService.GetAllProfessions(ctx.Request.Context())
+	q, err := bindListQuery[entities.ProfessionFilter](ctx, "name", "created_at")
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.Error(err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, professions)
+	if search := ctx.Query("q"); search != "" {
+		q.Filters.Search = search
+	}
+
+	page, err := c.professionService.GetAllProfessions(ctx.Request.Context(), q)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, page)
 }
 
 func (c *ProfessionController) GetProfessionByID(ctx *gin.Context) {
 	idStr := ctx.Param("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid profession ID"})
+		ctx.Error(invalidID("id"))
 		return
 	}
 
 	profession, err := c.professionService.GetProfessionByID(ctx.Request.Context(), id)
 	if err != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"error": "Profession not found"})
+		ctx.Error(err)
 		return
 	}
 
@@ -73,13 +83,13 @@ func (c *ProfessionController) UpdateProfession(ctx *gin.Context) {
 	idStr := ctx.Param("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid profession ID"})
+		ctx.Error(invalidID("id"))
 		return
 	}
 
 	var req CreateProfessionRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		ctx.Error(bindingError(err))
 		return
 	}
 
@@ -89,7 +99,7 @@ func (c *ProfessionController) UpdateProfession(ctx *gin.Context) {
 	}
 
 	if err := c.professionService.UpdateProfession(ctx.Request.Context(), profession); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.Error(err)
 		return
 	}
 
@@ -100,14 +110,14 @@ func (c *ProfessionController) DeleteProfession(ctx *gin.Context) {
 	idStr := ctx.Param("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid profession ID"})
+		ctx.Error(invalidID("id"))
 		return
 	}
 
 	if err := c.professionService.DeleteProfession(ctx.Request.Context(), id); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.Error(err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"message": "Profession deleted successfully"})
+	ctx.JSON(http.StatusOK, gin.H{"message": message(ctx, "profession_deleted")})
 }
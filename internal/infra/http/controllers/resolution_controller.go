@@ -12,8 +12,11 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/joaopanucci/apsdigital/internal/domain/apperrors"
 	"github.com/joaopanucci/apsdigital/internal/domain/entities"
 	"github.com/joaopanucci/apsdigital/internal/domain/services"
+	"github.com/joaopanucci/apsdigital/internal/infra/http/middlewares"
+	"github.com/joaopanucci/apsdigital/internal/infra/metrics"
 )
 
 type ResolutionController struct {
@@ -42,21 +45,19 @@ type ResolutionFilters struct {
 	Number         string `form:"number"`
 	Competence     string `form:"competence"`
 	MunicipalityID string `form:"municipality_id"`
-	Page           int    `form:"page"`
-	Limit          int    `form:"limit"`
 }
 
 func (c *ResolutionController) CreateResolution(ctx *gin.Context) {
 	var req CreateResolutionRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		ctx.Error(bindingError(err))
 		return
 	}
 
 	// Get user from context (set by auth middleware)
 	user, exists := ctx.Get("user")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		ctx.Error(apperrors.ErrUnauthenticated)
 		return
 	}
 
@@ -73,8 +74,13 @@ func (c *ResolutionController) CreateResolution(ctx *gin.Context) {
 		MunicipalityID: func() *int { i := int(req.MunicipalityID); return &i }(),
 	}
 
+	if !canAccessMunicipality(ctx, resolution.MunicipalityID) {
+		ctx.Error(apperrors.ErrAccessDenied)
+		return
+	}
+
 	if err := c.resolutionService.CreateResolution(ctx.Request.Context(), resolution); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.Error(err)
 		return
 	}
 
@@ -84,94 +90,76 @@ func (c *ResolutionController) CreateResolution(ctx *gin.Context) {
 func (c *ResolutionController) GetResolutions(ctx *gin.Context) {
 	var filters ResolutionFilters
 	if err := ctx.ShouldBindQuery(&filters); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		ctx.Error(bindingError(err))
 		return
 	}
 
-	// Get user from context
-	user, exists := ctx.Get("user")
-	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+	q, err := bindListQuery[entities.ResolutionFilter](ctx, "created_at", "year", "number", "title", "type", "municipality")
+	if err != nil {
+		ctx.Error(err)
 		return
 	}
 
-	userEntity := user.(*entities.User)
-
-	// Build filters map
-	filterMap := make(map[string]interface{})
-
-	// Add municipality filter for non-admin users
-	if userEntity.Role.Name != "ADM" {
-		filterMap["municipality_id"] = userEntity.MunicipalityID
-	} else if filters.MunicipalityID != "" {
-		municipalityID, err := strconv.ParseUint(filters.MunicipalityID, 10, 32)
-		if err == nil {
-			filterMap["municipality_id"] = uint(municipalityID)
+	// Restrict to the user's scope, optionally narrowed to one municipality
+	scope := middlewares.MunicipalityScope(ctx)
+	if filters.MunicipalityID != "" {
+		municipalityID, err := strconv.Atoi(filters.MunicipalityID)
+		if err != nil {
+			ctx.Error(invalidID("municipality_id"))
+			return
+		}
+		if !scope.Contains(&municipalityID) {
+			ctx.Error(apperrors.ErrAccessDenied)
+			return
 		}
+		q.Filters.MunicipalityID = &municipalityID
+	} else if ids := scope.Filter(); ids != nil {
+		q.Filters.MunicipalityIDs = ids
 	}
 
 	if filters.Year != "" {
 		year, err := strconv.Atoi(filters.Year)
 		if err == nil {
-			filterMap["year"] = year
+			q.Filters.Year = year
 		}
 	}
 
 	if filters.Type != "" {
-		filterMap["type"] = filters.Type
+		q.Filters.Type = entities.ResolutionType(filters.Type)
 	}
 
 	if filters.Number != "" {
-		filterMap["number"] = filters.Number
+		q.Filters.Number = filters.Number
 	}
 
 	if filters.Competence != "" {
-		filterMap["competence"] = filters.Competence
-	}
-
-	var resolutions []*entities.Resolution
-	var err error
-
-	if filters.Page > 0 && filters.Limit > 0 {
-		resolutions, err = c.resolutionService.GetResolutionsWithPagination(ctx.Request.Context(), filterMap, filters.Page, filters.Limit)
-	} else {
-		resolutions, err = c.resolutionService.GetAllResolutions(ctx.Request.Context(), filterMap)
+		q.Filters.Competence = filters.Competence
 	}
 
+	page, err := c.resolutionService.GetAllResolutions(ctx.Request.Context(), q)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.Error(err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, resolutions)
+	ctx.JSON(http.StatusOK, page)
 }
 
 func (c *ResolutionController) GetResolutionByID(ctx *gin.Context) {
-	idStr := ctx.Param("id")
-	_, err := strconv.ParseUint(idStr, 10, 32)
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid resolution ID"})
+	if _, err := uuid.Parse(ctx.Param("id")); err != nil {
+		ctx.Error(invalidID("id"))
 		return
 	}
 
 	resolution, err := c.resolutionService.GetResolutionByID(ctx.Request.Context(), ctx.Param("id"))
 	if err != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"error": "Resolution not found"})
-		return
-	}
-
-	// Check access permissions
-	user, exists := ctx.Get("user")
-	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		ctx.Error(err)
 		return
 	}
 
-	userEntity := user.(*entities.User)
-
-	// Admin can access all, others only their municipality
-	if userEntity.Role.Name != "ADM" && resolution.MunicipalityID != userEntity.MunicipalityID {
-		ctx.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+	// Users only access municipalities within their scope
+	if !canAccessMunicipality(ctx, resolution.MunicipalityID) {
+		ctx.Error(apperrors.ErrAccessDenied)
 		return
 	}
 
@@ -179,16 +167,14 @@ func (c *ResolutionController) GetResolutionByID(ctx *gin.Context) {
 }
 
 func (c *ResolutionController) UpdateResolution(ctx *gin.Context) {
-	idStr := ctx.Param("id")
-	_, err := strconv.ParseUint(idStr, 10, 32)
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid resolution ID"})
+	if _, err := uuid.Parse(ctx.Param("id")); err != nil {
+		ctx.Error(invalidID("id"))
 		return
 	}
 
 	var req CreateResolutionRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		ctx.Error(bindingError(err))
 		return
 	}
 
@@ -203,8 +189,20 @@ func (c *ResolutionController) UpdateResolution(ctx *gin.Context) {
 		Number:     req.Number,
 	}
 
+	existing, err := c.resolutionService.GetResolutionByID(ctx.Request.Context(), ctx.Param("id"))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	// Users only modify municipalities within their scope
+	if !canAccessMunicipality(ctx, existing.MunicipalityID) {
+		ctx.Error(apperrors.ErrAccessDenied)
+		return
+	}
+
 	if err := c.resolutionService.UpdateResolution(ctx.Request.Context(), resolution); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.Error(err)
 		return
 	}
 
@@ -212,41 +210,35 @@ func (c *ResolutionController) UpdateResolution(ctx *gin.Context) {
 }
 
 func (c *ResolutionController) DeleteResolution(ctx *gin.Context) {
-	idStr := ctx.Param("id")
-	_, err := strconv.ParseUint(idStr, 10, 32)
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid resolution ID"})
+	if _, err := uuid.Parse(ctx.Param("id")); err != nil {
+		ctx.Error(invalidID("id"))
 		return
 	}
 
-	if err := c.resolutionService.DeleteResolution(ctx.Request.Context(), ctx.Param("id")); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	existing, err := c.resolutionService.GetResolutionByID(ctx.Request.Context(), ctx.Param("id"))
+	if err != nil {
+		ctx.Error(err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"message": "Resolution deleted successfully"})
-}
-
-func (c *ResolutionController) GetTypes(ctx *gin.Context) {
-	user, exists := ctx.Get("user")
-	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+	// Users only modify municipalities within their scope
+	if !canAccessMunicipality(ctx, existing.MunicipalityID) {
+		ctx.Error(apperrors.ErrAccessDenied)
 		return
 	}
 
-	userEntity := user.(*entities.User)
-
-	var municipalityID *uint
-	if userEntity.Role.Name != "ADM" {
-		if userEntity.MunicipalityID != nil {
-			id := uint(*userEntity.MunicipalityID)
-			municipalityID = &id
-		}
+	if err := c.resolutionService.DeleteResolution(ctx.Request.Context(), ctx.Param("id")); err != nil {
+		ctx.Error(err)
+		return
 	}
 
-	types, err := c.resolutionService.GetTypes(ctx.Request.Context(), municipalityID)
+	ctx.JSON(http.StatusOK, gin.H{"message": message(ctx, "resolution_deleted")})
+}
+
+func (c *ResolutionController) GetTypes(ctx *gin.Context) {
+	types, err := c.resolutionService.GetTypes(ctx.Request.Context(), middlewares.MunicipalityScope(ctx).Filter())
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.Error(err)
 		return
 	}
 
@@ -254,25 +246,9 @@ func (c *ResolutionController) GetTypes(ctx *gin.Context) {
 }
 
 func (c *ResolutionController) GetYears(ctx *gin.Context) {
-	user, exists := ctx.Get("user")
-	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
-		return
-	}
-
-	userEntity := user.(*entities.User)
-
-	var municipalityID *uint
-	if userEntity.Role.Name != "ADM" {
-		if userEntity.MunicipalityID != nil {
-			id := uint(*userEntity.MunicipalityID)
-			municipalityID = &id
-		}
-	}
-
-	years, err := c.resolutionService.GetYears(ctx.Request.Context(), municipalityID)
+	years, err := c.resolutionService.GetYears(ctx.Request.Context(), middlewares.MunicipalityScope(ctx).Filter())
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.Error(err)
 		return
 	}
 
@@ -286,25 +262,9 @@ func (c *ResolutionController) GetRecentResolutions(ctx *gin.Context) {
 		limit = 10
 	}
 
-	user, exists := ctx.Get("user")
-	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
-		return
-	}
-
-	userEntity := user.(*entities.User)
-
-	var municipalityID *uint
-	if userEntity.Role.Name != "ADM" {
-		if userEntity.MunicipalityID != nil {
-			id := uint(*userEntity.MunicipalityID)
-			municipalityID = &id
-		}
-	}
-
-	resolutions, err := c.resolutionService.GetRecentResolutions(ctx.Request.Context(), municipalityID, limit)
+	resolutions, err := c.resolutionService.GetRecentResolutions(ctx.Request.Context(), middlewares.MunicipalityScope(ctx).Filter(), limit)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.Error(err)
 		return
 	}
 
@@ -312,31 +272,20 @@ func (c *ResolutionController) GetRecentResolutions(ctx *gin.Context) {
 }
 
 func (c *ResolutionController) ViewPDF(ctx *gin.Context) {
-	idStr := ctx.Param("id")
-	_, err := strconv.ParseUint(idStr, 10, 32)
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid resolution ID"})
+	if _, err := uuid.Parse(ctx.Param("id")); err != nil {
+		ctx.Error(invalidID("id"))
 		return
 	}
 
 	resolution, err := c.resolutionService.GetResolutionByID(ctx.Request.Context(), ctx.Param("id"))
 	if err != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"error": "Resolution not found"})
+		ctx.Error(err)
 		return
 	}
 
-	// Check access permissions
-	user, exists := ctx.Get("user")
-	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
-		return
-	}
-
-	userEntity := user.(*entities.User)
-
-	// Admin can access all, others only their municipality
-	if userEntity.Role.Name != "ADM" && resolution.MunicipalityID != userEntity.MunicipalityID {
-		ctx.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+	// Users only access municipalities within their scope
+	if !canAccessMunicipality(ctx, resolution.MunicipalityID) {
+		ctx.Error(apperrors.ErrAccessDenied)
 		return
 	}
 
@@ -348,31 +297,20 @@ func (c *ResolutionController) ViewPDF(ctx *gin.Context) {
 }
 
 func (c *ResolutionController) DownloadPDF(ctx *gin.Context) {
-	idStr := ctx.Param("id")
-	_, err := strconv.ParseUint(idStr, 10, 32)
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid resolution ID"})
+	if _, err := uuid.Parse(ctx.Param("id")); err != nil {
+		ctx.Error(invalidID("id"))
 		return
 	}
 
 	resolution, err := c.resolutionService.GetResolutionByID(ctx.Request.Context(), ctx.Param("id"))
 	if err != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"error": "Resolution not found"})
+		ctx.Error(err)
 		return
 	}
 
-	// Check access permissions
-	user, exists := ctx.Get("user")
-	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
-		return
-	}
-
-	userEntity := user.(*entities.User)
-
-	// Admin can access all, others only their municipality
-	if userEntity.Role.Name != "ADM" && resolution.MunicipalityID != userEntity.MunicipalityID {
-		ctx.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+	// Users only access municipalities within their scope
+	if !canAccessMunicipality(ctx, resolution.MunicipalityID) {
+		ctx.Error(apperrors.ErrAccessDenied)
 		return
 	}
 
@@ -383,77 +321,12 @@ func (c *ResolutionController) DownloadPDF(ctx *gin.Context) {
 	})
 }
 
-// GetResolutionsPublic - Public version for testing (no auth required)
-func (c *ResolutionController) GetResolutionsPublic(ctx *gin.Context) {
-	var filters ResolutionFilters
-	if err := ctx.ShouldBindQuery(&filters); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	// Build filters map (without user restrictions for testing)
-	filterMap := make(map[string]interface{})
-
-	if filters.Year != "" {
-		year, err := strconv.Atoi(filters.Year)
-		if err == nil {
-			filterMap["year"] = year
-		}
-	}
-
-	if filters.Type != "" {
-		filterMap["type"] = filters.Type
-	}
-
-	if filters.Competence != "" {
-		filterMap["competence"] = filters.Competence
-	}
-
-	var resolutions []*entities.Resolution
-	var err error
-
-	if filters.Page > 0 && filters.Limit > 0 {
-		resolutions, err = c.resolutionService.GetResolutionsWithPagination(ctx.Request.Context(), filterMap, filters.Page, filters.Limit)
-	} else {
-		resolutions, err = c.resolutionService.GetAllResolutions(ctx.Request.Context(), filterMap)
-	}
-
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get resolutions: " + err.Error()})
-		return
-	}
-
-	ctx.JSON(http.StatusOK, resolutions)
-}
-
-// GetTypesPublic - Public version for testing
-func (c *ResolutionController) GetTypesPublic(ctx *gin.Context) {
-	types, err := c.resolutionService.GetTypes(ctx.Request.Context(), nil)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get types: " + err.Error()})
-		return
-	}
-
-	ctx.JSON(http.StatusOK, types)
-}
-
-// GetYearsPublic - Public version for testing
-func (c *ResolutionController) GetYearsPublic(ctx *gin.Context) {
-	years, err := c.resolutionService.GetYears(ctx.Request.Context(), nil)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get years: " + err.Error()})
-		return
-	}
-
-	ctx.JSON(http.StatusOK, years)
-}
-
 // UploadResolutionFile handles PDF file upload for resolutions
 func (c *ResolutionController) UploadResolutionFile(ctx *gin.Context) {
 	// Get the multipart form file
 	file, header, err := ctx.Request.FormFile("file")
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "No file provided"})
+		ctx.Error(apperrors.Required("file"))
 		return
 	}
 	defer file.Close()
@@ -466,47 +339,62 @@ func (c *ResolutionController) UploadResolutionFile(ctx *gin.Context) {
 	municipalityIDStr := ctx.PostForm("municipality_id")
 
 	if title == "" {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Title is required"})
+		ctx.Error(apperrors.Required("title"))
 		return
 	}
 
 	if resolutionType == "" {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Type is required"})
+		ctx.Error(apperrors.Required("type"))
 		return
 	}
 
 	if yearStr == "" {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Year is required"})
+		ctx.Error(apperrors.Required("year"))
 		return
 	}
 
 	if municipalityIDStr == "" {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Municipality ID is required"})
+		ctx.Error(apperrors.Required("municipality_id"))
 		return
 	}
 
 	year, err := strconv.Atoi(yearStr)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid year"})
+		ctx.Error(apperrors.Invalid("year", "year_invalid"))
 		return
 	}
 
 	municipalityID, err := strconv.ParseUint(municipalityIDStr, 10, 32)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid municipality ID"})
+		ctx.Error(invalidID("municipality_id"))
+		return
+	}
+
+	// Get user from context (set by auth middleware)
+	user, exists := ctx.Get("user")
+	if !exists {
+		ctx.Error(apperrors.ErrUnauthenticated)
+		return
+	}
+
+	userEntity := user.(*entities.User)
+
+	uploadMunicipalityID := int(municipalityID)
+	if !canAccessMunicipality(ctx, &uploadMunicipalityID) {
+		ctx.Error(apperrors.ErrAccessDenied)
 		return
 	}
 
 	// Validate file type
 	if !strings.HasSuffix(strings.ToLower(header.Filename), ".pdf") {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Only PDF files are allowed"})
+		ctx.Error(errPDFOnly)
 		return
 	}
 
 	// Create uploads directory if it doesn't exist
 	uploadsDir := "uploads/resolutions"
 	if err := os.MkdirAll(uploadsDir, os.ModePerm); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create uploads directory"})
+		ctx.Error(fmt.Errorf("failed to create uploads directory: %w", err))
 		return
 	}
 
@@ -518,16 +406,17 @@ func (c *ResolutionController) UploadResolutionFile(ctx *gin.Context) {
 	// Create the file on disk
 	dst, err := os.Create(filePath)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create file"})
+		ctx.Error(fmt.Errorf("failed to create file: %w", err))
 		return
 	}
 	defer dst.Close()
 
 	// Copy the uploaded file to destination
 	if _, err := io.Copy(dst, file); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save file"})
+		ctx.Error(fmt.Errorf("failed to save file: %w", err))
 		return
 	}
+	metrics.ObserveUpload(metrics.UploadResolution, header.Size)
 
 	// Create resolution record in database
 	resolution := &entities.Resolution{
@@ -536,19 +425,19 @@ func (c *ResolutionController) UploadResolutionFile(ctx *gin.Context) {
 		Type:           entities.ResolutionType(resolutionType),
 		Year:           year,
 		Number:         number,
-		MunicipalityID: func() *int { i := int(municipalityID); return &i }(),
-		// Note: UploadedBy will need authentication middleware to set user
+		MunicipalityID: &uploadMunicipalityID,
+		UploadedBy:     userEntity.ID,
 	}
 
 	if err := c.resolutionService.CreateResolution(ctx.Request.Context(), resolution); err != nil {
 		// If database save fails, clean up the file
 		os.Remove(filePath)
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save resolution record"})
+		ctx.Error(err)
 		return
 	}
 
 	ctx.JSON(http.StatusCreated, gin.H{
-		"message":    "Resolution file uploaded successfully",
+		"message":    message(ctx, "resolution_uploaded"),
 		"resolution": resolution,
 		"filename":   filename,
 	})
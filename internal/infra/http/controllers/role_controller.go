@@ -0,0 +1,80 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/joaopanucci/apsdigital/internal/domain/services"
+)
+
+type RoleController struct {
+	permissionService *services.PermissionService
+}
+
+func NewRoleController(permissionService *services.PermissionService) *RoleController {
+	return &RoleController{
+		permissionService: permissionService,
+	}
+}
+
+type SetRolePermissionsRequest struct {
+	Permissions []string `json:"permissions" binding:"required"`
+}
+
+func (c *RoleController) GetRoles(ctx *gin.Context) {
+	roles, err := c.permissionService.ListRoles(ctx.Request.Context())
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, roles)
+}
+
+func (c *RoleController) GetPermissions(ctx *gin.Context) {
+	permissions, err := c.permissionService.ListPermissions(ctx.Request.Context())
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, permissions)
+}
+
+func (c *RoleController) GetRolePermissions(ctx *gin.Context) {
+	roleID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.Error(invalidID("id"))
+		return
+	}
+
+	permissions, err := c.permissionService.GetPermissionsByRoleID(ctx.Request.Context(), roleID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"permissions": permissions})
+}
+
+func (c *RoleController) SetRolePermissions(ctx *gin.Context) {
+	roleID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.Error(invalidID("id"))
+		return
+	}
+
+	var req SetRolePermissionsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(bindingError(err))
+		return
+	}
+
+	if err := c.permissionService.SetRolePermissions(ctx.Request.Context(), roleID, req.Permissions); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": message(ctx, "role_permissions_updated")})
+}
@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joaopanucci/apsdigital/internal/domain/apperrors"
+	"github.com/joaopanucci/apsdigital/internal/domain/entities"
+	"github.com/joaopanucci/apsdigital/internal/domain/services"
+	"github.com/joaopanucci/apsdigital/internal/infra/http/middlewares"
+)
+
+type StatsController struct {
+	tabletStatsService *services.TabletStatsService
+}
+
+func NewStatsController(tabletStatsService *services.TabletStatsService) *StatsController {
+	return &StatsController{
+		tabletStatsService: tabletStatsService,
+	}
+}
+
+type TabletStatsRequest struct {
+	MunicipalityID string `form:"municipality_id"`
+	HealthRegionID string `form:"health_region_id"`
+	From           string `form:"from"` // YYYY-MM-DD
+	To             string `form:"to"`   // YYYY-MM-DD, inclusive
+}
+
+func (c *StatsController) GetTabletStats(ctx *gin.Context) {
+	var req TabletStatsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.Error(bindingError(err))
+		return
+	}
+
+	var filter entities.TabletStatsFilter
+	scope := middlewares.MunicipalityScope(ctx)
+	if req.MunicipalityID != "" {
+		municipalityID, err := strconv.Atoi(req.MunicipalityID)
+		if err != nil {
+			ctx.Error(invalidID("municipality_id"))
+			return
+		}
+		if !scope.Contains(&municipalityID) {
+			ctx.Error(apperrors.ErrAccessDenied)
+			return
+		}
+		filter.MunicipalityID = &municipalityID
+	} else {
+		// A region only narrows the municipalities the user can already see
+		filter.MunicipalityIDs = scope.Filter()
+	}
+
+	if req.HealthRegionID != "" {
+		regionID, err := strconv.Atoi(req.HealthRegionID)
+		if err != nil {
+			ctx.Error(invalidID("health_region_id"))
+			return
+		}
+		filter.HealthRegionID = &regionID
+	}
+
+	if req.From != "" {
+		from, err := time.ParseInLocation("2006-01-02", req.From, time.Local)
+		if err != nil {
+			ctx.Error(apperrors.Invalid("from", "date_invalid"))
+			return
+		}
+		filter.From = &from
+	}
+
+	if req.To != "" {
+		to, err := time.ParseInLocation("2006-01-02", req.To, time.Local)
+		if err != nil {
+			ctx.Error(apperrors.Invalid("to", "date_invalid"))
+			return
+		}
+		to = to.AddDate(0, 0, 1)
+		filter.To = &to
+	}
+
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		ctx.Error(apperrors.Invalid("from", "date_range_invalid"))
+		return
+	}
+
+	stats, err := c.tabletStatsService.GetStats(ctx.Request.Context(), filter)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, stats)
+}
@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joaopanucci/apsdigital/internal/domain/apperrors"
+	"github.com/joaopanucci/apsdigital/internal/domain/entities"
+	"github.com/joaopanucci/apsdigital/internal/domain/services"
+	"github.com/joaopanucci/apsdigital/internal/infra/http/middlewares"
+)
+
+type TabletAllocationController struct {
+	allocationService *services.TabletAllocationService
+}
+
+func NewTabletAllocationController(allocationService *services.TabletAllocationService) *TabletAllocationController {
+	return &TabletAllocationController{
+		allocationService: allocationService,
+	}
+}
+
+type AcceptAllocationPlanRequest struct {
+	Assignments []entities.AllocationDecision `json:"assignments" binding:"required,dive"`
+}
+
+// GetAllocationPlan proposes how to distribute the available tablets among the pending
+// new-tablet requests of the user's scope
+func (c *TabletAllocationController) GetAllocationPlan(ctx *gin.Context) {
+	plan, err := c.allocationService.BuildPlan(ctx.Request.Context(), middlewares.MunicipalityScope(ctx))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, plan)
+}
+
+// AcceptAllocationPlan applies the assignments of a plan, all or none
+func (c *TabletAllocationController) AcceptAllocationPlan(ctx *gin.Context) {
+	var req AcceptAllocationPlanRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(bindingError(err))
+		return
+	}
+
+	user, exists := ctx.Get("user")
+	if !exists {
+		ctx.Error(apperrors.ErrUnauthenticated)
+		return
+	}
+
+	userEntity := user.(*entities.User)
+
+	if err := c.allocationService.AcceptPlan(ctx.Request.Context(), req.Assignments, middlewares.MunicipalityScope(ctx), userEntity.ID); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": message(ctx, "allocation_applied"), "assignments": len(req.Assignments)})
+}
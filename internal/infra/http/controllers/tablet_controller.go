@@ -1,45 +1,409 @@
 package controllers
 
 import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
+	"net/url"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/joaopanucci/apsdigital/internal/domain/apperrors"
 	"github.com/joaopanucci/apsdigital/internal/domain/entities"
 	"github.com/joaopanucci/apsdigital/internal/domain/services"
+	"github.com/joaopanucci/apsdigital/internal/infra/export"
+	"github.com/joaopanucci/apsdigital/internal/infra/http/middlewares"
+	"github.com/joaopanucci/apsdigital/internal/infra/images"
+	"github.com/joaopanucci/apsdigital/internal/infra/labels"
+	"github.com/joaopanucci/apsdigital/internal/infra/metrics"
+	"github.com/joaopanucci/apsdigital/internal/infra/storage"
+
+	"github.com/google/uuid"
 )
 
 type TabletController struct {
 	tabletService *services.TabletService
+	signer        *labels.AssetURLSigner
+	files         *storage.Local
+	// Frontend page scanned labels redirect to
+	scanURL string
 }
 
-func NewTabletController(tabletService *services.TabletService) *TabletController {
+func NewTabletController(tabletService *services.TabletService, signer *labels.AssetURLSigner, files *storage.Local, scanURL string) *TabletController {
 	return &TabletController{
 		tabletService: tabletService,
+		signer:        signer,
+		files:         files,
+		scanURL:       scanURL,
 	}
 }
 
+// SearchAgentRequest takes either the agent's CPF or the payload of a scanned tablet label
 type SearchAgentRequest struct {
-	CPF string `form:"cpf" binding:"required"`
+	CPF string `form:"cpf"`
+	QR  string `form:"qr"`
 }
 
+type TabletFilters struct {
+	Status         string `form:"status"`
+	Model          string `form:"model"`
+	MunicipalityID string `form:"municipality_id"`
+}
+
+type TabletRequestFilters struct {
+	Status string `form:"status"`
+	Type   string `form:"type"`
+}
+
+// TabletRequestRequest opens a request; photos and the police report (BO) of theft
+// reports are attached afterwards as multipart uploads
 type TabletRequestRequest struct {
-	Type          string `json:"type" binding:"required"`
-	AgentCPF      string `json:"agent_cpf" binding:"required"`
-	Reason        string `json:"reason"`
-	BODocumentURL string `json:"bo_document_url"`
+	Type     string `json:"type" binding:"required"`
+	AgentCPF string `json:"agent_cpf" binding:"required"`
+	Reason   string `json:"reason"`
+}
+
+func (c *TabletController) GetTablets(ctx *gin.Context) {
+	var filters TabletFilters
+	if err := ctx.ShouldBindQuery(&filters); err != nil {
+		ctx.Error(bindingError(err))
+		return
+	}
+
+	q, err := bindListQuery[entities.TabletFilter](ctx, "created_at", "serial_number", "model", "status", "assigned_at")
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	// Restrict to the user's scope, optionally narrowed to one municipality
+	scope := middlewares.MunicipalityScope(ctx)
+	if filters.MunicipalityID != "" {
+		municipalityID, err := strconv.Atoi(filters.MunicipalityID)
+		if err != nil {
+			ctx.Error(invalidID("municipality_id"))
+			return
+		}
+		if !scope.Contains(&municipalityID) {
+			ctx.Error(apperrors.ErrAccessDenied)
+			return
+		}
+		q.Filters.MunicipalityID = &municipalityID
+	} else if ids := scope.Filter(); ids != nil {
+		q.Filters.MunicipalityIDs = ids
+	}
+
+	if filters.Status != "" {
+		q.Filters.Status = entities.TabletStatus(filters.Status)
+	}
+
+	if filters.Model != "" {
+		q.Filters.Model = filters.Model
+	}
+
+	page, err := c.tabletService.GetAll(ctx.Request.Context(), q)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, page)
+}
+
+// tabletStatusOrder is the order of the statuses in the export summary
+var tabletStatusOrder = []entities.TabletStatus{
+	entities.TabletStatusAvailable,
+	entities.TabletStatusAssigned,
+	entities.TabletStatusActive,
+	entities.TabletStatusReturned,
+	entities.TabletStatusInTransit,
+	entities.TabletStatusMaintenance,
+	entities.TabletStatusBroken,
+	entities.TabletStatusStolen,
+}
+
+// ExportTablets streams the tablet inventory of the user's scope as CSV or XLSX,
+// followed by a summary of the totals by status
+func (c *TabletController) ExportTablets(ctx *gin.Context) {
+	format, err := export.ParseFormat(ctx.DefaultQuery("format", string(export.FormatCSV)))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	var filters TabletFilters
+	if err := ctx.ShouldBindQuery(&filters); err != nil {
+		ctx.Error(bindingError(err))
+		return
+	}
+
+	var filter entities.TabletFilter
+	scope := middlewares.MunicipalityScope(ctx)
+	if filters.MunicipalityID != "" {
+		municipalityID, err := strconv.Atoi(filters.MunicipalityID)
+		if err != nil {
+			ctx.Error(invalidID("municipality_id"))
+			return
+		}
+		if !scope.Contains(&municipalityID) {
+			ctx.Error(apperrors.ErrAccessDenied)
+			return
+		}
+		filter.MunicipalityID = &municipalityID
+	} else if ids := scope.Filter(); ids != nil {
+		filter.MunicipalityIDs = ids
+	}
+	filter.Status = entities.TabletStatus(filters.Status)
+	filter.Model = filters.Model
+
+	filename := fmt.Sprintf("tablets-%s.%s", time.Now().Format("2006-01-02"), format)
+	ctx.Header("Content-Type", format.ContentType())
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	ctx.Status(http.StatusOK)
+
+	w, err := export.NewWriter(ctx.Writer, format, "Tablets")
+	if err != nil {
+		slog.ErrorContext(ctx.Request.Context(), "Error exporting tablets", "error", err)
+		return
+	}
+
+	if err := w.WriteRow("Patrimônio", "Número de série", "Modelo", "Status", "Agente", "CPF", "Atribuído em", "Último evento"); err != nil {
+		slog.ErrorContext(ctx.Request.Context(), "Error exporting tablets", "error", err)
+		return
+	}
+
+	totals := make(map[entities.TabletStatus]int)
+	count := 0
+	err = c.tabletService.Export(ctx.Request.Context(), filter, func(tablet *entities.Tablet) error {
+		totals[tablet.Status]++
+		count++
+		return w.WriteRow(tabletExportRow(tablet)...)
+	})
+	if err != nil {
+		// The response is already under way, so the truncated file is all the client gets
+		slog.ErrorContext(ctx.Request.Context(), "Error exporting tablets", "error", err)
+		ctx.Abort()
+		return
+	}
+
+	summary := [][]string{{"Status", "Quantidade"}}
+	for _, status := range tabletStatusOrder {
+		summary = append(summary, []string{string(status), strconv.Itoa(totals[status])})
+	}
+	summary = append(summary, []string{"Total", strconv.Itoa(count)})
+
+	if err := w.AddSheet("Resumo", summary); err != nil {
+		slog.ErrorContext(ctx.Request.Context(), "Error exporting tablets", "error", err)
+		return
+	}
+	if err := w.Close(); err != nil {
+		slog.ErrorContext(ctx.Request.Context(), "Error exporting tablets", "error", err)
+	}
+}
+
+func tabletExportRow(tablet *entities.Tablet) []string {
+	var agent, cpf, assignedAt, lastEvent string
+	if tablet.AssignedUser != nil {
+		agent = tablet.AssignedUser.Name
+	}
+	if tablet.UserCPF != nil {
+		cpf = *tablet.UserCPF
+	}
+	if tablet.AssignedAt != nil {
+		assignedAt = tablet.AssignedAt.Format("02/01/2006 15:04")
+	}
+	if tablet.LastEvent != nil {
+		lastEvent = fmt.Sprintf("%s em %s", tablet.LastEvent.Type, tablet.LastEvent.CreatedAt.Format("02/01/2006 15:04"))
+	}
+
+	return []string{tablet.AssetCode, tablet.SerialNumber, tablet.Model, string(tablet.Status), agent, cpf, assignedAt, lastEvent}
+}
+
+func (c *TabletController) GetTabletByID(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.Error(invalidID("id"))
+		return
+	}
+
+	tablet, err := c.tabletService.GetByID(ctx.Request.Context(), id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	// Users only access municipalities within their scope
+	if !canAccessMunicipality(ctx, &tablet.MunicipalityID) {
+		ctx.Error(apperrors.ErrAccessDenied)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, tablet)
+}
+
+// ImportTablets registers the tablets of a supplier delivery manifest (multipart "file")
+func (c *TabletController) ImportTablets(ctx *gin.Context) {
+	file, header, err := ctx.Request.FormFile("file")
+	if err != nil {
+		ctx.Error(apperrors.Required("file"))
+		return
+	}
+	defer file.Close()
+	metrics.ObserveUpload(metrics.UploadTabletImport, header.Size)
+
+	report, err := c.tabletService.ImportManifest(ctx.Request.Context(), file, middlewares.MunicipalityScope(ctx))
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, report)
+}
+
+// GetTabletLabel renders the QR code label of a tablet as PNG
+func (c *TabletController) GetTabletLabel(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.Error(invalidID("id"))
+		return
+	}
+
+	tablet, err := c.tabletService.GetByID(ctx.Request.Context(), id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if !canAccessMunicipality(ctx, &tablet.MunicipalityID) {
+		ctx.Error(apperrors.ErrAccessDenied)
+		return
+	}
+
+	if tablet.AssetCode == "" {
+		ctx.Error(apperrors.Unprocessable("tablet_without_asset_code"))
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := labels.PNG(&buf, c.tabletLabel(tablet)); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Data(http.StatusOK, "image/png", buf.Bytes())
+}
+
+// GetTabletLabels renders the labels of every tablet of a municipality as a PDF of label sheets
+func (c *TabletController) GetTabletLabels(ctx *gin.Context) {
+	municipalityID, err := strconv.Atoi(ctx.Query("municipality_id"))
+	if err != nil {
+		ctx.Error(apperrors.Required("municipality_id"))
+		return
+	}
+
+	if !canAccessMunicipality(ctx, &municipalityID) {
+		ctx.Error(apperrors.ErrAccessDenied)
+		return
+	}
+
+	var tabletLabels []labels.Label
+	filter := entities.TabletFilter{MunicipalityID: &municipalityID}
+	err = c.tabletService.Export(ctx.Request.Context(), filter, func(tablet *entities.Tablet) error {
+		// Tablets without an asset code have nothing to identify them by
+		if tablet.AssetCode != "" {
+			tabletLabels = append(tabletLabels, c.tabletLabel(tablet))
+		}
+		return nil
+	})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := labels.PDF(&buf, tabletLabels); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf(`inline; filename="etiquetas-tablets-%d.pdf"`, municipalityID))
+	ctx.Data(http.StatusOK, "application/pdf", buf.Bytes())
+}
+
+func (c *TabletController) tabletLabel(tablet *entities.Tablet) labels.Label {
+	label := labels.Label{
+		AssetCode: tablet.AssetCode,
+		URL:       c.signer.URL(tablet.AssetCode),
+	}
+	if tablet.Municipality != nil {
+		label.Municipality = tablet.Municipality.Name
+	}
+	return label
+}
+
+// ScanLabel opens the URL of a scanned label. Phones open it without a session, so it only
+// checks the signature and redirects to the frontend, which resolves the tablet and its
+// agent through SearchAgent within the user's scope.
+func (c *TabletController) ScanLabel(ctx *gin.Context) {
+	assetCode, err := c.signer.Verify(ctx.Request.URL.RequestURI())
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	target, err := url.Parse(c.scanURL)
+	if err != nil {
+		ctx.Error(fmt.Errorf("invalid label scan URL: %w", err))
+		return
+	}
+	query := target.Query()
+	query.Set("qr", c.signer.URL(assetCode))
+	target.RawQuery = query.Encode()
+
+	ctx.Redirect(http.StatusFound, target.String())
 }
 
 func (c *TabletController) SearchAgent(ctx *gin.Context) {
 	var req SearchAgentRequest
 	if err := ctx.ShouldBindQuery(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		ctx.Error(bindingError(err))
+		return
+	}
+
+	// A scanned label resolves straight to the tablet and the agent holding it
+	if req.QR != "" {
+		assetCode, err := c.signer.Verify(req.QR)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		tablet, err := c.tabletService.GetByAssetCode(ctx.Request.Context(), assetCode)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		if !canAccessMunicipality(ctx, &tablet.MunicipalityID) {
+			ctx.Error(apperrors.ErrAccessDenied)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"tablet": tablet, "agent": tablet.AssignedUser})
+		return
+	}
+
+	if req.CPF == "" {
+		ctx.Error(apperrors.Validation(apperrors.CodeInvalidInput).WithMessage("agent_lookup_required"))
 		return
 	}
 
 	agent, err := c.tabletService.SearchAgentByCPF(ctx.Request.Context(), req.CPF)
 	if err != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		ctx.Error(err)
 		return
 	}
 
@@ -49,121 +413,243 @@ func (c *TabletController) SearchAgent(ctx *gin.Context) {
 func (c *TabletController) RequestTablet(ctx *gin.Context) {
 	var req TabletRequestRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		ctx.Error(bindingError(err))
+		return
+	}
+
+	// Requests are only opened for agents within the user's scope
+	agent, err := c.tabletService.SearchAgentByCPF(ctx.Request.Context(), req.AgentCPF)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	if !canAccessMunicipality(ctx, agent.MunicipalityID) {
+		ctx.Error(apperrors.ErrAccessDenied)
 		return
 	}
 
 	// Get user from context (set by auth middleware)
 	user, exists := ctx.Get("user")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		ctx.Error(apperrors.ErrUnauthenticated)
 		return
 	}
 
 	userEntity := user.(*entities.User)
 
+	var request *entities.TabletRequest
 	switch req.Type {
 	case "new":
-		err := c.tabletService.RequestNewTablet(ctx.Request.Context(), req.AgentCPF, userEntity.ID)
-		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
+		request, err = c.tabletService.RequestNewTablet(ctx.Request.Context(), req.AgentCPF, userEntity.ID)
 	case "return":
-		err := c.tabletService.RequestTabletReturn(ctx.Request.Context(), req.AgentCPF)
-		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
+		request, err = c.tabletService.RequestTabletReturn(ctx.Request.Context(), req.AgentCPF, userEntity.ID)
 	case "broken":
-		err := c.tabletService.ReportTabletBroken(ctx.Request.Context(), req.AgentCPF, req.Reason)
-		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
+		request, err = c.tabletService.ReportTabletBroken(ctx.Request.Context(), req.AgentCPF, req.Reason, userEntity.ID)
 	case "stolen":
-		if req.BODocumentURL == "" {
-			ctx.JSON(http.StatusBadRequest, gin.H{"error": "BO document is required for stolen tablets"})
-			return
-		}
-		err := c.tabletService.ReportTabletStolen(ctx.Request.Context(), req.AgentCPF, req.BODocumentURL)
-		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
+		// The police report (BO) is attached afterwards and required for approval
+		request, err = c.tabletService.ReportTabletStolen(ctx.Request.Context(), req.AgentCPF, req.Reason, userEntity.ID)
 	default:
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request type"})
+		ctx.Error(apperrors.Invalid("type", "tablet_request_type_invalid"))
+		return
+	}
+	if err != nil {
+		ctx.Error(err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"message": "Tablet request submitted successfully"})
+	ctx.JSON(http.StatusCreated, gin.H{"message": message(ctx, "tablet_request_submitted"), "request": request})
 }
 
 func (c *TabletController) GetTabletRequests(ctx *gin.Context) {
-	// Get user from context
-	user, exists := ctx.Get("user")
-	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+	var filters TabletRequestFilters
+	if err := ctx.ShouldBindQuery(&filters); err != nil {
+		ctx.Error(bindingError(err))
 		return
 	}
 
-	userEntity := user.(*entities.User)
+	q, err := bindListQuery[entities.TabletRequestFilter](ctx, "created_at", "updated_at", "status", "type")
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
 
-	// Build filters based on user role
-	filters := make(map[string]interface{})
+	// Restrict to requests of agents within the user's scope
+	q.Filters.MunicipalityIDs = middlewares.MunicipalityScope(ctx).Filter()
+	q.Filters.Status = entities.TabletRequestStatus(filters.Status)
+	q.Filters.Type = entities.TabletRequestType(filters.Type)
 
-	// Non-admin users only see requests from their municipality
-	if userEntity.Role.Name != "ADM" {
-		filters["municipality_id"] = userEntity.MunicipalityID
+	page, err := c.tabletService.GetTabletRequests(ctx.Request.Context(), q)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, page)
+}
+
+// Upload limits of tablet request attachments
+const (
+	maxRequestPhotoSize    = 15 << 20
+	maxRequestDocumentSize = 10 << 20
+)
+
+// AttachRequestPhoto stores a photo (JPEG, PNG or HEIC, multipart "file") of a pending
+// request. Photos are downsized and re-encoded as JPEG, which strips their EXIF data.
+func (c *TabletController) AttachRequestPhoto(ctx *gin.Context) {
+	request, ok := c.scopedRequest(ctx)
+	if !ok {
+		return
 	}
 
-	requests, err := c.tabletService.GetTabletRequests(ctx.Request.Context(), filters)
+	data, ok := readUpload(ctx, metrics.UploadTabletRequestPhoto, maxRequestPhotoSize)
+	if !ok {
+		return
+	}
+
+	photo, err := images.Normalize(data)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	url, err := c.files.Save(requestAttachmentDir(request), uuid.NewString()+".jpg", bytes.NewReader(photo))
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		slog.ErrorContext(ctx.Request.Context(), "Error storing tablet request photo", "error", err)
+		ctx.Error(fmt.Errorf("failed to save file: %w", err))
+		return
+	}
+
+	if err := c.tabletService.AddRequestPhoto(ctx.Request.Context(), request.ID, url); err != nil {
+		c.files.Remove(url)
+		ctx.Error(err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, requests)
+	ctx.JSON(http.StatusCreated, gin.H{"message": message(ctx, "photo_attached"), "url": url})
 }
 
-func (c *TabletController) ApproveRequest(ctx *gin.Context) {
-	idStr := ctx.Param("id")
-	id, err := strconv.ParseUint(idStr, 10, 32)
+// AttachRequestDocument stores the police report (BO) PDF of a pending request,
+// replacing the previous one
+func (c *TabletController) AttachRequestDocument(ctx *gin.Context) {
+	request, ok := c.scopedRequest(ctx)
+	if !ok {
+		return
+	}
+
+	data, ok := readUpload(ctx, metrics.UploadTabletRequestDocument, maxRequestDocumentSize)
+	if !ok {
+		return
+	}
+
+	if !bytes.HasPrefix(data, []byte("%PDF-")) {
+		ctx.Error(errPDFOnly)
+		return
+	}
+
+	url, err := c.files.Save(requestAttachmentDir(request), "bo-"+uuid.NewString()+".pdf", bytes.NewReader(data))
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request ID"})
+		slog.ErrorContext(ctx.Request.Context(), "Error storing tablet request document", "error", err)
+		ctx.Error(fmt.Errorf("failed to save file: %w", err))
+		return
+	}
+
+	if err := c.tabletService.SetRequestDocument(ctx.Request.Context(), request.ID, url); err != nil {
+		c.files.Remove(url)
+		ctx.Error(err)
+		return
+	}
+
+	if request.DocumentURL != "" {
+		if err := c.files.Remove(request.DocumentURL); err != nil {
+			slog.ErrorContext(ctx.Request.Context(), "Error removing replaced tablet request document", "error", err)
+		}
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"message": message(ctx, "document_attached"), "url": url})
+}
+
+func requestAttachmentDir(request *entities.TabletRequest) string {
+	return "tablet-requests/" + request.ID.String()
+}
+
+// readUpload reads the multipart "file" up to limit bytes
+func readUpload(ctx *gin.Context, kind string, limit int64) ([]byte, bool) {
+	file, _, err := ctx.Request.FormFile("file")
+	if err != nil {
+		ctx.Error(apperrors.Required("file"))
+		return nil, false
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, limit+1))
+	if err != nil {
+		ctx.Error(apperrors.InvalidFile("file_unreadable").Wrap(err))
+		return nil, false
+	}
+	if int64(len(data)) > limit {
+		ctx.Error(apperrors.TooLarge("file_too_large", limit>>20))
+		return nil, false
+	}
+	metrics.ObserveUpload(kind, int64(len(data)))
+
+	return data, true
+}
+
+// scopedRequest loads the request of the route, checking its agent is within the user's scope
+func (c *TabletController) scopedRequest(ctx *gin.Context) (*entities.TabletRequest, bool) {
+	id, err := uuid.Parse(ctx.Param("request_id"))
+	if err != nil {
+		ctx.Error(invalidID("request_id"))
+		return nil, false
+	}
+
+	request, err := c.tabletService.GetTabletRequest(ctx.Request.Context(), id)
+	if err != nil {
+		ctx.Error(err)
+		return nil, false
+	}
+
+	if !canAccessMunicipality(ctx, request.User.MunicipalityID) {
+		ctx.Error(apperrors.ErrAccessDenied)
+		return nil, false
+	}
+
+	return request, true
+}
+
+func (c *TabletController) ApproveRequest(ctx *gin.Context) {
+	request, ok := c.scopedRequest(ctx)
+	if !ok {
 		return
 	}
 
 	// Get user from context
 	user, exists := ctx.Get("user")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		ctx.Error(apperrors.ErrUnauthenticated)
 		return
 	}
 
 	userEntity := user.(*entities.User)
 
-	err = c.tabletService.ApproveTabletRequest(ctx.Request.Context(), uint(id), userEntity.ID)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := c.tabletService.ApproveTabletRequest(ctx.Request.Context(), request.ID, userEntity.ID); err != nil {
+		ctx.Error(err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"message": "Request approved successfully"})
+	ctx.JSON(http.StatusOK, gin.H{"message": message(ctx, "tablet_request_approved")})
 }
 
 func (c *TabletController) RejectRequest(ctx *gin.Context) {
-	idStr := ctx.Param("id")
-	id, err := strconv.ParseUint(idStr, 10, 32)
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request ID"})
+	request, ok := c.scopedRequest(ctx)
+	if !ok {
 		return
 	}
 
 	// Get user from context
 	user, exists := ctx.Get("user")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		ctx.Error(apperrors.ErrUnauthenticated)
 		return
 	}
 
@@ -175,15 +661,15 @@ func (c *TabletController) RejectRequest(ctx *gin.Context) {
 
 	var req RejectRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		ctx.Error(bindingError(err))
 		return
 	}
 
-	err = c.tabletService.RejectTabletRequest(ctx.Request.Context(), uint(id), userEntity.ID, req.Reason)
+	err := c.tabletService.RejectTabletRequest(ctx.Request.Context(), request.ID, userEntity.ID, req.Reason)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.Error(err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"message": "Request rejected successfully"})
+	ctx.JSON(http.StatusOK, gin.H{"message": message(ctx, "tablet_request_rejected")})
 }
@@ -0,0 +1,195 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joaopanucci/apsdigital/internal/domain/apperrors"
+	"github.com/joaopanucci/apsdigital/internal/domain/entities"
+	"github.com/joaopanucci/apsdigital/internal/domain/services"
+	"github.com/joaopanucci/apsdigital/internal/infra/http/middlewares"
+)
+
+type TabletTransferController struct {
+	tabletService   *services.TabletService
+	transferService *services.TabletTransferService
+}
+
+func NewTabletTransferController(tabletService *services.TabletService, transferService *services.TabletTransferService) *TabletTransferController {
+	return &TabletTransferController{
+		tabletService:   tabletService,
+		transferService: transferService,
+	}
+}
+
+type TabletTransferFilters struct {
+	Status   string `form:"status"`
+	TabletID string `form:"tablet_id"`
+}
+
+type ProposeTransferRequest struct {
+	ToMunicipalityID int    `json:"to_municipality_id" binding:"required"`
+	Reason           string `json:"reason"`
+}
+
+type RejectTransferRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// GetTransfers lists the transfers leaving or arriving in the user's scope
+func (c *TabletTransferController) GetTransfers(ctx *gin.Context) {
+	var filters TabletTransferFilters
+	if err := ctx.ShouldBindQuery(&filters); err != nil {
+		ctx.Error(bindingError(err))
+		return
+	}
+
+	q, err := bindListQuery[entities.TabletTransferFilter](ctx, "proposed_at", "updated_at", "status")
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	q.Filters.MunicipalityIDs = middlewares.MunicipalityScope(ctx).Filter()
+	q.Filters.Status = entities.TabletTransferStatus(filters.Status)
+
+	if filters.TabletID != "" {
+		tabletID, err := strconv.Atoi(filters.TabletID)
+		if err != nil {
+			ctx.Error(invalidID("tablet_id"))
+			return
+		}
+		q.Filters.TabletID = &tabletID
+	}
+
+	page, err := c.transferService.GetAll(ctx.Request.Context(), q)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, page)
+}
+
+// ProposeTransfer opens a transfer of a tablet of the user's municipality to another one
+func (c *TabletTransferController) ProposeTransfer(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.Error(invalidID("id"))
+		return
+	}
+
+	var req ProposeTransferRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(bindingError(err))
+		return
+	}
+
+	user, exists := ctx.Get("user")
+	if !exists {
+		ctx.Error(apperrors.ErrUnauthenticated)
+		return
+	}
+
+	userEntity := user.(*entities.User)
+
+	tablet, err := c.tabletService.GetByID(ctx.Request.Context(), id)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	// Only the source municipality proposes
+	if !canAccessMunicipality(ctx, &tablet.MunicipalityID) {
+		ctx.Error(apperrors.ErrAccessDenied)
+		return
+	}
+
+	transfer, err := c.transferService.Propose(ctx.Request.Context(), tablet, req.ToMunicipalityID, req.Reason, userEntity.ID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, transfer)
+}
+
+// ApproveTransfer puts the tablet in transit
+func (c *TabletTransferController) ApproveTransfer(ctx *gin.Context) {
+	transfer, userEntity, ok := c.loadTransfer(ctx, func(t *entities.TabletTransfer) *int { return &t.FromMunicipalityID })
+	if !ok {
+		return
+	}
+
+	if err := c.transferService.Approve(ctx.Request.Context(), transfer.ID, userEntity.ID); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": message(ctx, "transfer_approved")})
+}
+
+func (c *TabletTransferController) RejectTransfer(ctx *gin.Context) {
+	var req RejectTransferRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(bindingError(err))
+		return
+	}
+
+	transfer, userEntity, ok := c.loadTransfer(ctx, func(t *entities.TabletTransfer) *int { return &t.FromMunicipalityID })
+	if !ok {
+		return
+	}
+
+	if err := c.transferService.Reject(ctx.Request.Context(), transfer.ID, userEntity.ID, req.Reason); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": message(ctx, "transfer_rejected")})
+}
+
+// ReceiveTransfer confirms the tablet arrived; only the destination can confirm it
+func (c *TabletTransferController) ReceiveTransfer(ctx *gin.Context) {
+	transfer, userEntity, ok := c.loadTransfer(ctx, func(t *entities.TabletTransfer) *int { return &t.ToMunicipalityID })
+	if !ok {
+		return
+	}
+
+	if err := c.transferService.Receive(ctx.Request.Context(), transfer.ID, userEntity.ID); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": message(ctx, "transfer_received")})
+}
+
+// loadTransfer reads the transfer of the route and checks the user can act on the
+// municipality picked by side
+func (c *TabletTransferController) loadTransfer(ctx *gin.Context, side func(*entities.TabletTransfer) *int) (*entities.TabletTransfer, *entities.User, bool) {
+	id, err := strconv.ParseInt(ctx.Param("transfer_id"), 10, 64)
+	if err != nil {
+		ctx.Error(invalidID("transfer_id"))
+		return nil, nil, false
+	}
+
+	user, exists := ctx.Get("user")
+	if !exists {
+		ctx.Error(apperrors.ErrUnauthenticated)
+		return nil, nil, false
+	}
+
+	transfer, err := c.transferService.GetByID(ctx.Request.Context(), id)
+	if err != nil {
+		ctx.Error(err)
+		return nil, nil, false
+	}
+
+	if !canAccessMunicipality(ctx, side(transfer)) {
+		ctx.Error(apperrors.ErrAccessDenied)
+		return nil, nil, false
+	}
+
+	return transfer, user.(*entities.User), true
+}
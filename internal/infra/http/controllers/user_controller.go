@@ -0,0 +1,191 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/joaopanucci/apsdigital/internal/domain/apperrors"
+	"github.com/joaopanucci/apsdigital/internal/domain/entities"
+	"github.com/joaopanucci/apsdigital/internal/domain/services"
+	"github.com/joaopanucci/apsdigital/internal/infra/http/middlewares"
+)
+
+type UserController struct {
+	scopeService         *services.UserScopeService
+	authorizationService *services.UserAuthorizationService
+}
+
+func NewUserController(scopeService *services.UserScopeService, authorizationService *services.UserAuthorizationService) *UserController {
+	return &UserController{
+		scopeService:         scopeService,
+		authorizationService: authorizationService,
+	}
+}
+
+type UserFilters struct {
+	Status         string `form:"status"`
+	RoleID         string `form:"role_id"`
+	ProfessionID   string `form:"profession_id"`
+	MunicipalityID string `form:"municipality_id"`
+	Search         string `form:"q"`
+}
+
+type SetUserScopeRequest struct {
+	Type           string `json:"type" binding:"required"`
+	MunicipalityID *int   `json:"municipality_id"`
+	HealthRegionID *int   `json:"health_region_id"`
+	State          string `json:"state"`
+}
+
+func (c *UserController) GetUsers(ctx *gin.Context) {
+	var filters UserFilters
+	if err := ctx.ShouldBindQuery(&filters); err != nil {
+		ctx.Error(bindingError(err))
+		return
+	}
+
+	q, err := bindListQuery[entities.UserFilter](ctx, "name", "email", "status", "created_at")
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	// Restrict to the user's scope, optionally narrowed to one municipality
+	scope := middlewares.MunicipalityScope(ctx)
+	if filters.MunicipalityID != "" {
+		municipalityID, err := strconv.Atoi(filters.MunicipalityID)
+		if err != nil {
+			ctx.Error(invalidID("municipality_id"))
+			return
+		}
+		if !scope.Contains(&municipalityID) {
+			ctx.Error(apperrors.ErrAccessDenied)
+			return
+		}
+		q.Filters.MunicipalityID = &municipalityID
+	} else if ids := scope.Filter(); ids != nil {
+		q.Filters.MunicipalityIDs = ids
+	}
+
+	if filters.Status != "" {
+		q.Filters.Status = entities.UserStatus(filters.Status)
+	}
+
+	if filters.RoleID != "" {
+		roleID, err := uuid.Parse(filters.RoleID)
+		if err != nil {
+			ctx.Error(invalidID("role_id"))
+			return
+		}
+		q.Filters.RoleID = &roleID
+	}
+
+	if filters.ProfessionID != "" {
+		professionID, err := strconv.Atoi(filters.ProfessionID)
+		if err != nil {
+			ctx.Error(invalidID("profession_id"))
+			return
+		}
+		q.Filters.ProfessionID = &professionID
+	}
+
+	if filters.Search != "" {
+		q.Filters.Search = filters.Search
+	}
+
+	page, err := c.authorizationService.ListUsers(ctx.Request.Context(), q)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, page)
+}
+
+func (c *UserController) SetScope(ctx *gin.Context) {
+	userID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.Error(invalidID("id"))
+		return
+	}
+
+	var req SetUserScopeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(bindingError(err))
+		return
+	}
+
+	scope := entities.UserScope{
+		Type:           entities.ScopeType(req.Type),
+		MunicipalityID: req.MunicipalityID,
+		HealthRegionID: req.HealthRegionID,
+		State:          req.State,
+	}
+
+	if err := c.scopeService.SetUserScope(ctx.Request.Context(), userID, scope); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": message(ctx, "user_scope_updated")})
+}
+
+// AuthorizeUser approves a pending registration
+func (c *UserController) AuthorizeUser(ctx *gin.Context) {
+	target, ok := c.authorizableUser(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.authorizationService.AuthorizeUser(ctx.Request.Context(), target.ID); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": message(ctx, "user_authorized")})
+}
+
+// RejectUser turns down a pending registration
+func (c *UserController) RejectUser(ctx *gin.Context) {
+	target, ok := c.authorizableUser(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.authorizationService.RejectUser(ctx.Request.Context(), target.ID); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": message(ctx, "user_rejected")})
+}
+
+// authorizableUser loads the user of the route, checking they are within the user's scope
+// and below them in the role hierarchy
+func (c *UserController) authorizableUser(ctx *gin.Context) (*entities.User, bool) {
+	userID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.Error(invalidID("id"))
+		return nil, false
+	}
+
+	target, err := c.authorizationService.GetByID(ctx.Request.Context(), userID)
+	if err != nil {
+		ctx.Error(err)
+		return nil, false
+	}
+
+	if !canAccessMunicipality(ctx, target.MunicipalityID) {
+		ctx.Error(apperrors.ErrAccessDenied)
+		return nil, false
+	}
+
+	if target.Role == nil || !entities.CanAuthorizeLevel(ctx.GetInt("user_level"), target.Role.Level) {
+		ctx.Error(entities.ErrLevelNotAllowed)
+		return nil, false
+	}
+
+	return target, true
+}
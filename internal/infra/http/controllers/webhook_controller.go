@@ -0,0 +1,274 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/joaopanucci/apsdigital/internal/domain/apperrors"
+	"github.com/joaopanucci/apsdigital/internal/domain/entities"
+	"github.com/joaopanucci/apsdigital/internal/domain/services"
+	"github.com/joaopanucci/apsdigital/internal/infra/http/middlewares"
+)
+
+type WebhookController struct {
+	webhookService *services.WebhookService
+}
+
+func NewWebhookController(webhookService *services.WebhookService) *WebhookController {
+	return &WebhookController{
+		webhookService: webhookService,
+	}
+}
+
+type CreateWebhookRequest struct {
+	MunicipalityID int      `json:"municipality_id" binding:"required"`
+	Name           string   `json:"name" binding:"required"`
+	URL            string   `json:"url" binding:"required"`
+	EventTypes     []string `json:"event_types" binding:"required"`
+}
+
+type UpdateWebhookRequest struct {
+	Name       string   `json:"name" binding:"required"`
+	URL        string   `json:"url" binding:"required"`
+	EventTypes []string `json:"event_types" binding:"required"`
+	Active     *bool    `json:"active"`
+}
+
+// WebhookWithSecret is returned when the secret is created or rotated, the only times it is shown
+type WebhookWithSecret struct {
+	*entities.WebhookSubscription
+	Secret string `json:"secret"`
+}
+
+type WebhookDeliveryFilters struct {
+	Status    string `form:"status"`
+	EventType string `form:"event_type"`
+}
+
+// GetWebhooks lists the webhooks of the municipalities in the user's scope
+func (c *WebhookController) GetWebhooks(ctx *gin.Context) {
+	filter := entities.WebhookFilter{MunicipalityIDs: middlewares.MunicipalityScope(ctx).Filter()}
+
+	if value := ctx.Query("municipality_id"); value != "" {
+		municipalityID, err := strconv.Atoi(value)
+		if err != nil {
+			ctx.Error(invalidID("municipality_id"))
+			return
+		}
+		filter.MunicipalityID = &municipalityID
+	}
+
+	subscriptions, err := c.webhookService.GetAll(ctx.Request.Context(), filter)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, subscriptions)
+}
+
+func (c *WebhookController) GetWebhook(ctx *gin.Context) {
+	subscription, ok := c.loadWebhook(ctx)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, subscription)
+}
+
+// CreateWebhook subscribes a URL to events of a municipality; the response carries the
+// signing secret
+func (c *WebhookController) CreateWebhook(ctx *gin.Context) {
+	var req CreateWebhookRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(bindingError(err))
+		return
+	}
+
+	userEntity, ok := currentUser(ctx)
+	if !ok {
+		return
+	}
+
+	if !canAccessMunicipality(ctx, &req.MunicipalityID) {
+		ctx.Error(apperrors.ErrAccessDenied)
+		return
+	}
+
+	subscription := &entities.WebhookSubscription{
+		MunicipalityID: req.MunicipalityID,
+		Name:           req.Name,
+		URL:            req.URL,
+		EventTypes:     req.EventTypes,
+		Active:         true,
+		CreatedBy:      &userEntity.ID,
+	}
+
+	if err := c.webhookService.Create(ctx.Request.Context(), subscription); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, WebhookWithSecret{subscription, subscription.Secret})
+}
+
+func (c *WebhookController) UpdateWebhook(ctx *gin.Context) {
+	subscription, ok := c.loadWebhook(ctx)
+	if !ok {
+		return
+	}
+
+	var req UpdateWebhookRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(bindingError(err))
+		return
+	}
+
+	subscription.Name = req.Name
+	subscription.URL = req.URL
+	subscription.EventTypes = req.EventTypes
+	if req.Active != nil {
+		subscription.Active = *req.Active
+	}
+
+	if err := c.webhookService.Update(ctx.Request.Context(), subscription); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, subscription)
+}
+
+func (c *WebhookController) DeleteWebhook(ctx *gin.Context) {
+	subscription, ok := c.loadWebhook(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.webhookService.Delete(ctx.Request.Context(), subscription.ID); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": message(ctx, "webhook_deleted")})
+}
+
+// RotateSecret replaces the signing secret and returns the new one
+func (c *WebhookController) RotateSecret(ctx *gin.Context) {
+	subscription, ok := c.loadWebhook(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.webhookService.RotateSecret(ctx.Request.Context(), subscription); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, WebhookWithSecret{subscription, subscription.Secret})
+}
+
+// PingWebhook queues a ping to check the endpoint; its result shows up in the delivery log
+func (c *WebhookController) PingWebhook(ctx *gin.Context) {
+	subscription, ok := c.loadWebhook(ctx)
+	if !ok {
+		return
+	}
+
+	delivery, err := c.webhookService.Ping(ctx.Request.Context(), subscription)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, delivery)
+}
+
+// GetDeliveries lists the delivery log of a webhook, newest first
+func (c *WebhookController) GetDeliveries(ctx *gin.Context) {
+	subscription, ok := c.loadWebhook(ctx)
+	if !ok {
+		return
+	}
+
+	var filters WebhookDeliveryFilters
+	if err := ctx.ShouldBindQuery(&filters); err != nil {
+		ctx.Error(bindingError(err))
+		return
+	}
+
+	q, err := bindListQuery[entities.WebhookDeliveryFilter](ctx, "created_at", "next_attempt_at", "status")
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	q.Filters.SubscriptionID = subscription.ID
+	q.Filters.Status = entities.WebhookDeliveryStatus(filters.Status)
+	q.Filters.EventType = filters.EventType
+
+	page, err := c.webhookService.GetDeliveries(ctx.Request.Context(), q)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, page)
+}
+
+// ReplayDelivery sends a logged delivery again, as a new delivery with the same event
+func (c *WebhookController) ReplayDelivery(ctx *gin.Context) {
+	subscription, ok := c.loadWebhook(ctx)
+	if !ok {
+		return
+	}
+
+	deliveryID, err := strconv.ParseInt(ctx.Param("delivery_id"), 10, 64)
+	if err != nil {
+		ctx.Error(invalidID("delivery_id"))
+		return
+	}
+
+	delivery, err := c.webhookService.GetDelivery(ctx.Request.Context(), deliveryID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+	if delivery.SubscriptionID != subscription.ID {
+		ctx.Error(entities.ErrWebhookNotFound)
+		return
+	}
+
+	replay, err := c.webhookService.Replay(ctx.Request.Context(), delivery.ID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, replay)
+}
+
+// loadWebhook reads the :id webhook, answering 404 when it does not exist or is outside
+// the user's scope
+func (c *WebhookController) loadWebhook(ctx *gin.Context) (*entities.WebhookSubscription, bool) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.Error(invalidID("id"))
+		return nil, false
+	}
+
+	subscription, err := c.webhookService.GetByID(ctx.Request.Context(), id)
+	if err != nil {
+		ctx.Error(err)
+		return nil, false
+	}
+
+	if !canAccessMunicipality(ctx, &subscription.MunicipalityID) {
+		ctx.Error(entities.ErrWebhookNotFound)
+		return nil, false
+	}
+
+	return subscription, true
+}
@@ -1,10 +1,11 @@
 package middlewares
 
 import (
-	"net/http"
+	"context"
+	"fmt"
 	"strings"
 
-	"github.com/joaopanucci/apsdigital/internal/config"
+	"github.com/joaopanucci/apsdigital/internal/domain/apperrors"
 	"github.com/joaopanucci/apsdigital/internal/domain/entities"
 
 	"github.com/gin-gonic/gin"
@@ -12,44 +13,80 @@ import (
 	"github.com/google/uuid"
 )
 
+// errInvalidToken rejects an access token that is malformed, expired or badly signed
+var errInvalidToken = apperrors.Unauthorized("invalid_token")
+
+// errSessionRevoked rejects an access token whose session was logged out or revoked
+var errSessionRevoked = apperrors.Unauthorized("session_revoked")
+
 type Claims struct {
-	UserID uuid.UUID `json:"user_id"`
-	Email  string    `json:"email"`
-	Role   string    `json:"role"`
-	Level  int       `json:"level"`
+	UserID    uuid.UUID `json:"user_id"`
+	Email     string    `json:"email"`
+	CPF       string    `json:"cpf"`
+	Role      string    `json:"role"`
+	Level     int       `json:"level"`
+	SessionID uuid.UUID `json:"sid"`
 	jwt.RegisteredClaims
 }
 
-func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
+// TokenVerifier resolves the key that signed an access token
+type TokenVerifier interface {
+	Keyfunc(token *jwt.Token) (interface{}, error)
+	ValidMethods() []string
+}
+
+// AccessTokenFromQuery accepts the access token as ?access_token= for clients that cannot
+// set headers, like browsers' EventSource. The Authorization header wins when both are sent.
+func AccessTokenFromQuery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token := c.Query("access_token"); token != "" && c.GetHeader("Authorization") == "" {
+			c.Request.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		c.Next()
+	}
+}
+
+// SessionChecker reports whether the session an access token belongs to is still open
+type SessionChecker interface {
+	IsSessionActive(ctx context.Context, sessionID uuid.UUID) (bool, error)
+}
+
+func AuthMiddleware(verifier TokenVerifier, sessions SessionChecker) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
-			c.Abort()
+			abort(c, apperrors.ErrUnauthenticated)
 			return
 		}
 
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 		if tokenString == authHeader {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization header format"})
-			c.Abort()
+			abort(c, errInvalidToken)
 			return
 		}
 
-		token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-			return []byte(cfg.JWT.Secret), nil
-		})
+		token, err := jwt.ParseWithClaims(tokenString, &Claims{}, verifier.Keyfunc, jwt.WithValidMethods(verifier.ValidMethods()))
 
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-			c.Abort()
+			abort(c, errInvalidToken.Wrap(err))
 			return
 		}
 
 		claims, ok := token.Claims.(*Claims)
 		if !ok || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
-			c.Abort()
+			abort(c, errInvalidToken)
+			return
+		}
+
+		// Revoking a session ends its access tokens too, not only its refreshes
+		active, err := sessions.IsSessionActive(c.Request.Context(), claims.SessionID)
+		if err != nil {
+			abort(c, fmt.Errorf("failed to check session: %w", err))
+			return
+		}
+		if !active {
+			abort(c, errSessionRevoked)
 			return
 		}
 
@@ -58,6 +95,9 @@ func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 		c.Set("user_email", claims.Email)
 		c.Set("user_role", claims.Role)
 		c.Set("user_level", claims.Level)
+		c.Set("user_cpf", claims.CPF)
+		c.Set("session_id", claims.SessionID)
+		addLogAttrs(c, "user_id", claims.UserID.String(), "role", claims.Role)
 
 		c.Next()
 	}
@@ -67,15 +107,13 @@ func RequireRole(requiredRoles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userRole, exists := c.Get("user_role")
 		if !exists {
-			c.JSON(http.StatusForbidden, gin.H{"error": "User role not found"})
-			c.Abort()
+			abort(c, apperrors.ErrAccessDenied)
 			return
 		}
 
 		role, ok := userRole.(string)
 		if !ok {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Invalid user role"})
-			c.Abort()
+			abort(c, apperrors.ErrAccessDenied)
 			return
 		}
 
@@ -87,8 +125,7 @@ func RequireRole(requiredRoles ...string) gin.HandlerFunc {
 			}
 		}
 
-		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
-		c.Abort()
+		abort(c, apperrors.ErrAccessDenied)
 	}
 }
 
@@ -96,22 +133,19 @@ func RequireMinLevel(minLevel int) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userLevel, exists := c.Get("user_level")
 		if !exists {
-			c.JSON(http.StatusForbidden, gin.H{"error": "User level not found"})
-			c.Abort()
+			abort(c, apperrors.ErrAccessDenied)
 			return
 		}
 
 		level, ok := userLevel.(int)
 		if !ok {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Invalid user level"})
-			c.Abort()
+			abort(c, apperrors.ErrAccessDenied)
 			return
 		}
 
 		// Lower level number means higher hierarchy (1=ADM, 2=Coordenador, etc.)
 		if level > minLevel {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
-			c.Abort()
+			abort(c, apperrors.ErrAccessDenied)
 			return
 		}
 
@@ -124,39 +158,20 @@ func CanAuthorize(targetUserLevel int) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userLevel, exists := c.Get("user_level")
 		if !exists {
-			c.JSON(http.StatusForbidden, gin.H{"error": "User level not found"})
-			c.Abort()
+			abort(c, apperrors.ErrAccessDenied)
 			return
 		}
 
 		level, ok := userLevel.(int)
 		if !ok {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Invalid user level"})
-			c.Abort()
+			abort(c, apperrors.ErrAccessDenied)
 			return
 		}
 
-		// Check authorization hierarchy:
-		// ADM (1) can authorize anyone
-		// Coordenador (2) can authorize Gerente (3) and ACS (4)
-		// Gerente (3) can authorize ACS (4)
-		// ACS (4) cannot authorize anyone
-
-		canAuth := false
-		switch level {
-		case entities.LevelAdmin: // ADM
-			canAuth = true
-		case entities.LevelCoordenador: // Coordenador
-			canAuth = targetUserLevel >= entities.LevelGerente
-		case entities.LevelGerente: // Gerente
-			canAuth = targetUserLevel == entities.LevelACS
-		case entities.LevelACS: // ACS
-			canAuth = false
-		}
+		canAuth := entities.CanAuthorizeLevel(level, targetUserLevel)
 
 		if !canAuth {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Cannot authorize users of this level"})
-			c.Abort()
+			abort(c, entities.ErrLevelNotAllowed)
 			return
 		}
 
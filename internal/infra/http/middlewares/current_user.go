@@ -0,0 +1,47 @@
+package middlewares
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/joaopanucci/apsdigital/internal/domain/apperrors"
+	"github.com/joaopanucci/apsdigital/internal/domain/entities"
+	"github.com/joaopanucci/apsdigital/internal/domain/repositories"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// LoadCurrentUser loads the authenticated user and stores it in the context as "user".
+// It must run after AuthMiddleware.
+func LoadCurrentUser(userRepo repositories.UserRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := c.Get("user_id")
+		if !ok {
+			abort(c, apperrors.ErrUnauthenticated)
+			return
+		}
+
+		user, err := userRepo.GetByID(c.Request.Context(), userID.(uuid.UUID))
+		if errors.Is(err, entities.ErrUserNotFound) {
+			abort(c, apperrors.ErrUnauthenticated)
+			return
+		}
+		if err != nil {
+			abort(c, fmt.Errorf("failed to load current user: %w", err))
+			return
+		}
+
+		if user.Status != entities.UserStatusActive {
+			abort(c, entities.ErrAccountInactive)
+			return
+		}
+
+		user.Password = ""
+		c.Set("user", user)
+		if user.MunicipalityID != nil {
+			addLogAttrs(c, "municipality_id", *user.MunicipalityID)
+		}
+		c.Next()
+	}
+}
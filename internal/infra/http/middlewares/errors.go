@@ -0,0 +1,107 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/joaopanucci/apsdigital/internal/domain/apperrors"
+	"github.com/joaopanucci/apsdigital/internal/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ProblemContentType is the media type of error responses (RFC 7807)
+const ProblemContentType = "application/problem+json"
+
+// Problem is the body of every error response. Code is stable and meant for clients
+// to match on; Title, Detail and the field messages are in the negotiated language.
+type Problem struct {
+	Type      string                 `json:"type"`
+	Title     string                 `json:"title"`
+	Status    int                    `json:"status"`
+	Detail    string                 `json:"detail"`
+	Instance  string                 `json:"instance,omitempty"`
+	Code      string                 `json:"code"`
+	Errors    []apperrors.FieldError `json:"errors,omitempty"`
+	RequestID string                 `json:"request_id,omitempty"`
+}
+
+var problemStatus = map[apperrors.Kind]int{
+	apperrors.KindInternal:      http.StatusInternalServerError,
+	apperrors.KindValidation:    http.StatusBadRequest,
+	apperrors.KindUnauthorized:  http.StatusUnauthorized,
+	apperrors.KindForbidden:     http.StatusForbidden,
+	apperrors.KindNotFound:      http.StatusNotFound,
+	apperrors.KindConflict:      http.StatusConflict,
+	apperrors.KindUnprocessable: http.StatusUnprocessableEntity,
+	apperrors.KindTooLarge:      http.StatusRequestEntityTooLarge,
+}
+
+// problemTitle is the catalog key of the title of each kind of problem
+var problemTitle = map[apperrors.Kind]string{
+	apperrors.KindInternal:      "title_internal",
+	apperrors.KindValidation:    "title_validation",
+	apperrors.KindUnauthorized:  "title_unauthorized",
+	apperrors.KindForbidden:     "title_forbidden",
+	apperrors.KindNotFound:      "title_not_found",
+	apperrors.KindConflict:      "title_conflict",
+	apperrors.KindUnprocessable: "title_unprocessable",
+	apperrors.KindTooLarge:      "title_too_large",
+}
+
+// ErrorHandler answers the last error a handler recorded with c.Error as a problem
+// response, unless the handler already wrote one. Errors that aren't *apperrors.Error
+// are internal: the client gets a generic message and the cause is only logged by
+// RequestLogger, which must run before this middleware.
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		writeProblem(c, c.Errors.Last().Err)
+	}
+}
+
+func writeProblem(c *gin.Context, err error) {
+	appErr, ok := apperrors.As(err)
+	if !ok || appErr.Kind == apperrors.KindInternal {
+		appErr = apperrors.ErrInternal
+	}
+
+	lang := Language(c)
+	var fields []apperrors.FieldError
+	for _, field := range appErr.Fields {
+		field.Message = field.Localize(lang)
+		fields = append(fields, field)
+	}
+
+	status := problemStatus[appErr.Kind]
+	c.Header("Content-Type", ProblemContentType)
+	c.JSON(status, Problem{
+		Type:      "urn:apsdigital:error:" + appErr.Code,
+		Title:     i18n.Message(lang, problemTitle[appErr.Kind]),
+		Status:    status,
+		Detail:    appErr.Localize(lang),
+		Instance:  c.Request.URL.Path,
+		Code:      appErr.Code,
+		Errors:    fields,
+		RequestID: c.GetString("request_id"),
+	})
+}
+
+var errRouteNotFound = apperrors.NotFound("route_not_found")
+
+// NoRoute answers requests to unknown routes, for registering with gin's NoRoute
+func NoRoute() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		abort(c, errRouteNotFound)
+	}
+}
+
+// abort stops the chain with err, for ErrorHandler to answer
+func abort(c *gin.Context, err error) {
+	c.Error(err)
+	c.Abort()
+}
@@ -0,0 +1,29 @@
+package middlewares
+
+import (
+	"github.com/joaopanucci/apsdigital/internal/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NegotiateLanguage picks the language of the response from Accept-Language, pt-BR
+// when the client accepts neither of the supported ones
+func NegotiateLanguage() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		lang := i18n.Negotiate(c.GetHeader("Accept-Language"))
+
+		c.Set("language", lang)
+		c.Header("Content-Language", lang)
+		c.Writer.Header().Add("Vary", "Accept-Language")
+
+		c.Next()
+	}
+}
+
+// Language is the language negotiated for the request
+func Language(c *gin.Context) string {
+	if lang := c.GetString("language"); lang != "" {
+		return lang
+	}
+	return i18n.Default
+}
@@ -0,0 +1,78 @@
+package middlewares
+
+import (
+	"fmt"
+	"log/slog"
+	"net/url"
+	"runtime/debug"
+	"time"
+
+	"github.com/joaopanucci/apsdigital/internal/infra/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestLogger logs each request once it is answered, with the attributes the
+// middlewares added to its context. It must run after RequestID.
+func RequestLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("client_ip", c.ClientIP()),
+			slog.Int("bytes", c.Writer.Size()),
+			slog.String("user_agent", c.Request.UserAgent()),
+		}
+		if query := redactQuery(c.Request.URL.Query()); query != "" {
+			attrs = append(attrs, slog.String("query", query))
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("errors", c.Errors.String()))
+		}
+
+		slog.LogAttrs(c.Request.Context(), level, "request", attrs...)
+	}
+}
+
+// redactQuery hides sensitive parameters, like the access token event streams send
+func redactQuery(query url.Values) string {
+	for key := range query {
+		if logging.IsSensitive(key) {
+			query[key] = []string{logging.Redacted}
+		}
+	}
+	return query.Encode()
+}
+
+// Recovery turns a handler panic into an internal error and logs the panic with its
+// stack. It must run after ErrorHandler.
+func Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				slog.ErrorContext(c.Request.Context(), "panic serving request",
+					"error", fmt.Sprint(recovered),
+					"stack", string(debug.Stack()),
+				)
+				abort(c, fmt.Errorf("panic: %v", recovered))
+			}
+		}()
+
+		c.Next()
+	}
+}
@@ -0,0 +1,29 @@
+package middlewares
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/joaopanucci/apsdigital/internal/infra/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics records the count and latency of requests by route template. Requests that
+// match no route share one label so scanners can't grow the series.
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(c.Writer.Status())
+
+		metrics.HTTPRequests.WithLabelValues(c.Request.Method, route, status).Inc()
+		metrics.HTTPRequestDuration.WithLabelValues(c.Request.Method, route, status).Observe(time.Since(start).Seconds())
+	}
+}
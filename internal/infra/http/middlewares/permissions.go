@@ -0,0 +1,66 @@
+package middlewares
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/joaopanucci/apsdigital/internal/domain/apperrors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PermissionLoader resolves the permissions granted to a role
+type PermissionLoader interface {
+	GetRolePermissions(ctx context.Context, roleName string) (map[string]bool, error)
+}
+
+// LoadPermissions stores the permissions of the authenticated user's role in the context.
+// It must run after AuthMiddleware.
+func LoadPermissions(loader PermissionLoader) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("user_role")
+		if role == "" {
+			abort(c, apperrors.ErrAccessDenied)
+			return
+		}
+
+		permissions, err := loader.GetRolePermissions(c.Request.Context(), role)
+		if err != nil {
+			abort(c, fmt.Errorf("failed to load permissions: %w", err))
+			return
+		}
+
+		c.Set("permissions", permissions)
+		c.Next()
+	}
+}
+
+// RequirePermission allows the request only if the user's role has the named permission
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !HasPermission(c, permission) {
+			abort(c, apperrors.ErrAccessDenied)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// HasPermission reports whether the permissions loaded by LoadPermissions include permission
+func HasPermission(c *gin.Context, permission string) bool {
+	value, exists := c.Get("permissions")
+	if !exists {
+		return false
+	}
+
+	permissions, ok := value.(map[string]bool)
+	return ok && permissions[permission]
+}
+
+// Permissions returns the permissions loaded by LoadPermissions
+func Permissions(c *gin.Context) map[string]bool {
+	value, _ := c.Get("permissions")
+	permissions, _ := value.(map[string]bool)
+	return permissions
+}
@@ -0,0 +1,38 @@
+package middlewares
+
+import (
+	"regexp"
+
+	"github.com/joaopanucci/apsdigital/internal/infra/logging"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader carries the ID that correlates the logs of a request
+const RequestIDHeader = "X-Request-ID"
+
+// validRequestID keeps IDs from proxies usable in logs and headers
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestID takes the request ID from the proxy or generates one, echoes it in the
+// response and adds it to the logs of the request
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = uuid.NewString()
+		}
+
+		c.Set("request_id", id)
+		c.Header(RequestIDHeader, id)
+		c.Request = c.Request.WithContext(logging.With(c.Request.Context(), "request_id", id))
+
+		c.Next()
+	}
+}
+
+// addLogAttrs adds attributes to the logs of the rest of the request
+func addLogAttrs(c *gin.Context, args ...any) {
+	c.Request = c.Request.WithContext(logging.With(c.Request.Context(), args...))
+}
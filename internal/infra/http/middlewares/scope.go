@@ -0,0 +1,53 @@
+package middlewares
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/joaopanucci/apsdigital/internal/domain/apperrors"
+	"github.com/joaopanucci/apsdigital/internal/domain/entities"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ScopeResolver expands a user's scope into the municipalities it covers
+type ScopeResolver interface {
+	ResolveMunicipalityScope(ctx context.Context, user *entities.User) (*entities.MunicipalityScope, error)
+}
+
+// LoadMunicipalityScope stores the municipalities visible to the user in the context as
+// "municipality_scope". It must run after LoadPermissions and LoadCurrentUser.
+func LoadMunicipalityScope(resolver ScopeResolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if HasPermission(c, entities.PermissionMunicipalitiesViewAll) {
+			c.Set("municipality_scope", &entities.MunicipalityScope{All: true})
+			c.Next()
+			return
+		}
+
+		user, exists := c.Get("user")
+		if !exists {
+			abort(c, apperrors.ErrUnauthenticated)
+			return
+		}
+
+		scope, err := resolver.ResolveMunicipalityScope(c.Request.Context(), user.(*entities.User))
+		if err != nil {
+			abort(c, fmt.Errorf("failed to load user scope: %w", err))
+			return
+		}
+
+		c.Set("municipality_scope", scope)
+		c.Next()
+	}
+}
+
+// MunicipalityScope returns the scope loaded by LoadMunicipalityScope. Without one nothing is visible.
+func MunicipalityScope(c *gin.Context) *entities.MunicipalityScope {
+	if value, exists := c.Get("municipality_scope"); exists {
+		if scope, ok := value.(*entities.MunicipalityScope); ok {
+			return scope
+		}
+	}
+	return &entities.MunicipalityScope{}
+}
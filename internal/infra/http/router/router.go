@@ -3,173 +3,347 @@ package router
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/joaopanucci/apsdigital/internal/config"
+	"github.com/joaopanucci/apsdigital/internal/domain/entities"
 	"github.com/joaopanucci/apsdigital/internal/domain/services"
+	"github.com/joaopanucci/apsdigital/internal/infra/cnes"
 	"github.com/joaopanucci/apsdigital/internal/infra/db"
+	"github.com/joaopanucci/apsdigital/internal/infra/events"
+	"github.com/joaopanucci/apsdigital/internal/infra/health"
 	"github.com/joaopanucci/apsdigital/internal/infra/http/controllers"
 	"github.com/joaopanucci/apsdigital/internal/infra/http/middlewares"
+	"github.com/joaopanucci/apsdigital/internal/infra/ibge"
+	"github.com/joaopanucci/apsdigital/internal/infra/jobs"
+	"github.com/joaopanucci/apsdigital/internal/infra/jwtkeys"
+	"github.com/joaopanucci/apsdigital/internal/infra/labels"
+	"github.com/joaopanucci/apsdigital/internal/infra/manifest"
+	"github.com/joaopanucci/apsdigital/internal/infra/metrics"
 	"github.com/joaopanucci/apsdigital/internal/infra/repositories"
+	"github.com/joaopanucci/apsdigital/internal/infra/storage"
 )
 
-func NewRouter(database *db.PostgresDB, cfg *config.Config) *gin.Engine {
+// handlers groups the controllers mounted under every route prefix
+type handlers struct {
+	health       *controllers.HealthController
+	auth         *controllers.AuthController
+	jwks         *controllers.JWKSController
+	municipality *controllers.MunicipalityController
+	payment      *controllers.PaymentController
+	resolution   *controllers.ResolutionController
+	profession   *controllers.ProfessionController
+	role         *controllers.RoleController
+	healthRegion *controllers.HealthRegionController
+	healthUnit   *controllers.HealthUnitController
+	user         *controllers.UserController
+	tablet       *controllers.TabletController
+	allocation   *controllers.TabletAllocationController
+	transfer     *controllers.TabletTransferController
+	stats        *controllers.StatsController
+	notification *controllers.NotificationController
+	event        *controllers.EventController
+	webhook      *controllers.WebhookController
+}
+
+func NewRouter(database *db.PostgresDB, cfg *config.Config, keys *jwtkeys.Manager, broker *events.Broker, runner *jobs.Runner, checker *health.Checker) *gin.Engine {
 	r := gin.New()
 
 	// Add middlewares
-	r.Use(gin.Logger())
-	r.Use(gin.Recovery())
+	r.Use(middlewares.RequestID())
+	r.Use(middlewares.Tracing())
+	r.Use(middlewares.RequestLogger())
+	r.Use(middlewares.Metrics())
+	r.Use(middlewares.NegotiateLanguage())
+	r.Use(middlewares.ErrorHandler())
+	r.Use(middlewares.Recovery())
 	r.Use(middlewares.CORSMiddleware())
+	r.NoRoute(middlewares.NoRoute())
 
-	// Serve static files (uploaded PDFs)
-	r.Static("/uploads", "./uploads")
+	// Serve static files (uploaded PDFs and photos)
+	r.Static(storage.URLPrefix, cfg.Upload.Path)
+	files := storage.NewLocal(cfg.Upload.Path)
 
 	// Initialize repositories
+	userRepo := repositories.NewUserRepository(database)
+	roleRepo := repositories.NewRoleRepository(database)
+	refreshTokenRepo := repositories.NewRefreshTokenRepository(database)
 	municipalityRepo := repositories.NewMunicipalityRepository(database)
 	paymentRepo := repositories.NewPaymentRepository(database)
 	resolutionRepo := repositories.NewResolutionRepository(database)
 	professionRepo := repositories.NewProfessionRepository(database)
+	healthRegionRepo := repositories.NewHealthRegionRepository(database)
+	healthUnitRepo := repositories.NewHealthUnitRepository(database)
+	tabletRepo := repositories.NewTabletRepository(database)
+	tabletRequestRepo := repositories.NewTabletRequestRepository(database)
+	notificationRepo := repositories.NewNotificationRepository(database)
+	tabletStatsRepo := repositories.NewTabletStatsRepository(database)
+	tabletAllocationRepo := repositories.NewTabletAllocationRepository(database)
+	tabletTransferRepo := repositories.NewTabletTransferRepository(database)
+	webhookRepo := repositories.NewWebhookRepository(database)
+	jobRepo := repositories.NewJobRepository(database)
 
 	// Initialize services
-	// authService := services.NewAuthService(userRepo, refreshTokenRepo, roleRepo, cfg)  // TODO: Fix AuthorizeUser method
-	municipalityService := services.NewMunicipalityService(municipalityRepo)
-	// tabletService := services.NewTabletService(tabletRepo, userRepo)  // TODO: Fix AuthorizeUser method
-	paymentService := services.NewPaymentService(paymentRepo)
-	resolutionService := services.NewResolutionService(resolutionRepo)
+	notificationService := services.NewNotificationService(notificationRepo)
+	webhookService := services.NewWebhookService(webhookRepo, municipalityRepo)
+	authService := services.NewAuthService(userRepo, refreshTokenRepo, roleRepo, keys, cfg, notificationService)
+	municipalityService := services.NewMunicipalityService(municipalityRepo, ibge.NewLocalityLoader())
+	tabletService := services.NewTabletService(tabletRepo, tabletRequestRepo, userRepo, municipalityRepo, manifest.NewParser(), jobRepo, database, notificationService, webhookService)
+	tabletStatsService := services.NewTabletStatsService(tabletStatsRepo)
+	tabletAllocationService := services.NewTabletAllocationService(tabletAllocationRepo)
+	tabletTransferService := services.NewTabletTransferService(tabletTransferRepo, tabletRepo, municipalityRepo)
+	paymentService := services.NewPaymentService(paymentRepo, jobRepo, database, notificationService, webhookService)
+	resolutionService := services.NewResolutionService(resolutionRepo, jobRepo, database, notificationService)
 	professionService := services.NewProfessionService(professionRepo)
+	permissionService := services.NewPermissionService(roleRepo)
+	healthRegionService := services.NewHealthRegionService(healthRegionRepo)
+	healthUnitService := services.NewHealthUnitService(healthUnitRepo, municipalityRepo, cnes.NewParser())
+	userScopeService := services.NewUserScopeService(userRepo, municipalityRepo, healthRegionRepo)
+	userAuthorizationService := services.NewUserAuthorizationService(userRepo, notificationService)
+
+	// Handlers of the jobs the services queue
+	jobs.Handle(runner, entities.JobPaymentPublished, paymentService.AnnouncePublished)
+	jobs.Handle(runner, entities.JobResolutionPublished, resolutionService.AnnouncePublished)
+	jobs.Handle(runner, entities.JobTabletStolen, tabletService.AnnounceStolen)
 
 	// Initialize controllers
-	// authController := controllers.NewAuthController(authService)  // TODO: Fix AuthorizeUser method
-	municipalityController := controllers.NewMunicipalityController(municipalityService)
-	// tabletController := controllers.NewTabletController(tabletService)  // TODO: Fix AuthorizeUser method
-	paymentController := controllers.NewPaymentController(paymentService)
-	resolutionController := controllers.NewResolutionController(resolutionService)
-	professionController := controllers.NewProfessionController(professionService)
+	h := &handlers{
+		health:       controllers.NewHealthController(checker),
+		auth:         controllers.NewAuthController(authService),
+		jwks:         controllers.NewJWKSController(keys),
+		municipality: controllers.NewMunicipalityController(municipalityService, cfg.IBGE.LocalitiesURL),
+		payment:      controllers.NewPaymentController(paymentService, files),
+		resolution:   controllers.NewResolutionController(resolutionService),
+		profession:   controllers.NewProfessionController(professionService),
+		role:         controllers.NewRoleController(permissionService),
+		healthRegion: controllers.NewHealthRegionController(healthRegionService),
+		healthUnit:   controllers.NewHealthUnitController(healthUnitService),
+		user:         controllers.NewUserController(userScopeService, userAuthorizationService),
+		tablet:       controllers.NewTabletController(tabletService, labels.NewAssetURLSigner(cfg.Assets.BaseURL, cfg.Assets.URLSecret), files, cfg.Assets.ScanURL),
+		allocation:   controllers.NewTabletAllocationController(tabletAllocationService),
+		transfer:     controllers.NewTabletTransferController(tabletService, tabletTransferService),
+		stats:        controllers.NewStatsController(tabletStatsService),
+		notification: controllers.NewNotificationController(notificationService),
+		event:        controllers.NewEventController(broker),
+		webhook:      controllers.NewWebhookController(webhookService),
+	}
+
+	authMiddleware := middlewares.AuthMiddleware(keys, authService)
+
+	// Authenticated routes also need the user's permissions, profile and municipality scope
+	protected := []gin.HandlerFunc{
+		authMiddleware,
+		middlewares.LoadPermissions(permissionService),
+		middlewares.LoadCurrentUser(userRepo),
+		middlewares.LoadMunicipalityScope(userScopeService),
+	}
+
+	// Probes for the orchestrator
+	r.GET("/livez", h.health.Livez)
+	r.GET("/readyz", h.health.Readyz)
+
+	// Prometheus metrics, unless they are served on their own address
+	if cfg.Metrics.Addr == "" && cfg.Metrics.Token != "" {
+		r.GET("/metrics", gin.WrapH(metrics.Handler(cfg.Metrics.Token)))
+	}
+
+	// Public keys for services that verify our access tokens
+	r.GET("/.well-known/jwks.json", h.jwks.GetJWKS)
+
+	// QR code labels point here, outside the API prefixes, so printed URLs stay short; the
+	// wildcard also takes asset codes containing an escaped slash
+	r.GET("/t/*asset_code", h.tablet.ScanLabel)
 
 	// API Routes with /api/v1 prefix (for direct API access)
-	api := r.Group("/api/v1")
+	registerRoutes(r.Group("/api/v1"), h, authMiddleware, protected)
+
+	// Frontend API Routes (without /api/v1 prefix for frontend compatibility)
+	// These will be accessed via nginx proxy as /api/ -> backend:8080/
+	registerRoutes(&r.RouterGroup, h, authMiddleware, protected)
+
+	// Temporary workaround: Add /api prefix routes directly in backend
+	registerRoutes(r.Group("/api"), h, authMiddleware, protected)
+
+	return r
+}
+
+func registerRoutes(api *gin.RouterGroup, h *handlers, authMiddleware gin.HandlerFunc, protected []gin.HandlerFunc) {
+	// Health check, kept for existing monitors; see /livez and /readyz
+	api.GET("/health", h.health.Livez)
+
+	// Auth
+	auth := api.Group("/auth")
 	{
-		// Health check
-		api.GET("/health", func(c *gin.Context) {
-			c.JSON(200, gin.H{"status": "ok"})
-		})
+		auth.POST("/register", h.auth.Register)
+		auth.POST("/login", h.auth.Login)
+		auth.POST("/refresh", h.auth.RefreshToken)
+		auth.POST("/logout", h.auth.Logout)
 
-		// Municipalities
-		municipalities := api.Group("/municipalities")
+		authenticated := auth.Group("")
+		authenticated.Use(authMiddleware)
 		{
-			municipalities.GET("/", municipalityController.GetMunicipalities)
+			authenticated.GET("/me", h.auth.Me)
+			authenticated.GET("/sessions", h.auth.ListSessions)
+			authenticated.DELETE("/sessions/:id", h.auth.RevokeSession)
 		}
+	}
 
-		// Payments
-		payments := api.Group("/payments")
-		{
-			payments.GET("/", paymentController.GetPaymentsPublic)
-			payments.GET("/competences", paymentController.GetCompetencesPublic)
-			payments.GET("/years", paymentController.GetYearsPublic)
-			payments.POST("/upload", paymentController.UploadPaymentFile)
-		}
+	// Municipalities
+	municipalities := api.Group("/municipalities")
+	{
+		municipalities.GET("/", h.municipality.GetMunicipalities)
 
-		// Resolutions
-		resolutions := api.Group("/resolutions")
-		{
-			resolutions.GET("/", resolutionController.GetResolutionsPublic)
-			resolutions.GET("/types", resolutionController.GetTypesPublic)
-			resolutions.GET("/years", resolutionController.GetYearsPublic)
-			resolutions.POST("/upload", resolutionController.UploadResolutionFile)
-		}
+		manage := municipalities.Group("", protected...)
+		manage.POST("/sync", middlewares.RequirePermission(entities.PermissionMunicipalitiesSync), h.municipality.SyncFromIBGE)
+	}
 
-		// Professions
-		professions := api.Group("/professions")
-		{
-			professions.GET("/", professionController.GetProfessions)
-			professions.GET("/:id", professionController.GetProfessionByID)
-			professions.POST("/", professionController.CreateProfession)
-			professions.PUT("/:id", professionController.UpdateProfession)
-			professions.DELETE("/:id", professionController.DeleteProfession)
-		}
+	// Health regions
+	healthRegions := api.Group("/health-regions", protected...)
+	{
+		healthRegions.GET("/", h.healthRegion.GetHealthRegions)
+		healthRegions.GET("/:id", h.healthRegion.GetHealthRegionByID)
+		healthRegions.POST("/", middlewares.RequirePermission(entities.PermissionHealthRegionsManage), h.healthRegion.CreateHealthRegion)
+		healthRegions.PUT("/:id", middlewares.RequirePermission(entities.PermissionHealthRegionsManage), h.healthRegion.UpdateHealthRegion)
+		healthRegions.DELETE("/:id", middlewares.RequirePermission(entities.PermissionHealthRegionsManage), h.healthRegion.DeleteHealthRegion)
+		healthRegions.PUT("/:id/municipalities", middlewares.RequirePermission(entities.PermissionHealthRegionsManage), h.healthRegion.SetMunicipalities)
 	}
 
-	// Frontend API Routes (without /api/v1 prefix for frontend compatibility)
-	// These will be accessed via nginx proxy as /api/ -> backend:8080/
+	// Health units (CNES establishments)
+	healthUnits := api.Group("/health-units", protected...)
 	{
-		// Health check
-		r.GET("/health", func(c *gin.Context) {
-			c.JSON(200, gin.H{"status": "ok"})
-		})
+		healthUnits.GET("/", h.healthUnit.GetHealthUnits)
+		healthUnits.GET("/:id", h.healthUnit.GetHealthUnitByID)
+		healthUnits.POST("/", middlewares.RequirePermission(entities.PermissionHealthUnitsManage), h.healthUnit.CreateHealthUnit)
+		healthUnits.POST("/import", middlewares.RequirePermission(entities.PermissionHealthUnitsManage), h.healthUnit.ImportCNES)
+		healthUnits.PUT("/:id", middlewares.RequirePermission(entities.PermissionHealthUnitsManage), h.healthUnit.UpdateHealthUnit)
+		healthUnits.DELETE("/:id", middlewares.RequirePermission(entities.PermissionHealthUnitsManage), h.healthUnit.DeleteHealthUnit)
+	}
 
-		// Municipalities
-		municipalitiesRoot := r.Group("/municipalities")
-		{
-			municipalitiesRoot.GET("/", municipalityController.GetMunicipalities)
-		}
+	// Users
+	users := api.Group("/users", protected...)
+	{
+		users.GET("/", middlewares.RequirePermission(entities.PermissionUsersView), h.user.GetUsers)
+		users.PUT("/:id/scope", middlewares.RequirePermission(entities.PermissionUsersManageScope), h.user.SetScope)
+		users.POST("/:id/authorize", middlewares.RequirePermission(entities.PermissionUsersAuthorize), h.user.AuthorizeUser)
+		users.POST("/:id/reject", middlewares.RequirePermission(entities.PermissionUsersAuthorize), h.user.RejectUser)
+	}
 
-		// Payments
-		paymentsRoot := r.Group("/payments")
-		{
-			paymentsRoot.GET("/", paymentController.GetPaymentsPublic)
-			paymentsRoot.GET("/competences", paymentController.GetCompetencesPublic)
-			paymentsRoot.GET("/years", paymentController.GetYearsPublic)
-			paymentsRoot.POST("/upload", paymentController.UploadPaymentFile)
-		}
+	// Notifications of the current user
+	notifications := api.Group("/notifications", protected...)
+	{
+		notifications.GET("/", h.notification.GetNotifications)
+		notifications.GET("/unread-count", h.notification.GetUnreadCount)
+		notifications.POST("/read", h.notification.MarkRead)
+		notifications.POST("/read-all", h.notification.MarkAllRead)
+	}
 
-		// Resolutions
-		resolutionsRoot := r.Group("/resolutions")
-		{
-			resolutionsRoot.GET("/", resolutionController.GetResolutionsPublic)
-			resolutionsRoot.GET("/types", resolutionController.GetTypesPublic)
-			resolutionsRoot.GET("/years", resolutionController.GetYearsPublic)
-			resolutionsRoot.POST("/upload", resolutionController.UploadResolutionFile)
-		}
+	// Live notifications and record changes; EventSource cannot send headers, so the
+	// access token may come in the query string
+	liveEvents := api.Group("/events", append([]gin.HandlerFunc{middlewares.AccessTokenFromQuery()}, protected...)...)
+	{
+		liveEvents.GET("/stream", h.event.Stream)
+	}
 
-		// Professions
-		professionsRoot := r.Group("/professions")
-		{
-			professionsRoot.GET("/", professionController.GetProfessions)
-			professionsRoot.GET("/:id", professionController.GetProfessionByID)
-			professionsRoot.POST("/", professionController.CreateProfession)
-			professionsRoot.PUT("/:id", professionController.UpdateProfession)
-			professionsRoot.DELETE("/:id", professionController.DeleteProfession)
-		}
+	// Tablets
+	tablets := api.Group("/tablets", protected...)
+	tablets.Use(middlewares.RequirePermission(entities.PermissionTabletsView))
+	{
+		tablets.GET("/", h.tablet.GetTablets)
+		tablets.GET("/export", middlewares.RequirePermission(entities.PermissionTabletsExport), h.tablet.ExportTablets)
+		tablets.GET("/labels.pdf", h.tablet.GetTabletLabels)
+		tablets.POST("/import", middlewares.RequirePermission(entities.PermissionTabletsManage), h.tablet.ImportTablets)
+		tablets.GET("/allocation-plan", middlewares.RequirePermission(entities.PermissionTabletsAllocate), h.allocation.GetAllocationPlan)
+		tablets.POST("/allocation-plan/accept", middlewares.RequirePermission(entities.PermissionTabletsAllocate), h.allocation.AcceptAllocationPlan)
+		tablets.GET("/transfers", h.transfer.GetTransfers)
+		tablets.POST("/transfers/:transfer_id/approve", middlewares.RequirePermission(entities.PermissionTabletsTransferApprove), h.transfer.ApproveTransfer)
+		tablets.POST("/transfers/:transfer_id/reject", middlewares.RequirePermission(entities.PermissionTabletsTransferApprove), h.transfer.RejectTransfer)
+		tablets.POST("/transfers/:transfer_id/receive", middlewares.RequirePermission(entities.PermissionTabletsTransferReceive), h.transfer.ReceiveTransfer)
+		tablets.GET("/requests", h.tablet.GetTabletRequests)
+		tablets.POST("/requests", middlewares.RequirePermission(entities.PermissionTabletsRequest), h.tablet.RequestTablet)
+		tablets.POST("/requests/:request_id/photos", middlewares.RequirePermission(entities.PermissionTabletsRequest), h.tablet.AttachRequestPhoto)
+		tablets.POST("/requests/:request_id/document", middlewares.RequirePermission(entities.PermissionTabletsRequest), h.tablet.AttachRequestDocument)
+		tablets.POST("/requests/:request_id/approve", middlewares.RequirePermission(entities.PermissionTabletsApproveRequest), h.tablet.ApproveRequest)
+		tablets.POST("/requests/:request_id/reject", middlewares.RequirePermission(entities.PermissionTabletsApproveRequest), h.tablet.RejectRequest)
+		tablets.GET("/search-agent", h.tablet.SearchAgent)
+		tablets.GET("/:id", h.tablet.GetTabletByID)
+		tablets.GET("/:id/label.png", h.tablet.GetTabletLabel)
+		tablets.POST("/:id/transfers", middlewares.RequirePermission(entities.PermissionTabletsTransferPropose), h.transfer.ProposeTransfer)
 	}
 
-	// Temporary workaround: Add /api prefix routes directly in backend
-	apiGroup := r.Group("/api")
+	// Dashboard statistics
+	stats := api.Group("/stats", protected...)
 	{
-		apiGroup.GET("/health", func(c *gin.Context) {
-			c.JSON(200, gin.H{"status": "ok"})
-		})
+		stats.GET("/tablets", middlewares.RequirePermission(entities.PermissionTabletsStats), h.stats.GetTabletStats)
+	}
 
-		// Municipalities
-		apiMunicipalities := apiGroup.Group("/municipalities")
-		{
-			apiMunicipalities.GET("/", municipalityController.GetMunicipalities)
-		}
+	// Payments
+	payments := api.Group("/payments", protected...)
+	{
+		payments.GET("/", middlewares.RequirePermission(entities.PermissionPaymentsView), h.payment.GetPayments)
+		payments.GET("/competences", middlewares.RequirePermission(entities.PermissionPaymentsView), h.payment.GetCompetences)
+		payments.GET("/years", middlewares.RequirePermission(entities.PermissionPaymentsView), h.payment.GetYears)
+		payments.GET("/:id", middlewares.RequirePermission(entities.PermissionPaymentsView), h.payment.GetPaymentByID)
+		payments.GET("/:id/view", middlewares.RequirePermission(entities.PermissionPaymentsView), h.payment.ViewPDF)
+		payments.GET("/:id/download", middlewares.RequirePermission(entities.PermissionPaymentsView), h.payment.DownloadPDF)
+		payments.POST("/", middlewares.RequirePermission(entities.PermissionPaymentsUpload), h.payment.CreatePayment)
+		payments.POST("/upload", middlewares.RequirePermission(entities.PermissionPaymentsUpload), h.payment.UploadPaymentFile)
+		payments.PUT("/:id", middlewares.RequirePermission(entities.PermissionPaymentsUpload), h.payment.UpdatePayment)
+		payments.DELETE("/:id", middlewares.RequirePermission(entities.PermissionPaymentsDelete), h.payment.DeletePayment)
+	}
 
-		// Payments
-		apiPayments := apiGroup.Group("/payments")
-		{
-			apiPayments.GET("/", paymentController.GetPaymentsPublic)
-			apiPayments.GET("/competences", paymentController.GetCompetencesPublic)
-			apiPayments.GET("/years", paymentController.GetYearsPublic)
-			apiPayments.POST("/upload", paymentController.UploadPaymentFile)
-		}
+	// Resolutions
+	resolutions := api.Group("/resolutions", protected...)
+	{
+		resolutions.GET("/", middlewares.RequirePermission(entities.PermissionResolutionsView), h.resolution.GetResolutions)
+		resolutions.GET("/types", middlewares.RequirePermission(entities.PermissionResolutionsView), h.resolution.GetTypes)
+		resolutions.GET("/years", middlewares.RequirePermission(entities.PermissionResolutionsView), h.resolution.GetYears)
+		resolutions.GET("/recent", middlewares.RequirePermission(entities.PermissionResolutionsView), h.resolution.GetRecentResolutions)
+		resolutions.GET("/:id", middlewares.RequirePermission(entities.PermissionResolutionsView), h.resolution.GetResolutionByID)
+		resolutions.GET("/:id/view", middlewares.RequirePermission(entities.PermissionResolutionsView), h.resolution.ViewPDF)
+		resolutions.GET("/:id/download", middlewares.RequirePermission(entities.PermissionResolutionsView), h.resolution.DownloadPDF)
+		resolutions.POST("/", middlewares.RequirePermission(entities.PermissionResolutionsUpload), h.resolution.CreateResolution)
+		resolutions.POST("/upload", middlewares.RequirePermission(entities.PermissionResolutionsUpload), h.resolution.UploadResolutionFile)
+		resolutions.PUT("/:id", middlewares.RequirePermission(entities.PermissionResolutionsUpload), h.resolution.UpdateResolution)
+		resolutions.DELETE("/:id", middlewares.RequirePermission(entities.PermissionResolutionsDelete), h.resolution.DeleteResolution)
+	}
 
-		// Resolutions
-		apiResolutions := apiGroup.Group("/resolutions")
-		{
-			apiResolutions.GET("/", resolutionController.GetResolutionsPublic)
-			apiResolutions.GET("/types", resolutionController.GetTypesPublic)
-			apiResolutions.GET("/years", resolutionController.GetYearsPublic)
-			apiResolutions.POST("/upload", resolutionController.UploadResolutionFile)
-		}
+	// Professions
+	professions := api.Group("/professions")
+	{
+		professions.GET("/", h.profession.GetProfessions)
+		professions.GET("/:id", h.profession.GetProfessionByID)
 
-		// Professions
-		apiProfessions := apiGroup.Group("/professions")
+		manage := professions.Group("", protected...)
+		manage.Use(middlewares.RequirePermission(entities.PermissionProfessionsManage))
 		{
-			apiProfessions.GET("/", professionController.GetProfessions)
-			apiProfessions.GET("/:id", professionController.GetProfessionByID)
-			apiProfessions.POST("/", professionController.CreateProfession)
-			apiProfessions.PUT("/:id", professionController.UpdateProfession)
-			apiProfessions.DELETE("/:id", professionController.DeleteProfession)
+			manage.POST("/", h.profession.CreateProfession)
+			manage.PUT("/:id", h.profession.UpdateProfession)
+			manage.DELETE("/:id", h.profession.DeleteProfession)
 		}
 	}
 
-	return r
+	// Roles and permissions
+	roles := api.Group("/roles", protected...)
+	{
+		roles.GET("/", middlewares.RequirePermission(entities.PermissionRolesView), h.role.GetRoles)
+		roles.GET("/:id/permissions", middlewares.RequirePermission(entities.PermissionRolesView), h.role.GetRolePermissions)
+		roles.PUT("/:id/permissions", middlewares.RequirePermission(entities.PermissionRolesManage), h.role.SetRolePermissions)
+	}
+
+	permissions := api.Group("/permissions", protected...)
+	{
+		permissions.GET("/", middlewares.RequirePermission(entities.PermissionRolesView), h.role.GetPermissions)
+	}
+
+	// Webhooks for municipal systems
+	webhooks := api.Group("/webhooks", protected...)
+	webhooks.Use(middlewares.RequirePermission(entities.PermissionWebhooksManage))
+	{
+		webhooks.GET("/", h.webhook.GetWebhooks)
+		webhooks.POST("/", h.webhook.CreateWebhook)
+		webhooks.GET("/:id", h.webhook.GetWebhook)
+		webhooks.PUT("/:id", h.webhook.UpdateWebhook)
+		webhooks.DELETE("/:id", h.webhook.DeleteWebhook)
+		webhooks.POST("/:id/rotate-secret", h.webhook.RotateSecret)
+		webhooks.POST("/:id/ping", h.webhook.PingWebhook)
+		webhooks.GET("/:id/deliveries", h.webhook.GetDeliveries)
+		webhooks.POST("/:id/deliveries/:delivery_id/replay", h.webhook.ReplayDelivery)
+	}
 }
@@ -0,0 +1,316 @@
+package ibge
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joaopanucci/apsdigital/internal/domain/apperrors"
+	"github.com/joaopanucci/apsdigital/internal/domain/entities"
+)
+
+const (
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+)
+
+// maxDownloadSize bounds the dataset fetched from a URL; the full country list is about 4 MB
+const maxDownloadSize = 64 << 20
+
+// LocalityLoader reads municipalities in the formats published by the IBGE localities API
+// (servicodados.ibge.gov.br/api/v1/localidades/municipios), nested or with view=nivelado,
+// and in CSV with the nivelado column names.
+type LocalityLoader struct {
+	client *http.Client
+}
+
+func NewLocalityLoader() *LocalityLoader {
+	return &LocalityLoader{client: &http.Client{Timeout: time.Minute}}
+}
+
+// Load reads the dataset from a file path or an http(s) URL
+func (l *LocalityLoader) Load(ctx context.Context, source string) ([]entities.IBGEMunicipality, error) {
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		return l.fetch(ctx, source)
+	}
+
+	file, err := os.Open(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", source, err)
+	}
+	defer file.Close()
+
+	return l.Parse(file, FormatFromName(source))
+}
+
+// Parse decodes a dataset. An empty format is detected from the content.
+func (l *LocalityLoader) Parse(r io.Reader, format string) ([]entities.IBGEMunicipality, error) {
+	reader := bufio.NewReader(r)
+
+	if format == "" {
+		format = sniffFormat(reader)
+	}
+
+	switch format {
+	case FormatJSON:
+		return parseJSON(reader)
+	case FormatCSV:
+		return parseCSV(reader)
+	default:
+		return nil, apperrors.InvalidFile("file_format_unsupported", format)
+	}
+}
+
+// FormatFromName guesses the format from a file name or URL, or returns "" to sniff the content
+func FormatFromName(name string) string {
+	switch strings.ToLower(filepath.Ext(strings.SplitN(name, "?", 2)[0])) {
+	case ".json":
+		return FormatJSON
+	case ".csv":
+		return FormatCSV
+	}
+	return ""
+}
+
+func (l *LocalityLoader) fetch(ctx context.Context, url string) ([]entities.IBGEMunicipality, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json, text/csv")
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: status %d", url, resp.StatusCode)
+	}
+
+	format := FormatFromName(url)
+	if format == "" {
+		contentType := resp.Header.Get("Content-Type")
+		switch {
+		case strings.Contains(contentType, "json"):
+			format = FormatJSON
+		case strings.Contains(contentType, "csv"):
+			format = FormatCSV
+		}
+	}
+
+	return l.Parse(io.LimitReader(resp.Body, maxDownloadSize), format)
+}
+
+func sniffFormat(r *bufio.Reader) string {
+	for {
+		b, err := r.Peek(1)
+		if err != nil {
+			return FormatCSV
+		}
+
+		switch b[0] {
+		case ' ', '\t', '\r', '\n':
+			r.ReadByte()
+		case 0xEF: // UTF-8 BOM
+			if bom, err := r.Peek(3); err == nil && bytes.Equal(bom, []byte{0xEF, 0xBB, 0xBF}) {
+				r.Discard(3)
+				continue
+			}
+			return FormatCSV
+		case '[', '{':
+			return FormatJSON
+		default:
+			return FormatCSV
+		}
+	}
+}
+
+// nestedMunicipality is the default API representation
+type nestedMunicipality struct {
+	ID           json.Number `json:"id"`
+	Nome         string      `json:"nome"`
+	Microrregiao *struct {
+		Nome        string `json:"nome"`
+		Mesorregiao struct {
+			UF ufRef `json:"UF"`
+		} `json:"mesorregiao"`
+	} `json:"microrregiao"`
+	RegiaoImediata *struct {
+		RegiaoIntermediaria struct {
+			UF ufRef `json:"UF"`
+		} `json:"regiao-intermediaria"`
+	} `json:"regiao-imediata"`
+}
+
+type ufRef struct {
+	Sigla string `json:"sigla"`
+}
+
+// flatMunicipality is the view=nivelado representation
+type flatMunicipality struct {
+	ID               json.Number `json:"municipio-id"`
+	Nome             string      `json:"municipio-nome"`
+	MicrorregiaoNome string      `json:"microrregiao-nome"`
+	UFSigla          string      `json:"UF-sigla"`
+}
+
+func parseJSON(r io.Reader) ([]entities.IBGEMunicipality, error) {
+	var raw []json.RawMessage
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, apperrors.InvalidFile("file_ibge_json").Wrap(err)
+	}
+
+	municipalities := make([]entities.IBGEMunicipality, 0, len(raw))
+	for i, item := range raw {
+		var m entities.IBGEMunicipality
+
+		if bytes.Contains(item, []byte(`"municipio-id"`)) {
+			var flat flatMunicipality
+			if err := json.Unmarshal(item, &flat); err != nil {
+				return nil, apperrors.InvalidFile("file_ibge_item_unreadable", i).Wrap(err)
+			}
+			m = entities.IBGEMunicipality{
+				IBGECode:    flat.ID.String(),
+				Name:        flat.Nome,
+				State:       flat.UFSigla,
+				Microregion: flat.MicrorregiaoNome,
+			}
+		} else {
+			var nested nestedMunicipality
+			if err := json.Unmarshal(item, &nested); err != nil {
+				return nil, apperrors.InvalidFile("file_ibge_item_unreadable", i).Wrap(err)
+			}
+			m = entities.IBGEMunicipality{IBGECode: nested.ID.String(), Name: nested.Nome}
+
+			// Municipalities created after 2017 have no microrregião; take the UF from the newer division
+			if nested.Microrregiao != nil {
+				m.Microregion = nested.Microrregiao.Nome
+				m.State = nested.Microrregiao.Mesorregiao.UF.Sigla
+			}
+			if m.State == "" && nested.RegiaoImediata != nil {
+				m.State = nested.RegiaoImediata.RegiaoIntermediaria.UF.Sigla
+			}
+		}
+
+		if err := normalize(&m); err != nil {
+			return nil, apperrors.InvalidFile("file_ibge_item_invalid", i, err)
+		}
+		municipalities = append(municipalities, m)
+	}
+
+	return municipalities, nil
+}
+
+// csvColumns maps the accepted header names to fields
+var csvColumns = map[string]string{
+	"municipio-id":      "code",
+	"codigo_municipio":  "code",
+	"ibge_code":         "code",
+	"municipio-nome":    "name",
+	"nome_municipio":    "name",
+	"name":              "name",
+	"uf-sigla":          "state",
+	"sigla_uf":          "state",
+	"uf":                "state",
+	"state":             "state",
+	"microrregiao-nome": "microregion",
+	"nome_microrregiao": "microregion",
+	"microregion":       "microregion",
+}
+
+func parseCSV(r *bufio.Reader) ([]entities.IBGEMunicipality, error) {
+	// Brazilian spreadsheets usually export with ';'
+	reader := csv.NewReader(r)
+	head, _ := r.Peek(4096)
+	line := string(head)
+	if i := strings.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+	if strings.Count(line, ";") > strings.Count(line, ",") {
+		reader.Comma = ';'
+	}
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, apperrors.InvalidFile("file_csv_header").Wrap(err)
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if field, ok := csvColumns[name]; ok {
+			columns[field] = i
+		}
+	}
+	for _, field := range []string{"code", "name", "state"} {
+		if _, ok := columns[field]; !ok {
+			return nil, apperrors.InvalidFile("file_csv_column_missing", field)
+		}
+	}
+
+	value := func(record []string, field string) string {
+		i, ok := columns[field]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return record[i]
+	}
+
+	var municipalities []entities.IBGEMunicipality
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, apperrors.InvalidFile("file_csv_line_unreadable", line).Wrap(err)
+		}
+
+		m := entities.IBGEMunicipality{
+			IBGECode:    value(record, "code"),
+			Name:        value(record, "name"),
+			State:       value(record, "state"),
+			Microregion: value(record, "microregion"),
+		}
+		if err := normalize(&m); err != nil {
+			return nil, apperrors.InvalidFile("file_csv_line_invalid", line, err)
+		}
+		municipalities = append(municipalities, m)
+	}
+
+	return municipalities, nil
+}
+
+func normalize(m *entities.IBGEMunicipality) error {
+	m.IBGECode = strings.TrimSpace(m.IBGECode)
+	m.Name = strings.TrimSpace(m.Name)
+	m.State = strings.ToUpper(strings.TrimSpace(m.State))
+	m.Microregion = strings.TrimSpace(m.Microregion)
+
+	if _, err := strconv.Atoi(m.IBGECode); err != nil || len(m.IBGECode) != 7 {
+		return apperrors.InvalidFile("file_ibge_code_invalid", m.IBGECode)
+	}
+	if m.Name == "" {
+		return apperrors.InvalidFile("file_municipality_name", m.IBGECode)
+	}
+	if len(m.State) != 2 {
+		return apperrors.InvalidFile("file_municipality_state", m.IBGECode, m.State)
+	}
+
+	return nil
+}
@@ -0,0 +1,25 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/joaopanucci/apsdigital/internal/domain/entities"
+	"github.com/joaopanucci/apsdigital/internal/domain/repositories"
+)
+
+// LiveEventCleanup deletes live events older than retention. Clients can only resume
+// streams within the retention.
+func LiveEventCleanup(liveEventRepo repositories.LiveEventRepository, retention time.Duration) Handler {
+	return func(ctx context.Context, job *entities.Job) error {
+		deleted, err := liveEventRepo.DeleteBefore(ctx, time.Now().Add(-retention))
+		if err != nil {
+			return err
+		}
+		if deleted > 0 {
+			slog.InfoContext(ctx, "Removed old live events", "count", deleted)
+		}
+		return nil
+	}
+}
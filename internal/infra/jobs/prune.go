@@ -0,0 +1,32 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/joaopanucci/apsdigital/internal/domain/entities"
+	"github.com/joaopanucci/apsdigital/internal/domain/repositories"
+)
+
+// Prune deletes finished jobs: succeeded ones after a week, failed ones after a month so
+// there is time to look into them
+func Prune(jobRepo repositories.JobRepository) Handler {
+	return func(ctx context.Context, job *entities.Job) error {
+		retention := map[entities.JobStatus]time.Duration{
+			entities.JobSucceeded: 7 * 24 * time.Hour,
+			entities.JobFailed:    30 * 24 * time.Hour,
+		}
+
+		for status, age := range retention {
+			deleted, err := jobRepo.DeleteFinishedBefore(ctx, status, time.Now().Add(-age))
+			if err != nil {
+				return err
+			}
+			if deleted > 0 {
+				slog.InfoContext(ctx, "Removed finished jobs", "count", deleted, "status", status)
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,23 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/joaopanucci/apsdigital/internal/domain/entities"
+	"github.com/joaopanucci/apsdigital/internal/domain/repositories"
+)
+
+// RefreshTokenCleanup deletes expired refresh tokens
+func RefreshTokenCleanup(refreshTokenRepo repositories.RefreshTokenRepository) Handler {
+	return func(ctx context.Context, job *entities.Job) error {
+		deleted, err := refreshTokenRepo.CleanupExpired(ctx)
+		if err != nil {
+			return err
+		}
+		if deleted > 0 {
+			slog.InfoContext(ctx, "Removed expired refresh tokens", "count", deleted)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,281 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/joaopanucci/apsdigital/internal/domain/entities"
+	"github.com/joaopanucci/apsdigital/internal/domain/repositories"
+	"github.com/joaopanucci/apsdigital/internal/infra/logging"
+	"github.com/joaopanucci/apsdigital/internal/utils"
+
+	"github.com/robfig/cron/v3"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/joaopanucci/apsdigital/internal/infra/jobs")
+
+// Handler runs one job. A returned error, or a panic, schedules a retry until the job
+// is out of attempts.
+type Handler func(ctx context.Context, job *entities.Job) error
+
+// Options tune a Runner; zero values take the defaults
+type Options struct {
+	// Workers is how many jobs run at once
+	Workers int
+	// PollInterval is how long an idle worker waits before looking for jobs again
+	PollInterval time.Duration
+	// Timeout bounds a single run; the job's lock lasts a minute longer
+	Timeout time.Duration
+	// ShutdownTimeout is how long Run waits for running jobs once its context is cancelled
+	ShutdownTimeout time.Duration
+}
+
+const (
+	// Failed jobs are retried after 10s, doubling each time up to an hour
+	firstRetryDelay = 10 * time.Second
+	maxRetryDelay   = time.Hour
+)
+
+type schedule struct {
+	jobType  string
+	schedule cron.Schedule
+}
+
+// Runner executes the jobs of the queue with the registered handlers and queues the
+// scheduled ones. Every replica may run one.
+type Runner struct {
+	jobRepo   repositories.JobRepository
+	opts      Options
+	handlers  map[string]Handler
+	schedules []schedule
+}
+
+func NewRunner(jobRepo repositories.JobRepository, opts Options) *Runner {
+	if opts.Workers <= 0 {
+		opts.Workers = 4
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = time.Second
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Minute
+	}
+	if opts.ShutdownTimeout <= 0 {
+		opts.ShutdownTimeout = 30 * time.Second
+	}
+
+	return &Runner{
+		jobRepo:  jobRepo,
+		opts:     opts,
+		handlers: make(map[string]Handler),
+	}
+}
+
+// Register sets the handler of a job type; register every handler before Run
+func (r *Runner) Register(jobType string, handler Handler) {
+	r.handlers[jobType] = handler
+}
+
+// Handle registers a handler that receives the job payload decoded into T
+func Handle[T any](r *Runner, jobType string, fn func(ctx context.Context, payload T) error) {
+	r.Register(jobType, func(ctx context.Context, job *entities.Job) error {
+		var payload T
+		if err := json.Unmarshal(job.Payload, &payload); err != nil {
+			return fmt.Errorf("invalid %s payload: %w", jobType, err)
+		}
+		return fn(ctx, payload)
+	})
+}
+
+// Schedule queues a job of jobType on a cron spec: five fields, a descriptor such as
+// "@daily", or "@every 1h". Each run is queued once however many replicas schedule it.
+func (r *Runner) Schedule(spec string, jobType string) error {
+	parsed, err := cron.ParseStandard(spec)
+	if err != nil {
+		return fmt.Errorf("invalid schedule %q for %s: %w", spec, jobType, err)
+	}
+
+	r.schedules = append(r.schedules, schedule{jobType: jobType, schedule: parsed})
+	return nil
+}
+
+// Run works until ctx is cancelled, then waits up to ShutdownTimeout for running jobs
+// before cancelling them too
+func (r *Runner) Run(ctx context.Context) {
+	// Jobs keep their context for the grace period after ctx is cancelled
+	workCtx, cancelWork := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelWork()
+
+	types := make([]string, 0, len(r.handlers))
+	for jobType := range r.handlers {
+		types = append(types, jobType)
+	}
+	sort.Strings(types)
+
+	var wg sync.WaitGroup
+	for i := 0; i < r.opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.work(ctx, workCtx, types)
+		}()
+	}
+
+	if len(r.schedules) > 0 {
+		go r.schedule(ctx)
+	}
+
+	<-ctx.Done()
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(r.opts.ShutdownTimeout):
+		slog.Warn("Jobs still running at shutdown, cancelling them", "timeout", r.opts.ShutdownTimeout)
+		cancelWork()
+		<-done
+	}
+}
+
+func (r *Runner) work(ctx, workCtx context.Context, types []string) {
+	for ctx.Err() == nil {
+		claimed, err := r.jobRepo.Claim(ctx, types, 1, r.opts.Timeout+time.Minute)
+		if err != nil && ctx.Err() == nil {
+			slog.WarnContext(ctx, "Failed to claim jobs", "error", err)
+		}
+
+		if len(claimed) == 0 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(r.opts.PollInterval):
+			}
+			continue
+		}
+
+		for _, job := range claimed {
+			r.run(workCtx, job)
+		}
+	}
+}
+
+func (r *Runner) run(ctx context.Context, job *entities.Job) {
+	ctx = logging.With(ctx, "job_id", job.ID, "job_type", job.Type)
+	ctx, span := tracer.Start(ctx, "job "+job.Type, trace.WithAttributes(
+		attribute.Int64("job.id", job.ID),
+		attribute.Int("job.attempt", job.Attempts),
+	))
+	defer span.End()
+
+	err := r.call(ctx, job)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	// Record the outcome even when shutdown cancelled the job
+	saveCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+
+	if err == nil {
+		if err := r.jobRepo.Complete(saveCtx, job.ID); err != nil {
+			slog.WarnContext(ctx, "Failed to record job outcome", "error", err)
+		}
+		return
+	}
+
+	// Interrupted by shutdown, not failed; another worker picks it up right away and
+	// deploys don't use up its attempts
+	if ctx.Err() != nil {
+		slog.InfoContext(ctx, "Job interrupted by shutdown", "attempt", job.Attempts, "error", err)
+		if err := r.jobRepo.Release(saveCtx, job.ID, err.Error()); err != nil {
+			slog.WarnContext(ctx, "Failed to record job outcome", "error", err)
+		}
+		return
+	}
+
+	var retryAt *time.Time
+	if job.Attempts < job.MaxAttempts {
+		next := time.Now().Add(utils.Backoff(job.Attempts, firstRetryDelay, maxRetryDelay))
+		retryAt = &next
+	}
+	slog.WarnContext(ctx, "Job failed", "attempt", job.Attempts, "max_attempts", job.MaxAttempts, "error", err)
+
+	if err := r.jobRepo.Fail(saveCtx, job.ID, err.Error(), retryAt); err != nil {
+		slog.WarnContext(ctx, "Failed to record job outcome", "error", err)
+	}
+}
+
+func (r *Runner) call(ctx context.Context, job *entities.Job) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("panic: %v", recovered)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, r.opts.Timeout)
+	defer cancel()
+
+	return r.handlers[job.Type](ctx, job)
+}
+
+// schedule queues each scheduled job when it is due. The unique key names the run, so
+// other replicas queueing the same run are ignored.
+func (r *Runner) schedule(ctx context.Context) {
+	next := make([]time.Time, len(r.schedules))
+	now := time.Now()
+	for i, s := range r.schedules {
+		next[i] = nextRun(s.schedule, now)
+	}
+
+	for {
+		soonest := 0
+		for i := range next {
+			if next[i].Before(next[soonest]) {
+				soonest = i
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(next[soonest])):
+		}
+
+		s := r.schedules[soonest]
+		job := &entities.Job{
+			Type:        s.jobType,
+			MaxAttempts: 1,
+			RunAt:       next[soonest],
+			UniqueKey:   fmt.Sprintf("cron:%s:%d", s.jobType, next[soonest].Unix()),
+		}
+		if _, err := r.jobRepo.Enqueue(ctx, job); err != nil {
+			slog.WarnContext(ctx, "Failed to queue scheduled job", "job_type", s.jobType, "error", err)
+		}
+
+		next[soonest] = nextRun(s.schedule, next[soonest])
+	}
+}
+
+// nextRun is when s runs after t. "@every" schedules would count from each replica's start,
+// so they are aligned to multiples of their interval instead; every replica then names
+// the same run with the same unique key.
+func nextRun(s cron.Schedule, t time.Time) time.Time {
+	if every, ok := s.(cron.ConstantDelaySchedule); ok {
+		return t.Truncate(every.Delay).Add(every.Delay)
+	}
+	return s.Next(t)
+}
@@ -0,0 +1,56 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+func TestNextRun(t *testing.T) {
+	base := time.Date(2024, 5, 10, 14, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		spec string
+		from time.Time
+		want time.Time
+	}{
+		{
+			name: "every is aligned to its interval",
+			spec: "@every 6h",
+			from: base.Add(time.Hour + 17*time.Second),
+			want: base.Add(4 * time.Hour),
+		},
+		{
+			name: "every from a run time is the next run",
+			spec: "@every 6h",
+			from: base.Add(4 * time.Hour),
+			want: base.Add(10 * time.Hour),
+		},
+		{
+			name: "replicas started apart agree",
+			spec: "@every 6h",
+			from: base.Add(3*time.Hour + 59*time.Minute),
+			want: base.Add(4 * time.Hour),
+		},
+		{
+			name: "cron specs keep their own times",
+			spec: "@hourly",
+			from: base.Add(30 * time.Minute),
+			want: base.Add(time.Hour),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := cron.ParseStandard(tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := nextRun(schedule, tt.from); !got.Equal(tt.want) {
+				t.Errorf("nextRun(%q, %v) = %v, want %v", tt.spec, tt.from, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,324 @@
+package jwtkeys
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+// Key is a signing key loaded from the key directory. The file name (without .pem) is its kid.
+type Key struct {
+	ID        string
+	Algorithm string
+	Private   crypto.Signer
+	CreatedAt time.Time
+}
+
+// Options configures a Manager
+type Options struct {
+	Algorithm string
+	// Secret is only used with HS256
+	Secret string
+	// Dir holds one PKCS#8 PEM private key per file
+	Dir string
+	// RotationInterval is the age after which a new signing key is generated; zero disables rotation
+	RotationInterval time.Duration
+	// TokenLifetime keeps retired keys published long enough to verify tokens they signed
+	TokenLifetime time.Duration
+}
+
+// Manager signs access tokens with the newest key and verifies them with any published key
+type Manager struct {
+	opts Options
+
+	mu   sync.RWMutex
+	keys []*Key // newest first
+}
+
+func NewManager(opts Options) (*Manager, error) {
+	m := &Manager{opts: opts}
+
+	switch opts.Algorithm {
+	case AlgorithmHS256:
+		if opts.Secret == "" {
+			return nil, fmt.Errorf("JWT secret is required for %s", AlgorithmHS256)
+		}
+		return m, nil
+	case AlgorithmRS256, AlgorithmEdDSA:
+	default:
+		return nil, fmt.Errorf("unsupported JWT algorithm %q", opts.Algorithm)
+	}
+
+	if err := os.MkdirAll(opts.Dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create key directory: %w", err)
+	}
+
+	if err := m.Reload(); err != nil {
+		return nil, err
+	}
+
+	// Bootstrap the first key so a fresh deployment can issue tokens
+	if m.SigningKey() == nil {
+		if _, err := m.Rotate(); err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+// Algorithm returns the algorithm used for new tokens
+func (m *Manager) Algorithm() string {
+	return m.opts.Algorithm
+}
+
+// Reload re-reads the key directory, picking up keys added by operators or other replicas
+func (m *Manager) Reload() error {
+	entries, err := os.ReadDir(m.opts.Dir)
+	if err != nil {
+		return fmt.Errorf("failed to read key directory: %w", err)
+	}
+
+	var keys []*Key
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".pem" {
+			continue
+		}
+
+		key, err := loadKey(filepath.Join(m.opts.Dir, entry.Name()))
+		if err != nil {
+			slog.Warn("Skipping JWT key", "file", entry.Name(), "error", err)
+			continue
+		}
+
+		if m.retired(key) {
+			continue
+		}
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.After(keys[j].CreatedAt)
+	})
+
+	m.mu.Lock()
+	m.keys = keys
+	m.mu.Unlock()
+
+	return nil
+}
+
+// Rotate generates a new signing key, writes it to the key directory and makes it active
+func (m *Manager) Rotate() (*Key, error) {
+	var signer crypto.Signer
+	var err error
+
+	switch m.opts.Algorithm {
+	case AlgorithmRS256:
+		signer, err = rsa.GenerateKey(rand.Reader, 2048)
+	case AlgorithmEdDSA:
+		_, signer, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, fmt.Errorf("key rotation is not supported for %s", m.opts.Algorithm)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(signer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode key: %w", err)
+	}
+
+	now := time.Now()
+	kid := fmt.Sprintf("%s-%s", strings.ToLower(m.opts.Algorithm), now.UTC().Format("20060102T150405"))
+	path := filepath.Join(m.opts.Dir, kid+".pem")
+
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		return nil, fmt.Errorf("failed to write key: %w", err)
+	}
+
+	if err := m.Reload(); err != nil {
+		return nil, err
+	}
+
+	slog.Info("Generated JWT signing key", "kid", kid)
+
+	return m.SigningKey(), nil
+}
+
+// Start reloads the key directory periodically and rotates the signing key when it gets too old
+func (m *Manager) Start(ctx context.Context, checkInterval time.Duration) {
+	if m.opts.Algorithm == AlgorithmHS256 {
+		return
+	}
+
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.Reload(); err != nil {
+				slog.WarnContext(ctx, "Failed to reload JWT keys", "error", err)
+				continue
+			}
+
+			if m.rotationDue() {
+				if _, err := m.Rotate(); err != nil {
+					slog.WarnContext(ctx, "Failed to rotate JWT signing key", "error", err)
+				}
+			}
+		}
+	}
+}
+
+// SigningKey returns the newest key usable with the configured algorithm
+func (m *Manager) SigningKey() *Key {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, key := range m.keys {
+		if key.Algorithm == m.opts.Algorithm {
+			return key
+		}
+	}
+	return nil
+}
+
+// Sign creates a signed JWT for the given claims, with the key ID in the header
+func (m *Manager) Sign(claims jwt.Claims) (string, error) {
+	if m.opts.Algorithm == AlgorithmHS256 {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(m.opts.Secret))
+	}
+
+	key := m.SigningKey()
+	if key == nil {
+		return "", fmt.Errorf("no signing key available")
+	}
+
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(key.Algorithm), claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.Private)
+}
+
+// Keyfunc resolves the verification key for a token from its kid header
+func (m *Manager) Keyfunc(token *jwt.Token) (interface{}, error) {
+	if m.opts.Algorithm == AlgorithmHS256 {
+		return []byte(m.opts.Secret), nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, fmt.Errorf("token has no key ID")
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, key := range m.keys {
+		if key.ID == kid {
+			if token.Method.Alg() != key.Algorithm {
+				return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+			}
+			return key.Private.Public(), nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown key ID %q", kid)
+}
+
+// ValidMethods lists the algorithms accepted when parsing tokens
+func (m *Manager) ValidMethods() []string {
+	if m.opts.Algorithm == AlgorithmHS256 {
+		return []string{AlgorithmHS256}
+	}
+	return []string{AlgorithmRS256, AlgorithmEdDSA}
+}
+
+// PublicKeys returns the keys currently published for verification
+func (m *Manager) PublicKeys() []*Key {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	keys := make([]*Key, len(m.keys))
+	copy(keys, m.keys)
+	return keys
+}
+
+func (m *Manager) rotationDue() bool {
+	if m.opts.RotationInterval <= 0 {
+		return false
+	}
+
+	key := m.SigningKey()
+	return key == nil || time.Since(key.CreatedAt) >= m.opts.RotationInterval
+}
+
+// retired reports whether a key is too old to have signed any token that is still valid
+func (m *Manager) retired(key *Key) bool {
+	if m.opts.RotationInterval <= 0 {
+		return false
+	}
+	return time.Since(key.CreatedAt) > m.opts.RotationInterval+m.opts.TokenLifetime
+}
+
+func loadKey(path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found")
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse PKCS#8 key: %w", err)
+	}
+
+	key := &Key{ID: strings.TrimSuffix(filepath.Base(path), ".pem")}
+
+	switch private := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Algorithm = AlgorithmRS256
+		key.Private = private
+	case ed25519.PrivateKey:
+		key.Algorithm = AlgorithmEdDSA
+		key.Private = private
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	key.CreatedAt = info.ModTime()
+
+	return key, nil
+}
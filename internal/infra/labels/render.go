@@ -0,0 +1,132 @@
+// Package labels renders the QR code labels stuck on tablets, as a single PNG or as
+// sheets of A4 labels in a PDF.
+package labels
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/skip2/go-qrcode"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// Label is what is printed for one tablet
+type Label struct {
+	AssetCode    string
+	Municipality string
+	URL          string
+}
+
+const (
+	pngQRSize   = 320
+	pngTextArea = 84
+)
+
+// PNG renders a label as the QR code with the asset code and municipality below it
+func PNG(w io.Writer, label Label) error {
+	qr, err := qrcode.New(label.URL, qrcode.Medium)
+	if err != nil {
+		return fmt.Errorf("error encoding QR code: %w", err)
+	}
+
+	canvas := image.NewRGBA(image.Rect(0, 0, pngQRSize, pngQRSize+pngTextArea))
+	draw.Draw(canvas, canvas.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(canvas, image.Rect(0, 0, pngQRSize, pngQRSize), qr.Image(pngQRSize), image.Point{}, draw.Src)
+
+	bold, err := newFace(gobold.TTF, 26)
+	if err != nil {
+		return err
+	}
+	regular, err := newFace(goregular.TTF, 18)
+	if err != nil {
+		return err
+	}
+
+	drawCentered(canvas, bold, label.AssetCode, pngQRSize+30)
+	drawCentered(canvas, regular, label.Municipality, pngQRSize+64)
+
+	return png.Encode(w, canvas)
+}
+
+func newFace(ttf []byte, size float64) (font.Face, error) {
+	parsed, err := opentype.Parse(ttf)
+	if err != nil {
+		return nil, fmt.Errorf("error loading label font: %w", err)
+	}
+	return opentype.NewFace(parsed, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+}
+
+// drawCentered writes text horizontally centered on the baseline y
+func drawCentered(dst draw.Image, face font.Face, text string, y int) {
+	d := &font.Drawer{Dst: dst, Src: image.NewUniform(color.Black), Face: face}
+	width := d.MeasureString(text).Ceil()
+	d.Dot = fixed.P((dst.Bounds().Dx()-width)/2, y)
+	d.DrawString(text)
+}
+
+// A4 sheet of 3 x 7 labels of 63.5 x 38.1 mm, the common adhesive label layout
+const (
+	sheetColumns = 3
+	sheetRows    = 7
+	labelWidth   = 63.5
+	labelHeight  = 38.1
+	marginLeft   = 7.2
+	marginTop    = 15.1
+	columnGap    = 2.5
+	labelPadding = 2.5
+	pdfQRSize    = labelHeight - 2*labelPadding
+)
+
+// PDF lays the labels out on A4 label sheets
+func PDF(w io.Writer, labels []Label) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(0, 0, 0)
+	pdf.SetAutoPageBreak(false, 0)
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	perPage := sheetColumns * sheetRows
+	for i, label := range labels {
+		if i%perPage == 0 {
+			pdf.AddPage()
+		}
+
+		slot := i % perPage
+		x := marginLeft + float64(slot%sheetColumns)*(labelWidth+columnGap)
+		y := marginTop + float64(slot/sheetColumns)*labelHeight
+
+		qr, err := qrcode.Encode(label.URL, qrcode.Medium, 256)
+		if err != nil {
+			return fmt.Errorf("error encoding QR code: %w", err)
+		}
+		name := fmt.Sprintf("qr-%d", i)
+		pdf.RegisterImageOptionsReader(name, fpdf.ImageOptions{ImageType: "PNG"}, bytes.NewReader(qr))
+		pdf.ImageOptions(name, x+labelPadding, y+labelPadding, pdfQRSize, pdfQRSize, false, fpdf.ImageOptions{ImageType: "PNG"}, 0, "")
+
+		textX := x + labelPadding + pdfQRSize + 1
+		textWidth := labelWidth - pdfQRSize - 2*labelPadding - 1
+
+		pdf.SetXY(textX, y+labelPadding+6)
+		pdf.SetFont("Helvetica", "B", 11)
+		pdf.MultiCell(textWidth, 5, tr(label.AssetCode), "", "L", false)
+
+		pdf.SetX(textX)
+		pdf.SetFont("Helvetica", "", 8)
+		pdf.MultiCell(textWidth, 4, tr(label.Municipality), "", "L", false)
+	}
+
+	if len(labels) == 0 {
+		pdf.AddPage()
+	}
+
+	return pdf.Output(w)
+}
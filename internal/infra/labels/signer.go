@@ -0,0 +1,65 @@
+package labels
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/url"
+	"strings"
+
+	"github.com/joaopanucci/apsdigital/internal/domain/apperrors"
+)
+
+// signatureSize is the number of HMAC bytes kept in the URL, enough to make guessing
+// a valid code impractical while keeping the QR code small
+const signatureSize = 12
+
+// AssetURLSigner builds and verifies the signed URLs printed on tablet labels, so a
+// scanned code is known to come from one of our labels and not from a typed asset code
+type AssetURLSigner struct {
+	baseURL string
+	secret  []byte
+}
+
+func NewAssetURLSigner(baseURL, secret string) *AssetURLSigner {
+	return &AssetURLSigner{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		secret:  []byte(secret),
+	}
+}
+
+// URL returns the signed asset URL of a tablet, {base}/t/{asset code}?s={signature}
+func (s *AssetURLSigner) URL(assetCode string) string {
+	return s.baseURL + "/t/" + url.PathEscape(assetCode) + "?s=" + s.sign(assetCode)
+}
+
+// Verify checks a scanned payload and returns the asset code it carries. Only the path
+// and signature are checked, so labels keep working if the base URL changes.
+func (s *AssetURLSigner) Verify(payload string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(payload))
+	if err != nil {
+		return "", apperrors.Invalid("qr", "qr_invalid")
+	}
+
+	escaped := u.EscapedPath()
+	i := strings.LastIndex(escaped, "/t/")
+	if i < 0 {
+		return "", apperrors.Invalid("qr", "qr_invalid")
+	}
+	assetCode, err := url.PathUnescape(escaped[i+len("/t/"):])
+	if err != nil || assetCode == "" {
+		return "", apperrors.Invalid("qr", "qr_invalid")
+	}
+
+	if !hmac.Equal([]byte(u.Query().Get("s")), []byte(s.sign(assetCode))) {
+		return "", apperrors.Invalid("qr", "qr_signature_invalid")
+	}
+
+	return assetCode, nil
+}
+
+func (s *AssetURLSigner) sign(assetCode string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(assetCode))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:signatureSize])
+}
@@ -0,0 +1,99 @@
+// Package logging sets up structured logging. Records carry the attributes stored in
+// their context, such as the request ID and user, and the trace IDs of the current span;
+// sensitive values are redacted.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// New returns a logger writing JSON, or text when format is "text", at the given level
+// ("debug", "info", "warn" or "error")
+func New(w io.Writer, format, level string) *slog.Logger {
+	opts := &slog.HandlerOptions{
+		Level:       parseLevel(level),
+		ReplaceAttr: redactAttr,
+	}
+
+	var handler slog.Handler
+	if format == "text" {
+		handler = slog.NewTextHandler(w, opts)
+	} else {
+		handler = slog.NewJSONHandler(w, opts)
+	}
+
+	return slog.New(&contextHandler{Handler: handler})
+}
+
+func parseLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+type attrsKey struct{}
+
+// With returns a context whose log records also carry args, given as to slog.Logger.With
+func With(ctx context.Context, args ...any) context.Context {
+	record := slog.Record{}
+	record.Add(args...)
+
+	attrs := append([]slog.Attr(nil), contextAttrs(ctx)...)
+	record.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+
+	return context.WithValue(ctx, attrsKey{}, attrs)
+}
+
+func contextAttrs(ctx context.Context) []slog.Attr {
+	if ctx == nil {
+		return nil
+	}
+	attrs, _ := ctx.Value(attrsKey{}).([]slog.Attr)
+	return attrs
+}
+
+// contextHandler adds the context's attributes and trace IDs to each record
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	attrs := contextAttrs(ctx)
+	if ctx != nil {
+		if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+			attrs = append(attrs[:len(attrs):len(attrs)],
+				slog.String("trace_id", span.TraceID().String()),
+				slog.String("span_id", span.SpanID().String()),
+			)
+		}
+	}
+
+	if len(attrs) > 0 {
+		record = record.Clone()
+		record.AddAttrs(attrs...)
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
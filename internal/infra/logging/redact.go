@@ -0,0 +1,51 @@
+package logging
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces sensitive values
+const Redacted = "[REDACTED]"
+
+// sensitiveKeys redact any attribute whose key contains one of them
+var sensitiveKeys = []string{"cpf", "password", "senha", "token", "secret", "authorization", "cookie"}
+
+var (
+	cpfPattern = regexp.MustCompile(`\b\d{3}\.?\d{3}\.?\d{3}-?\d{2}\b`)
+	jwtPattern = regexp.MustCompile(`eyJ[\w-]+\.[\w-]+\.[\w-]+`)
+)
+
+// IsSensitive reports whether values under key must not be logged
+func IsSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(key, sensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+// Mask hides CPFs and tokens inside free text such as messages and errors
+func Mask(s string) string {
+	s = cpfPattern.ReplaceAllString(s, "***.***.***-**")
+	return jwtPattern.ReplaceAllString(s, Redacted)
+}
+
+func redactAttr(groups []string, a slog.Attr) slog.Attr {
+	if IsSensitive(a.Key) {
+		return slog.String(a.Key, Redacted)
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, Mask(a.Value.String()))
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			return slog.String(a.Key, Mask(err.Error()))
+		}
+	}
+	return a
+}
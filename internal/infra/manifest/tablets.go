@@ -0,0 +1,109 @@
+package manifest
+
+import (
+	"bufio"
+	"encoding/csv"
+	"io"
+	"strings"
+
+	"github.com/joaopanucci/apsdigital/internal/domain/apperrors"
+	"github.com/joaopanucci/apsdigital/internal/domain/entities"
+)
+
+// columns maps the accepted header names of a supplier delivery manifest to fields
+var columns = map[string]string{
+	"serial":           "serial",
+	"serial_number":    "serial",
+	"numero_serie":     "serial",
+	"número de série":  "serial",
+	"numero de serie":  "serial",
+	"model":            "model",
+	"modelo":           "model",
+	"asset_code":       "asset_code",
+	"patrimonio":       "asset_code",
+	"patrimônio":       "asset_code",
+	"municipality":     "municipality",
+	"municipio":        "municipality",
+	"município":        "municipality",
+	"ibge_code":        "municipality",
+	"codigo_municipio": "municipality",
+}
+
+// Parser reads tablet delivery manifests in CSV
+type Parser struct{}
+
+func NewParser() *Parser {
+	return &Parser{}
+}
+
+// Parse decodes a CSV with a header row, separated by ';' or ','. Only the layout is
+// checked here; the values of each row are validated by the import.
+func (p *Parser) Parse(r io.Reader) ([]entities.TabletManifestRow, error) {
+	buffered := bufio.NewReader(r)
+	head, _ := buffered.Peek(4096)
+	line := string(head)
+	if i := strings.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+
+	reader := csv.NewReader(buffered)
+	if strings.Count(line, ";") > strings.Count(line, ",") {
+		reader.Comma = ';'
+	}
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, apperrors.InvalidFile("file_csv_header").Wrap(err)
+	}
+
+	index := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if field, ok := columns[name]; ok {
+			if _, seen := index[field]; !seen {
+				index[field] = i
+			}
+		}
+	}
+	for _, field := range []string{"serial", "municipality"} {
+		if _, ok := index[field]; !ok {
+			return nil, apperrors.InvalidFile("file_csv_column_missing", field)
+		}
+	}
+
+	value := func(record []string, field string) string {
+		i, ok := index[field]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var rows []entities.TabletManifestRow
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, apperrors.InvalidFile("file_csv_line_unreadable", line).Wrap(err)
+		}
+
+		row := entities.TabletManifestRow{
+			Line:         line,
+			SerialNumber: value(record, "serial"),
+			Model:        value(record, "model"),
+			AssetCode:    value(record, "asset_code"),
+			Municipality: value(record, "municipality"),
+		}
+		// Spreadsheets leave fully blank lines at the end
+		if row.SerialNumber == "" && row.Model == "" && row.AssetCode == "" && row.Municipality == "" {
+			continue
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
@@ -0,0 +1,100 @@
+// Package metrics exposes Prometheus metrics: HTTP traffic, the database pool, uploads,
+// logins and counts of work waiting for someone.
+package metrics
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "apsdigital"
+
+// Registry holds the application metrics along with the Go runtime and process ones
+var Registry = prometheus.NewRegistry()
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+var (
+	// HTTPRequests counts answered requests by route template, so path IDs don't
+	// multiply the series
+	HTTPRequests = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests answered, by method, route template and status.",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = promauto.With(Registry).NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Time to answer HTTP requests, by method, route template and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	// UploadSize also counts uploads, through its _count series
+	UploadSize = promauto.With(Registry).NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "upload_size_bytes",
+		Help:      "Size of uploaded files, by kind.",
+		Buckets:   prometheus.ExponentialBuckets(64<<10, 4, 6),
+	}, []string{"kind"})
+
+	Logins = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "logins_total",
+		Help:      "Login attempts, by result (success or failure).",
+	}, []string{"result"})
+)
+
+// Upload kinds
+const (
+	UploadPayment               = "payment"
+	UploadResolution            = "resolution"
+	UploadTabletRequestPhoto    = "tablet_request_photo"
+	UploadTabletRequestDocument = "tablet_request_document"
+	UploadTabletImport          = "tablet_import"
+	UploadHealthUnitImport      = "health_unit_import"
+	UploadMunicipalityImport    = "municipality_import"
+)
+
+// ObserveUpload records a received file
+func ObserveUpload(kind string, size int64) {
+	UploadSize.WithLabelValues(kind).Observe(float64(size))
+}
+
+// ObserveLogin records a login attempt
+func ObserveLogin(err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	Logins.WithLabelValues(result).Inc()
+}
+
+// Handler serves the registry. When token is set, scrapers must send it as a bearer token.
+func Handler(token string) http.Handler {
+	handler := promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+	if token == "" {
+		return handler
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/joaopanucci/apsdigital/internal/domain/repositories"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const pendingTimeout = 5 * time.Second
+
+// pendingCollector counts the work waiting for a decision on each scrape
+type pendingCollector struct {
+	userRepo    repositories.UserRepository
+	requestRepo repositories.TabletRequestRepository
+
+	authorizations *prometheus.Desc
+	tabletRequests *prometheus.Desc
+}
+
+// NewPendingCollector collects the users awaiting authorization and the pending tablet requests
+func NewPendingCollector(userRepo repositories.UserRepository, requestRepo repositories.TabletRequestRepository) prometheus.Collector {
+	return &pendingCollector{
+		userRepo:    userRepo,
+		requestRepo: requestRepo,
+		authorizations: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "pending_authorizations"),
+			"Users awaiting authorization.", nil, nil),
+		tabletRequests: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "pending_tablet_requests"),
+			"Tablet requests awaiting a decision.", nil, nil),
+	}
+}
+
+func (c *pendingCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.authorizations
+	ch <- c.tabletRequests
+}
+
+// Collect leaves out the counts it fails to read, so the rest of the scrape still succeeds
+func (c *pendingCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), pendingTimeout)
+	defer cancel()
+
+	c.collect(ctx, ch, c.authorizations, c.userRepo.CountPendingAuthorization)
+	c.collect(ctx, ch, c.tabletRequests, c.requestRepo.CountPending)
+}
+
+func (c *pendingCollector) collect(ctx context.Context, ch chan<- prometheus.Metric, desc *prometheus.Desc, count func(context.Context) (int, error)) {
+	n, err := count(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to collect metric", "error", err)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, float64(n))
+}
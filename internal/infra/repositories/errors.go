@@ -0,0 +1,70 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/joaopanucci/apsdigital/internal/domain/apperrors"
+	"github.com/joaopanucci/apsdigital/internal/domain/entities"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// uniqueViolations names the conflict behind each unique constraint
+var uniqueViolations = map[string]*apperrors.Error{
+	"users_email_key":                     entities.ErrEmailTaken,
+	"users_cpf_key":                       entities.ErrCPFTaken,
+	"users_cpf_unique":                    entities.ErrCPFTaken,
+	"tablets_asset_code_key":              duplicate("asset_code", "asset_code_taken", "asset_code_taken"),
+	"idx_tablets_serial_number_unique":    duplicate("serial_number", "serial_number_taken", "serial_number_taken"),
+	"municipalities_ibge_code_key":        duplicate("ibge_code", "ibge_code_taken", "ibge_code_taken"),
+	"idx_municipalities_ibge_code_unique": duplicate("ibge_code", "ibge_code_taken", "ibge_code_taken"),
+	"health_units_cnes_key":               duplicate("cnes", "cnes_taken", "cnes_taken"),
+	"health_regions_state_type_name_key":  duplicate("name", "name_taken", "health_region_name_taken"),
+	"professions_name_key":                entities.ErrProfessionNameTaken,
+	"roles_name_key":                      duplicate("name", "name_taken", "role_name_taken"),
+	"idx_tablet_transfers_open":           entities.ErrTransferState,
+}
+
+// duplicate is the conflict on field, worded by the catalog message key
+func duplicate(field, code, key string, args ...any) *apperrors.Error {
+	return apperrors.Conflict(code).WithMessage(key, args...).
+		WithFields(apperrors.FieldError{Field: field, Code: "taken", Key: key, Args: args})
+}
+
+// dbError translates database errors into domain errors: a missing row becomes notFound
+// (a generic not found error when nil) and constraint violations become conflicts or
+// validation errors. Other errors are returned as they are.
+func dbError(err error, notFound *apperrors.Error) error {
+	if errors.Is(err, pgx.ErrNoRows) || errors.Is(err, sql.ErrNoRows) {
+		if notFound == nil {
+			notFound = apperrors.ErrNotFound
+		}
+		return notFound.Wrap(err)
+	}
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+
+	switch pgErr.Code {
+	case "23505": // unique_violation
+		if conflict, ok := uniqueViolations[pgErr.ConstraintName]; ok {
+			return conflict.Wrap(err)
+		}
+		return apperrors.ErrAlreadyExists.Wrap(err)
+	case "23503": // foreign_key_violation
+		// Deleting a referenced row, or pointing at a row that doesn't exist
+		if strings.HasPrefix(pgErr.Message, "update or delete") {
+			return apperrors.ErrInUse.Wrap(err)
+		}
+		return apperrors.Validation("invalid_reference").Wrap(err)
+	case "23502", "23514", "22001", "22003", "22007", "22008", "22P02": // not null, check, value too long or malformed
+		return apperrors.Validation("invalid_value").Wrap(err)
+	}
+
+	return err
+}
@@ -0,0 +1,309 @@
+package repositories
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/joaopanucci/apsdigital/internal/domain/entities"
+	"github.com/joaopanucci/apsdigital/internal/infra/db"
+)
+
+// whereCase is the clause and arguments a filter should produce
+type whereCase[F any] struct {
+	name   string
+	filter F
+	sql    string
+	args   []interface{}
+}
+
+func testWhere[F any](t *testing.T, build func(F) *db.Where, tests []whereCase[F]) {
+	t.Helper()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			where := build(tt.filter)
+			if got := where.SQL(); got != tt.sql {
+				t.Errorf("SQL() = %q, want %q", got, tt.sql)
+			}
+			if got := where.Args(); !reflect.DeepEqual(got, tt.args) {
+				t.Errorf("Args() = %#v, want %#v", got, tt.args)
+			}
+		})
+	}
+}
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func TestUserWhere(t *testing.T) {
+	roleID := uuid.MustParse("6f1c2b9e-0d2a-4c55-9a43-3f3a1b7d9e10")
+	testWhere(t, userWhere, []whereCase[entities.UserFilter]{
+		{name: "no filters", sql: ""},
+		{
+			name:   "scope",
+			filter: entities.UserFilter{MunicipalityIDs: []int{1, 2}},
+			sql:    " WHERE u.municipality_id = ANY($1)",
+			args:   []interface{}{[]int{1, 2}},
+		},
+		{
+			name:   "empty scope matches nothing",
+			filter: entities.UserFilter{MunicipalityIDs: []int{}},
+			sql:    " WHERE u.municipality_id = ANY($1)",
+			args:   []interface{}{[]int{}},
+		},
+		{
+			name: "every filter",
+			filter: entities.UserFilter{
+				Municipality:    "Campo Grande",
+				MunicipalityID:  intPtr(3),
+				MunicipalityIDs: []int{3},
+				Status:          entities.UserStatusActive,
+				RoleID:          &roleID,
+				ProfessionID:    intPtr(4),
+				Search:          "maria",
+			},
+			sql: " WHERE u.municipality = $1 AND u.municipality_id = $2 AND u.municipality_id = ANY($3)" +
+				" AND u.status = $4 AND u.role_id = $5 AND u.profession_id = $6" +
+				" AND (u.name ILIKE $7 OR u.email ILIKE $8 OR u.cpf = $9)",
+			args: []interface{}{"Campo Grande", intPtr(3), []int{3}, entities.UserStatusActive, &roleID, intPtr(4), "%maria%", "%maria%", "maria"},
+		},
+	})
+}
+
+func TestTabletWhere(t *testing.T) {
+	testWhere(t, tabletWhere, []whereCase[entities.TabletFilter]{
+		{name: "no filters", sql: ""},
+		{
+			name:   "scope",
+			filter: entities.TabletFilter{MunicipalityIDs: []int{5}},
+			sql:    " WHERE t.municipality_id = ANY($1)",
+			args:   []interface{}{[]int{5}},
+		},
+		{
+			name: "every filter",
+			filter: entities.TabletFilter{
+				SerialNumber:    "SN1",
+				Status:          entities.TabletStatusAvailable,
+				Model:           "Galaxy",
+				MunicipalityID:  intPtr(5),
+				MunicipalityIDs: []int{5, 6},
+			},
+			sql: " WHERE t.serial_number = $1 AND t.status = $2 AND t.model ILIKE $3" +
+				" AND t.municipality_id = $4 AND t.municipality_id = ANY($5)",
+			args: []interface{}{"SN1", entities.TabletStatusAvailable, "%Galaxy%", intPtr(5), []int{5, 6}},
+		},
+	})
+}
+
+func TestPaymentWhere(t *testing.T) {
+	testWhere(t, paymentWhere, []whereCase[entities.PaymentFilter]{
+		{name: "no filters", sql: ""},
+		{
+			name:   "scope",
+			filter: entities.PaymentFilter{MunicipalityIDs: []int{1}},
+			sql:    " WHERE p.municipality_id = ANY($1)",
+			args:   []interface{}{[]int{1}},
+		},
+		{
+			name:   "period",
+			filter: entities.PaymentFilter{Year: 2024, Month: 3},
+			sql:    " WHERE EXTRACT(YEAR FROM p.created_at) = $1 AND EXTRACT(MONTH FROM p.created_at) = $2",
+			args:   []interface{}{2024, 3},
+		},
+		{
+			name: "every filter",
+			filter: entities.PaymentFilter{
+				MunicipalityID:  intPtr(1),
+				MunicipalityIDs: []int{1, 2},
+				Year:            2024,
+				Month:           3,
+				Competence:      "03/2024",
+			},
+			sql: " WHERE p.municipality_id = $1 AND p.municipality_id = ANY($2)" +
+				" AND EXTRACT(YEAR FROM p.created_at) = $3 AND EXTRACT(MONTH FROM p.created_at) = $4" +
+				" AND p.competence = $5",
+			args: []interface{}{intPtr(1), []int{1, 2}, 2024, 3, "03/2024"},
+		},
+	})
+}
+
+func TestResolutionWhere(t *testing.T) {
+	testWhere(t, resolutionWhere, []whereCase[entities.ResolutionFilter]{
+		{name: "no filters", sql: ""},
+		{
+			name:   "scope",
+			filter: entities.ResolutionFilter{MunicipalityIDs: []int{7}},
+			sql:    " WHERE r.municipality_id = ANY($1)",
+			args:   []interface{}{[]int{7}},
+		},
+		{
+			name: "every filter",
+			filter: entities.ResolutionFilter{
+				MunicipalityID:  intPtr(7),
+				MunicipalityIDs: []int{7},
+				Year:            2023,
+				Type:            entities.ResolutionTypeSES,
+				Number:          "12",
+				Competence:      "01/2023",
+			},
+			sql: " WHERE r.municipality_id = $1 AND r.municipality_id = ANY($2) AND r.year = $3" +
+				" AND r.type = $4 AND r.number ILIKE $5 AND r.competence = $6",
+			args: []interface{}{intPtr(7), []int{7}, 2023, entities.ResolutionTypeSES, "%12%", "01/2023"},
+		},
+	})
+}
+
+func TestHealthUnitWhere(t *testing.T) {
+	active := true
+	testWhere(t, healthUnitWhere, []whereCase[entities.HealthUnitFilter]{
+		{name: "no filters", sql: ""},
+		{
+			name:   "scope",
+			filter: entities.HealthUnitFilter{MunicipalityIDs: []int{2}},
+			sql:    " WHERE municipality_id = ANY($1)",
+			args:   []interface{}{[]int{2}},
+		},
+		{
+			name: "every filter",
+			filter: entities.HealthUnitFilter{
+				MunicipalityID:  intPtr(2),
+				MunicipalityIDs: []int{2},
+				Type:            "UBS",
+				Active:          &active,
+				Search:          "1234567",
+			},
+			sql: " WHERE municipality_id = $1 AND municipality_id = ANY($2) AND type ILIKE $3" +
+				" AND active = $4 AND (name ILIKE $5 OR cnes = $6)",
+			args: []interface{}{intPtr(2), []int{2}, "%UBS%", &active, "%1234567%", "1234567"},
+		},
+	})
+}
+
+func TestMunicipalityWhere(t *testing.T) {
+	testWhere(t, municipalityWhere, []whereCase[entities.MunicipalityFilter]{
+		{name: "active only", sql: " WHERE active = true"},
+		{
+			name:   "state is upper-cased",
+			filter: entities.MunicipalityFilter{State: "ms"},
+			sql:    " WHERE active = true AND state = $1",
+			args:   []interface{}{"MS"},
+		},
+		{
+			name:   "every filter",
+			filter: entities.MunicipalityFilter{State: "MS", HealthRegionID: intPtr(9), Search: "5002704"},
+			sql:    " WHERE active = true AND state = $1 AND health_region_id = $2 AND (name ILIKE $3 OR ibge_code = $4)",
+			args:   []interface{}{"MS", intPtr(9), "%5002704%", "5002704"},
+		},
+	})
+}
+
+func TestProfessionWhere(t *testing.T) {
+	testWhere(t, professionWhere, []whereCase[entities.ProfessionFilter]{
+		{name: "no filters", sql: ""},
+		{
+			name:   "search",
+			filter: entities.ProfessionFilter{Search: "agente"},
+			sql:    " WHERE name ILIKE $1",
+			args:   []interface{}{"%agente%"},
+		},
+	})
+}
+
+func TestTabletTransferWhere(t *testing.T) {
+	testWhere(t, tabletTransferWhere, []whereCase[entities.TabletTransferFilter]{
+		{name: "no filters", sql: ""},
+		{
+			name:   "scope matches either end",
+			filter: entities.TabletTransferFilter{MunicipalityIDs: []int{1, 2}},
+			sql:    " WHERE (tr.from_municipality_id = ANY($1) OR tr.to_municipality_id = ANY($2))",
+			args:   []interface{}{[]int{1, 2}, []int{1, 2}},
+		},
+		{
+			name: "every filter",
+			filter: entities.TabletTransferFilter{
+				Status:          entities.TabletTransferPending,
+				TabletID:        intPtr(10),
+				MunicipalityIDs: []int{1},
+			},
+			sql:  " WHERE tr.status = $1 AND tr.tablet_id = $2 AND (tr.from_municipality_id = ANY($3) OR tr.to_municipality_id = ANY($4))",
+			args: []interface{}{entities.TabletTransferPending, intPtr(10), []int{1}, []int{1}},
+		},
+	})
+}
+
+func TestTabletRequestWhere(t *testing.T) {
+	userID := uuid.MustParse("9a7b6c5d-4e3f-4a1b-8c2d-0e1f2a3b4c5d")
+	testWhere(t, tabletRequestWhere, []whereCase[entities.TabletRequestFilter]{
+		{name: "no filters", sql: ""},
+		{
+			name:   "scope is the requester's municipality",
+			filter: entities.TabletRequestFilter{MunicipalityIDs: []int{3}},
+			sql:    " WHERE u.municipality_id = ANY($1)",
+			args:   []interface{}{[]int{3}},
+		},
+		{
+			name: "every filter",
+			filter: entities.TabletRequestFilter{
+				Status:          entities.TabletRequestStatusPending,
+				Type:            entities.TabletRequestTypeTheft,
+				UserID:          &userID,
+				MunicipalityIDs: []int{3, 4},
+			},
+			sql:  " WHERE tr.status = $1 AND tr.type = $2 AND tr.user_id = $3 AND u.municipality_id = ANY($4)",
+			args: []interface{}{entities.TabletRequestStatusPending, entities.TabletRequestTypeTheft, &userID, []int{3, 4}},
+		},
+	})
+}
+
+func TestNotificationWhere(t *testing.T) {
+	userID := uuid.MustParse("0b8e4f5a-2c1d-4e6f-8a9b-1c2d3e4f5a6b")
+	testWhere(t, notificationWhere, []whereCase[entities.NotificationFilter]{
+		{
+			name:   "user",
+			filter: entities.NotificationFilter{UserID: userID},
+			sql:    " WHERE n.user_id = $1",
+			args:   []interface{}{userID},
+		},
+		{
+			name:   "unread only",
+			filter: entities.NotificationFilter{UserID: userID, UnreadOnly: true},
+			sql:    " WHERE n.user_id = $1 AND n.read_at IS NULL",
+			args:   []interface{}{userID},
+		},
+	})
+}
+
+func TestWebhookWhere(t *testing.T) {
+	testWhere(t, webhookWhere, []whereCase[entities.WebhookFilter]{
+		{name: "no filters", sql: ""},
+		{
+			name:   "every filter",
+			filter: entities.WebhookFilter{MunicipalityID: intPtr(4), MunicipalityIDs: []int{4}},
+			sql:    " WHERE s.municipality_id = $1 AND s.municipality_id = ANY($2)",
+			args:   []interface{}{intPtr(4), []int{4}},
+		},
+	})
+}
+
+func TestWebhookDeliveryWhere(t *testing.T) {
+	subscriptionID := uuid.MustParse("3d9f7a1e-5b2c-4f80-9e6d-7a8b9c0d1e2f")
+	testWhere(t, webhookDeliveryWhere, []whereCase[entities.WebhookDeliveryFilter]{
+		{
+			name:   "subscription",
+			filter: entities.WebhookDeliveryFilter{SubscriptionID: subscriptionID},
+			sql:    " WHERE d.subscription_id = $1",
+			args:   []interface{}{subscriptionID},
+		},
+		{
+			name: "every filter",
+			filter: entities.WebhookDeliveryFilter{
+				SubscriptionID: subscriptionID,
+				Status:         entities.WebhookDeliveryFailed,
+				EventType:      "tablet.stolen",
+			},
+			sql:  " WHERE d.subscription_id = $1 AND d.status = $2 AND d.event_type = $3",
+			args: []interface{}{subscriptionID, entities.WebhookDeliveryFailed, "tablet.stolen"},
+		},
+	})
+}
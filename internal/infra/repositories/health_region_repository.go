@@ -0,0 +1,199 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/joaopanucci/apsdigital/internal/domain/apperrors"
+	"github.com/joaopanucci/apsdigital/internal/domain/entities"
+	"github.com/joaopanucci/apsdigital/internal/infra/db"
+)
+
+type healthRegionRepository struct {
+	db *db.PostgresDB
+}
+
+func NewHealthRegionRepository(db *db.PostgresDB) *healthRegionRepository {
+	return &healthRegionRepository{db: db}
+}
+
+func (r *healthRegionRepository) Create(ctx context.Context, region *entities.HealthRegion) error {
+	query := `
+		INSERT INTO health_regions (name, type, parent_id, state, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, NOW(), NOW())
+		RETURNING id, created_at, updated_at
+	`
+
+	err := r.db.Conn(ctx).QueryRow(ctx, query,
+		region.Name,
+		region.Type,
+		region.ParentID,
+		region.State,
+	).Scan(&region.ID, &region.CreatedAt, &region.UpdatedAt)
+
+	if err != nil {
+		return fmt.Errorf("error creating health region: %w", dbError(err, nil))
+	}
+
+	return nil
+}
+
+func (r *healthRegionRepository) GetByID(ctx context.Context, id int) (*entities.HealthRegion, error) {
+	query := `
+		SELECT id, name, type, parent_id, state, created_at, updated_at
+		FROM health_regions
+		WHERE id = $1
+	`
+
+	region := &entities.HealthRegion{}
+	err := r.db.Conn(ctx).QueryRow(ctx, query, id).Scan(
+		&region.ID,
+		&region.Name,
+		&region.Type,
+		&region.ParentID,
+		&region.State,
+		&region.CreatedAt,
+		&region.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("error getting health region: %w", dbError(err, entities.ErrHealthRegionNotFound))
+	}
+
+	return region, nil
+}
+
+func (r *healthRegionRepository) List(ctx context.Context) ([]*entities.HealthRegion, error) {
+	query := `
+		SELECT hr.id, hr.name, hr.type, hr.parent_id, hr.state, hr.created_at, hr.updated_at,
+		       COALESCE(array_agg(m.id ORDER BY m.id) FILTER (WHERE m.id IS NOT NULL), '{}')
+		FROM health_regions hr
+		LEFT JOIN municipalities m ON m.health_region_id = hr.id
+		GROUP BY hr.id
+		ORDER BY hr.state, hr.type, hr.name
+	`
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("error listing health regions: %w", err)
+	}
+	defer rows.Close()
+
+	var regions []*entities.HealthRegion
+	for rows.Next() {
+		region := &entities.HealthRegion{}
+		err := rows.Scan(
+			&region.ID,
+			&region.Name,
+			&region.Type,
+			&region.ParentID,
+			&region.State,
+			&region.CreatedAt,
+			&region.UpdatedAt,
+			&region.MunicipalityIDs,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning health region: %w", err)
+		}
+		regions = append(regions, region)
+	}
+
+	return regions, rows.Err()
+}
+
+func (r *healthRegionRepository) Update(ctx context.Context, region *entities.HealthRegion) error {
+	query := `
+		UPDATE health_regions
+		SET name = $2, type = $3, parent_id = $4, state = $5, updated_at = NOW()
+		WHERE id = $1
+	`
+
+	cmdTag, err := r.db.Conn(ctx).Exec(ctx, query,
+		region.ID,
+		region.Name,
+		region.Type,
+		region.ParentID,
+		region.State,
+	)
+
+	if err != nil {
+		return fmt.Errorf("error updating health region: %w", dbError(err, entities.ErrHealthRegionNotFound))
+	}
+
+	if cmdTag.RowsAffected() == 0 {
+		return entities.ErrHealthRegionNotFound
+	}
+
+	return nil
+}
+
+func (r *healthRegionRepository) Delete(ctx context.Context, id int) error {
+	query := `DELETE FROM health_regions WHERE id = $1`
+
+	cmdTag, err := r.db.Conn(ctx).Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("error deleting health region: %w", dbError(err, entities.ErrHealthRegionNotFound))
+	}
+
+	if cmdTag.RowsAffected() == 0 {
+		return entities.ErrHealthRegionNotFound
+	}
+
+	return nil
+}
+
+func (r *healthRegionRepository) ListMunicipalityIDs(ctx context.Context, regionID int) ([]int, error) {
+	query := `
+		WITH RECURSIVE region_tree AS (
+			SELECT id FROM health_regions WHERE id = $1
+			UNION
+			SELECT hr.id FROM health_regions hr JOIN region_tree rt ON hr.parent_id = rt.id
+		)
+		SELECT m.id
+		FROM municipalities m
+		JOIN region_tree rt ON m.health_region_id = rt.id
+		ORDER BY m.id
+	`
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, regionID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing region municipalities: %w", err)
+	}
+	defer rows.Close()
+
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("error scanning municipality: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// SetMunicipalities makes the given municipalities the direct members of the region
+func (r *healthRegionRepository) SetMunicipalities(ctx context.Context, regionID int, municipalityIDs []int) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return dbError(err, nil)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `UPDATE municipalities SET health_region_id = NULL, updated_at = NOW() WHERE health_region_id = $1`, regionID)
+	if err != nil {
+		return fmt.Errorf("error clearing region municipalities: %w", dbError(err, nil))
+	}
+
+	cmdTag, err := tx.Exec(ctx, `UPDATE municipalities SET health_region_id = $1, updated_at = NOW() WHERE id = ANY($2)`, regionID, municipalityIDs)
+	if err != nil {
+		return fmt.Errorf("error assigning region municipalities: %w", dbError(err, nil))
+	}
+
+	if cmdTag.RowsAffected() != int64(len(municipalityIDs)) {
+		return apperrors.Invalid("municipality_ids", "municipality_unknown")
+	}
+
+	return tx.Commit(ctx)
+}
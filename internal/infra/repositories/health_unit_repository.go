@@ -0,0 +1,234 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/joaopanucci/apsdigital/internal/domain/entities"
+	"github.com/joaopanucci/apsdigital/internal/infra/db"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type healthUnitRepository struct {
+	db *db.PostgresDB
+}
+
+func NewHealthUnitRepository(db *db.PostgresDB) *healthUnitRepository {
+	return &healthUnitRepository{db: db}
+}
+
+func (r *healthUnitRepository) Create(ctx context.Context, unit *entities.HealthUnit) error {
+	query := `
+		INSERT INTO health_units (cnes, name, type, municipality_id, active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NOW(), NOW())
+		RETURNING id, created_at, updated_at
+	`
+
+	err := r.db.Conn(ctx).QueryRow(ctx, query,
+		unit.CNES,
+		unit.Name,
+		unit.Type,
+		unit.MunicipalityID,
+		unit.Active,
+	).Scan(&unit.ID, &unit.CreatedAt, &unit.UpdatedAt)
+
+	if err != nil {
+		return fmt.Errorf("error creating health unit: %w", dbError(err, nil))
+	}
+
+	return nil
+}
+
+func (r *healthUnitRepository) GetByID(ctx context.Context, id int) (*entities.HealthUnit, error) {
+	query := `
+		SELECT id, cnes, name, type, municipality_id, active, created_at, updated_at
+		FROM health_units
+		WHERE id = $1
+	`
+
+	unit := &entities.HealthUnit{}
+	err := r.db.Conn(ctx).QueryRow(ctx, query, id).Scan(
+		&unit.ID,
+		&unit.CNES,
+		&unit.Name,
+		&unit.Type,
+		&unit.MunicipalityID,
+		&unit.Active,
+		&unit.CreatedAt,
+		&unit.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("error getting health unit: %w", dbError(err, entities.ErrHealthUnitNotFound))
+	}
+
+	return unit, nil
+}
+
+// healthUnitSortColumns are the fields health units can be sorted by
+var healthUnitSortColumns = map[string]string{
+	"name":       "name",
+	"cnes":       "cnes",
+	"type":       "type",
+	"created_at": "created_at",
+}
+
+// healthUnitWhere builds the conditions of a health unit list
+func healthUnitWhere(f entities.HealthUnitFilter) *db.Where {
+	where := &db.Where{}
+	where.AddIf(f.MunicipalityID != nil, "municipality_id = ?", f.MunicipalityID)
+	// Restrict to the municipalities of the user's scope
+	where.AddIf(f.MunicipalityIDs != nil, "municipality_id = ANY(?)", f.MunicipalityIDs)
+	where.AddIf(f.Type != "", "type ILIKE ?", "%"+f.Type+"%")
+	where.AddIf(f.Active != nil, "active = ?", f.Active)
+	where.AddIf(f.Search != "", "(name ILIKE ? OR cnes = ?)", "%"+f.Search+"%", f.Search)
+	return where
+}
+
+// List returns a page of health units and the number of units matching the filters
+func (r *healthUnitRepository) List(ctx context.Context, q entities.ListQuery[entities.HealthUnitFilter]) (*entities.Page[*entities.HealthUnit], error) {
+	where := healthUnitWhere(q.Filters)
+
+	var total int
+	if err := r.db.Conn(ctx).QueryRow(ctx, "SELECT COUNT(*) FROM health_units"+where.SQL(), where.Args()...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("error counting health units: %w", err)
+	}
+
+	order, err := orderBy(q.Sort, healthUnitSortColumns, "name ASC", "id")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := keyset(where, order, q); err != nil {
+		return nil, err
+	}
+
+	query, args := paginate("SELECT "+order.Key()+", id, cnes, name, type, municipality_id, active, created_at, updated_at FROM health_units"+where.SQL()+order.SQL(), where.Args(), q)
+
+	rows, err := queryPage(ctx, r.db.Conn(ctx), query, args)
+	if err != nil {
+		return nil, fmt.Errorf("error listing health units: %w", err)
+	}
+	defer rows.Close()
+
+	var units []*entities.HealthUnit
+	for rows.Next() {
+		unit := &entities.HealthUnit{}
+		err := rows.Scan(
+			&unit.ID,
+			&unit.CNES,
+			&unit.Name,
+			&unit.Type,
+			&unit.MunicipalityID,
+			&unit.Active,
+			&unit.CreatedAt,
+			&unit.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning health unit: %w", err)
+		}
+		units = append(units, unit)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return listPage(units, total, q, rows), nil
+}
+
+func (r *healthUnitRepository) Update(ctx context.Context, unit *entities.HealthUnit) error {
+	query := `
+		UPDATE health_units
+		SET cnes = $2, name = $3, type = $4, municipality_id = $5, active = $6, updated_at = NOW()
+		WHERE id = $1
+		RETURNING created_at, updated_at
+	`
+
+	err := r.db.Conn(ctx).QueryRow(ctx, query,
+		unit.ID,
+		unit.CNES,
+		unit.Name,
+		unit.Type,
+		unit.MunicipalityID,
+		unit.Active,
+	).Scan(&unit.CreatedAt, &unit.UpdatedAt)
+
+	if err != nil {
+		return fmt.Errorf("error updating health unit: %w", dbError(err, entities.ErrHealthUnitNotFound))
+	}
+
+	return nil
+}
+
+func (r *healthUnitRepository) Delete(ctx context.Context, id int) error {
+	query := `DELETE FROM health_units WHERE id = $1`
+
+	cmdTag, err := r.db.Conn(ctx).Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("error deleting health unit: %w", dbError(err, entities.ErrHealthUnitNotFound))
+	}
+
+	if cmdTag.RowsAffected() == 0 {
+		return entities.ErrHealthUnitNotFound
+	}
+
+	return nil
+}
+
+func (r *healthUnitRepository) UpsertByCNES(ctx context.Context, units []*entities.HealthUnit) (int, int, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, 0, dbError(err, nil)
+	}
+	defer tx.Rollback(ctx)
+
+	// xmax is zero only for rows inserted by this statement
+	query := `
+		INSERT INTO health_units (cnes, name, type, municipality_id, active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NOW(), NOW())
+		ON CONFLICT (cnes) DO UPDATE
+		SET name = EXCLUDED.name, type = EXCLUDED.type, municipality_id = EXCLUDED.municipality_id,
+		    active = EXCLUDED.active, updated_at = NOW()
+		RETURNING id, (xmax = 0)
+	`
+
+	batch := &pgx.Batch{}
+	for _, unit := range units {
+		batch.Queue(query, unit.CNES, unit.Name, unit.Type, unit.MunicipalityID, unit.Active)
+	}
+
+	created, updated := 0, 0
+	results := tx.SendBatch(ctx, batch)
+	for _, unit := range units {
+		var inserted bool
+		if err := results.QueryRow().Scan(&unit.ID, &inserted); err != nil {
+			results.Close()
+			return 0, 0, fmt.Errorf("error upserting health unit %s: %w", unit.CNES, err)
+		}
+		if inserted {
+			created++
+		} else {
+			updated++
+		}
+	}
+	if err := results.Close(); err != nil {
+		return 0, 0, fmt.Errorf("error upserting health units: %w", dbError(err, nil))
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, 0, dbError(err, nil)
+	}
+
+	return created, updated, nil
+}
+
+func (r *healthUnitRepository) MatchUserUnits(ctx context.Context) (int, error) {
+	var matched int
+	if err := r.db.Conn(ctx).QueryRow(ctx, `SELECT match_user_units()`).Scan(&matched); err != nil {
+		return 0, fmt.Errorf("error matching user units: %w", err)
+	}
+
+	return matched, nil
+}
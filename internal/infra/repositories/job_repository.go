@@ -0,0 +1,153 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/joaopanucci/apsdigital/internal/domain/entities"
+	"github.com/joaopanucci/apsdigital/internal/infra/db"
+)
+
+type jobRepository struct {
+	db *db.PostgresDB
+}
+
+func NewJobRepository(db *db.PostgresDB) *jobRepository {
+	return &jobRepository{db: db}
+}
+
+func (r *jobRepository) Enqueue(ctx context.Context, job *entities.Job) (bool, error) {
+	if job.MaxAttempts == 0 {
+		job.MaxAttempts = entities.DefaultJobMaxAttempts
+	}
+	if job.RunAt.IsZero() {
+		job.RunAt = time.Now()
+	}
+	if job.Payload == nil {
+		job.Payload = []byte("{}")
+	}
+
+	query := `
+		INSERT INTO jobs (type, payload, status, max_attempts, run_at, unique_key, created_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NOW())
+		ON CONFLICT (unique_key) DO NOTHING
+		RETURNING id, created_at
+	`
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query,
+		job.Type,
+		job.Payload,
+		entities.JobPending,
+		job.MaxAttempts,
+		job.RunAt,
+		job.UniqueKey,
+	)
+	if err != nil {
+		return false, fmt.Errorf("error queueing job: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return false, rows.Err()
+	}
+	if err := rows.Scan(&job.ID, &job.CreatedAt); err != nil {
+		return false, fmt.Errorf("error queueing job: %w", err)
+	}
+	job.Status = entities.JobPending
+
+	return true, rows.Err()
+}
+
+// Claim also takes running jobs whose lock expired, left behind by a worker that died
+func (r *jobRepository) Claim(ctx context.Context, types []string, limit int, lease time.Duration) ([]*entities.Job, error) {
+	query := `
+		WITH due AS (
+			SELECT id FROM jobs
+			WHERE type = ANY($1) AND (
+				(status = $2 AND run_at <= NOW())
+				OR (status = $3 AND locked_until < NOW())
+			)
+			ORDER BY run_at
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE jobs j
+		SET status = $3, attempts = j.attempts + 1, locked_until = NOW() + make_interval(secs => $5)
+		FROM due WHERE j.id = due.id
+		RETURNING j.id, j.type, j.payload, j.status, j.attempts, j.max_attempts, j.run_at,
+		          j.locked_until, COALESCE(j.last_error, ''), COALESCE(j.unique_key, ''), j.created_at, j.finished_at
+	`
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, types, entities.JobPending, entities.JobRunning, limit, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("error claiming jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []*entities.Job
+	for rows.Next() {
+		job := &entities.Job{}
+		err := rows.Scan(&job.ID, &job.Type, &job.Payload, &job.Status, &job.Attempts, &job.MaxAttempts, &job.RunAt,
+			&job.LockedUntil, &job.LastError, &job.UniqueKey, &job.CreatedAt, &job.FinishedAt)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning job: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+
+	return jobs, rows.Err()
+}
+
+func (r *jobRepository) Complete(ctx context.Context, id int64) error {
+	_, err := r.db.Conn(ctx).Exec(ctx, `
+		UPDATE jobs SET status = $2, locked_until = NULL, finished_at = NOW() WHERE id = $1
+	`, id, entities.JobSucceeded)
+	if err != nil {
+		return fmt.Errorf("error completing job: %w", err)
+	}
+
+	return nil
+}
+
+func (r *jobRepository) Fail(ctx context.Context, id int64, message string, retryAt *time.Time) error {
+	var err error
+	if retryAt != nil {
+		_, err = r.db.Conn(ctx).Exec(ctx, `
+			UPDATE jobs SET status = $2, run_at = $3, locked_until = NULL, last_error = $4 WHERE id = $1
+		`, id, entities.JobPending, *retryAt, message)
+	} else {
+		_, err = r.db.Conn(ctx).Exec(ctx, `
+			UPDATE jobs SET status = $2, locked_until = NULL, last_error = $3, finished_at = NOW() WHERE id = $1
+		`, id, entities.JobFailed, message)
+	}
+	if err != nil {
+		return fmt.Errorf("error recording failed job: %w", err)
+	}
+
+	return nil
+}
+
+func (r *jobRepository) Release(ctx context.Context, id int64, message string) error {
+	_, err := r.db.Conn(ctx).Exec(ctx, `
+		UPDATE jobs SET status = $2, run_at = NOW(), locked_until = NULL, last_error = $3,
+		       attempts = GREATEST(attempts - 1, 0)
+		WHERE id = $1
+	`, id, entities.JobPending, message)
+	if err != nil {
+		return fmt.Errorf("error releasing job: %w", err)
+	}
+
+	return nil
+}
+
+func (r *jobRepository) DeleteFinishedBefore(ctx context.Context, status entities.JobStatus, before time.Time) (int64, error) {
+	result, err := r.db.Conn(ctx).Exec(ctx, `
+		DELETE FROM jobs WHERE status = $1 AND finished_at < $2
+	`, status, before)
+	if err != nil {
+		return 0, fmt.Errorf("error deleting finished jobs: %w", err)
+	}
+
+	return result.RowsAffected(), nil
+}
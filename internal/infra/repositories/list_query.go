@@ -0,0 +1,172 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/joaopanucci/apsdigital/internal/domain/apperrors"
+	"github.com/joaopanucci/apsdigital/internal/domain/entities"
+	"github.com/joaopanucci/apsdigital/internal/infra/db"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// listOrder is the sort of a list query: the requested columns, then a unique tiebreaker.
+// Its columns are also the sort key cursors resume from.
+type listOrder struct {
+	columns []string
+	desc    []bool
+}
+
+// orderBy builds the order from the requested sort fields. columns maps each sortable field
+// to its SQL expression; fallback applies when none is requested and tiebreaker, a unique
+// column, keeps the order stable between pages. Both are written as in ORDER BY.
+func orderBy(sort []entities.SortField, columns map[string]string, fallback string, tiebreaker string) (*listOrder, error) {
+	order := &listOrder{}
+	for _, field := range sort {
+		column, ok := columns[field.Field]
+		if !ok {
+			return nil, apperrors.Invalid("sort", "sort_field_unknown", field.Field)
+		}
+		order.add(column, field.Desc)
+	}
+
+	if len(sort) == 0 {
+		order.addSpec(fallback)
+	}
+	order.addSpec(tiebreaker)
+
+	return order, nil
+}
+
+func (o *listOrder) add(column string, desc bool) {
+	o.columns = append(o.columns, column)
+	o.desc = append(o.desc, desc)
+}
+
+// addSpec adds a column written as in ORDER BY, e.g. "p.created_at DESC"
+func (o *listOrder) addSpec(spec string) {
+	column, desc := strings.CutSuffix(spec, " DESC")
+	o.add(strings.TrimSuffix(column, " ASC"), desc)
+}
+
+// SQL is the ORDER BY clause
+func (o *listOrder) SQL() string {
+	parts := make([]string, len(o.columns))
+	for i, column := range o.columns {
+		parts[i] = column + " ASC"
+		if o.desc[i] {
+			parts[i] = column + " DESC"
+		}
+	}
+	return " ORDER BY " + strings.Join(parts, ", ")
+}
+
+// Key selects the sort key of a row as text, the first column of page queries
+func (o *listOrder) Key() string {
+	parts := make([]string, len(o.columns))
+	for i, column := range o.columns {
+		parts[i] = "(" + column + ")::text"
+	}
+	return "ARRAY[" + strings.Join(parts, ", ") + "]"
+}
+
+// after restricts where to the rows following the sort key after, in the order Postgres
+// sorts by default: NULLs last when ascending and first when descending
+func (o *listOrder) after(where *db.Where, after []*string) error {
+	if len(after) != len(o.columns) {
+		return apperrors.Invalid("cursor", "cursor_invalid")
+	}
+
+	var alternatives []string
+	var args []interface{}
+	for i, column := range o.columns {
+		var conditions []string
+		var conditionArgs []interface{}
+		for j := 0; j < i; j++ {
+			if after[j] == nil {
+				conditions = append(conditions, o.columns[j]+" IS NULL")
+				continue
+			}
+			conditions = append(conditions, o.columns[j]+" = ?")
+			conditionArgs = append(conditionArgs, *after[j])
+		}
+
+		switch {
+		case after[i] == nil && o.desc[i]:
+			conditions = append(conditions, column+" IS NOT NULL")
+		case after[i] == nil:
+			// Nothing sorts after NULL
+			continue
+		case o.desc[i]:
+			conditions = append(conditions, column+" < ?")
+			conditionArgs = append(conditionArgs, *after[i])
+		default:
+			conditions = append(conditions, "("+column+" > ? OR "+column+" IS NULL)")
+			conditionArgs = append(conditionArgs, *after[i])
+		}
+
+		alternatives = append(alternatives, "("+strings.Join(conditions, " AND ")+")")
+		args = append(args, conditionArgs...)
+	}
+
+	if len(alternatives) == 0 {
+		where.Add("false")
+		return nil
+	}
+	where.Add("("+strings.Join(alternatives, " OR ")+")", args...)
+	return nil
+}
+
+// keyset restricts where to the rows after the cursor of q, if it has one. Call it after
+// counting the rows, since the total covers every page.
+func keyset[F any](where *db.Where, order *listOrder, q entities.ListQuery[F]) error {
+	after, err := q.After()
+	if err != nil || after == nil {
+		return err
+	}
+	return order.after(where, after)
+}
+
+// paginate appends LIMIT and OFFSET as the next positional arguments. It asks for one row
+// more than the page holds, to know whether there is a next page.
+func paginate[F any](query string, args []interface{}, q entities.ListQuery[F]) (string, []interface{}) {
+	query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(args)+1, len(args)+2)
+	return query, append(args, q.Limit+1, q.Offset())
+}
+
+// queryPage runs a page query built with paginate
+func queryPage(ctx context.Context, conn db.Querier, query string, args []interface{}) (*pageRows, error) {
+	rows, err := conn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return &pageRows{Rows: rows}, nil
+}
+
+// pageRows reads the sort key page queries select first, so callers scan rows as usual
+type pageRows struct {
+	pgx.Rows
+	keys [][]*string
+}
+
+func (r *pageRows) Scan(dest ...interface{}) error {
+	var key []*string
+	if err := r.Rows.Scan(append([]interface{}{&key}, dest...)...); err != nil {
+		return err
+	}
+	r.keys = append(r.keys, key)
+	return nil
+}
+
+// listPage wraps the rows read from a page query, dropping the extra row paginate asked
+// for and pointing the next cursor at the last row kept
+func listPage[T any, F any](items []T, total int, q entities.ListQuery[F], rows *pageRows) *entities.Page[T] {
+	var next []*string
+	if q.Limit > 0 && len(items) > q.Limit {
+		items = items[:q.Limit]
+		next = rows.keys[q.Limit-1]
+	}
+	return entities.NewPage(items, total, q, next)
+}
@@ -0,0 +1,109 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/joaopanucci/apsdigital/internal/domain/entities"
+	"github.com/joaopanucci/apsdigital/internal/infra/db"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type liveEventRepository struct {
+	db *db.PostgresDB
+}
+
+func NewLiveEventRepository(db *db.PostgresDB) *liveEventRepository {
+	return &liveEventRepository{db: db}
+}
+
+const liveEventColumns = `id, kind, type, user_id, COALESCE(municipality_ids, '{}'), COALESCE(permission, ''), payload, created_at`
+
+func scanLiveEvent(row pgx.Row) (*entities.LiveEvent, error) {
+	event := &entities.LiveEvent{}
+	err := row.Scan(
+		&event.ID,
+		&event.Kind,
+		&event.Type,
+		&event.UserID,
+		&event.MunicipalityIDs,
+		&event.Permission,
+		&event.Payload,
+		&event.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
+
+func (r *liveEventRepository) GetByID(ctx context.Context, id int64) (*entities.LiveEvent, error) {
+	query := `SELECT ` + liveEventColumns + ` FROM live_events WHERE id = $1`
+
+	event, err := scanLiveEvent(r.db.Conn(ctx).QueryRow(ctx, query, id))
+	if err != nil {
+		return nil, fmt.Errorf("error getting live event: %w", dbError(err, entities.ErrLiveEventNotFound))
+	}
+
+	return event, nil
+}
+
+func (r *liveEventRepository) ListAfter(ctx context.Context, afterID int64, limit int) ([]*entities.LiveEvent, error) {
+	return r.list(ctx, (&db.Where{}).Add("id > ?", afterID), limit)
+}
+
+// ListVisibleAfter applies the same rules as entities.LiveEvent.VisibleTo
+func (r *liveEventRepository) ListVisibleAfter(ctx context.Context, afterID int64, audience entities.LiveEventAudience, limit int) ([]*entities.LiveEvent, error) {
+	var permissions []string
+	for name, granted := range audience.Permissions {
+		if granted {
+			permissions = append(permissions, name)
+		}
+	}
+
+	where := (&db.Where{}).Add("id > ?", afterID)
+	if ids := audience.Scope.Filter(); ids == nil {
+		where.Add("(user_id = ? OR (user_id IS NULL AND (permission IS NULL OR permission = ANY(?))))",
+			audience.UserID, permissions)
+	} else {
+		where.Add("(user_id = ? OR (user_id IS NULL AND (permission IS NULL OR permission = ANY(?)) AND municipality_ids && ?::integer[]))",
+			audience.UserID, permissions, ids)
+	}
+
+	return r.list(ctx, where, limit)
+}
+
+func (r *liveEventRepository) list(ctx context.Context, where *db.Where, limit int) ([]*entities.LiveEvent, error) {
+	args := append(where.Args(), limit)
+	query := `SELECT ` + liveEventColumns + ` FROM live_events` + where.SQL() +
+		fmt.Sprintf(" ORDER BY id LIMIT $%d", len(args))
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error listing live events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*entities.LiveEvent
+	for rows.Next() {
+		event, err := scanLiveEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning live event: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+func (r *liveEventRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.Conn(ctx).Exec(ctx, `DELETE FROM live_events WHERE created_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("error deleting live events: %w", err)
+	}
+
+	return result.RowsAffected(), nil
+}
@@ -3,9 +3,12 @@ package repositories
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/joaopanucci/apsdigital/internal/domain/entities"
 	"github.com/joaopanucci/apsdigital/internal/infra/db"
+
+	"github.com/jackc/pgx/v5"
 )
 
 type municipalityRepository struct {
@@ -23,14 +26,14 @@ func (r *municipalityRepository) Create(ctx context.Context, municipality *entit
 		RETURNING id
 	`
 
-	err := r.db.Pool.QueryRow(ctx, query,
+	err := r.db.Conn(ctx).QueryRow(ctx, query,
 		municipality.Name,
 		municipality.State,
 		municipality.Active,
 	).Scan(&municipality.ID)
 
 	if err != nil {
-		return fmt.Errorf("error creating municipality: %w", err)
+		return fmt.Errorf("error creating municipality: %w", dbError(err, nil))
 	}
 
 	return nil
@@ -38,16 +41,19 @@ func (r *municipalityRepository) Create(ctx context.Context, municipality *entit
 
 func (r *municipalityRepository) GetByID(ctx context.Context, id int) (*entities.Municipality, error) {
 	query := `
-		SELECT id, name, state, active, created_at, updated_at
+		SELECT id, name, COALESCE(ibge_code, ''), COALESCE(state, ''), COALESCE(microregion, ''), health_region_id, active, created_at, updated_at
 		FROM municipalities
 		WHERE id = $1
 	`
 
 	municipality := &entities.Municipality{}
-	err := r.db.Pool.QueryRow(ctx, query, id).Scan(
+	err := r.db.Conn(ctx).QueryRow(ctx, query, id).Scan(
 		&municipality.ID,
 		&municipality.Name,
+		&municipality.IBGECode,
 		&municipality.State,
+		&municipality.Microregion,
+		&municipality.HealthRegionID,
 		&municipality.Active,
 		&municipality.CreatedAt,
 		&municipality.UpdatedAt,
@@ -62,16 +68,19 @@ func (r *municipalityRepository) GetByID(ctx context.Context, id int) (*entities
 
 func (r *municipalityRepository) GetByName(ctx context.Context, name string) (*entities.Municipality, error) {
 	query := `
-		SELECT id, name, state, active, created_at, updated_at
+		SELECT id, name, COALESCE(ibge_code, ''), COALESCE(state, ''), COALESCE(microregion, ''), health_region_id, active, created_at, updated_at
 		FROM municipalities
 		WHERE name = $1
 	`
 
 	municipality := &entities.Municipality{}
-	err := r.db.Pool.QueryRow(ctx, query, name).Scan(
+	err := r.db.Conn(ctx).QueryRow(ctx, query, name).Scan(
 		&municipality.ID,
 		&municipality.Name,
+		&municipality.IBGECode,
 		&municipality.State,
+		&municipality.Microregion,
+		&municipality.HealthRegionID,
 		&municipality.Active,
 		&municipality.CreatedAt,
 		&municipality.UpdatedAt,
@@ -84,15 +93,44 @@ func (r *municipalityRepository) GetByName(ctx context.Context, name string) (*e
 	return municipality, nil
 }
 
-func (r *municipalityRepository) List(ctx context.Context) ([]*entities.Municipality, error) {
-	query := `
-		SELECT id, name, state, active, created_at, updated_at
-		FROM municipalities
-		WHERE active = true
-		ORDER BY name ASC
-	`
+// municipalitySortColumns are the fields municipalities can be sorted by
+var municipalitySortColumns = map[string]string{
+	"name":      "name",
+	"state":     "state",
+	"ibge_code": "ibge_code",
+}
+
+// municipalityWhere builds the conditions of a municipality list, active ones only
+func municipalityWhere(f entities.MunicipalityFilter) *db.Where {
+	where := (&db.Where{}).Add("active = true")
+	where.AddIf(f.State != "", "state = ?", strings.ToUpper(f.State))
+	where.AddIf(f.HealthRegionID != nil, "health_region_id = ?", f.HealthRegionID)
+	where.AddIf(f.Search != "", "(name ILIKE ? OR ibge_code = ?)", "%"+f.Search+"%", f.Search)
+	return where
+}
+
+// List returns a page of active municipalities and the number of them matching the filters
+func (r *municipalityRepository) List(ctx context.Context, q entities.ListQuery[entities.MunicipalityFilter]) (*entities.Page[*entities.Municipality], error) {
+	where := municipalityWhere(q.Filters)
+
+	var total int
+	if err := r.db.Conn(ctx).QueryRow(ctx, "SELECT COUNT(*) FROM municipalities"+where.SQL(), where.Args()...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("error counting municipalities: %w", err)
+	}
+
+	order, err := orderBy(q.Sort, municipalitySortColumns, "name ASC", "id")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := keyset(where, order, q); err != nil {
+		return nil, err
+	}
+
+	query, args := paginate(`
+		SELECT `+order.Key()+`, id, name, COALESCE(ibge_code, ''), COALESCE(state, ''), COALESCE(microregion, ''), health_region_id, active, created_at, updated_at FROM municipalities`+where.SQL()+order.SQL(), where.Args(), q)
 
-	rows, err := r.db.Pool.Query(ctx, query)
+	rows, err := queryPage(ctx, r.db.Conn(ctx), query, args)
 	if err != nil {
 		return nil, fmt.Errorf("error listing municipalities: %w", err)
 	}
@@ -104,7 +142,10 @@ func (r *municipalityRepository) List(ctx context.Context) ([]*entities.Municipa
 		err := rows.Scan(
 			&municipality.ID,
 			&municipality.Name,
+			&municipality.IBGECode,
 			&municipality.State,
+			&municipality.Microregion,
+			&municipality.HealthRegionID,
 			&municipality.Active,
 			&municipality.CreatedAt,
 			&municipality.UpdatedAt,
@@ -115,7 +156,11 @@ func (r *municipalityRepository) List(ctx context.Context) ([]*entities.Municipa
 		municipalities = append(municipalities, municipality)
 	}
 
-	return municipalities, nil
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return listPage(municipalities, total, q, rows), nil
 }
 
 func (r *municipalityRepository) Update(ctx context.Context, municipality *entities.Municipality) error {
@@ -125,7 +170,7 @@ func (r *municipalityRepository) Update(ctx context.Context, municipality *entit
 		WHERE id = $1
 	`
 
-	cmdTag, err := r.db.Pool.Exec(ctx, query,
+	cmdTag, err := r.db.Conn(ctx).Exec(ctx, query,
 		municipality.ID,
 		municipality.Name,
 		municipality.State,
@@ -133,11 +178,11 @@ func (r *municipalityRepository) Update(ctx context.Context, municipality *entit
 	)
 
 	if err != nil {
-		return fmt.Errorf("error updating municipality: %w", err)
+		return fmt.Errorf("error updating municipality: %w", dbError(err, entities.ErrMunicipalityNotFound))
 	}
 
 	if cmdTag.RowsAffected() == 0 {
-		return fmt.Errorf("municipality not found")
+		return entities.ErrMunicipalityNotFound
 	}
 
 	return nil
@@ -146,14 +191,115 @@ func (r *municipalityRepository) Update(ctx context.Context, municipality *entit
 func (r *municipalityRepository) Delete(ctx context.Context, id int) error {
 	query := `DELETE FROM municipalities WHERE id = $1`
 
-	cmdTag, err := r.db.Pool.Exec(ctx, query, id)
+	cmdTag, err := r.db.Conn(ctx).Exec(ctx, query, id)
 	if err != nil {
-		return fmt.Errorf("error deleting municipality: %w", err)
+		return fmt.Errorf("error deleting municipality: %w", dbError(err, entities.ErrMunicipalityNotFound))
 	}
 
 	if cmdTag.RowsAffected() == 0 {
-		return fmt.Errorf("municipality not found")
+		return entities.ErrMunicipalityNotFound
 	}
 
 	return nil
 }
+
+// ListAll returns every municipality, including inactive ones
+func (r *municipalityRepository) ListAll(ctx context.Context) ([]*entities.Municipality, error) {
+	query := `
+		SELECT id, name, COALESCE(ibge_code, ''), COALESCE(state, ''), COALESCE(microregion, ''), health_region_id, active, created_at, updated_at
+		FROM municipalities
+		ORDER BY name ASC
+	`
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("error listing municipalities: %w", err)
+	}
+	defer rows.Close()
+
+	var municipalities []*entities.Municipality
+	for rows.Next() {
+		municipality := &entities.Municipality{}
+		err := rows.Scan(
+			&municipality.ID,
+			&municipality.Name,
+			&municipality.IBGECode,
+			&municipality.State,
+			&municipality.Microregion,
+			&municipality.HealthRegionID,
+			&municipality.Active,
+			&municipality.CreatedAt,
+			&municipality.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning municipality: %w", err)
+		}
+		municipalities = append(municipalities, municipality)
+	}
+
+	return municipalities, rows.Err()
+}
+
+// ApplySync upserts municipalities by IBGE code and deactivates the given ones in a single transaction
+func (r *municipalityRepository) ApplySync(ctx context.Context, upserts []*entities.Municipality, deactivateIDs []int) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO municipalities (name, ibge_code, state, microregion, active, created_at, updated_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), true, NOW(), NOW())
+		ON CONFLICT (ibge_code) DO UPDATE
+		SET name = EXCLUDED.name, state = EXCLUDED.state, microregion = EXCLUDED.microregion,
+		    active = true, updated_at = NOW()
+		RETURNING id
+	`
+
+	batch := &pgx.Batch{}
+	for _, municipality := range upserts {
+		batch.Queue(query, municipality.Name, municipality.IBGECode, municipality.State, municipality.Microregion)
+	}
+
+	results := tx.SendBatch(ctx, batch)
+	for _, municipality := range upserts {
+		if err := results.QueryRow().Scan(&municipality.ID); err != nil {
+			results.Close()
+			return fmt.Errorf("error upserting municipality %s: %w", municipality.IBGECode, err)
+		}
+	}
+	if err := results.Close(); err != nil {
+		return fmt.Errorf("error upserting municipalities: %w", err)
+	}
+
+	if len(deactivateIDs) > 0 {
+		_, err := tx.Exec(ctx, `UPDATE municipalities SET active = false, updated_at = NOW() WHERE id = ANY($1)`, deactivateIDs)
+		if err != nil {
+			return fmt.Errorf("error deactivating municipalities: %w", err)
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *municipalityRepository) ListIDsByState(ctx context.Context, state string) ([]int, error) {
+	query := `SELECT id FROM municipalities WHERE state = $1 ORDER BY id`
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, state)
+	if err != nil {
+		return nil, fmt.Errorf("error listing municipalities: %w", err)
+	}
+	defer rows.Close()
+
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("error scanning municipality: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
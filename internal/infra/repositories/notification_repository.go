@@ -0,0 +1,176 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/joaopanucci/apsdigital/internal/domain/entities"
+	"github.com/joaopanucci/apsdigital/internal/infra/db"
+
+	"github.com/google/uuid"
+)
+
+type notificationRepository struct {
+	db *db.PostgresDB
+}
+
+func NewNotificationRepository(db *db.PostgresDB) *notificationRepository {
+	return &notificationRepository{db: db}
+}
+
+// ResolveRecipients finds the active users holding the audience's permission whose scope
+// covers its municipality, the same way scopes are resolved for requests: users with
+// municipalities.view_all see every municipality, the others their own municipality,
+// health region or state
+func (r *notificationRepository) ResolveRecipients(ctx context.Context, audience entities.NotificationAudience) ([]uuid.UUID, error) {
+	if audience.Permission == "" {
+		return nil, nil
+	}
+
+	hasPermission := `EXISTS (
+		SELECT 1 FROM role_permissions rp JOIN permissions p ON p.id = rp.permission_id
+		WHERE rp.role_id = u.role_id AND p.name = ?
+	)`
+
+	where := &db.Where{}
+	where.Add("u.status = ?", entities.UserStatusActive)
+	where.Add(hasPermission, audience.Permission)
+	where.AddIf(audience.MaxLevel > 0, "r.level <= ?", audience.MaxLevel)
+	where.AddIf(audience.MunicipalityID != nil, "("+hasPermission+` OR EXISTS (
+		SELECT 1 FROM municipalities m
+		WHERE m.id = ? AND (
+			(u.scope_type = 'municipality' AND u.municipality_id = m.id)
+			OR (u.scope_type = 'region' AND u.health_region_id = m.health_region_id)
+			OR (u.scope_type = 'state' AND u.scope_state = m.state)
+		)
+	))`, entities.PermissionMunicipalitiesViewAll, audience.MunicipalityID)
+	where.AddIf(audience.Except != nil, "u.id <> ?", audience.Except)
+
+	query := `SELECT u.id FROM users u JOIN roles r ON r.id = u.role_id` + where.SQL()
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, where.Args()...)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving notification recipients: %w", err)
+	}
+	defer rows.Close()
+
+	var userIDs []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("error scanning notification recipient: %w", err)
+		}
+		userIDs = append(userIDs, id)
+	}
+
+	return userIDs, rows.Err()
+}
+
+// CreateForUsers stores one copy of the notification per user
+func (r *notificationRepository) CreateForUsers(ctx context.Context, notification *entities.Notification, userIDs []uuid.UUID) error {
+	query := `
+		INSERT INTO notifications (user_id, type, title, body, link, created_at)
+		SELECT user_id, $2, $3, NULLIF($4, ''), NULLIF($5, ''), NOW()
+		FROM unnest($1::uuid[]) AS user_id
+	`
+
+	_, err := r.db.Conn(ctx).Exec(ctx, query,
+		userIDs,
+		notification.Type,
+		notification.Title,
+		notification.Body,
+		notification.Link,
+	)
+	if err != nil {
+		return fmt.Errorf("error creating notifications: %w", dbError(err, nil))
+	}
+
+	return nil
+}
+
+// notificationWhere builds the conditions of a user's notification list
+func notificationWhere(f entities.NotificationFilter) *db.Where {
+	where := &db.Where{}
+	where.Add("n.user_id = ?", f.UserID)
+	where.AddIf(f.UnreadOnly, "n.read_at IS NULL")
+	return where
+}
+
+func (r *notificationRepository) List(ctx context.Context, q entities.ListQuery[entities.NotificationFilter]) (*entities.Page[*entities.Notification], error) {
+	where := notificationWhere(q.Filters)
+
+	var total int
+	if err := r.db.Conn(ctx).QueryRow(ctx, "SELECT COUNT(*) FROM notifications n"+where.SQL(), where.Args()...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("error counting notifications: %w", err)
+	}
+
+	order, err := orderBy(q.Sort, map[string]string{"created_at": "n.created_at"}, "n.created_at DESC", "n.id DESC")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := keyset(where, order, q); err != nil {
+		return nil, err
+	}
+
+	query, args := paginate(`
+		SELECT `+order.Key()+`, n.id, n.user_id, n.type, n.title, COALESCE(n.body, ''), COALESCE(n.link, ''), n.read_at, n.created_at
+		FROM notifications n`+where.SQL()+order.SQL(), where.Args(), q)
+
+	rows, err := queryPage(ctx, r.db.Conn(ctx), query, args)
+	if err != nil {
+		return nil, fmt.Errorf("error listing notifications: %w", err)
+	}
+	defer rows.Close()
+
+	var notifications []*entities.Notification
+	for rows.Next() {
+		n := &entities.Notification{}
+		if err := rows.Scan(&n.ID, &n.UserID, &n.Type, &n.Title, &n.Body, &n.Link, &n.ReadAt, &n.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning notification: %w", err)
+		}
+		notifications = append(notifications, n)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return listPage(notifications, total, q, rows), nil
+}
+
+func (r *notificationRepository) CountUnread(ctx context.Context, userID uuid.UUID) (int, error) {
+	var count int
+	err := r.db.Conn(ctx).QueryRow(ctx, `
+		SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND read_at IS NULL
+	`, userID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("error counting unread notifications: %w", err)
+	}
+
+	return count, nil
+}
+
+// MarkRead marks the given notifications of the user as read and returns how many changed
+func (r *notificationRepository) MarkRead(ctx context.Context, userID uuid.UUID, ids []int64) (int, error) {
+	result, err := r.db.Conn(ctx).Exec(ctx, `
+		UPDATE notifications SET read_at = NOW()
+		WHERE user_id = $1 AND id = ANY($2) AND read_at IS NULL
+	`, userID, ids)
+	if err != nil {
+		return 0, fmt.Errorf("error marking notifications as read: %w", err)
+	}
+
+	return int(result.RowsAffected()), nil
+}
+
+func (r *notificationRepository) MarkAllRead(ctx context.Context, userID uuid.UUID) (int, error) {
+	result, err := r.db.Conn(ctx).Exec(ctx, `
+		UPDATE notifications SET read_at = NOW() WHERE user_id = $1 AND read_at IS NULL
+	`, userID)
+	if err != nil {
+		return 0, fmt.Errorf("error marking notifications as read: %w", err)
+	}
+
+	return int(result.RowsAffected()), nil
+}
@@ -2,8 +2,6 @@ package repositories
 
 import (
 	"context"
-	"fmt"
-	"strings"
 
 	"github.com/joaopanucci/apsdigital/internal/domain/entities"
 	"github.com/joaopanucci/apsdigital/internal/infra/db"
@@ -24,9 +22,9 @@ func (r *PaymentRepository) Create(ctx context.Context, payment *entities.Paymen
 		RETURNING id, created_at, updated_at
 	`
 
-	row := r.db.Pool.QueryRow(ctx, query, payment.FileURL, payment.Competence, payment.UploadedBy, payment.MunicipalityID)
+	row := r.db.Conn(ctx).QueryRow(ctx, query, payment.FileURL, payment.Competence, payment.UploadedBy, payment.MunicipalityID)
 
-	return row.Scan(&payment.ID, &payment.CreatedAt, &payment.UpdatedAt)
+	return dbError(row.Scan(&payment.ID, &payment.CreatedAt, &payment.UpdatedAt), nil)
 }
 
 func (r *PaymentRepository) GetByID(ctx context.Context, id string) (*entities.Payment, error) {
@@ -41,7 +39,7 @@ func (r *PaymentRepository) GetByID(ctx context.Context, id string) (*entities.P
 	`
 
 	var payment entities.Payment
-	row := r.db.Pool.QueryRow(ctx, query, id)
+	row := r.db.Conn(ctx).QueryRow(ctx, query, id)
 
 	err := row.Scan(
 		&payment.ID, &payment.FileURL, &payment.Competence, &payment.UploadedBy, &payment.MunicipalityID,
@@ -49,74 +47,61 @@ func (r *PaymentRepository) GetByID(ctx context.Context, id string) (*entities.P
 	)
 
 	if err != nil {
-		return nil, err
+		return nil, dbError(err, entities.ErrPaymentNotFound)
 	}
 
 	return &payment, nil
 }
 
-func (r *PaymentRepository) GetAll(ctx context.Context, filters map[string]interface{}) ([]*entities.Payment, error) {
-	query := `
-		SELECT p.id, p.file_url, p.competence, p.uploaded_by, p.municipality_id, p.created_at, p.updated_at,
-		       u.name as uploaded_by_name, u.cpf as uploaded_by_cpf,
-		       m.name as municipality_name
+// paymentSortColumns are the fields payments can be sorted by
+var paymentSortColumns = map[string]string{
+	"created_at":   "p.created_at",
+	"competence":   "p.competence",
+	"municipality": "m.name",
+}
+
+// paymentWhere builds the conditions of a payment list
+func paymentWhere(f entities.PaymentFilter) *db.Where {
+	where := &db.Where{}
+	where.AddIf(f.MunicipalityID != nil, "p.municipality_id = ?", f.MunicipalityID)
+	// Restrict to the municipalities of the user's scope
+	where.AddIf(f.MunicipalityIDs != nil, "p.municipality_id = ANY(?)", f.MunicipalityIDs)
+	where.AddIf(f.Year > 0, "EXTRACT(YEAR FROM p.created_at) = ?", f.Year)
+	where.AddIf(f.Month > 0, "EXTRACT(MONTH FROM p.created_at) = ?", f.Month)
+	where.AddIf(f.Competence != "", "p.competence = ?", f.Competence)
+	return where
+}
+
+// GetAll returns a page of payments and the number of payments matching the filters
+func (r *PaymentRepository) GetAll(ctx context.Context, q entities.ListQuery[entities.PaymentFilter]) (*entities.Page[*entities.Payment], error) {
+	where := paymentWhere(q.Filters)
+
+	from := `
 		FROM payments p
 		LEFT JOIN users u ON p.uploaded_by = u.id
 		LEFT JOIN municipalities m ON p.municipality_id = m.id
 	`
 
-	var whereConditions []string
-	var args []interface{}
-	argIndex := 1
-
-	// Filter by municipality
-	if municipalityID, ok := filters["municipality_id"]; ok && municipalityID != nil {
-		whereConditions = append(whereConditions, fmt.Sprintf("p.municipality_id = $%d", argIndex))
-		args = append(args, municipalityID)
-		argIndex++
-	}
-
-	// Filter by year
-	if year, ok := filters["year"]; ok && year != nil && year != "" {
-		whereConditions = append(whereConditions, fmt.Sprintf("EXTRACT(YEAR FROM p.created_at) = $%d", argIndex))
-		args = append(args, year)
-		argIndex++
-	}
-
-	// Filter by month
-	if month, ok := filters["month"]; ok && month != nil && month != "" {
-		whereConditions = append(whereConditions, fmt.Sprintf("EXTRACT(MONTH FROM p.created_at) = $%d", argIndex))
-		args = append(args, month)
-		argIndex++
-	}
-
-	// Filter by competence
-	if competence, ok := filters["competence"]; ok && competence != nil && competence != "" {
-		whereConditions = append(whereConditions, fmt.Sprintf("p.competence = $%d", argIndex))
-		args = append(args, competence)
-		argIndex++
+	var total int
+	if err := r.db.Conn(ctx).QueryRow(ctx, "SELECT COUNT(*)"+from+where.SQL(), where.Args()...).Scan(&total); err != nil {
+		return nil, err
 	}
 
-	if len(whereConditions) > 0 {
-		query += " WHERE " + strings.Join(whereConditions, " AND ")
+	order, err := orderBy(q.Sort, paymentSortColumns, "p.created_at DESC", "p.id")
+	if err != nil {
+		return nil, err
 	}
 
-	query += " ORDER BY p.created_at DESC"
-
-	// Add limit if specified
-	if limit, ok := filters["limit"]; ok && limit != nil {
-		query += fmt.Sprintf(" LIMIT $%d", argIndex)
-		args = append(args, limit)
-		argIndex++
+	if err := keyset(where, order, q); err != nil {
+		return nil, err
 	}
 
-	// Add offset if specified
-	if offset, ok := filters["offset"]; ok && offset != nil {
-		query += fmt.Sprintf(" OFFSET $%d", argIndex)
-		args = append(args, offset)
-	}
+	query, args := paginate(`
+		SELECT `+order.Key()+`, p.id, p.file_url, p.competence, p.uploaded_by, p.municipality_id, p.created_at, p.updated_at,
+		       u.name as uploaded_by_name, u.cpf as uploaded_by_cpf,
+		       m.name as municipality_name`+from+where.SQL()+order.SQL(), where.Args(), q)
 
-	rows, err := r.db.Pool.Query(ctx, query, args...)
+	rows, err := queryPage(ctx, r.db.Conn(ctx), query, args)
 	if err != nil {
 		return nil, err
 	}
@@ -135,7 +120,11 @@ func (r *PaymentRepository) GetAll(ctx context.Context, filters map[string]inter
 		payments = append(payments, &payment)
 	}
 
-	return payments, nil
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return listPage(payments, total, q, rows), nil
 }
 
 func (r *PaymentRepository) Update(ctx context.Context, payment *entities.Payment) error {
@@ -145,31 +134,47 @@ func (r *PaymentRepository) Update(ctx context.Context, payment *entities.Paymen
 		WHERE id = $1
 	`
 
-	_, err := r.db.Pool.Exec(ctx, query, payment.ID, payment.FileURL, payment.Competence)
-	return err
+	cmdTag, err := r.db.Conn(ctx).Exec(ctx, query, payment.ID, payment.FileURL, payment.Competence)
+	if err != nil {
+		return dbError(err, entities.ErrPaymentNotFound)
+	}
+
+	if cmdTag.RowsAffected() == 0 {
+		return entities.ErrPaymentNotFound
+	}
+
+	return nil
 }
 
 func (r *PaymentRepository) Delete(ctx context.Context, id string) error {
 	query := "DELETE FROM payments WHERE id = $1"
-	_, err := r.db.Pool.Exec(ctx, query, id)
-	return err
+	cmdTag, err := r.db.Conn(ctx).Exec(ctx, query, id)
+	if err != nil {
+		return dbError(err, entities.ErrPaymentNotFound)
+	}
+
+	if cmdTag.RowsAffected() == 0 {
+		return entities.ErrPaymentNotFound
+	}
+
+	return nil
 }
 
-func (r *PaymentRepository) GetCompetences(ctx context.Context, municipalityID *uint) ([]string, error) {
+func (r *PaymentRepository) GetCompetences(ctx context.Context, municipalityIDs []int) ([]string, error) {
 	query := `
 		SELECT DISTINCT competence 
 		FROM payments 
 	`
 
 	var args []interface{}
-	if municipalityID != nil {
-		query += " WHERE municipality_id = $1"
-		args = append(args, *municipalityID)
+	if municipalityIDs != nil {
+		query += " WHERE municipality_id = ANY($1)"
+		args = append(args, municipalityIDs)
 	}
 
 	query += " ORDER BY competence DESC"
 
-	rows, err := r.db.Pool.Query(ctx, query, args...)
+	rows, err := r.db.Conn(ctx).Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -187,21 +192,21 @@ func (r *PaymentRepository) GetCompetences(ctx context.Context, municipalityID *
 	return competences, nil
 }
 
-func (r *PaymentRepository) GetYears(ctx context.Context, municipalityID *uint) ([]int, error) {
+func (r *PaymentRepository) GetYears(ctx context.Context, municipalityIDs []int) ([]int, error) {
 	query := `
 		SELECT DISTINCT EXTRACT(YEAR FROM created_at) as year 
 		FROM payments 
 	`
 
 	var args []interface{}
-	if municipalityID != nil {
-		query += " WHERE municipality_id = $1"
-		args = append(args, *municipalityID)
+	if municipalityIDs != nil {
+		query += " WHERE municipality_id = ANY($1)"
+		args = append(args, municipalityIDs)
 	}
 
 	query += " ORDER BY year DESC"
 
-	rows, err := r.db.Pool.Query(ctx, query, args...)
+	rows, err := r.db.Conn(ctx).Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -11,7 +11,7 @@ type ProfessionRepository interface {
 	Create(ctx context.Context, profession *entities.Profession) error
 	GetByID(ctx context.Context, id int) (*entities.Profession, error)
 	GetByName(ctx context.Context, name string) (*entities.Profession, error)
-	GetAll(ctx context.Context) ([]*entities.Profession, error)
+	GetAll(ctx context.Context, q entities.ListQuery[entities.ProfessionFilter]) (*entities.Page[*entities.Profession], error)
 	Update(ctx context.Context, profession *entities.Profession) error
 	Delete(ctx context.Context, id int) error
 }
@@ -33,7 +33,7 @@ func (r *professionRepository) Create(ctx context.Context, profession *entities.
 		RETURNING id, created_at, updated_at
 	`
 
-	row := r.db.Pool.QueryRow(ctx, query, profession.Name)
+	row := r.db.Conn(ctx).QueryRow(ctx, query, profession.Name)
 	return row.Scan(&profession.ID, &profession.CreatedAt, &profession.UpdatedAt)
 }
 
@@ -45,7 +45,7 @@ func (r *professionRepository) GetByID(ctx context.Context, id int) (*entities.P
 	`
 
 	var profession entities.Profession
-	row := r.db.Pool.QueryRow(ctx, query, id)
+	row := r.db.Conn(ctx).QueryRow(ctx, query, id)
 
 	err := row.Scan(
 		&profession.ID,
@@ -69,7 +69,7 @@ func (r *professionRepository) GetByName(ctx context.Context, name string) (*ent
 	`
 
 	var profession entities.Profession
-	row := r.db.Pool.QueryRow(ctx, query, name)
+	row := r.db.Conn(ctx).QueryRow(ctx, query, name)
 
 	err := row.Scan(
 		&profession.ID,
@@ -85,14 +85,40 @@ func (r *professionRepository) GetByName(ctx context.Context, name string) (*ent
 	return &profession, nil
 }
 
-func (r *professionRepository) GetAll(ctx context.Context) ([]*entities.Profession, error) {
-	query := `
-		SELECT id, name, created_at, updated_at
-		FROM professions
-		ORDER BY name ASC
-	`
+// professionSortColumns are the fields professions can be sorted by
+var professionSortColumns = map[string]string{
+	"name":       "name",
+	"created_at": "created_at",
+}
+
+// professionWhere builds the conditions of a profession list
+func professionWhere(f entities.ProfessionFilter) *db.Where {
+	where := &db.Where{}
+	where.AddIf(f.Search != "", "name ILIKE ?", "%"+f.Search+"%")
+	return where
+}
+
+// GetAll returns a page of professions and the number of professions matching the filters
+func (r *professionRepository) GetAll(ctx context.Context, q entities.ListQuery[entities.ProfessionFilter]) (*entities.Page[*entities.Profession], error) {
+	where := professionWhere(q.Filters)
+
+	var total int
+	if err := r.db.Conn(ctx).QueryRow(ctx, "SELECT COUNT(*) FROM professions"+where.SQL(), where.Args()...).Scan(&total); err != nil {
+		return nil, err
+	}
 
-	rows, err := r.db.Pool.Query(ctx, query)
+	order, err := orderBy(q.Sort, professionSortColumns, "name ASC", "id")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := keyset(where, order, q); err != nil {
+		return nil, err
+	}
+
+	query, args := paginate("SELECT "+order.Key()+", id, name, created_at, updated_at FROM professions"+where.SQL()+order.SQL(), where.Args(), q)
+
+	rows, err := queryPage(ctx, r.db.Conn(ctx), query, args)
 	if err != nil {
 		return nil, err
 	}
@@ -113,7 +139,11 @@ func (r *professionRepository) GetAll(ctx context.Context) ([]*entities.Professi
 		professions = append(professions, &profession)
 	}
 
-	return professions, rows.Err()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return listPage(professions, total, q, rows), nil
 }
 
 func (r *professionRepository) Update(ctx context.Context, profession *entities.Profession) error {
@@ -124,12 +154,12 @@ func (r *professionRepository) Update(ctx context.Context, profession *entities.
 		RETURNING updated_at
 	`
 
-	row := r.db.Pool.QueryRow(ctx, query, profession.ID, profession.Name)
+	row := r.db.Conn(ctx).QueryRow(ctx, query, profession.ID, profession.Name)
 	return row.Scan(&profession.UpdatedAt)
 }
 
 func (r *professionRepository) Delete(ctx context.Context, id int) error {
 	query := `DELETE FROM professions WHERE id = $1`
-	_, err := r.db.Pool.Exec(ctx, query, id)
-	return err
+	_, err := r.db.Conn(ctx).Exec(ctx, query, id)
+	return dbError(err, entities.ErrProfessionNotFound)
 }
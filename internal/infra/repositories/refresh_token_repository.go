@@ -2,8 +2,6 @@ package repositories
 
 import (
 	"context"
-	"database/sql"
-	"fmt"
 
 	"github.com/joaopanucci/apsdigital/internal/domain/entities"
 	"github.com/joaopanucci/apsdigital/internal/infra/db"
@@ -19,56 +17,161 @@ func NewRefreshTokenRepository(db *db.PostgresDB) *refreshTokenRepository {
 	return &refreshTokenRepository{db: db}
 }
 
-func (r *refreshTokenRepository) Create(ctx context.Context, token *entities.RefreshToken) error {
-	query := `
-		INSERT INTO refresh_tokens (id, user_id, token, expires_at)
-		VALUES ($1, $2, $3, $4)
-	`
+const insertRefreshTokenQuery = `
+	INSERT INTO refresh_tokens (id, user_id, token_hash, family_id, device_name, user_agent, ip_address, expires_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	RETURNING created_at
+`
 
+func (r *refreshTokenRepository) Create(ctx context.Context, token *entities.RefreshToken) error {
 	token.ID = uuid.New()
-	_, err := r.db.Pool.Exec(ctx, query, token.ID, token.UserID, token.Token, token.ExpiresAt)
-	return err
+	return r.db.Conn(ctx).QueryRow(ctx, insertRefreshTokenQuery,
+		token.ID, token.UserID, token.TokenHash, token.FamilyID,
+		token.DeviceName, token.UserAgent, token.IPAddress, token.ExpiresAt,
+	).Scan(&token.CreatedAt)
 }
 
-func (r *refreshTokenRepository) GetByToken(ctx context.Context, token string) (*entities.RefreshToken, error) {
+func (r *refreshTokenRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*entities.RefreshToken, error) {
 	query := `
-		SELECT id, user_id, token, expires_at, is_revoked, created_at
+		SELECT id, user_id, token_hash, family_id, replaced_by,
+		       COALESCE(device_name, ''), COALESCE(user_agent, ''), COALESCE(ip_address, ''),
+		       expires_at, last_used_at, revoked_at, is_revoked, created_at
 		FROM refresh_tokens
-		WHERE token = $1 AND is_revoked = false AND expires_at > NOW()
+		WHERE token_hash = $1
 	`
 
-	row := r.db.Pool.QueryRow(ctx, query, token)
+	row := r.db.Conn(ctx).QueryRow(ctx, query, tokenHash)
 
 	var refreshToken entities.RefreshToken
 	err := row.Scan(
-		&refreshToken.ID, &refreshToken.UserID, &refreshToken.Token,
-		&refreshToken.ExpiresAt, &refreshToken.IsRevoked, &refreshToken.CreatedAt,
+		&refreshToken.ID, &refreshToken.UserID, &refreshToken.TokenHash, &refreshToken.FamilyID, &refreshToken.ReplacedBy,
+		&refreshToken.DeviceName, &refreshToken.UserAgent, &refreshToken.IPAddress,
+		&refreshToken.ExpiresAt, &refreshToken.LastUsedAt, &refreshToken.RevokedAt, &refreshToken.IsRevoked, &refreshToken.CreatedAt,
 	)
 
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("refresh token not found or expired")
-		}
-		return nil, err
+		return nil, dbError(err, entities.ErrSessionNotFound)
 	}
 
 	return &refreshToken, nil
 }
 
-func (r *refreshTokenRepository) RevokeByUserID(ctx context.Context, userID uuid.UUID) error {
-	query := `UPDATE refresh_tokens SET is_revoked = true WHERE user_id = $1`
-	_, err := r.db.Pool.Exec(ctx, query, userID)
+func (r *refreshTokenRepository) Rotate(ctx context.Context, oldID uuid.UUID, newToken *entities.RefreshToken) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	newToken.ID = uuid.New()
+	if err := tx.QueryRow(ctx, insertRefreshTokenQuery,
+		newToken.ID, newToken.UserID, newToken.TokenHash, newToken.FamilyID,
+		newToken.DeviceName, newToken.UserAgent, newToken.IPAddress, newToken.ExpiresAt,
+	).Scan(&newToken.CreatedAt); err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE refresh_tokens
+		SET is_revoked = true, revoked_at = NOW(), last_used_at = NOW(), replaced_by = $2
+		WHERE id = $1 AND is_revoked = false
+	`
+
+	cmdTag, err := tx.Exec(ctx, query, oldID, newToken.ID)
+	if err != nil {
+		return err
+	}
+
+	// Another request rotated the same token first
+	if cmdTag.RowsAffected() == 0 {
+		return entities.ErrSessionNotFound
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *refreshTokenRepository) RevokeFamily(ctx context.Context, familyID uuid.UUID) error {
+	query := `UPDATE refresh_tokens SET is_revoked = true, revoked_at = NOW() WHERE family_id = $1 AND is_revoked = false`
+	_, err := r.db.Conn(ctx).Exec(ctx, query, familyID)
 	return err
 }
 
-func (r *refreshTokenRepository) RevokeToken(ctx context.Context, token string) error {
-	query := `UPDATE refresh_tokens SET is_revoked = true WHERE token = $1`
-	_, err := r.db.Pool.Exec(ctx, query, token)
+func (r *refreshTokenRepository) RevokeUserFamily(ctx context.Context, userID, familyID uuid.UUID) error {
+	query := `
+		UPDATE refresh_tokens SET is_revoked = true, revoked_at = NOW()
+		WHERE user_id = $1 AND family_id = $2 AND is_revoked = false
+	`
+
+	cmdTag, err := r.db.Conn(ctx).Exec(ctx, query, userID, familyID)
+	if err != nil {
+		return err
+	}
+
+	if cmdTag.RowsAffected() == 0 {
+		return entities.ErrSessionNotFound
+	}
+
+	return nil
+}
+
+// IsFamilyActive reports whether the session still has a live refresh token
+func (r *refreshTokenRepository) IsFamilyActive(ctx context.Context, familyID uuid.UUID) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM refresh_tokens
+			WHERE family_id = $1 AND is_revoked = false AND expires_at > NOW()
+		)
+	`
+
+	var active bool
+	err := r.db.Conn(ctx).QueryRow(ctx, query, familyID).Scan(&active)
+	return active, err
+}
+
+func (r *refreshTokenRepository) RevokeByUserID(ctx context.Context, userID uuid.UUID) error {
+	query := `UPDATE refresh_tokens SET is_revoked = true, revoked_at = NOW() WHERE user_id = $1 AND is_revoked = false`
+	_, err := r.db.Conn(ctx).Exec(ctx, query, userID)
 	return err
 }
 
-func (r *refreshTokenRepository) CleanupExpired(ctx context.Context) error {
+// ListSessions returns one entry per family that still has a live token
+func (r *refreshTokenRepository) ListSessions(ctx context.Context, userID uuid.UUID) ([]*entities.Session, error) {
+	query := `
+		SELECT t.family_id, COALESCE(t.device_name, ''), COALESCE(t.user_agent, ''), COALESCE(t.ip_address, ''),
+		       (SELECT MIN(f.created_at) FROM refresh_tokens f WHERE f.family_id = t.family_id),
+		       t.created_at, t.expires_at
+		FROM refresh_tokens t
+		WHERE t.user_id = $1 AND t.is_revoked = false AND t.expires_at > NOW()
+		ORDER BY t.created_at DESC
+	`
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []*entities.Session
+	for rows.Next() {
+		var session entities.Session
+		err := rows.Scan(
+			&session.ID, &session.DeviceName, &session.UserAgent, &session.IPAddress,
+			&session.CreatedAt, &session.LastUsedAt, &session.ExpiresAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, &session)
+	}
+
+	return sessions, rows.Err()
+}
+
+func (r *refreshTokenRepository) CleanupExpired(ctx context.Context) (int64, error) {
 	query := `DELETE FROM refresh_tokens WHERE expires_at < NOW()`
-	_, err := r.db.Pool.Exec(ctx, query)
-	return err
+	cmdTag, err := r.db.Conn(ctx).Exec(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	return cmdTag.RowsAffected(), nil
 }
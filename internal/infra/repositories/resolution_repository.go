@@ -3,7 +3,6 @@ package repositories
 import (
 	"context"
 	"fmt"
-	"strings"
 
 	"github.com/joaopanucci/apsdigital/internal/domain/entities"
 	"github.com/joaopanucci/apsdigital/internal/infra/db"
@@ -24,12 +23,12 @@ func (r *ResolutionRepository) Create(ctx context.Context, resolution *entities.
 		RETURNING id, created_at, updated_at
 	`
 
-	row := r.db.Pool.QueryRow(ctx, query,
+	row := r.db.Conn(ctx).QueryRow(ctx, query,
 		resolution.Title, resolution.FileURL, resolution.Competence,
 		resolution.Type, resolution.Year, resolution.Number,
 		resolution.UploadedBy, resolution.MunicipalityID)
 
-	return row.Scan(&resolution.ID, &resolution.CreatedAt, &resolution.UpdatedAt)
+	return dbError(row.Scan(&resolution.ID, &resolution.CreatedAt, &resolution.UpdatedAt), nil)
 }
 
 func (r *ResolutionRepository) GetByID(ctx context.Context, id string) (*entities.Resolution, error) {
@@ -44,7 +43,7 @@ func (r *ResolutionRepository) GetByID(ctx context.Context, id string) (*entitie
 	`
 
 	var resolution entities.Resolution
-	row := r.db.Pool.QueryRow(ctx, query, id)
+	row := r.db.Conn(ctx).QueryRow(ctx, query, id)
 
 	err := row.Scan(
 		&resolution.ID, &resolution.Title, &resolution.FileURL, &resolution.Competence, &resolution.Type,
@@ -53,81 +52,65 @@ func (r *ResolutionRepository) GetByID(ctx context.Context, id string) (*entitie
 	)
 
 	if err != nil {
-		return nil, err
+		return nil, dbError(err, entities.ErrResolutionNotFound)
 	}
 
 	return &resolution, nil
 }
 
-func (r *ResolutionRepository) GetAll(ctx context.Context, filters map[string]interface{}) ([]*entities.Resolution, error) {
-	query := `
-		SELECT r.id, r.title, r.file_url, r.competence, r.type, r.year, r.number, r.uploaded_by, r.municipality_id, r.created_at, r.updated_at,
-		       u.name as uploaded_by_name, u.cpf as uploaded_by_cpf,
-		       m.name as municipality_name
+// resolutionSortColumns are the fields resolutions can be sorted by
+var resolutionSortColumns = map[string]string{
+	"created_at":   "r.created_at",
+	"year":         "r.year",
+	"number":       "r.number",
+	"title":        "r.title",
+	"type":         "r.type",
+	"municipality": "m.name",
+}
+
+// resolutionWhere builds the conditions of a resolution list
+func resolutionWhere(f entities.ResolutionFilter) *db.Where {
+	where := &db.Where{}
+	where.AddIf(f.MunicipalityID != nil, "r.municipality_id = ?", f.MunicipalityID)
+	// Restrict to the municipalities of the user's scope
+	where.AddIf(f.MunicipalityIDs != nil, "r.municipality_id = ANY(?)", f.MunicipalityIDs)
+	where.AddIf(f.Year > 0, "r.year = ?", f.Year)
+	where.AddIf(f.Type != "", "r.type = ?", f.Type)
+	where.AddIf(f.Number != "", "r.number ILIKE ?", "%"+f.Number+"%")
+	where.AddIf(f.Competence != "", "r.competence = ?", f.Competence)
+	return where
+}
+
+// GetAll returns a page of resolutions and the number of resolutions matching the filters
+func (r *ResolutionRepository) GetAll(ctx context.Context, q entities.ListQuery[entities.ResolutionFilter]) (*entities.Page[*entities.Resolution], error) {
+	where := resolutionWhere(q.Filters)
+
+	from := `
 		FROM resolutions r
 		LEFT JOIN users u ON r.uploaded_by = u.id
 		LEFT JOIN municipalities m ON r.municipality_id = m.id
 	`
 
-	var whereConditions []string
-	var args []interface{}
-	argIndex := 1
-
-	// Filter by municipality
-	if municipalityID, ok := filters["municipality_id"]; ok && municipalityID != nil {
-		whereConditions = append(whereConditions, fmt.Sprintf("r.municipality_id = $%d", argIndex))
-		args = append(args, municipalityID)
-		argIndex++
-	}
-
-	// Filter by year
-	if year, ok := filters["year"]; ok && year != nil && year != "" {
-		whereConditions = append(whereConditions, fmt.Sprintf("r.year = $%d", argIndex))
-		args = append(args, year)
-		argIndex++
-	}
-
-	// Filter by type
-	if resType, ok := filters["type"]; ok && resType != nil && resType != "" {
-		whereConditions = append(whereConditions, fmt.Sprintf("r.type = $%d", argIndex))
-		args = append(args, resType)
-		argIndex++
-	}
-
-	// Filter by number
-	if number, ok := filters["number"]; ok && number != nil && number != "" {
-		whereConditions = append(whereConditions, fmt.Sprintf("r.number ILIKE $%d", argIndex))
-		args = append(args, "%"+fmt.Sprintf("%v", number)+"%")
-		argIndex++
-	}
-
-	// Filter by competence
-	if competence, ok := filters["competence"]; ok && competence != nil && competence != "" {
-		whereConditions = append(whereConditions, fmt.Sprintf("r.competence = $%d", argIndex))
-		args = append(args, competence)
-		argIndex++
+	var total int
+	if err := r.db.Conn(ctx).QueryRow(ctx, "SELECT COUNT(*)"+from+where.SQL(), where.Args()...).Scan(&total); err != nil {
+		return nil, err
 	}
 
-	if len(whereConditions) > 0 {
-		query += " WHERE " + strings.Join(whereConditions, " AND ")
+	order, err := orderBy(q.Sort, resolutionSortColumns, "r.created_at DESC", "r.id")
+	if err != nil {
+		return nil, err
 	}
 
-	query += " ORDER BY r.created_at DESC"
-
-	// Add limit if specified
-	if limit, ok := filters["limit"]; ok && limit != nil {
-		query += fmt.Sprintf(" LIMIT $%d", argIndex)
-		args = append(args, limit)
-		argIndex++
+	if err := keyset(where, order, q); err != nil {
+		return nil, err
 	}
 
-	// Add offset if specified
-	if offset, ok := filters["offset"]; ok && offset != nil {
-		query += fmt.Sprintf(" OFFSET $%d", argIndex)
-		args = append(args, offset)
-	}
+	query, args := paginate(`
+		SELECT `+order.Key()+`, r.id, r.title, r.file_url, r.competence, r.type, r.year, r.number, r.uploaded_by, r.municipality_id, r.created_at, r.updated_at,
+		       u.name as uploaded_by_name, u.cpf as uploaded_by_cpf,
+		       m.name as municipality_name`+from+where.SQL()+order.SQL(), where.Args(), q)
 
-	rows, err := r.db.Pool.Query(ctx, query, args...)
+	rows, err := queryPage(ctx, r.db.Conn(ctx), query, args)
 	if err != nil {
 		return nil, err
 	}
@@ -147,7 +130,11 @@ func (r *ResolutionRepository) GetAll(ctx context.Context, filters map[string]in
 		resolutions = append(resolutions, &resolution)
 	}
 
-	return resolutions, nil
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return listPage(resolutions, total, q, rows), nil
 }
 
 func (r *ResolutionRepository) Update(ctx context.Context, resolution *entities.Resolution) error {
@@ -157,33 +144,49 @@ func (r *ResolutionRepository) Update(ctx context.Context, resolution *entities.
 		WHERE id = $1
 	`
 
-	_, err := r.db.Pool.Exec(ctx, query,
+	cmdTag, err := r.db.Conn(ctx).Exec(ctx, query,
 		resolution.ID, resolution.Title, resolution.FileURL, resolution.Competence,
 		resolution.Type, resolution.Year, resolution.Number)
-	return err
+	if err != nil {
+		return dbError(err, entities.ErrResolutionNotFound)
+	}
+
+	if cmdTag.RowsAffected() == 0 {
+		return entities.ErrResolutionNotFound
+	}
+
+	return nil
 }
 
 func (r *ResolutionRepository) Delete(ctx context.Context, id string) error {
 	query := "DELETE FROM resolutions WHERE id = $1"
-	_, err := r.db.Pool.Exec(ctx, query, id)
-	return err
+	cmdTag, err := r.db.Conn(ctx).Exec(ctx, query, id)
+	if err != nil {
+		return dbError(err, entities.ErrResolutionNotFound)
+	}
+
+	if cmdTag.RowsAffected() == 0 {
+		return entities.ErrResolutionNotFound
+	}
+
+	return nil
 }
 
-func (r *ResolutionRepository) GetTypes(ctx context.Context, municipalityID *uint) ([]string, error) {
+func (r *ResolutionRepository) GetTypes(ctx context.Context, municipalityIDs []int) ([]string, error) {
 	query := `
 		SELECT DISTINCT type 
 		FROM resolutions 
 	`
 
 	var args []interface{}
-	if municipalityID != nil {
-		query += " WHERE municipality_id = $1"
-		args = append(args, *municipalityID)
+	if municipalityIDs != nil {
+		query += " WHERE municipality_id = ANY($1)"
+		args = append(args, municipalityIDs)
 	}
 
 	query += " ORDER BY type ASC"
 
-	rows, err := r.db.Pool.Query(ctx, query, args...)
+	rows, err := r.db.Conn(ctx).Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -201,21 +204,21 @@ func (r *ResolutionRepository) GetTypes(ctx context.Context, municipalityID *uin
 	return types, nil
 }
 
-func (r *ResolutionRepository) GetYears(ctx context.Context, municipalityID *uint) ([]int, error) {
+func (r *ResolutionRepository) GetYears(ctx context.Context, municipalityIDs []int) ([]int, error) {
 	query := `
 		SELECT DISTINCT year 
 		FROM resolutions 
 	`
 
 	var args []interface{}
-	if municipalityID != nil {
-		query += " WHERE municipality_id = $1"
-		args = append(args, *municipalityID)
+	if municipalityIDs != nil {
+		query += " WHERE municipality_id = ANY($1)"
+		args = append(args, municipalityIDs)
 	}
 
 	query += " ORDER BY year DESC"
 
-	rows, err := r.db.Pool.Query(ctx, query, args...)
+	rows, err := r.db.Conn(ctx).Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -233,7 +236,7 @@ func (r *ResolutionRepository) GetYears(ctx context.Context, municipalityID *uin
 	return years, nil
 }
 
-func (r *ResolutionRepository) GetRecent(ctx context.Context, municipalityID *uint, limit int) ([]*entities.Resolution, error) {
+func (r *ResolutionRepository) GetRecent(ctx context.Context, municipalityIDs []int, limit int) ([]*entities.Resolution, error) {
 	query := `
 		SELECT r.id, r.title, r.file_url, r.competence, r.type, r.year, r.number, r.uploaded_by, r.municipality_id, r.created_at, r.updated_at,
 		       u.name as uploaded_by_name, u.cpf as uploaded_by_cpf,
@@ -244,9 +247,9 @@ func (r *ResolutionRepository) GetRecent(ctx context.Context, municipalityID *ui
 	`
 
 	var args []interface{}
-	if municipalityID != nil {
-		query += " WHERE r.municipality_id = $1"
-		args = append(args, *municipalityID)
+	if municipalityIDs != nil {
+		query += " WHERE r.municipality_id = ANY($1)"
+		args = append(args, municipalityIDs)
 		query += fmt.Sprintf(" ORDER BY r.created_at DESC LIMIT $%d", len(args)+1)
 		args = append(args, limit)
 	} else {
@@ -254,7 +257,7 @@ func (r *ResolutionRepository) GetRecent(ctx context.Context, municipalityID *ui
 		args = append(args, limit)
 	}
 
-	rows, err := r.db.Pool.Query(ctx, query, args...)
+	rows, err := r.db.Conn(ctx).Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
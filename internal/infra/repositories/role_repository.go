@@ -2,9 +2,8 @@ package repositories
 
 import (
 	"context"
-	"database/sql"
-	"fmt"
 
+	"github.com/joaopanucci/apsdigital/internal/domain/apperrors"
 	"github.com/joaopanucci/apsdigital/internal/domain/entities"
 	"github.com/joaopanucci/apsdigital/internal/infra/db"
 
@@ -26,8 +25,8 @@ func (r *roleRepository) Create(ctx context.Context, role *entities.Role) error
 	`
 
 	role.ID = uuid.New()
-	_, err := r.db.Pool.Exec(ctx, query, role.ID, role.Name, role.Description, role.Level)
-	return err
+	_, err := r.db.Conn(ctx).Exec(ctx, query, role.ID, role.Name, role.Description, role.Level)
+	return dbError(err, nil)
 }
 
 func (r *roleRepository) GetByID(ctx context.Context, id uuid.UUID) (*entities.Role, error) {
@@ -37,16 +36,13 @@ func (r *roleRepository) GetByID(ctx context.Context, id uuid.UUID) (*entities.R
 		WHERE id = $1
 	`
 
-	row := r.db.Pool.QueryRow(ctx, query, id)
+	row := r.db.Conn(ctx).QueryRow(ctx, query, id)
 
 	var role entities.Role
 	err := row.Scan(&role.ID, &role.Name, &role.Description, &role.Level, &role.CreatedAt, &role.UpdatedAt)
 
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("role not found")
-		}
-		return nil, err
+		return nil, dbError(err, entities.ErrRoleNotFound)
 	}
 
 	return &role, nil
@@ -59,16 +55,13 @@ func (r *roleRepository) GetByName(ctx context.Context, name string) (*entities.
 		WHERE name = $1
 	`
 
-	row := r.db.Pool.QueryRow(ctx, query, name)
+	row := r.db.Conn(ctx).QueryRow(ctx, query, name)
 
 	var role entities.Role
 	err := row.Scan(&role.ID, &role.Name, &role.Description, &role.Level, &role.CreatedAt, &role.UpdatedAt)
 
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("role not found")
-		}
-		return nil, err
+		return nil, dbError(err, entities.ErrRoleNotFound)
 	}
 
 	return &role, nil
@@ -81,7 +74,7 @@ func (r *roleRepository) List(ctx context.Context) ([]*entities.Role, error) {
 		ORDER BY level ASC
 	`
 
-	rows, err := r.db.Pool.Query(ctx, query)
+	rows, err := r.db.Conn(ctx).Query(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -108,12 +101,94 @@ func (r *roleRepository) Update(ctx context.Context, role *entities.Role) error
 		WHERE id = $1
 	`
 
-	_, err := r.db.Pool.Exec(ctx, query, role.ID, role.Name, role.Description, role.Level)
-	return err
+	_, err := r.db.Conn(ctx).Exec(ctx, query, role.ID, role.Name, role.Description, role.Level)
+	return dbError(err, entities.ErrRoleNotFound)
 }
 
 func (r *roleRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	query := `DELETE FROM roles WHERE id = $1`
-	_, err := r.db.Pool.Exec(ctx, query, id)
-	return err
+	_, err := r.db.Conn(ctx).Exec(ctx, query, id)
+	return dbError(err, entities.ErrRoleNotFound)
+}
+
+func (r *roleRepository) ListPermissions(ctx context.Context) ([]*entities.Permission, error) {
+	query := `
+		SELECT id, name, COALESCE(description, ''), created_at
+		FROM permissions
+		ORDER BY name ASC
+	`
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var permissions []*entities.Permission
+
+	for rows.Next() {
+		var permission entities.Permission
+		if err := rows.Scan(&permission.ID, &permission.Name, &permission.Description, &permission.CreatedAt); err != nil {
+			return nil, err
+		}
+		permissions = append(permissions, &permission)
+	}
+
+	return permissions, rows.Err()
+}
+
+func (r *roleRepository) GetPermissions(ctx context.Context, roleID uuid.UUID) ([]string, error) {
+	query := `
+		SELECT p.name
+		FROM role_permissions rp
+		JOIN permissions p ON rp.permission_id = p.id
+		WHERE rp.role_id = $1
+		ORDER BY p.name ASC
+	`
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, roleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var permissions []string
+
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		permissions = append(permissions, name)
+	}
+
+	return permissions, rows.Err()
+}
+
+func (r *roleRepository) SetPermissions(ctx context.Context, roleID uuid.UUID, permissions []string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return dbError(err, nil)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM role_permissions WHERE role_id = $1`, roleID); err != nil {
+		return dbError(err, nil)
+	}
+
+	query := `
+		INSERT INTO role_permissions (role_id, permission_id)
+		SELECT $1, id FROM permissions WHERE name = ANY($2)
+	`
+
+	cmdTag, err := tx.Exec(ctx, query, roleID, permissions)
+	if err != nil {
+		return dbError(err, nil)
+	}
+
+	if cmdTag.RowsAffected() != int64(len(permissions)) {
+		return apperrors.Invalid("permissions", "permission_unknown")
+	}
+
+	return tx.Commit(ctx)
 }
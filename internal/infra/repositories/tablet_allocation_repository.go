@@ -0,0 +1,228 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/joaopanucci/apsdigital/internal/domain/entities"
+	"github.com/joaopanucci/apsdigital/internal/infra/db"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type tabletAllocationRepository struct {
+	db *db.PostgresDB
+}
+
+func NewTabletAllocationRepository(db *db.PostgresDB) *tabletAllocationRepository {
+	return &tabletAllocationRepository{db: db}
+}
+
+// PendingNewRequests lists the oldest pending new-tablet request of each active user who
+// still has no tablet, oldest first
+func (r *tabletAllocationRepository) PendingNewRequests(ctx context.Context, municipalityIDs []int) ([]*entities.AllocationRequest, error) {
+	where := &db.Where{}
+	where.Add("tr.type = ?", entities.TabletRequestTypeNew)
+	where.Add("tr.status = ?", entities.TabletRequestStatusPending)
+	where.Add("u.status = ?", entities.UserStatusActive)
+	where.Add("u.municipality_id IS NOT NULL")
+	where.Add("NOT EXISTS (SELECT 1 FROM tablets t WHERE t.assigned_user_id = u.id AND t.status IN ('atribuido', 'ativo'))")
+	where.AddIf(municipalityIDs != nil, "u.municipality_id = ANY(?)", municipalityIDs)
+
+	query := `
+		SELECT id, user_id, name, municipality_id, created_at
+		FROM (
+			SELECT DISTINCT ON (u.id) tr.id, u.id AS user_id, u.name, u.municipality_id, tr.created_at
+			FROM tablet_requests tr
+			JOIN users u ON u.id = tr.user_id
+	` + where.SQL() + `
+			ORDER BY u.id, tr.created_at
+		) requests
+		ORDER BY created_at
+	`
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, where.Args()...)
+	if err != nil {
+		return nil, fmt.Errorf("error listing pending tablet requests: %w", err)
+	}
+	defer rows.Close()
+
+	var requests []*entities.AllocationRequest
+	for rows.Next() {
+		request := &entities.AllocationRequest{}
+		if err := rows.Scan(&request.RequestID, &request.UserID, &request.UserName, &request.MunicipalityID, &request.RequestedAt); err != nil {
+			return nil, fmt.Errorf("error scanning tablet request: %w", err)
+		}
+		requests = append(requests, request)
+	}
+
+	return requests, rows.Err()
+}
+
+// MunicipalityCoverage counts the active ACS, those holding a tablet and the available
+// tablets of each active municipality that has any of them
+func (r *tabletAllocationRepository) MunicipalityCoverage(ctx context.Context, municipalityIDs []int) ([]*entities.MunicipalityAllocation, error) {
+	where := &db.Where{}
+	where.Add("m.active = true")
+	where.AddIf(municipalityIDs != nil, "m.id = ANY(?)", municipalityIDs)
+
+	query := `
+		SELECT id, name, health_region_id, acs, acs_with_tablet, available
+		FROM (
+			SELECT m.id, m.name, m.health_region_id,
+			       COUNT(u.id) AS acs,
+			       COUNT(u.id) FILTER (WHERE EXISTS (
+			           SELECT 1 FROM tablets t
+			           WHERE t.assigned_user_id = u.id AND t.status IN ('atribuido', 'ativo')
+			       )) AS acs_with_tablet,
+			       (SELECT COUNT(*) FROM tablets t WHERE t.municipality_id = m.id AND t.status = 'disponivel') AS available
+			FROM municipalities m
+			LEFT JOIN (
+				users u JOIN roles ro ON ro.id = u.role_id AND ro.name = 'ACS'
+			) ON u.municipality_id = m.id AND u.status = 'active'
+	` + where.SQL() + `
+			GROUP BY m.id
+		) coverage
+		WHERE acs > 0 OR available > 0
+		ORDER BY name
+	`
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, where.Args()...)
+	if err != nil {
+		return nil, fmt.Errorf("error counting tablet coverage: %w", err)
+	}
+	defer rows.Close()
+
+	var municipalities []*entities.MunicipalityAllocation
+	for rows.Next() {
+		m := &entities.MunicipalityAllocation{}
+		if err := rows.Scan(&m.MunicipalityID, &m.MunicipalityName, &m.HealthRegionID, &m.ActiveACS, &m.ACSWithTablet, &m.Available); err != nil {
+			return nil, fmt.Errorf("error scanning tablet coverage: %w", err)
+		}
+		municipalities = append(municipalities, m)
+	}
+
+	return municipalities, rows.Err()
+}
+
+// AvailableTablets lists the tablets in stock, oldest first
+func (r *tabletAllocationRepository) AvailableTablets(ctx context.Context, municipalityIDs []int) ([]*entities.Tablet, error) {
+	where := &db.Where{}
+	where.Add("status = ?", entities.TabletStatusAvailable)
+	where.AddIf(municipalityIDs != nil, "municipality_id = ANY(?)", municipalityIDs)
+
+	query := `
+		SELECT id, COALESCE(serial_number, ''), COALESCE(model, ''), status, municipality_id, COALESCE(asset_code, ''), created_at
+		FROM tablets
+	` + where.SQL() + `
+		ORDER BY created_at, id
+	`
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, where.Args()...)
+	if err != nil {
+		return nil, fmt.Errorf("error listing available tablets: %w", err)
+	}
+	defer rows.Close()
+
+	var tablets []*entities.Tablet
+	for rows.Next() {
+		tablet := &entities.Tablet{}
+		if err := rows.Scan(&tablet.ID, &tablet.SerialNumber, &tablet.Model, &tablet.Status, &tablet.MunicipalityID, &tablet.AssetCode, &tablet.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning tablet: %w", err)
+		}
+		tablets = append(tablets, tablet)
+	}
+
+	return tablets, rows.Err()
+}
+
+func (r *tabletAllocationRepository) ApplyAllocation(ctx context.Context, decisions []entities.AllocationDecision, municipalityIDs []int, approvedBy uuid.UUID) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	inScope := func(id int) bool {
+		if municipalityIDs == nil {
+			return true
+		}
+		for _, allowed := range municipalityIDs {
+			if allowed == id {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, decision := range decisions {
+		var userID uuid.UUID
+		var userCPF string
+		var userMunicipalityID *int
+		err := tx.QueryRow(ctx, `
+			SELECT u.id, u.cpf, u.municipality_id
+			FROM tablet_requests tr
+			JOIN users u ON u.id = tr.user_id
+			WHERE tr.id = $1 AND tr.type = $2 AND tr.status = $3
+			  AND NOT EXISTS (SELECT 1 FROM tablets t WHERE t.assigned_user_id = u.id AND t.status IN ('atribuido', 'ativo'))
+			FOR UPDATE OF tr
+		`, decision.RequestID, entities.TabletRequestTypeNew, entities.TabletRequestStatusPending).Scan(&userID, &userCPF, &userMunicipalityID)
+		if err == pgx.ErrNoRows || (err == nil && (userMunicipalityID == nil || !inScope(*userMunicipalityID))) {
+			return fmt.Errorf("%w: request %s is no longer pending", entities.ErrAllocationConflict, decision.RequestID)
+		}
+		if err != nil {
+			return fmt.Errorf("error locking tablet request: %w", err)
+		}
+
+		var tabletMunicipalityID int
+		err = tx.QueryRow(ctx, `
+			SELECT municipality_id FROM tablets WHERE id = $1 AND status = $2 FOR UPDATE
+		`, decision.TabletID, entities.TabletStatusAvailable).Scan(&tabletMunicipalityID)
+		if err == pgx.ErrNoRows || (err == nil && !inScope(tabletMunicipalityID)) {
+			return fmt.Errorf("%w: tablet %d is no longer available", entities.ErrAllocationConflict, decision.TabletID)
+		}
+		if err != nil {
+			return fmt.Errorf("error locking tablet: %w", err)
+		}
+
+		if tabletMunicipalityID != *userMunicipalityID {
+			_, err = tx.Exec(ctx, `
+				INSERT INTO tablet_events (tablet_id, type, from_status, to_status, user_id, note, from_municipality_id, to_municipality_id, created_at)
+				VALUES ($1, $2, $3, $3, $4, 'Plano de distribuição', $5, $6, NOW())
+			`, decision.TabletID, entities.TabletEventTransferred, entities.TabletStatusAvailable, approvedBy, tabletMunicipalityID, *userMunicipalityID)
+			if err != nil {
+				return fmt.Errorf("error recording tablet transfer: %w", err)
+			}
+		}
+
+		_, err = tx.Exec(ctx, `
+			UPDATE tablets
+			SET municipality_id = $2, status = $3, assigned_user_id = $4, user_cpf = $5,
+			    assigned_at = NOW(), returned_at = NULL, updated_at = NOW()
+			WHERE id = $1
+		`, decision.TabletID, *userMunicipalityID, entities.TabletStatusAssigned, userID, userCPF)
+		if err != nil {
+			return fmt.Errorf("error assigning tablet: %w", err)
+		}
+
+		_, err = tx.Exec(ctx, `
+			INSERT INTO tablet_events (tablet_id, type, from_status, to_status, user_id, created_at)
+			VALUES ($1, $2, $3, $4, $5, NOW())
+		`, decision.TabletID, entities.TabletEventAssigned, entities.TabletStatusAvailable, entities.TabletStatusAssigned, userID)
+		if err != nil {
+			return fmt.Errorf("error recording tablet assignment: %w", err)
+		}
+
+		_, err = tx.Exec(ctx, `
+			UPDATE tablet_requests
+			SET status = $2, approved_by = $3, approved_at = NOW(), updated_at = NOW()
+			WHERE id = $1
+		`, decision.RequestID, entities.TabletRequestStatusCompleted, approvedBy)
+		if err != nil {
+			return fmt.Errorf("error completing tablet request: %w", err)
+		}
+	}
+
+	return tx.Commit(ctx)
+}
@@ -2,13 +2,15 @@ package repositories
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"strings"
+	"time"
 
 	"github.com/joaopanucci/apsdigital/internal/domain/entities"
 	"github.com/joaopanucci/apsdigital/internal/infra/db"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 type tabletRepository struct {
@@ -21,63 +23,166 @@ func NewTabletRepository(db *db.PostgresDB) *tabletRepository {
 
 func (r *tabletRepository) Create(ctx context.Context, tablet *entities.Tablet) error {
 	query := `
-		INSERT INTO tablets (serial_number, model, status, assigned_user_id, user_cpf, assigned_at, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, NOW(), NOW())
+		INSERT INTO tablets (serial_number, model, status, assigned_user_id, user_cpf, assigned_at, municipality_id, asset_code, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), NOW(), NOW())
 		RETURNING id
 	`
 	
-	err := r.db.Pool.QueryRow(ctx, query,
+	err := r.db.Conn(ctx).QueryRow(ctx, query,
 		tablet.SerialNumber,
 		tablet.Model,
 		tablet.Status,
 		tablet.AssignedUserID,
 		tablet.UserCPF,
 		tablet.AssignedAt,
+		tablet.MunicipalityID,
+		tablet.AssetCode,
 	).Scan(&tablet.ID)
 	
 	if err != nil {
-		return fmt.Errorf("error creating tablet: %w", err)
+		if dup := duplicateTabletError(err, tablet); dup != nil {
+			return dup
+		}
+		return fmt.Errorf("error creating tablet: %w", dbError(err, nil))
 	}
 	
 	return nil
 }
 
+// CreateBatch inserts the tablets and their registration events in one transaction. Each
+// tablet runs in a savepoint, so a rejected row is reported in its slot of the returned
+// errors without discarding the others.
+func (r *tabletRepository) CreateBatch(ctx context.Context, tablets []*entities.Tablet) ([]error, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, dbError(err, nil)
+	}
+	defer tx.Rollback(ctx)
+	
+	insertTablet := `
+		INSERT INTO tablets (serial_number, model, status, municipality_id, asset_code, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), NOW(), NOW())
+		RETURNING id, created_at, updated_at
+	`
+	insertEvent := `
+		INSERT INTO tablet_events (tablet_id, type, to_status, created_at)
+		VALUES ($1, $2, $3, NOW())
+	`
+	
+	rowErrors := make([]error, len(tablets))
+	for i, tablet := range tablets {
+		savepoint, err := tx.Begin(ctx)
+		if err != nil {
+			return nil, dbError(err, nil)
+		}
+		
+		err = savepoint.QueryRow(ctx, insertTablet,
+			tablet.SerialNumber,
+			tablet.Model,
+			tablet.Status,
+			tablet.MunicipalityID,
+			tablet.AssetCode,
+		).Scan(&tablet.ID, &tablet.CreatedAt, &tablet.UpdatedAt)
+		if err == nil {
+			_, err = savepoint.Exec(ctx, insertEvent, tablet.ID, entities.TabletEventCreated, tablet.Status)
+		}
+		
+		if err != nil {
+			if dup := duplicateTabletError(err, tablet); dup != nil {
+				rowErrors[i] = dup
+			} else {
+				rowErrors[i] = fmt.Errorf("error creating tablet: %w", dbError(err, nil))
+			}
+			tablet.ID = 0
+			if err := savepoint.Rollback(ctx); err != nil {
+				return nil, dbError(err, nil)
+			}
+			continue
+		}
+		
+		if err := savepoint.Commit(ctx); err != nil {
+			return nil, dbError(err, nil)
+		}
+	}
+	
+	if err := tx.Commit(ctx); err != nil {
+		return nil, dbError(err, nil)
+	}
+	
+	return rowErrors, nil
+}
+
+// duplicateTabletError describes a unique violation on the serial number or asset code,
+// or returns nil for any other error
+func duplicateTabletError(err error, tablet *entities.Tablet) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != "23505" {
+		return nil
+	}
+	
+	switch pgErr.ConstraintName {
+	case "idx_tablets_serial_number_unique":
+		return duplicate("serial_number", "serial_number_taken", "tablet_serial_number_taken", tablet.SerialNumber).Wrap(err)
+	case "tablets_asset_code_key":
+		return duplicate("asset_code", "asset_code_taken", "tablet_asset_code_taken", tablet.AssetCode).Wrap(err)
+	}
+	return nil
+}
+
 func (r *tabletRepository) GetByID(ctx context.Context, id int) (*entities.Tablet, error) {
+	return r.getOne(ctx, "t.id = $1", id)
+}
+
+func (r *tabletRepository) GetByAssetCode(ctx context.Context, assetCode string) (*entities.Tablet, error) {
+	return r.getOne(ctx, "t.asset_code = $1", assetCode)
+}
+
+// getOne loads the tablet matching condition together with its municipality name
+func (r *tabletRepository) getOne(ctx context.Context, condition string, arg interface{}) (*entities.Tablet, error) {
 	query := `
-		SELECT id, serial_number, model, status, assigned_user_id, user_cpf, assigned_at, created_at, updated_at
-		FROM tablets
-		WHERE id = $1
-	`
+		SELECT t.id, COALESCE(t.serial_number, ''), COALESCE(t.model, ''), t.status, t.assigned_user_id, t.user_cpf,
+		       t.municipality_id, COALESCE(t.asset_code, ''), t.assigned_at, t.created_at, t.updated_at,
+		       COALESCE(m.name, '')
+		FROM tablets t
+		LEFT JOIN municipalities m ON m.id = t.municipality_id
+		WHERE ` + condition
 	
 	tablet := &entities.Tablet{}
-	err := r.db.Pool.QueryRow(ctx, query, id).Scan(
+	var municipalityName string
+	err := r.db.Conn(ctx).QueryRow(ctx, query, arg).Scan(
 		&tablet.ID,
 		&tablet.SerialNumber,
 		&tablet.Model,
 		&tablet.Status,
 		&tablet.AssignedUserID,
 		&tablet.UserCPF,
+		&tablet.MunicipalityID,
+		&tablet.AssetCode,
 		&tablet.AssignedAt,
 		&tablet.CreatedAt,
 		&tablet.UpdatedAt,
+		&municipalityName,
 	)
 	
 	if err != nil {
 		return nil, fmt.Errorf("error getting tablet: %w", err)
 	}
 	
+	tablet.Municipality = &entities.Municipality{ID: tablet.MunicipalityID, Name: municipalityName}
+	
 	return tablet, nil
 }
 
 func (r *tabletRepository) GetByUserCPF(ctx context.Context, cpf string) ([]*entities.Tablet, error) {
 	query := `
-		SELECT id, serial_number, model, status, assigned_user_id, user_cpf, assigned_at, created_at, updated_at
+		SELECT id, serial_number, model, status, assigned_user_id, user_cpf, municipality_id, COALESCE(asset_code, ''),
+		       assigned_at, created_at, updated_at
 		FROM tablets
 		WHERE user_cpf = $1
 		ORDER BY assigned_at DESC
 	`
 	
-	rows, err := r.db.Pool.Query(ctx, query, cpf)
+	rows, err := r.db.Conn(ctx).Query(ctx, query, cpf)
 	if err != nil {
 		return nil, fmt.Errorf("error getting tablets by user CPF: %w", err)
 	}
@@ -93,6 +198,8 @@ func (r *tabletRepository) GetByUserCPF(ctx context.Context, cpf string) ([]*ent
 			&tablet.Status,
 			&tablet.AssignedUserID,
 			&tablet.UserCPF,
+			&tablet.MunicipalityID,
+			&tablet.AssetCode,
 			&tablet.AssignedAt,
 			&tablet.CreatedAt,
 			&tablet.UpdatedAt,
@@ -108,13 +215,14 @@ func (r *tabletRepository) GetByUserCPF(ctx context.Context, cpf string) ([]*ent
 
 func (r *tabletRepository) GetByAssignedUser(ctx context.Context, userID uuid.UUID) ([]*entities.Tablet, error) {
 	query := `
-		SELECT id, serial_number, model, status, assigned_user_id, user_cpf, assigned_at, created_at, updated_at
+		SELECT id, serial_number, model, status, assigned_user_id, user_cpf, municipality_id, COALESCE(asset_code, ''),
+		       assigned_at, created_at, updated_at
 		FROM tablets
 		WHERE assigned_user_id = $1
 		ORDER BY assigned_at DESC
 	`
 	
-	rows, err := r.db.Pool.Query(ctx, query, userID)
+	rows, err := r.db.Conn(ctx).Query(ctx, query, userID)
 	if err != nil {
 		return nil, fmt.Errorf("error getting tablets by assigned user: %w", err)
 	}
@@ -130,6 +238,8 @@ func (r *tabletRepository) GetByAssignedUser(ctx context.Context, userID uuid.UU
 			&tablet.Status,
 			&tablet.AssignedUserID,
 			&tablet.UserCPF,
+			&tablet.MunicipalityID,
+			&tablet.AssetCode,
 			&tablet.AssignedAt,
 			&tablet.CreatedAt,
 			&tablet.UpdatedAt,
@@ -143,35 +253,63 @@ func (r *tabletRepository) GetByAssignedUser(ctx context.Context, userID uuid.UU
 	return tablets, nil
 }
 
-func (r *tabletRepository) List(ctx context.Context, filters map[string]interface{}) ([]*entities.Tablet, error) {
-	query := `
-		SELECT id, serial_number, model, status, assigned_user_id, user_cpf, assigned_at, created_at, updated_at
-		FROM tablets
-	`
-	
-	var conditions []string
-	var args []interface{}
-	argIndex := 1
+// tabletSortColumns are the fields tablets can be sorted by
+var tabletSortColumns = map[string]string{
+	"created_at":    "t.created_at",
+	"serial_number": "t.serial_number",
+	"model":         "t.model",
+	"status":        "t.status",
+	"assigned_at":   "t.assigned_at",
+}
+
+// tabletWhere builds the conditions of a tablet list
+func tabletWhere(f entities.TabletFilter) *db.Where {
+	where := &db.Where{}
+	where.AddIf(f.SerialNumber != "", "t.serial_number = ?", f.SerialNumber)
+	where.AddIf(f.Status != "", "t.status = ?", f.Status)
+	where.AddIf(f.Model != "", "t.model ILIKE ?", "%"+f.Model+"%")
+	where.AddIf(f.MunicipalityID != nil, "t.municipality_id = ?", f.MunicipalityID)
+	// Restrict to the municipalities of the user's scope
+	where.AddIf(f.MunicipalityIDs != nil, "t.municipality_id = ANY(?)", f.MunicipalityIDs)
+	return where
+}
+
+// List returns a page of tablets, with the assigned agent's name and the latest event, and the
+// number of tablets matching the filters
+func (r *tabletRepository) List(ctx context.Context, q entities.ListQuery[entities.TabletFilter]) (*entities.Page[*entities.Tablet], error) {
+	where := tabletWhere(q.Filters)
 	
-	if status, ok := filters["status"].(string); ok && status != "" {
-		conditions = append(conditions, fmt.Sprintf("status = $%d", argIndex))
-		args = append(args, status)
-		argIndex++
+	var total int
+	if err := r.db.Conn(ctx).QueryRow(ctx, "SELECT COUNT(*) FROM tablets t"+where.SQL(), where.Args()...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("error counting tablets: %w", err)
 	}
 	
-	if model, ok := filters["model"].(string); ok && model != "" {
-		conditions = append(conditions, fmt.Sprintf("model ILIKE $%d", argIndex))
-		args = append(args, "%"+model+"%")
-		argIndex++
+	order, err := orderBy(q.Sort, tabletSortColumns, "t.created_at DESC", "t.id")
+	if err != nil {
+		return nil, err
 	}
 	
-	if len(conditions) > 0 {
-		query += " WHERE " + strings.Join(conditions, " AND ")
+	if err := keyset(where, order, q); err != nil {
+		return nil, err
 	}
 	
-	query += " ORDER BY created_at DESC"
+	query, args := paginate(`
+		SELECT `+order.Key()+`, t.id, COALESCE(t.serial_number, ''), COALESCE(t.model, ''), t.status, t.assigned_user_id, t.user_cpf,
+		       t.municipality_id, COALESCE(t.asset_code, ''), t.assigned_at, t.created_at, t.updated_at,
+		       COALESCE(u.name, ''), COALESCE(m.name, ''),
+		       e.id, e.type, COALESCE(e.from_status, ''), e.to_status, COALESCE(e.note, ''), e.created_at
+		FROM tablets t
+		LEFT JOIN users u ON u.id = t.assigned_user_id
+		LEFT JOIN municipalities m ON m.id = t.municipality_id
+		LEFT JOIN LATERAL (
+			SELECT id, type, from_status, to_status, note, created_at
+			FROM tablet_events
+			WHERE tablet_id = t.id
+			ORDER BY created_at DESC, id DESC
+			LIMIT 1
+		) e ON true`+where.SQL()+order.SQL(), where.Args(), q)
 	
-	rows, err := r.db.Pool.Query(ctx, query, args...)
+	rows, err := queryPage(ctx, r.db.Conn(ctx), query, args)
 	if err != nil {
 		return nil, fmt.Errorf("error listing tablets: %w", err)
 	}
@@ -180,6 +318,11 @@ func (r *tabletRepository) List(ctx context.Context, filters map[string]interfac
 	var tablets []*entities.Tablet
 	for rows.Next() {
 		tablet := &entities.Tablet{}
+		var userName, municipalityName string
+		var eventID *int64
+		var eventType, eventToStatus *string
+		var eventCreatedAt *time.Time
+		event := &entities.TabletEvent{}
 		err := rows.Scan(
 			&tablet.ID,
 			&tablet.SerialNumber,
@@ -187,17 +330,72 @@ func (r *tabletRepository) List(ctx context.Context, filters map[string]interfac
 			&tablet.Status,
 			&tablet.AssignedUserID,
 			&tablet.UserCPF,
+			&tablet.MunicipalityID,
+			&tablet.AssetCode,
 			&tablet.AssignedAt,
 			&tablet.CreatedAt,
 			&tablet.UpdatedAt,
+			&userName,
+			&municipalityName,
+			&eventID,
+			&eventType,
+			&event.FromStatus,
+			&eventToStatus,
+			&event.Note,
+			&eventCreatedAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("error scanning tablet: %w", err)
 		}
+		
+		if tablet.AssignedUserID != nil {
+			tablet.AssignedUser = &entities.User{ID: *tablet.AssignedUserID, Name: userName}
+		}
+		tablet.Municipality = &entities.Municipality{ID: tablet.MunicipalityID, Name: municipalityName}
+		if eventID != nil {
+			event.ID = *eventID
+			event.TabletID = tablet.ID
+			event.Type = entities.TabletEventType(*eventType)
+			event.ToStatus = entities.TabletStatus(*eventToStatus)
+			event.CreatedAt = *eventCreatedAt
+			tablet.LastEvent = event
+		}
+		
 		tablets = append(tablets, tablet)
 	}
 	
-	return tablets, nil
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	
+	return listPage(tablets, total, q, rows), nil
+}
+
+// AddEvent appends an entry to the tablet's history
+func (r *tabletRepository) AddEvent(ctx context.Context, event *entities.TabletEvent) error {
+	query := `
+		INSERT INTO tablet_events (tablet_id, type, from_status, to_status, user_id, note,
+		                           from_municipality_id, to_municipality_id, created_at)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, NULLIF($6, ''), $7, $8, NOW())
+		RETURNING id, created_at
+	`
+	
+	err := r.db.Conn(ctx).QueryRow(ctx, query,
+		event.TabletID,
+		event.Type,
+		event.FromStatus,
+		event.ToStatus,
+		event.UserID,
+		event.Note,
+		event.FromMunicipalityID,
+		event.ToMunicipalityID,
+	).Scan(&event.ID, &event.CreatedAt)
+	
+	if err != nil {
+		return fmt.Errorf("error adding tablet event: %w", err)
+	}
+	
+	return nil
 }
 
 func (r *tabletRepository) Update(ctx context.Context, tablet *entities.Tablet) error {
@@ -208,7 +406,7 @@ func (r *tabletRepository) Update(ctx context.Context, tablet *entities.Tablet)
 		WHERE id = $1
 	`
 	
-	cmdTag, err := r.db.Pool.Exec(ctx, query,
+	cmdTag, err := r.db.Conn(ctx).Exec(ctx, query,
 		tablet.ID,
 		tablet.SerialNumber,
 		tablet.Model,
@@ -219,11 +417,11 @@ func (r *tabletRepository) Update(ctx context.Context, tablet *entities.Tablet)
 	)
 	
 	if err != nil {
-		return fmt.Errorf("error updating tablet: %w", err)
+		return fmt.Errorf("error updating tablet: %w", dbError(err, entities.ErrTabletNotFound))
 	}
 	
 	if cmdTag.RowsAffected() == 0 {
-		return fmt.Errorf("tablet not found")
+		return entities.ErrTabletNotFound
 	}
 	
 	return nil
@@ -232,13 +430,13 @@ func (r *tabletRepository) Update(ctx context.Context, tablet *entities.Tablet)
 func (r *tabletRepository) Delete(ctx context.Context, id int) error {
 	query := `DELETE FROM tablets WHERE id = $1`
 	
-	cmdTag, err := r.db.Pool.Exec(ctx, query, id)
+	cmdTag, err := r.db.Conn(ctx).Exec(ctx, query, id)
 	if err != nil {
-		return fmt.Errorf("error deleting tablet: %w", err)
+		return fmt.Errorf("error deleting tablet: %w", dbError(err, entities.ErrTabletNotFound))
 	}
 	
 	if cmdTag.RowsAffected() == 0 {
-		return fmt.Errorf("tablet not found")
+		return entities.ErrTabletNotFound
 	}
 	
 	return nil
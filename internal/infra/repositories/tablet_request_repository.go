@@ -0,0 +1,298 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/joaopanucci/apsdigital/internal/domain/apperrors"
+	"github.com/joaopanucci/apsdigital/internal/domain/entities"
+	"github.com/joaopanucci/apsdigital/internal/infra/db"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type tabletRequestRepository struct {
+	db *db.PostgresDB
+}
+
+func NewTabletRequestRepository(db *db.PostgresDB) *tabletRequestRepository {
+	return &tabletRequestRepository{db: db}
+}
+
+const tabletRequestColumns = `
+	tr.id, tr.user_id, tr.type, tr.status, COALESCE(tr.justification, ''), COALESCE(tr.description, ''),
+	COALESCE(tr.photos, '[]'::jsonb), COALESCE(tr.document_url, ''), tr.approved_by, tr.approved_at,
+	tr.rejected_by, tr.rejected_at, COALESCE(tr.rejection_reason, ''), tr.created_at, tr.updated_at,
+	u.name, u.cpf, u.municipality_id`
+
+func scanTabletRequest(row pgx.Row) (*entities.TabletRequest, error) {
+	request := &entities.TabletRequest{User: &entities.User{}}
+	err := row.Scan(
+		&request.ID,
+		&request.UserID,
+		&request.Type,
+		&request.Status,
+		&request.Justification,
+		&request.Description,
+		&request.Photos,
+		&request.DocumentURL,
+		&request.ApprovedBy,
+		&request.ApprovedAt,
+		&request.RejectedBy,
+		&request.RejectedAt,
+		&request.RejectionReason,
+		&request.CreatedAt,
+		&request.UpdatedAt,
+		&request.User.Name,
+		&request.User.CPF,
+		&request.User.MunicipalityID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	request.User.ID = request.UserID
+
+	return request, nil
+}
+
+func (r *tabletRequestRepository) Create(ctx context.Context, request *entities.TabletRequest) error {
+	if request.Photos == nil {
+		request.Photos = []string{}
+	}
+
+	query := `
+		INSERT INTO tablet_requests (user_id, type, status, justification, description, photos, document_url, created_at, updated_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), $6, NULLIF($7, ''), NOW(), NOW())
+		RETURNING id, created_at, updated_at
+	`
+
+	err := r.db.Conn(ctx).QueryRow(ctx, query,
+		request.UserID,
+		request.Type,
+		request.Status,
+		request.Justification,
+		request.Description,
+		request.Photos,
+		request.DocumentURL,
+	).Scan(&request.ID, &request.CreatedAt, &request.UpdatedAt)
+
+	if err != nil {
+		return fmt.Errorf("error creating tablet request: %w", dbError(err, nil))
+	}
+
+	return nil
+}
+
+func (r *tabletRequestRepository) GetByID(ctx context.Context, id uuid.UUID) (*entities.TabletRequest, error) {
+	query := `SELECT ` + tabletRequestColumns + `
+		FROM tablet_requests tr
+		JOIN users u ON u.id = tr.user_id
+		WHERE tr.id = $1
+	`
+
+	request, err := scanTabletRequest(r.db.Conn(ctx).QueryRow(ctx, query, id))
+	if err != nil {
+		return nil, fmt.Errorf("error getting tablet request: %w", dbError(err, entities.ErrTabletRequestNotFound))
+	}
+
+	return request, nil
+}
+
+// tabletRequestSortColumns are the fields tablet requests can be sorted by
+var tabletRequestSortColumns = map[string]string{
+	"created_at": "tr.created_at",
+	"updated_at": "tr.updated_at",
+	"status":     "tr.status",
+	"type":       "tr.type",
+}
+
+// tabletRequestWhere builds the conditions of a tablet request list; the municipality is the requester's
+func tabletRequestWhere(f entities.TabletRequestFilter) *db.Where {
+	where := &db.Where{}
+	where.AddIf(f.Status != "", "tr.status = ?", f.Status)
+	where.AddIf(f.Type != "", "tr.type = ?", f.Type)
+	where.AddIf(f.UserID != nil, "tr.user_id = ?", f.UserID)
+	// Restrict to the municipalities of the user's scope
+	where.AddIf(f.MunicipalityIDs != nil, "u.municipality_id = ANY(?)", f.MunicipalityIDs)
+	return where
+}
+
+// List returns a page of tablet requests and the number of requests matching the filters
+func (r *tabletRequestRepository) List(ctx context.Context, q entities.ListQuery[entities.TabletRequestFilter]) (*entities.Page[*entities.TabletRequest], error) {
+	where := tabletRequestWhere(q.Filters)
+
+	from := `
+		FROM tablet_requests tr
+		JOIN users u ON u.id = tr.user_id`
+
+	var total int
+	if err := r.db.Conn(ctx).QueryRow(ctx, "SELECT COUNT(*)"+from+where.SQL(), where.Args()...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("error counting tablet requests: %w", err)
+	}
+
+	order, err := orderBy(q.Sort, tabletRequestSortColumns, "tr.created_at DESC", "tr.id")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := keyset(where, order, q); err != nil {
+		return nil, err
+	}
+
+	query, args := paginate(`SELECT `+order.Key()+`, `+tabletRequestColumns+from+where.SQL()+order.SQL(), where.Args(), q)
+
+	rows, err := queryPage(ctx, r.db.Conn(ctx), query, args)
+	if err != nil {
+		return nil, fmt.Errorf("error listing tablet requests: %w", err)
+	}
+	defer rows.Close()
+
+	var requests []*entities.TabletRequest
+	for rows.Next() {
+		request, err := scanTabletRequest(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning tablet request: %w", err)
+		}
+		requests = append(requests, request)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return listPage(requests, total, q, rows), nil
+}
+
+// GetByUserID returns every request of an agent, newest first
+func (r *tabletRequestRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*entities.TabletRequest, error) {
+	where := tabletRequestWhere(entities.TabletRequestFilter{UserID: &userID})
+
+	query := `SELECT ` + tabletRequestColumns + `
+		FROM tablet_requests tr
+		JOIN users u ON u.id = tr.user_id` + where.SQL() + `
+		ORDER BY tr.created_at DESC, tr.id
+	`
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, where.Args()...)
+	if err != nil {
+		return nil, fmt.Errorf("error listing tablet requests: %w", err)
+	}
+	defer rows.Close()
+
+	requests := []*entities.TabletRequest{}
+	for rows.Next() {
+		request, err := scanTabletRequest(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning tablet request: %w", err)
+		}
+		requests = append(requests, request)
+	}
+
+	return requests, rows.Err()
+}
+
+// Update records the decision on a request that is still pending, so two concurrent
+// decisions cannot both apply
+func (r *tabletRequestRepository) Update(ctx context.Context, request *entities.TabletRequest) error {
+	query := `
+		UPDATE tablet_requests
+		SET status = $2, justification = NULLIF($3, ''), description = NULLIF($4, ''),
+		    approved_by = $5, approved_at = $6, rejected_by = $7, rejected_at = $8,
+		    rejection_reason = NULLIF($9, ''), updated_at = NOW()
+		WHERE id = $1 AND status = $10
+	`
+
+	result, err := r.db.Conn(ctx).Exec(ctx, query,
+		request.ID,
+		request.Status,
+		request.Justification,
+		request.Description,
+		request.ApprovedBy,
+		request.ApprovedAt,
+		request.RejectedBy,
+		request.RejectedAt,
+		request.RejectionReason,
+		entities.TabletRequestStatusPending,
+	)
+
+	if err != nil {
+		return fmt.Errorf("error updating tablet request: %w", dbError(err, entities.ErrTabletRequestNotFound))
+	}
+
+	if result.RowsAffected() == 0 {
+		return entities.ErrTabletRequestNotPending
+	}
+
+	return nil
+}
+
+// AddPhoto appends a photo while the request is pending and has room for it
+func (r *tabletRequestRepository) AddPhoto(ctx context.Context, id uuid.UUID, url string) error {
+	query := `
+		UPDATE tablet_requests
+		SET photos = COALESCE(photos, '[]'::jsonb) || jsonb_build_array($2::text), updated_at = NOW()
+		WHERE id = $1 AND status = $3 AND jsonb_array_length(COALESCE(photos, '[]'::jsonb)) < $4
+	`
+
+	result, err := r.db.Conn(ctx).Exec(ctx, query, id, url, entities.TabletRequestStatusPending, entities.MaxTabletRequestPhotos)
+	if err != nil {
+		return fmt.Errorf("error adding tablet request photo: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		// A request that exists and is pending was refused for being full
+		if err := r.checkPending(ctx, id); err != nil {
+			return err
+		}
+		return apperrors.Conflict("tablet_request_photo_limit", entities.MaxTabletRequestPhotos)
+	}
+
+	return nil
+}
+
+// SetDocument replaces the document of a pending request
+func (r *tabletRequestRepository) SetDocument(ctx context.Context, id uuid.UUID, url string) error {
+	query := `
+		UPDATE tablet_requests SET document_url = $2, updated_at = NOW()
+		WHERE id = $1 AND status = $3
+	`
+
+	result, err := r.db.Conn(ctx).Exec(ctx, query, id, url, entities.TabletRequestStatusPending)
+	if err != nil {
+		return fmt.Errorf("error setting tablet request document: %w", dbError(err, nil))
+	}
+
+	if result.RowsAffected() == 0 {
+		return r.checkPending(ctx, id)
+	}
+
+	return nil
+}
+
+// checkPending tells why a change to a pending request matched no row: the request is
+// missing or was already decided. It returns nil when the request is still pending.
+func (r *tabletRequestRepository) checkPending(ctx context.Context, id uuid.UUID) error {
+	var status entities.TabletRequestStatus
+	err := r.db.Conn(ctx).QueryRow(ctx, `SELECT status FROM tablet_requests WHERE id = $1`, id).Scan(&status)
+	if err != nil {
+		return fmt.Errorf("error getting tablet request status: %w", dbError(err, entities.ErrTabletRequestNotFound))
+	}
+
+	if status != entities.TabletRequestStatusPending {
+		return entities.ErrTabletRequestNotPending
+	}
+
+	return nil
+}
+
+// CountPending counts the requests awaiting a decision
+func (r *tabletRequestRepository) CountPending(ctx context.Context) (int, error) {
+	var count int
+	err := r.db.Conn(ctx).QueryRow(ctx, `SELECT COUNT(*) FROM tablet_requests WHERE status = $1`, entities.TabletRequestStatusPending).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("error counting pending tablet requests: %w", err)
+	}
+
+	return count, nil
+}
@@ -0,0 +1,236 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/joaopanucci/apsdigital/internal/domain/entities"
+	"github.com/joaopanucci/apsdigital/internal/infra/db"
+)
+
+type tabletStatsRepository struct {
+	db *db.PostgresDB
+}
+
+func NewTabletStatsRepository(db *db.PostgresDB) *tabletStatsRepository {
+	return &tabletStatsRepository{db: db}
+}
+
+// scopeWhere restricts a query to the filter's municipalities, given the column holding
+// the municipality ID and the alias of the joined municipalities table
+func scopeWhere(f entities.TabletStatsFilter, municipalityColumn, municipalities string) *db.Where {
+	where := &db.Where{}
+	where.AddIf(f.MunicipalityID != nil, municipalityColumn+" = ?", f.MunicipalityID)
+	where.AddIf(f.MunicipalityIDs != nil, municipalityColumn+" = ANY(?)", f.MunicipalityIDs)
+	where.AddIf(f.HealthRegionID != nil, municipalities+".health_region_id = ?", f.HealthRegionID)
+	return where
+}
+
+// addPeriod restricts the column to the filter's date range
+func addPeriod(where *db.Where, f entities.TabletStatsFilter, column string) {
+	where.AddIf(f.From != nil, column+" >= ?", f.From)
+	where.AddIf(f.To != nil, column+" < ?", f.To)
+}
+
+func (r *tabletStatsRepository) CountByMunicipalityStatus(ctx context.Context, f entities.TabletStatsFilter) ([]*entities.MunicipalityTabletStats, error) {
+	where := scopeWhere(f, "t.municipality_id", "m")
+	query := `
+		SELECT m.id, m.name, t.status, COUNT(*)
+		FROM tablets t
+		JOIN municipalities m ON m.id = t.municipality_id
+	` + where.SQL() + `
+		GROUP BY m.id, m.name, t.status
+		ORDER BY m.name, t.status
+	`
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, where.Args()...)
+	if err != nil {
+		return nil, fmt.Errorf("error counting tablets by status: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []*entities.MunicipalityTabletStats
+	var current *entities.MunicipalityTabletStats
+	for rows.Next() {
+		var municipalityID, count int
+		var name string
+		var status entities.TabletStatus
+		if err := rows.Scan(&municipalityID, &name, &status, &count); err != nil {
+			return nil, fmt.Errorf("error scanning tablet status count: %w", err)
+		}
+
+		if current == nil || current.MunicipalityID != municipalityID {
+			current = &entities.MunicipalityTabletStats{
+				MunicipalityID:   municipalityID,
+				MunicipalityName: name,
+				ByStatus:         make(map[entities.TabletStatus]int),
+			}
+			stats = append(stats, current)
+		}
+		current.ByStatus[status] = count
+		current.Total += count
+	}
+
+	return stats, rows.Err()
+}
+
+func (r *tabletStatsRepository) ACSCoverage(ctx context.Context, f entities.TabletStatsFilter) (*entities.ACSTabletCoverage, error) {
+	where := scopeWhere(f, "u.municipality_id", "m")
+	where.Add("ro.name = ?", entities.RoleACS)
+	where.Add("u.status = ?", entities.UserStatusActive)
+	query := `
+		SELECT COUNT(*),
+		       COUNT(*) FILTER (WHERE NOT EXISTS (
+		           SELECT 1 FROM tablets t
+		           WHERE t.assigned_user_id = u.id AND t.status IN ('atribuido', 'ativo')
+		       ))
+		FROM users u
+		JOIN roles ro ON ro.id = u.role_id
+		LEFT JOIN municipalities m ON m.id = u.municipality_id
+	` + where.SQL()
+
+	coverage := &entities.ACSTabletCoverage{}
+	if err := r.db.Conn(ctx).QueryRow(ctx, query, where.Args()...).Scan(&coverage.ActiveACS, &coverage.WithoutTablet); err != nil {
+		return nil, fmt.Errorf("error counting agents without tablet: %w", err)
+	}
+
+	if coverage.ActiveACS > 0 {
+		coverage.WithoutTabletRate = float64(coverage.WithoutTablet) * 100 / float64(coverage.ActiveACS)
+	}
+
+	return coverage, nil
+}
+
+// MaintenanceDurations averages the periods tablets spent in maintenance, from the event
+// that put them there to the next one. Periods still open count up to now.
+func (r *tabletStatsRepository) MaintenanceDurations(ctx context.Context, f entities.TabletStatsFilter) (*entities.TabletMaintenanceStats, error) {
+	where := scopeWhere(f, "t.municipality_id", "m")
+	query := `
+		SELECT COUNT(*), COALESCE(AVG(EXTRACT(EPOCH FROM COALESCE(left_at, NOW()) - created_at)) / 86400, 0)
+		FROM (
+			SELECT e.to_status, e.created_at,
+			       LEAD(e.created_at) OVER (PARTITION BY e.tablet_id ORDER BY e.created_at, e.id) AS left_at
+			FROM tablet_events e
+			JOIN tablets t ON t.id = e.tablet_id
+			JOIN municipalities m ON m.id = t.municipality_id
+	` + where.SQL() + `
+		) periods
+		WHERE to_status = 'manutencao'`
+
+	// The range is applied outside the window so LEAD still sees the event that ends a period
+	args := where.Args()
+	if f.From != nil {
+		args = append(args, f.From)
+		query += fmt.Sprintf(" AND created_at >= $%d", len(args))
+	}
+	if f.To != nil {
+		args = append(args, f.To)
+		query += fmt.Sprintf(" AND created_at < $%d", len(args))
+	}
+
+	stats := &entities.TabletMaintenanceStats{}
+	if err := r.db.Conn(ctx).QueryRow(ctx, query, args...).Scan(&stats.Periods, &stats.AverageDays); err != nil {
+		return nil, fmt.Errorf("error averaging maintenance time: %w", err)
+	}
+
+	return stats, nil
+}
+
+// IncidentsByQuarter counts the theft and breakage requests that were not rejected,
+// against the tablets registered up to the end of each quarter
+func (r *tabletStatsRepository) IncidentsByQuarter(ctx context.Context, f entities.TabletStatsFilter) ([]*entities.TabletQuarterIncidents, error) {
+	where := scopeWhere(f, "u.municipality_id", "m")
+	where.Add("tr.type IN ('furto', 'quebra')")
+	where.Add("tr.status <> 'rejected'")
+	addPeriod(where, f, "tr.created_at")
+	query := `
+		SELECT date_trunc('quarter', tr.created_at) AS quarter,
+		       COUNT(*) FILTER (WHERE tr.type = 'furto'),
+		       COUNT(*) FILTER (WHERE tr.type = 'quebra')
+		FROM tablet_requests tr
+		JOIN users u ON u.id = tr.user_id
+		LEFT JOIN municipalities m ON m.id = u.municipality_id
+	` + where.SQL() + `
+		GROUP BY quarter
+		ORDER BY quarter
+	`
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, where.Args()...)
+	if err != nil {
+		return nil, fmt.Errorf("error counting tablet incidents: %w", err)
+	}
+	defer rows.Close()
+
+	var quarters []time.Time
+	var incidents []*entities.TabletQuarterIncidents
+	for rows.Next() {
+		var quarter time.Time
+		incident := &entities.TabletQuarterIncidents{}
+		if err := rows.Scan(&quarter, &incident.Thefts, &incident.Breakages); err != nil {
+			return nil, fmt.Errorf("error scanning tablet incidents: %w", err)
+		}
+		incident.Quarter = fmt.Sprintf("%d-Q%d", quarter.Year(), (int(quarter.Month())-1)/3+1)
+		quarters = append(quarters, quarter)
+		incidents = append(incidents, incident)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(incidents) == 0 {
+		return incidents, nil
+	}
+
+	inventory, err := r.inventoryByQuarter(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, incident := range incidents {
+		end := quarters[i].AddDate(0, 3, 0)
+		for _, registered := range inventory {
+			if registered.quarter.Before(end) {
+				incident.Inventory += registered.count
+			}
+		}
+		if incident.Inventory > 0 {
+			incident.Rate = float64(incident.Thefts+incident.Breakages) * 100 / float64(incident.Inventory)
+		}
+	}
+
+	return incidents, nil
+}
+
+type quarterCount struct {
+	quarter time.Time
+	count   int
+}
+
+// inventoryByQuarter counts the tablets registered in each quarter
+func (r *tabletStatsRepository) inventoryByQuarter(ctx context.Context, f entities.TabletStatsFilter) ([]quarterCount, error) {
+	where := scopeWhere(f, "t.municipality_id", "m")
+	query := `
+		SELECT date_trunc('quarter', t.created_at) AS quarter, COUNT(*)
+		FROM tablets t
+		JOIN municipalities m ON m.id = t.municipality_id
+	` + where.SQL() + `
+		GROUP BY quarter
+	`
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, where.Args()...)
+	if err != nil {
+		return nil, fmt.Errorf("error counting tablet inventory: %w", err)
+	}
+	defer rows.Close()
+
+	var counts []quarterCount
+	for rows.Next() {
+		var c quarterCount
+		if err := rows.Scan(&c.quarter, &c.count); err != nil {
+			return nil, fmt.Errorf("error scanning tablet inventory: %w", err)
+		}
+		counts = append(counts, c)
+	}
+
+	return counts, rows.Err()
+}
@@ -0,0 +1,340 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/joaopanucci/apsdigital/internal/domain/entities"
+	"github.com/joaopanucci/apsdigital/internal/infra/db"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type tabletTransferRepository struct {
+	db *db.PostgresDB
+}
+
+func NewTabletTransferRepository(db *db.PostgresDB) *tabletTransferRepository {
+	return &tabletTransferRepository{db: db}
+}
+
+const tabletTransferColumns = `
+	tr.id, tr.tablet_id, tr.from_municipality_id, tr.to_municipality_id, tr.status, COALESCE(tr.reason, ''),
+	tr.proposed_by, tr.proposed_at, tr.approved_by, tr.approved_at, tr.received_by, tr.received_at,
+	tr.rejected_by, tr.rejected_at, COALESCE(tr.rejection_reason, ''), tr.updated_at,
+	COALESCE(t.asset_code, ''), COALESCE(t.serial_number, ''), COALESCE(t.model, ''), t.status`
+
+func scanTabletTransfer(row pgx.Row) (*entities.TabletTransfer, error) {
+	transfer := &entities.TabletTransfer{Tablet: &entities.Tablet{}}
+	err := row.Scan(
+		&transfer.ID,
+		&transfer.TabletID,
+		&transfer.FromMunicipalityID,
+		&transfer.ToMunicipalityID,
+		&transfer.Status,
+		&transfer.Reason,
+		&transfer.ProposedBy,
+		&transfer.ProposedAt,
+		&transfer.ApprovedBy,
+		&transfer.ApprovedAt,
+		&transfer.ReceivedBy,
+		&transfer.ReceivedAt,
+		&transfer.RejectedBy,
+		&transfer.RejectedAt,
+		&transfer.RejectionReason,
+		&transfer.UpdatedAt,
+		&transfer.Tablet.AssetCode,
+		&transfer.Tablet.SerialNumber,
+		&transfer.Tablet.Model,
+		&transfer.Tablet.Status,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	transfer.Tablet.ID = transfer.TabletID
+	transfer.Tablet.MunicipalityID = transfer.FromMunicipalityID
+	if transfer.Status == entities.TabletTransferReceived {
+		transfer.Tablet.MunicipalityID = transfer.ToMunicipalityID
+	}
+
+	return transfer, nil
+}
+
+// Create proposes a transfer of a tablet that is available in the source municipality
+func (r *tabletTransferRepository) Create(ctx context.Context, transfer *entities.TabletTransfer) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return dbError(err, nil)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockTablet(ctx, tx, transfer.TabletID, transfer.FromMunicipalityID, entities.TabletStatusAvailable); err != nil {
+		return dbError(err, nil)
+	}
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO tablet_transfers (tablet_id, from_municipality_id, to_municipality_id, status, reason, proposed_by, proposed_at, updated_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, NOW(), NOW())
+		RETURNING id, proposed_at, updated_at
+	`,
+		transfer.TabletID,
+		transfer.FromMunicipalityID,
+		transfer.ToMunicipalityID,
+		entities.TabletTransferPending,
+		transfer.Reason,
+		transfer.ProposedBy,
+	).Scan(&transfer.ID, &transfer.ProposedAt, &transfer.UpdatedAt)
+
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return fmt.Errorf("%w: tablet already has an open transfer", entities.ErrTransferState)
+		}
+		return fmt.Errorf("error creating tablet transfer: %w", dbError(err, nil))
+	}
+	transfer.Status = entities.TabletTransferPending
+
+	if err := insertTransferEvent(ctx, tx, transfer, entities.TabletStatusAvailable, entities.TabletStatusAvailable, "proposta", transfer.ProposedBy); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *tabletTransferRepository) GetByID(ctx context.Context, id int64) (*entities.TabletTransfer, error) {
+	query := `SELECT ` + tabletTransferColumns + `
+		FROM tablet_transfers tr
+		JOIN tablets t ON t.id = tr.tablet_id
+		WHERE tr.id = $1
+	`
+
+	transfer, err := scanTabletTransfer(r.db.Conn(ctx).QueryRow(ctx, query, id))
+	if err != nil {
+		return nil, fmt.Errorf("error getting tablet transfer: %w", dbError(err, entities.ErrTabletTransferNotFound))
+	}
+
+	return transfer, nil
+}
+
+// tabletTransferSortColumns are the fields transfers can be sorted by
+var tabletTransferSortColumns = map[string]string{
+	"proposed_at": "tr.proposed_at",
+	"updated_at":  "tr.updated_at",
+	"status":      "tr.status",
+}
+
+// tabletTransferWhere builds the conditions of a transfer list, matching either end of the transfer to the scope
+func tabletTransferWhere(f entities.TabletTransferFilter) *db.Where {
+	where := &db.Where{}
+	where.AddIf(f.Status != "", "tr.status = ?", f.Status)
+	where.AddIf(f.TabletID != nil, "tr.tablet_id = ?", f.TabletID)
+	where.AddIf(f.MunicipalityIDs != nil, "(tr.from_municipality_id = ANY(?) OR tr.to_municipality_id = ANY(?))", f.MunicipalityIDs, f.MunicipalityIDs)
+	return where
+}
+
+func (r *tabletTransferRepository) List(ctx context.Context, q entities.ListQuery[entities.TabletTransferFilter]) (*entities.Page[*entities.TabletTransfer], error) {
+	where := tabletTransferWhere(q.Filters)
+
+	var total int
+	if err := r.db.Conn(ctx).QueryRow(ctx, "SELECT COUNT(*) FROM tablet_transfers tr"+where.SQL(), where.Args()...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("error counting tablet transfers: %w", err)
+	}
+
+	order, err := orderBy(q.Sort, tabletTransferSortColumns, "tr.proposed_at DESC", "tr.id")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := keyset(where, order, q); err != nil {
+		return nil, err
+	}
+
+	query, args := paginate(`SELECT `+order.Key()+`, `+tabletTransferColumns+`
+		FROM tablet_transfers tr
+		JOIN tablets t ON t.id = tr.tablet_id`+where.SQL()+order.SQL(), where.Args(), q)
+
+	rows, err := queryPage(ctx, r.db.Conn(ctx), query, args)
+	if err != nil {
+		return nil, fmt.Errorf("error listing tablet transfers: %w", err)
+	}
+	defer rows.Close()
+
+	var transfers []*entities.TabletTransfer
+	for rows.Next() {
+		transfer, err := scanTabletTransfer(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning tablet transfer: %w", err)
+		}
+		transfers = append(transfers, transfer)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return listPage(transfers, total, q, rows), nil
+}
+
+// Approve puts the tablet in transit
+func (r *tabletTransferRepository) Approve(ctx context.Context, id int64, approvedBy uuid.UUID) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	transfer, err := lockTransfer(ctx, tx, id, entities.TabletTransferPending)
+	if err != nil {
+		return err
+	}
+	if err := lockTablet(ctx, tx, transfer.TabletID, transfer.FromMunicipalityID, entities.TabletStatusAvailable); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE tablet_transfers SET status = $2, approved_by = $3, approved_at = NOW(), updated_at = NOW() WHERE id = $1
+	`, id, entities.TabletTransferApproved, approvedBy); err != nil {
+		return fmt.Errorf("error approving tablet transfer: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `
+		UPDATE tablets SET status = $2, updated_at = NOW() WHERE id = $1
+	`, transfer.TabletID, entities.TabletStatusInTransit); err != nil {
+		return fmt.Errorf("error updating tablet: %w", err)
+	}
+	if err := insertTransferEvent(ctx, tx, transfer, entities.TabletStatusAvailable, entities.TabletStatusInTransit, "aprovada", approvedBy); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Reject closes a transfer that was not approved yet; the tablet never left
+func (r *tabletTransferRepository) Reject(ctx context.Context, id int64, rejectedBy uuid.UUID, reason string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	transfer, err := lockTransfer(ctx, tx, id, entities.TabletTransferPending)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE tablet_transfers
+		SET status = $2, rejected_by = $3, rejected_at = NOW(), rejection_reason = NULLIF($4, ''), updated_at = NOW()
+		WHERE id = $1
+	`, id, entities.TabletTransferRejected, rejectedBy, reason); err != nil {
+		return fmt.Errorf("error rejecting tablet transfer: %w", err)
+	}
+	if err := insertTransferEvent(ctx, tx, transfer, entities.TabletStatusAvailable, entities.TabletStatusAvailable, "rejeitada", rejectedBy); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Receive moves the tablet to the destination municipality, available again
+func (r *tabletTransferRepository) Receive(ctx context.Context, id int64, receivedBy uuid.UUID) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	transfer, err := lockTransfer(ctx, tx, id, entities.TabletTransferApproved)
+	if err != nil {
+		return err
+	}
+	if err := lockTablet(ctx, tx, transfer.TabletID, transfer.FromMunicipalityID, entities.TabletStatusInTransit); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE tablet_transfers SET status = $2, received_by = $3, received_at = NOW(), updated_at = NOW() WHERE id = $1
+	`, id, entities.TabletTransferReceived, receivedBy); err != nil {
+		return fmt.Errorf("error receiving tablet transfer: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `
+		UPDATE tablets SET municipality_id = $2, status = $3, updated_at = NOW() WHERE id = $1
+	`, transfer.TabletID, transfer.ToMunicipalityID, entities.TabletStatusAvailable); err != nil {
+		return fmt.Errorf("error updating tablet: %w", err)
+	}
+	if err := insertTransferEvent(ctx, tx, transfer, entities.TabletStatusInTransit, entities.TabletStatusAvailable, "recebida", receivedBy); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// lockTransfer locks a transfer that must be in the given status
+func lockTransfer(ctx context.Context, tx pgx.Tx, id int64, status entities.TabletTransferStatus) (*entities.TabletTransfer, error) {
+	transfer := &entities.TabletTransfer{ID: id}
+	err := tx.QueryRow(ctx, `
+		SELECT tablet_id, from_municipality_id, to_municipality_id, status
+		FROM tablet_transfers WHERE id = $1 FOR UPDATE
+	`, id).Scan(&transfer.TabletID, &transfer.FromMunicipalityID, &transfer.ToMunicipalityID, &transfer.Status)
+	if err == pgx.ErrNoRows {
+		return nil, entities.ErrTabletTransferNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error locking tablet transfer: %w", err)
+	}
+
+	if transfer.Status != status {
+		return nil, fmt.Errorf("%w: transfer is %s", entities.ErrTransferState, transfer.Status)
+	}
+
+	return transfer, nil
+}
+
+// lockTablet locks a tablet that must be in the given municipality and status
+func lockTablet(ctx context.Context, tx pgx.Tx, id int, municipalityID int, status entities.TabletStatus) error {
+	var current entities.TabletStatus
+	var currentMunicipalityID int
+	err := tx.QueryRow(ctx, `
+		SELECT status, municipality_id FROM tablets WHERE id = $1 FOR UPDATE
+	`, id).Scan(&current, &currentMunicipalityID)
+	if err == pgx.ErrNoRows {
+		return entities.ErrTabletNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("error locking tablet: %w", err)
+	}
+
+	if currentMunicipalityID != municipalityID {
+		return fmt.Errorf("%w: tablet is no longer in the source municipality", entities.ErrTransferState)
+	}
+	if current != status {
+		return fmt.Errorf("%w: tablet is %s, expected %s", entities.ErrTransferState, current, status)
+	}
+
+	return nil
+}
+
+// insertTransferEvent records a step of a transfer in the tablet's history, with the user who took it
+func insertTransferEvent(ctx context.Context, tx pgx.Tx, transfer *entities.TabletTransfer, from, to entities.TabletStatus, step string, userID uuid.UUID) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO tablet_events (tablet_id, type, from_status, to_status, user_id, note, from_municipality_id, to_municipality_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW())
+	`,
+		transfer.TabletID,
+		entities.TabletEventTransferred,
+		from,
+		to,
+		userID,
+		fmt.Sprintf("Transferência #%d %s", transfer.ID, step),
+		transfer.FromMunicipalityID,
+		transfer.ToMunicipalityID,
+	)
+	if err != nil {
+		return fmt.Errorf("error recording tablet transfer: %w", err)
+	}
+
+	return nil
+}
@@ -3,13 +3,11 @@ package repositories
 import (
 	"context"
 	"fmt"
-	"strings"
 
 	"github.com/joaopanucci/apsdigital/internal/domain/entities"
 	"github.com/joaopanucci/apsdigital/internal/infra/db"
 
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5"
 )
 
 type userRepository struct {
@@ -22,34 +20,35 @@ func NewUserRepository(db *db.PostgresDB) *userRepository {
 
 func (r *userRepository) Create(ctx context.Context, user *entities.User) error {
 	query := `
-		INSERT INTO users (id, email, password, name, cpf, phone, role_id, profession_id, municipality, unit, status)
+		INSERT INTO users (id, email, password, name, cpf, phone, role_id, profession_id, municipality, unit_id, status)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 	`
 
 	user.ID = uuid.New()
-	_, err := r.db.Pool.Exec(ctx, query,
+	_, err := r.db.Conn(ctx).Exec(ctx, query,
 		user.ID, user.Email, user.Password, user.Name, user.CPF,
 		user.Phone, user.RoleID, user.ProfessionID, user.Municipality,
-		user.Unit, user.Status,
+		user.UnitID, user.Status,
 	)
 
-	return err
+	return dbError(err, nil)
 }
 
 func (r *userRepository) GetByID(ctx context.Context, id uuid.UUID) (*entities.User, error) {
 	query := `
 		SELECT u.id, u.email, u.password, u.name, u.cpf, u.phone, 
-		       u.role_id, u.profession_id, u.municipality, u.unit, u.status, 
+		       u.role_id, u.profession_id, u.municipality, u.municipality_id, u.unit_id, u.status, 
+		       COALESCE(u.is_authorized, false), u.scope_type, u.health_region_id, COALESCE(u.scope_state, ''),
 		       u.created_at, u.updated_at,
 		       r.id, r.name, r.description, r.level, r.created_at, r.updated_at,
-		       p.id, p.name, p.created_at, p.updated_at
+		       COALESCE(p.id, 0), COALESCE(p.name, ''), COALESCE(p.created_at, u.created_at), COALESCE(p.updated_at, u.updated_at)
 		FROM users u
 		LEFT JOIN roles r ON u.role_id = r.id
 		LEFT JOIN professions p ON u.profession_id = p.id
 		WHERE u.id = $1
 	`
 
-	row := r.db.Pool.QueryRow(ctx, query, id)
+	row := r.db.Conn(ctx).QueryRow(ctx, query, id)
 
 	var user entities.User
 	var role entities.Role
@@ -57,17 +56,15 @@ func (r *userRepository) GetByID(ctx context.Context, id uuid.UUID) (*entities.U
 
 	err := row.Scan(
 		&user.ID, &user.Email, &user.Password, &user.Name, &user.CPF,
-		&user.Phone, &user.RoleID, &user.ProfessionID, &user.Municipality,
-		&user.Unit, &user.Status, &user.CreatedAt, &user.UpdatedAt,
+		&user.Phone, &user.RoleID, &user.ProfessionID, &user.Municipality, &user.MunicipalityID,
+		&user.UnitID, &user.Status, &user.IsAuthorized, &user.ScopeType, &user.HealthRegionID, &user.ScopeState,
+		&user.CreatedAt, &user.UpdatedAt,
 		&role.ID, &role.Name, &role.Description, &role.Level, &role.CreatedAt, &role.UpdatedAt,
 		&profession.ID, &profession.Name, &profession.CreatedAt, &profession.UpdatedAt,
 	)
 
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			return nil, fmt.Errorf("user not found")
-		}
-		return nil, err
+		return nil, dbError(err, entities.ErrUserNotFound)
 	}
 
 	user.Role = &role
@@ -79,17 +76,18 @@ func (r *userRepository) GetByID(ctx context.Context, id uuid.UUID) (*entities.U
 func (r *userRepository) GetByEmail(ctx context.Context, email string) (*entities.User, error) {
 	query := `
 		SELECT u.id, u.email, u.password, u.name, u.cpf, u.phone, 
-		       u.role_id, u.profession_id, u.municipality, u.unit, u.status, 
+		       u.role_id, u.profession_id, u.municipality, u.municipality_id, u.unit_id, u.status, 
+		       COALESCE(u.is_authorized, false), u.scope_type, u.health_region_id, COALESCE(u.scope_state, ''),
 		       u.created_at, u.updated_at,
 		       r.id, r.name, r.description, r.level, r.created_at, r.updated_at,
-		       p.id, p.name, p.created_at, p.updated_at
+		       COALESCE(p.id, 0), COALESCE(p.name, ''), COALESCE(p.created_at, u.created_at), COALESCE(p.updated_at, u.updated_at)
 		FROM users u
 		LEFT JOIN roles r ON u.role_id = r.id
 		LEFT JOIN professions p ON u.profession_id = p.id
 		WHERE u.email = $1
 	`
 
-	row := r.db.Pool.QueryRow(ctx, query, email)
+	row := r.db.Conn(ctx).QueryRow(ctx, query, email)
 
 	var user entities.User
 	var role entities.Role
@@ -97,17 +95,15 @@ func (r *userRepository) GetByEmail(ctx context.Context, email string) (*entitie
 
 	err := row.Scan(
 		&user.ID, &user.Email, &user.Password, &user.Name, &user.CPF,
-		&user.Phone, &user.RoleID, &user.ProfessionID, &user.Municipality,
-		&user.Unit, &user.Status, &user.CreatedAt, &user.UpdatedAt,
+		&user.Phone, &user.RoleID, &user.ProfessionID, &user.Municipality, &user.MunicipalityID,
+		&user.UnitID, &user.Status, &user.IsAuthorized, &user.ScopeType, &user.HealthRegionID, &user.ScopeState,
+		&user.CreatedAt, &user.UpdatedAt,
 		&role.ID, &role.Name, &role.Description, &role.Level, &role.CreatedAt, &role.UpdatedAt,
 		&profession.ID, &profession.Name, &profession.CreatedAt, &profession.UpdatedAt,
 	)
 
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			return nil, fmt.Errorf("user not found")
-		}
-		return nil, err
+		return nil, dbError(err, entities.ErrUserNotFound)
 	}
 
 	user.Role = &role
@@ -119,29 +115,34 @@ func (r *userRepository) GetByEmail(ctx context.Context, email string) (*entitie
 func (r *userRepository) GetByCPF(ctx context.Context, cpf string) (*entities.User, error) {
 	query := `
 		SELECT u.id, u.email, u.password, u.name, u.cpf, u.phone, 
-		       u.role_id, u.profession_id, u.municipality, u.unit, u.status, 
-		       u.created_at, u.updated_at
+		       u.role_id, u.profession_id, u.municipality, u.municipality_id, u.unit_id, u.status, 
+		       COALESCE(u.is_authorized, false), u.scope_type, u.health_region_id, COALESCE(u.scope_state, ''),
+		       u.created_at, u.updated_at,
+		       r.id, r.name, r.description, r.level, r.created_at, r.updated_at
 		FROM users u
+		LEFT JOIN roles r ON u.role_id = r.id
 		WHERE u.cpf = $1
 	`
 
-	row := r.db.Pool.QueryRow(ctx, query, cpf)
+	row := r.db.Conn(ctx).QueryRow(ctx, query, cpf)
 
 	var user entities.User
+	var role entities.Role
 
 	err := row.Scan(
 		&user.ID, &user.Email, &user.Password, &user.Name, &user.CPF,
-		&user.Phone, &user.RoleID, &user.ProfessionID, &user.Municipality,
-		&user.Unit, &user.Status, &user.CreatedAt, &user.UpdatedAt,
+		&user.Phone, &user.RoleID, &user.ProfessionID, &user.Municipality, &user.MunicipalityID,
+		&user.UnitID, &user.Status, &user.IsAuthorized, &user.ScopeType, &user.HealthRegionID, &user.ScopeState,
+		&user.CreatedAt, &user.UpdatedAt,
+		&role.ID, &role.Name, &role.Description, &role.Level, &role.CreatedAt, &role.UpdatedAt,
 	)
 
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			return nil, fmt.Errorf("user not found")
-		}
-		return nil, err
+		return nil, dbError(err, entities.ErrUserNotFound)
 	}
 
+	user.Role = &role
+
 	return &user, nil
 }
 
@@ -149,61 +150,77 @@ func (r *userRepository) Update(ctx context.Context, user *entities.User) error
 	query := `
 		UPDATE users 
 		SET email = $2, name = $3, phone = $4, role_id = $5, profession_id = $6, 
-		    municipality = $7, unit = $8, status = $9, updated_at = NOW()
+		    municipality = $7, unit_id = $8, status = $9, updated_at = NOW()
 		WHERE id = $1
 	`
 
-	_, err := r.db.Pool.Exec(ctx, query,
+	_, err := r.db.Conn(ctx).Exec(ctx, query,
 		user.ID, user.Email, user.Name, user.Phone, user.RoleID,
-		user.ProfessionID, user.Municipality, user.Unit, user.Status,
+		user.ProfessionID, user.Municipality, user.UnitID, user.Status,
 	)
 
-	return err
+	return dbError(err, entities.ErrUserNotFound)
 }
 
 func (r *userRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	query := `DELETE FROM users WHERE id = $1`
-	_, err := r.db.Pool.Exec(ctx, query, id)
-	return err
+	_, err := r.db.Conn(ctx).Exec(ctx, query, id)
+	return dbError(err, entities.ErrUserNotFound)
 }
 
-func (r *userRepository) List(ctx context.Context, filters map[string]interface{}) ([]*entities.User, error) {
-	query := `
-		SELECT u.id, u.email, u.name, u.cpf, u.phone, 
-		       u.role_id, u.profession_id, u.municipality, u.unit, u.status, 
-		       u.created_at, u.updated_at,
-		       r.name, p.name
+// userSortColumns are the fields users can be sorted by
+var userSortColumns = map[string]string{
+	"name":       "u.name",
+	"email":      "u.email",
+	"status":     "u.status",
+	"created_at": "u.created_at",
+}
+
+// userWhere builds the conditions of a user list
+func userWhere(f entities.UserFilter) *db.Where {
+	where := &db.Where{}
+	where.AddIf(f.Municipality != "", "u.municipality = ?", f.Municipality)
+	where.AddIf(f.MunicipalityID != nil, "u.municipality_id = ?", f.MunicipalityID)
+	// Restrict to the municipalities of the user's scope
+	where.AddIf(f.MunicipalityIDs != nil, "u.municipality_id = ANY(?)", f.MunicipalityIDs)
+	where.AddIf(f.Status != "", "u.status = ?", f.Status)
+	where.AddIf(f.RoleID != nil, "u.role_id = ?", f.RoleID)
+	where.AddIf(f.ProfessionID != nil, "u.profession_id = ?", f.ProfessionID)
+	where.AddIf(f.Search != "", "(u.name ILIKE ? OR u.email ILIKE ? OR u.cpf = ?)", "%"+f.Search+"%", "%"+f.Search+"%", f.Search)
+	return where
+}
+
+// List returns a page of users and the number of users matching the filters
+func (r *userRepository) List(ctx context.Context, q entities.ListQuery[entities.UserFilter]) (*entities.Page[*entities.User], error) {
+	where := userWhere(q.Filters)
+
+	from := `
 		FROM users u
 		LEFT JOIN roles r ON u.role_id = r.id
 		LEFT JOIN professions p ON u.profession_id = p.id
 	`
 
-	var conditions []string
-	var args []interface{}
-	argCount := 0
-
-	for key, value := range filters {
-		argCount++
-		switch key {
-		case "municipality":
-			conditions = append(conditions, fmt.Sprintf("u.municipality = $%d", argCount))
-		case "status":
-			conditions = append(conditions, fmt.Sprintf("u.status = $%d", argCount))
-		case "role_id":
-			conditions = append(conditions, fmt.Sprintf("u.role_id = $%d", argCount))
-		case "profession_id":
-			conditions = append(conditions, fmt.Sprintf("u.profession_id = $%d", argCount))
-		}
-		args = append(args, value)
+	var total int
+	if err := r.db.Conn(ctx).QueryRow(ctx, "SELECT COUNT(*)"+from+where.SQL(), where.Args()...).Scan(&total); err != nil {
+		return nil, err
 	}
 
-	if len(conditions) > 0 {
-		query += " WHERE " + strings.Join(conditions, " AND ")
+	order, err := orderBy(q.Sort, userSortColumns, "u.created_at DESC", "u.id")
+	if err != nil {
+		return nil, err
 	}
 
-	query += " ORDER BY u.created_at DESC"
+	if err := keyset(where, order, q); err != nil {
+		return nil, err
+	}
 
-	rows, err := r.db.Pool.Query(ctx, query, args...)
+	query, args := paginate(`
+		SELECT `+order.Key()+`, u.id, u.email, u.name, u.cpf, u.phone, 
+		       u.role_id, u.profession_id, u.municipality, u.municipality_id, u.unit_id, u.status, 
+		       u.created_at, u.updated_at,
+		       COALESCE(r.name, ''), COALESCE(p.name, '')`+from+where.SQL()+order.SQL(), where.Args(), q)
+
+	rows, err := queryPage(ctx, r.db.Conn(ctx), query, args)
 	if err != nil {
 		return nil, err
 	}
@@ -217,7 +234,7 @@ func (r *userRepository) List(ctx context.Context, filters map[string]interface{
 
 		err := rows.Scan(
 			&user.ID, &user.Email, &user.Name, &user.CPF, &user.Phone,
-			&user.RoleID, &user.ProfessionID, &user.Municipality, &user.Unit,
+			&user.RoleID, &user.ProfessionID, &user.Municipality, &user.MunicipalityID, &user.UnitID,
 			&user.Status, &user.CreatedAt, &user.UpdatedAt,
 			&roleName, &professionName,
 		)
@@ -231,13 +248,17 @@ func (r *userRepository) List(ctx context.Context, filters map[string]interface{
 		users = append(users, &user)
 	}
 
-	return users, nil
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return listPage(users, total, q, rows), nil
 }
 
 func (r *userRepository) GetPendingAuthorization(ctx context.Context) ([]*entities.User, error) {
 	query := `
 		SELECT u.id, u.email, u.name, u.cpf, u.phone, 
-		       u.role_id, u.profession_id, u.municipality, u.unit, u.status, 
+		       u.role_id, u.profession_id, u.municipality, u.unit_id, u.status, 
 		       u.created_at, u.updated_at,
 		       r.name, r.level, p.name
 		FROM users u
@@ -247,7 +268,7 @@ func (r *userRepository) GetPendingAuthorization(ctx context.Context) ([]*entiti
 		ORDER BY u.created_at ASC
 	`
 
-	rows, err := r.db.Pool.Query(ctx, query)
+	rows, err := r.db.Conn(ctx).Query(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -263,7 +284,7 @@ func (r *userRepository) GetPendingAuthorization(ctx context.Context) ([]*entiti
 
 		err := rows.Scan(
 			&user.ID, &user.Email, &user.Name, &user.CPF, &user.Phone,
-			&user.RoleID, &user.ProfessionID, &user.Municipality, &user.Unit,
+			&user.RoleID, &user.ProfessionID, &user.Municipality, &user.UnitID,
 			&user.Status, &user.CreatedAt, &user.UpdatedAt,
 			&roleName, &roleLevel, &professionName,
 		)
@@ -279,3 +300,51 @@ func (r *userRepository) GetPendingAuthorization(ctx context.Context) ([]*entiti
 
 	return users, nil
 }
+
+func (r *userRepository) CountPendingAuthorization(ctx context.Context) (int, error) {
+	var count int
+	err := r.db.Conn(ctx).QueryRow(ctx, `SELECT COUNT(*) FROM users WHERE status = 'pending_authorization'`).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("error counting users pending authorization: %w", err)
+	}
+
+	return count, nil
+}
+
+func (r *userRepository) AuthorizeUser(ctx context.Context, userID uuid.UUID) error {
+	query := `
+		UPDATE users
+		SET is_authorized = true, status = 'active', updated_at = NOW()
+		WHERE id = $1
+	`
+
+	cmdTag, err := r.db.Conn(ctx).Exec(ctx, query, userID)
+	if err != nil {
+		return err
+	}
+
+	if cmdTag.RowsAffected() == 0 {
+		return entities.ErrUserNotFound
+	}
+
+	return nil
+}
+
+func (r *userRepository) UpdateScope(ctx context.Context, userID uuid.UUID, scope entities.UserScope) error {
+	query := `
+		UPDATE users
+		SET scope_type = $2, municipality_id = COALESCE($3, municipality_id), health_region_id = $4, scope_state = NULLIF($5, ''), updated_at = NOW()
+		WHERE id = $1
+	`
+
+	cmdTag, err := r.db.Conn(ctx).Exec(ctx, query, userID, scope.Type, scope.MunicipalityID, scope.HealthRegionID, scope.State)
+	if err != nil {
+		return dbError(err, entities.ErrUserNotFound)
+	}
+
+	if cmdTag.RowsAffected() == 0 {
+		return entities.ErrUserNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,388 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/joaopanucci/apsdigital/internal/domain/entities"
+	"github.com/joaopanucci/apsdigital/internal/infra/db"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type webhookRepository struct {
+	db *db.PostgresDB
+}
+
+func NewWebhookRepository(db *db.PostgresDB) *webhookRepository {
+	return &webhookRepository{db: db}
+}
+
+const webhookSubscriptionColumns = `
+	s.id, s.municipality_id, s.name, s.url, s.secret, s.event_types, s.active, s.created_by,
+	s.created_at, s.updated_at`
+
+func scanWebhookSubscription(row pgx.Row) (*entities.WebhookSubscription, error) {
+	subscription := &entities.WebhookSubscription{}
+	err := row.Scan(
+		&subscription.ID,
+		&subscription.MunicipalityID,
+		&subscription.Name,
+		&subscription.URL,
+		&subscription.Secret,
+		&subscription.EventTypes,
+		&subscription.Active,
+		&subscription.CreatedBy,
+		&subscription.CreatedAt,
+		&subscription.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return subscription, nil
+}
+
+func (r *webhookRepository) Create(ctx context.Context, subscription *entities.WebhookSubscription) error {
+	query := `
+		INSERT INTO webhook_subscriptions (municipality_id, name, url, secret, event_types, active, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NOW(), NOW())
+		RETURNING id, created_at, updated_at
+	`
+
+	err := r.db.Conn(ctx).QueryRow(ctx, query,
+		subscription.MunicipalityID,
+		subscription.Name,
+		subscription.URL,
+		subscription.Secret,
+		subscription.EventTypes,
+		subscription.Active,
+		subscription.CreatedBy,
+	).Scan(&subscription.ID, &subscription.CreatedAt, &subscription.UpdatedAt)
+
+	if err != nil {
+		return fmt.Errorf("error creating webhook: %w", dbError(err, nil))
+	}
+
+	return nil
+}
+
+func (r *webhookRepository) GetByID(ctx context.Context, id uuid.UUID) (*entities.WebhookSubscription, error) {
+	query := `SELECT ` + webhookSubscriptionColumns + ` FROM webhook_subscriptions s WHERE s.id = $1`
+
+	subscription, err := scanWebhookSubscription(r.db.Conn(ctx).QueryRow(ctx, query, id))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, entities.ErrWebhookNotFound
+		}
+		return nil, fmt.Errorf("error getting webhook: %w", err)
+	}
+
+	return subscription, nil
+}
+
+// webhookWhere builds the conditions of a webhook list
+func webhookWhere(filter entities.WebhookFilter) *db.Where {
+	where := &db.Where{}
+	where.AddIf(filter.MunicipalityID != nil, "s.municipality_id = ?", filter.MunicipalityID)
+	where.AddIf(filter.MunicipalityIDs != nil, "s.municipality_id = ANY(?)", filter.MunicipalityIDs)
+	return where
+}
+
+func (r *webhookRepository) List(ctx context.Context, filter entities.WebhookFilter) ([]*entities.WebhookSubscription, error) {
+	where := webhookWhere(filter)
+
+	query := `SELECT ` + webhookSubscriptionColumns + ` FROM webhook_subscriptions s` + where.SQL() + `
+		ORDER BY s.municipality_id, s.name, s.id
+	`
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, where.Args()...)
+	if err != nil {
+		return nil, fmt.Errorf("error listing webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	subscriptions := []*entities.WebhookSubscription{}
+	for rows.Next() {
+		subscription, err := scanWebhookSubscription(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning webhook: %w", err)
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	return subscriptions, rows.Err()
+}
+
+func (r *webhookRepository) Update(ctx context.Context, subscription *entities.WebhookSubscription) error {
+	query := `
+		UPDATE webhook_subscriptions
+		SET name = $2, url = $3, secret = $4, event_types = $5, active = $6, updated_at = NOW()
+		WHERE id = $1
+		RETURNING updated_at
+	`
+
+	err := r.db.Conn(ctx).QueryRow(ctx, query,
+		subscription.ID,
+		subscription.Name,
+		subscription.URL,
+		subscription.Secret,
+		subscription.EventTypes,
+		subscription.Active,
+	).Scan(&subscription.UpdatedAt)
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return entities.ErrWebhookNotFound
+		}
+		return fmt.Errorf("error updating webhook: %w", dbError(err, entities.ErrWebhookNotFound))
+	}
+
+	return nil
+}
+
+func (r *webhookRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.Conn(ctx).Exec(ctx, `DELETE FROM webhook_subscriptions WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("error deleting webhook: %w", dbError(err, entities.ErrWebhookNotFound))
+	}
+
+	if result.RowsAffected() == 0 {
+		return entities.ErrWebhookNotFound
+	}
+
+	return nil
+}
+
+func (r *webhookRepository) Enqueue(ctx context.Context, event *entities.WebhookEvent, payload []byte) (int, error) {
+	query := `
+		INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload, next_attempt_at, created_at)
+		SELECT s.id, $1, $2::text, $3, NOW(), NOW()
+		FROM webhook_subscriptions s
+		WHERE s.active AND s.municipality_id = $4 AND $2::text = ANY(s.event_types)
+	`
+
+	result, err := r.db.Conn(ctx).Exec(ctx, query, event.ID, event.Type, payload, event.MunicipalityID)
+	if err != nil {
+		return 0, fmt.Errorf("error queueing webhook deliveries: %w", err)
+	}
+
+	return int(result.RowsAffected()), nil
+}
+
+const webhookDeliveryColumns = `
+	d.id, d.subscription_id, d.event_id, d.event_type, d.payload, d.status, d.attempts,
+	d.next_attempt_at, d.last_attempt_at, d.response_status, COALESCE(d.last_error, ''),
+	d.delivered_at, d.replay_of, d.created_at`
+
+func webhookDeliveryFields(delivery *entities.WebhookDelivery) []interface{} {
+	return []interface{}{
+		&delivery.ID,
+		&delivery.SubscriptionID,
+		&delivery.EventID,
+		&delivery.EventType,
+		&delivery.Payload,
+		&delivery.Status,
+		&delivery.Attempts,
+		&delivery.NextAttemptAt,
+		&delivery.LastAttemptAt,
+		&delivery.ResponseStatus,
+		&delivery.LastError,
+		&delivery.DeliveredAt,
+		&delivery.ReplayOf,
+		&delivery.CreatedAt,
+	}
+}
+
+func scanWebhookDelivery(row pgx.Row) (*entities.WebhookDelivery, error) {
+	delivery := &entities.WebhookDelivery{}
+	if err := row.Scan(webhookDeliveryFields(delivery)...); err != nil {
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+func (r *webhookRepository) EnqueueFor(ctx context.Context, subscriptionID uuid.UUID, event *entities.WebhookEvent, payload []byte) (*entities.WebhookDelivery, error) {
+	query := `
+		WITH d AS (
+			INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload, next_attempt_at, created_at)
+			VALUES ($1, $2, $3, $4, NOW(), NOW())
+			RETURNING *
+		)
+		SELECT ` + webhookDeliveryColumns + ` FROM d
+	`
+
+	delivery, err := scanWebhookDelivery(r.db.Conn(ctx).QueryRow(ctx, query, subscriptionID, event.ID, event.Type, payload))
+	if err != nil {
+		return nil, fmt.Errorf("error queueing webhook delivery: %w", err)
+	}
+
+	return delivery, nil
+}
+
+func (r *webhookRepository) GetDelivery(ctx context.Context, id int64) (*entities.WebhookDelivery, error) {
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries d WHERE d.id = $1`
+
+	delivery, err := scanWebhookDelivery(r.db.Conn(ctx).QueryRow(ctx, query, id))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, entities.ErrWebhookNotFound
+		}
+		return nil, fmt.Errorf("error getting webhook delivery: %w", err)
+	}
+
+	return delivery, nil
+}
+
+// webhookDeliveryWhere builds the conditions of a subscription's delivery list
+func webhookDeliveryWhere(f entities.WebhookDeliveryFilter) *db.Where {
+	where := &db.Where{}
+	where.Add("d.subscription_id = ?", f.SubscriptionID)
+	where.AddIf(f.Status != "", "d.status = ?", f.Status)
+	where.AddIf(f.EventType != "", "d.event_type = ?", f.EventType)
+	return where
+}
+
+func (r *webhookRepository) ListDeliveries(ctx context.Context, q entities.ListQuery[entities.WebhookDeliveryFilter]) (*entities.Page[*entities.WebhookDelivery], error) {
+	where := webhookDeliveryWhere(q.Filters)
+
+	var total int
+	if err := r.db.Conn(ctx).QueryRow(ctx, "SELECT COUNT(*) FROM webhook_deliveries d"+where.SQL(), where.Args()...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("error counting webhook deliveries: %w", err)
+	}
+
+	order, err := orderBy(q.Sort, map[string]string{
+		"created_at":      "d.created_at",
+		"next_attempt_at": "d.next_attempt_at",
+		"status":          "d.status",
+	}, "d.created_at DESC", "d.id DESC")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := keyset(where, order, q); err != nil {
+		return nil, err
+	}
+
+	query, args := paginate(`SELECT `+order.Key()+`, `+webhookDeliveryColumns+` FROM webhook_deliveries d`+where.SQL()+order.SQL(), where.Args(), q)
+
+	rows, err := queryPage(ctx, r.db.Conn(ctx), query, args)
+	if err != nil {
+		return nil, fmt.Errorf("error listing webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []*entities.WebhookDelivery
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return listPage(deliveries, total, q, rows), nil
+}
+
+func (r *webhookRepository) Replay(ctx context.Context, id int64) (*entities.WebhookDelivery, error) {
+	query := `
+		WITH d AS (
+			INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload, replay_of, next_attempt_at, created_at)
+			SELECT subscription_id, event_id, event_type, payload, id, NOW(), NOW()
+			FROM webhook_deliveries WHERE id = $1
+			RETURNING *
+		)
+		SELECT ` + webhookDeliveryColumns + ` FROM d
+	`
+
+	delivery, err := scanWebhookDelivery(r.db.Conn(ctx).QueryRow(ctx, query, id))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, entities.ErrWebhookNotFound
+		}
+		return nil, fmt.Errorf("error replaying webhook delivery: %w", err)
+	}
+
+	return delivery, nil
+}
+
+// ClaimDue counts the attempt up front and pushes next_attempt_at past the lease, so a
+// worker that dies mid-send leaves the delivery to be retried once the lease expires.
+// Deliveries of inactive subscriptions wait until they are reactivated.
+func (r *webhookRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*entities.WebhookDelivery, error) {
+	query := `
+		WITH due AS (
+			SELECT d.id FROM webhook_deliveries d
+			JOIN webhook_subscriptions s ON s.id = d.subscription_id
+			WHERE d.status = $1 AND d.next_attempt_at <= NOW() AND s.active
+			ORDER BY d.next_attempt_at
+			LIMIT $2
+			FOR UPDATE OF d SKIP LOCKED
+		), d AS (
+			UPDATE webhook_deliveries w
+			SET attempts = w.attempts + 1, last_attempt_at = NOW(), next_attempt_at = NOW() + make_interval(secs => $3)
+			FROM due WHERE w.id = due.id
+			RETURNING w.*
+		)
+		SELECT ` + webhookDeliveryColumns + `, ` + webhookSubscriptionColumns + `
+		FROM d JOIN webhook_subscriptions s ON s.id = d.subscription_id
+	`
+
+	rows, err := r.db.Conn(ctx).Query(ctx, query, entities.WebhookDeliveryPending, limit, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("error claiming webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []*entities.WebhookDelivery
+	for rows.Next() {
+		delivery := &entities.WebhookDelivery{Subscription: &entities.WebhookSubscription{}}
+		s := delivery.Subscription
+		fields := append(webhookDeliveryFields(delivery),
+			&s.ID, &s.MunicipalityID, &s.Name, &s.URL, &s.Secret, &s.EventTypes, &s.Active, &s.CreatedBy, &s.CreatedAt, &s.UpdatedAt)
+		if err := rows.Scan(fields...); err != nil {
+			return nil, fmt.Errorf("error scanning webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+func (r *webhookRepository) MarkDelivered(ctx context.Context, id int64, responseStatus int) error {
+	_, err := r.db.Conn(ctx).Exec(ctx, `
+		UPDATE webhook_deliveries
+		SET status = $2, response_status = $3, last_error = NULL, delivered_at = NOW(), next_attempt_at = NULL
+		WHERE id = $1
+	`, id, entities.WebhookDeliveryDelivered, responseStatus)
+	if err != nil {
+		return fmt.Errorf("error marking webhook delivery as delivered: %w", err)
+	}
+
+	return nil
+}
+
+func (r *webhookRepository) MarkAttemptFailed(ctx context.Context, id int64, responseStatus *int, message string, retryAt *time.Time) error {
+	status := entities.WebhookDeliveryPending
+	if retryAt == nil {
+		status = entities.WebhookDeliveryFailed
+	}
+
+	_, err := r.db.Conn(ctx).Exec(ctx, `
+		UPDATE webhook_deliveries
+		SET status = $2, response_status = $3, last_error = $4, next_attempt_at = $5
+		WHERE id = $1
+	`, id, status, responseStatus, message, retryAt)
+	if err != nil {
+		return fmt.Errorf("error recording failed webhook delivery: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+// Package storage keeps uploaded files on local disk. Files are addressed by the URL
+// they are served under, which is what gets stored in the database.
+package storage
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// URLPrefix is where the router serves the upload directory
+const URLPrefix = "/uploads"
+
+type Local struct {
+	root string
+}
+
+func NewLocal(root string) *Local {
+	return &Local{root: root}
+}
+
+// Save writes r to dir/name under the upload directory and returns its URL. A partially
+// written file is removed.
+func (s *Local) Save(dir, name string, r io.Reader) (string, error) {
+	if strings.Contains(name, "/") || strings.Contains(name, `\`) || name == "" || name == "." || name == ".." {
+		return "", fmt.Errorf("invalid file name %q", name)
+	}
+
+	fullDir := filepath.Join(s.root, filepath.FromSlash(path.Clean("/"+dir)))
+	if err := os.MkdirAll(fullDir, os.ModePerm); err != nil {
+		return "", fmt.Errorf("error creating upload directory: %w", err)
+	}
+
+	filePath := filepath.Join(fullDir, name)
+	dst, err := os.Create(filePath)
+	if err != nil {
+		return "", fmt.Errorf("error creating file: %w", err)
+	}
+
+	if _, err := io.Copy(dst, r); err != nil {
+		dst.Close()
+		os.Remove(filePath)
+		return "", fmt.Errorf("error saving file: %w", err)
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(filePath)
+		return "", fmt.Errorf("error saving file: %w", err)
+	}
+
+	return path.Join(URLPrefix, path.Clean("/"+dir), name), nil
+}
+
+// Remove deletes the file behind a URL returned by Save
+func (s *Local) Remove(url string) error {
+	rel, ok := strings.CutPrefix(url, URLPrefix+"/")
+	if !ok {
+		return fmt.Errorf("not an uploaded file: %s", url)
+	}
+
+	if err := os.Remove(filepath.Join(s.root, filepath.FromSlash(path.Clean("/"+rel)))); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error removing file: %w", err)
+	}
+
+	return nil
+}
+
+// CheckWritable creates and removes a file in the upload directory
+func (s *Local) CheckWritable() error {
+	f, err := os.CreateTemp(s.root, ".write-check-*")
+	if err != nil {
+		return fmt.Errorf("upload directory is not writable: %w", err)
+	}
+	name := f.Name()
+
+	_, err = f.Write([]byte("ok"))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	os.Remove(name)
+	if err != nil {
+		return fmt.Errorf("upload directory is not writable: %w", err)
+	}
+
+	return nil
+}
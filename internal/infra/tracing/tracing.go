@@ -0,0 +1,101 @@
+// Package tracing sets up OpenTelemetry. Spans are started by the HTTP middleware, the
+// services and the database query tracer, and exported to an OTLP collector, stdout or
+// nowhere.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// Exporters
+const (
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+	ExporterNone   = "none"
+)
+
+// Options configures Setup
+type Options struct {
+	// ExporterOTLP, ExporterStdout or ExporterNone
+	Exporter string
+	// OTLP/HTTP collector address (host:port); empty uses OTEL_EXPORTER_OTLP_ENDPOINT or localhost:4318
+	Endpoint string
+	Insecure bool
+	// Fraction of new traces recorded; traces started upstream follow the caller's decision
+	SampleRatio float64
+	ServiceName string
+	Environment string
+}
+
+// Setup installs the global tracer provider and W3C trace context propagation. The
+// returned function flushes pending spans and must be called on shutdown. When the
+// exporter can't be created, tracing falls back to no-op and the error is logged.
+func Setup(ctx context.Context, opts Options) func(context.Context) error {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	exporter, err := newExporter(ctx, opts)
+	if err != nil {
+		slog.Warn("Tracing disabled", "exporter", opts.Exporter, "error", err)
+		return noShutdown
+	}
+	if exporter == nil {
+		return noShutdown
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithAttributes(
+			semconv.ServiceName(opts.ServiceName),
+			semconv.DeploymentEnvironment(opts.Environment),
+		),
+	)
+	if err != nil {
+		slog.Warn("Incomplete tracing resource", "error", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	slog.Info("Tracing enabled", "exporter", opts.Exporter, "sample_ratio", opts.SampleRatio)
+
+	return provider.Shutdown
+}
+
+func newExporter(ctx context.Context, opts Options) (sdktrace.SpanExporter, error) {
+	switch opts.Exporter {
+	case ExporterOTLP:
+		var clientOpts []otlptracehttp.Option
+		if opts.Endpoint != "" {
+			clientOpts = append(clientOpts, otlptracehttp.WithEndpoint(opts.Endpoint))
+		}
+		if opts.Insecure {
+			clientOpts = append(clientOpts, otlptracehttp.WithInsecure())
+		}
+		return otlptracehttp.New(ctx, clientOpts...)
+	case ExporterStdout:
+		return stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExporterNone, "":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown exporter %q", opts.Exporter)
+	}
+}
+
+func noShutdown(context.Context) error {
+	return nil
+}
@@ -0,0 +1,137 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/joaopanucci/apsdigital/internal/domain/entities"
+	"github.com/joaopanucci/apsdigital/internal/domain/repositories"
+	"github.com/joaopanucci/apsdigital/internal/utils"
+)
+
+const (
+	batchSize      = 20
+	requestTimeout = 10 * time.Second
+	// lease must outlast a send, so a delivery is not picked up twice
+	lease = time.Minute
+
+	// Failed deliveries are retried after 30s, doubling each time up to 6h
+	firstRetryDelay = 30 * time.Second
+	maxRetryDelay   = 6 * time.Hour
+)
+
+// Dispatcher sends the due deliveries of the outbox. Any number of replicas can run one;
+// each claims its own batch.
+type Dispatcher struct {
+	webhookRepo repositories.WebhookRepository
+	client      *http.Client
+}
+
+func NewDispatcher(webhookRepo repositories.WebhookRepository) *Dispatcher {
+	return &Dispatcher{
+		webhookRepo: webhookRepo,
+		client: &http.Client{
+			Timeout: requestTimeout,
+			// A redirect is an answer of its own; following it would send the payload elsewhere
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// Run sends due deliveries every interval until ctx is cancelled
+func (d *Dispatcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d.sendDue(ctx)
+		}
+	}
+}
+
+// sendDue keeps claiming batches while there are full ones
+func (d *Dispatcher) sendDue(ctx context.Context) {
+	for {
+		deliveries, err := d.webhookRepo.ClaimDue(ctx, batchSize, lease)
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to claim webhook deliveries", "error", err)
+			return
+		}
+
+		for _, delivery := range deliveries {
+			d.send(ctx, delivery)
+		}
+
+		if len(deliveries) < batchSize || ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+func (d *Dispatcher) send(ctx context.Context, delivery *entities.WebhookDelivery) {
+	status, err := d.post(ctx, delivery)
+	if err == nil {
+		if err := d.webhookRepo.MarkDelivered(ctx, delivery.ID, status); err != nil {
+			slog.WarnContext(ctx, "Failed to record webhook delivery", "delivery_id", delivery.ID, "error", err)
+		}
+		return
+	}
+
+	var responseStatus *int
+	if status != 0 {
+		responseStatus = &status
+	}
+
+	var retryAt *time.Time
+	if delivery.Attempts < entities.MaxWebhookAttempts {
+		next := time.Now().Add(utils.Backoff(delivery.Attempts, firstRetryDelay, maxRetryDelay))
+		retryAt = &next
+	} else {
+		slog.WarnContext(ctx, "Webhook delivery failed", "delivery_id", delivery.ID, "url", delivery.Subscription.URL, "attempts", delivery.Attempts, "error", err)
+	}
+
+	if err := d.webhookRepo.MarkAttemptFailed(ctx, delivery.ID, responseStatus, err.Error(), retryAt); err != nil {
+		slog.WarnContext(ctx, "Failed to record webhook delivery", "delivery_id", delivery.ID, "error", err)
+	}
+}
+
+// post sends the delivery and returns the response status; any status outside 2xx is an error
+func (d *Dispatcher) post(ctx context.Context, delivery *entities.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Subscription.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "APSDigital-Webhooks/1.0")
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderEventID, delivery.EventID.String())
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(HeaderSignature, Sign(delivery.Subscription.Secret, time.Now(), delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// Drain a little so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("receiver answered %s", resp.Status)
+	}
+
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,204 @@
+package webhooks
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/joaopanucci/apsdigital/internal/domain/entities"
+	"github.com/joaopanucci/apsdigital/internal/domain/repositories"
+
+	"github.com/google/uuid"
+)
+
+// outbox keeps deliveries in memory, claiming and recording them like the repository
+type outbox struct {
+	repositories.WebhookRepository
+
+	mu         sync.Mutex
+	deliveries []*entities.WebhookDelivery
+}
+
+func (o *outbox) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*entities.WebhookDelivery, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	now := time.Now()
+	var claimed []*entities.WebhookDelivery
+	for _, d := range o.deliveries {
+		if len(claimed) == limit {
+			break
+		}
+		if d.Status != entities.WebhookDeliveryPending || d.NextAttemptAt.After(now) {
+			continue
+		}
+		d.Attempts++
+		next := now.Add(lease)
+		d.NextAttemptAt = &next
+		copied := *d
+		claimed = append(claimed, &copied)
+	}
+
+	return claimed, nil
+}
+
+func (o *outbox) MarkDelivered(ctx context.Context, id int64, responseStatus int) error {
+	d := o.get(id)
+	d.Status = entities.WebhookDeliveryDelivered
+	d.ResponseStatus = &responseStatus
+	return nil
+}
+
+func (o *outbox) MarkAttemptFailed(ctx context.Context, id int64, responseStatus *int, message string, retryAt *time.Time) error {
+	d := o.get(id)
+	d.ResponseStatus = responseStatus
+	d.LastError = message
+	if retryAt == nil {
+		d.Status = entities.WebhookDeliveryFailed
+		return nil
+	}
+	d.NextAttemptAt = retryAt
+	return nil
+}
+
+func (o *outbox) get(id int64) *entities.WebhookDelivery {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	for _, d := range o.deliveries {
+		if d.ID == id {
+			return d
+		}
+	}
+	return nil
+}
+
+// makeDue moves the delivery's next attempt to now, as if its retry delay had passed
+func (o *outbox) makeDue(id int64) {
+	now := time.Now()
+	o.get(id).NextAttemptAt = &now
+}
+
+func TestDispatcherSignsAndRetries(t *testing.T) {
+	const secret = "whsec_test"
+	payload := []byte(`{"id":"1","type":"payment.published","data":{}}`)
+
+	var mu sync.Mutex
+	var received []*http.Request
+	var failures []error
+	answers := []int{http.StatusInternalServerError, http.StatusOK}
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		body, _ := io.ReadAll(r.Body)
+		if err := Verify(secret, r.Header.Get(HeaderSignature), body, time.Minute); err != nil {
+			failures = append(failures, err)
+		}
+		if string(body) != string(payload) {
+			t.Errorf("body = %s, want %s", body, payload)
+		}
+
+		received = append(received, r)
+		w.WriteHeader(answers[len(received)-1])
+	}))
+	defer receiver.Close()
+
+	now := time.Now()
+	delivery := &entities.WebhookDelivery{
+		ID:            7,
+		EventID:       uuid.New(),
+		EventType:     entities.WebhookEventPaymentPublished,
+		Payload:       payload,
+		Status:        entities.WebhookDeliveryPending,
+		NextAttemptAt: &now,
+		Subscription:  &entities.WebhookSubscription{URL: receiver.URL, Secret: secret},
+	}
+	repo := &outbox{deliveries: []*entities.WebhookDelivery{delivery}}
+	dispatcher := NewDispatcher(repo)
+	ctx := context.Background()
+
+	// The receiver fails the first attempt: the delivery stays pending for a later retry
+	dispatcher.sendDue(ctx)
+
+	if delivery.Status != entities.WebhookDeliveryPending {
+		t.Fatalf("status after a 500 = %s, want %s", delivery.Status, entities.WebhookDeliveryPending)
+	}
+	if delivery.ResponseStatus == nil || *delivery.ResponseStatus != http.StatusInternalServerError {
+		t.Errorf("response status after a 500 = %v, want 500", delivery.ResponseStatus)
+	}
+	if !delivery.NextAttemptAt.After(time.Now().Add(firstRetryDelay / 2)) {
+		t.Errorf("retry at %v, want about %v from now", delivery.NextAttemptAt, firstRetryDelay)
+	}
+
+	// Not due yet, so nothing is sent
+	dispatcher.sendDue(ctx)
+	if len(received) != 1 {
+		t.Fatalf("receiver got %d requests before the retry was due, want 1", len(received))
+	}
+
+	repo.makeDue(delivery.ID)
+	dispatcher.sendDue(ctx)
+
+	if delivery.Status != entities.WebhookDeliveryDelivered {
+		t.Fatalf("status after a 200 = %s, want %s", delivery.Status, entities.WebhookDeliveryDelivered)
+	}
+	if delivery.Attempts != 2 {
+		t.Errorf("attempts = %d, want 2", delivery.Attempts)
+	}
+
+	if len(received) != 2 {
+		t.Fatalf("receiver got %d requests, want 2", len(received))
+	}
+	for _, err := range failures {
+		t.Errorf("receiver rejected the signature: %v", err)
+	}
+	for _, r := range received {
+		if got := r.Header.Get(HeaderEvent); got != delivery.EventType {
+			t.Errorf("%s = %q, want %q", HeaderEvent, got, delivery.EventType)
+		}
+		if got := r.Header.Get(HeaderEventID); got != delivery.EventID.String() {
+			t.Errorf("%s = %q, want %q", HeaderEventID, got, delivery.EventID)
+		}
+		if got := r.Header.Get(HeaderDelivery); got != strconv.FormatInt(delivery.ID, 10) {
+			t.Errorf("%s = %q, want %d", HeaderDelivery, got, delivery.ID)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	const secret = "whsec_test"
+	body := []byte(`{"type":"ping"}`)
+	now := time.Now()
+
+	tests := []struct {
+		name   string
+		header string
+		body   []byte
+		valid  bool
+	}{
+		{name: "signed body", header: Sign(secret, now, body), body: body, valid: true},
+		{name: "changed body", header: Sign(secret, now, body), body: []byte(`{"type":"pong"}`)},
+		{name: "other secret", header: Sign("whsec_other", now, body), body: body},
+		{name: "stale", header: Sign(secret, now.Add(-time.Hour), body), body: body},
+		{name: "missing", header: "", body: body},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(secret, tt.header, tt.body, 5*time.Minute)
+			if tt.valid && err != nil {
+				t.Errorf("Verify() = %v, want nil", err)
+			}
+			if !tt.valid && err != ErrInvalidSignature {
+				t.Errorf("Verify() = %v, want %v", err, ErrInvalidSignature)
+			}
+		})
+	}
+}
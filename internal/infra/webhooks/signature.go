@@ -0,0 +1,70 @@
+// Package webhooks signs and sends the webhook deliveries queued in the outbox.
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers sent with every delivery
+const (
+	HeaderEvent     = "X-APS-Event"
+	HeaderEventID   = "X-APS-Event-ID"
+	HeaderDelivery  = "X-APS-Delivery"
+	HeaderSignature = "X-APS-Signature"
+)
+
+// ErrInvalidSignature is returned by Verify for missing, malformed, stale or wrong signatures
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// Sign returns the signature header value, "t=<unix time>,v1=<hex HMAC-SHA256>". The MAC
+// covers "<unix time>.<body>" so a captured request cannot be replayed much later.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+	return "t=" + t + ",v1=" + mac(secret, t, body)
+}
+
+// Verify checks a signature header made by Sign, rejecting it when its time is more than
+// tolerance away from now
+func Verify(secret, header string, body []byte, tolerance time.Duration) error {
+	var t, signature string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			t = value
+		case "v1":
+			signature = value
+		}
+	}
+	if t == "" || signature == "" {
+		return ErrInvalidSignature
+	}
+
+	unix, err := strconv.ParseInt(t, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if age := time.Since(time.Unix(unix, 0)); age > tolerance || age < -tolerance {
+		return ErrInvalidSignature
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(mac(secret, t, body))) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+func mac(secret, t string, body []byte) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(t))
+	h.Write([]byte("."))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -101,15 +101,21 @@ func (r *MunicipalityPostgresRepository) GetByName(ctx context.Context, name str
 	return municipality, nil
 }
 
-func (r *MunicipalityPostgresRepository) List(ctx context.Context) ([]*entities.Municipality, error) {
+func (r *MunicipalityPostgresRepository) List(ctx context.Context, q entities.ListQuery[entities.MunicipalityFilter]) (*entities.Page[*entities.Municipality], error) {
+	var total int
+	if err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM municipalities WHERE active = true`).Scan(&total); err != nil {
+		return nil, fmt.Errorf("error counting municipalities: %w", err)
+	}
+
 	query := `
 		SELECT id, name, ibge_code, state, active, created_at, updated_at
 		FROM municipalities
 		WHERE active = true
-		ORDER BY name ASC
+		ORDER BY name ASC, id
+		LIMIT $1 OFFSET $2
 	`
 
-	rows, err := r.db.Query(ctx, query)
+	rows, err := r.db.Query(ctx, query, q.Limit, q.Offset())
 	if err != nil {
 		return nil, fmt.Errorf("error listing municipalities: %w", err)
 	}
@@ -135,7 +141,8 @@ func (r *MunicipalityPostgresRepository) List(ctx context.Context) ([]*entities.
 		municipalities = append(municipalities, municipality)
 	}
 
-	return municipalities, nil
+	// Numbered pages only: this repository issues no cursors
+	return entities.NewPage(municipalities, total, q, nil), nil
 }
 
 func (r *MunicipalityPostgresRepository) Update(ctx context.Context, municipality *entities.Municipality) error {
@@ -178,3 +185,100 @@ func (r *MunicipalityPostgresRepository) Delete(ctx context.Context, id int) err
 
 	return nil
 }
+
+func (r *MunicipalityPostgresRepository) ListIDsByState(ctx context.Context, state string) ([]int, error) {
+	query := `SELECT id FROM municipalities WHERE state = $1 ORDER BY id`
+
+	rows, err := r.db.Query(ctx, query, state)
+	if err != nil {
+		return nil, fmt.Errorf("error listing municipalities: %w", err)
+	}
+	defer rows.Close()
+
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("error scanning municipality: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+func (r *MunicipalityPostgresRepository) ListAll(ctx context.Context) ([]*entities.Municipality, error) {
+	query := `
+		SELECT id, name, ibge_code, state, microregion, active, created_at, updated_at
+		FROM municipalities
+		ORDER BY name ASC
+	`
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("error listing municipalities: %w", err)
+	}
+	defer rows.Close()
+
+	var municipalities []*entities.Municipality
+	for rows.Next() {
+		municipality := &entities.Municipality{}
+		var state, microregion sql.NullString
+		err := rows.Scan(
+			&municipality.ID,
+			&municipality.Name,
+			&municipality.IBGECode,
+			&state,
+			&microregion,
+			&municipality.Active,
+			&municipality.CreatedAt,
+			&municipality.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning municipality: %w", err)
+		}
+		municipality.State = state.String
+		municipality.Microregion = microregion.String
+		municipalities = append(municipalities, municipality)
+	}
+
+	return municipalities, rows.Err()
+}
+
+func (r *MunicipalityPostgresRepository) ApplySync(ctx context.Context, upserts []*entities.Municipality, deactivateIDs []int) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO municipalities (name, ibge_code, state, microregion, active, created_at, updated_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), true, NOW(), NOW())
+		ON CONFLICT (ibge_code) DO UPDATE
+		SET name = EXCLUDED.name, state = EXCLUDED.state, microregion = EXCLUDED.microregion,
+		    active = true, updated_at = NOW()
+		RETURNING id
+	`
+
+	for _, municipality := range upserts {
+		err := tx.QueryRow(ctx, query,
+			municipality.Name,
+			municipality.IBGECode,
+			municipality.State,
+			municipality.Microregion,
+		).Scan(&municipality.ID)
+		if err != nil {
+			return fmt.Errorf("error upserting municipality %s: %w", municipality.IBGECode, err)
+		}
+	}
+
+	if len(deactivateIDs) > 0 {
+		_, err := tx.Exec(ctx, `UPDATE municipalities SET active = false, updated_at = NOW() WHERE id = ANY($1)`, deactivateIDs)
+		if err != nil {
+			return fmt.Errorf("error deactivating municipalities: %w", err)
+		}
+	}
+
+	return tx.Commit(ctx)
+}
@@ -4,12 +4,12 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"strings"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joaopanucci/apsdigital/internal/domain/entities"
 	"github.com/joaopanucci/apsdigital/internal/domain/repositories"
+	"github.com/joaopanucci/apsdigital/internal/infra/db"
 )
 
 type TabletPostgresRepository struct {
@@ -43,6 +43,54 @@ func (r *TabletPostgresRepository) Create(ctx context.Context, tablet *entities.
 	return nil
 }
 
+func (r *TabletPostgresRepository) CreateBatch(ctx context.Context, tablets []*entities.Tablet) ([]error, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+	
+	query := `
+		INSERT INTO tablets (serial_number, model, status, municipality_id, asset_code, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), NOW(), NOW())
+		RETURNING id
+	`
+	
+	rowErrors := make([]error, len(tablets))
+	for i, tablet := range tablets {
+		savepoint, err := tx.Begin(ctx)
+		if err != nil {
+			return nil, err
+		}
+		
+		err = savepoint.QueryRow(ctx, query,
+			tablet.SerialNumber,
+			tablet.Model,
+			tablet.Status,
+			tablet.MunicipalityID,
+			tablet.AssetCode,
+		).Scan(&tablet.ID)
+		if err != nil {
+			rowErrors[i] = fmt.Errorf("error creating tablet: %w", err)
+			tablet.ID = 0
+			if err := savepoint.Rollback(ctx); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		
+		if err := savepoint.Commit(ctx); err != nil {
+			return nil, err
+		}
+	}
+	
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	
+	return rowErrors, nil
+}
+
 func (r *TabletPostgresRepository) GetByID(ctx context.Context, id int) (*entities.Tablet, error) {
 	query := `
 		SELECT id, serial_number, model, status, assigned_user_id, user_cpf, assigned_at, created_at, updated_at
@@ -73,6 +121,38 @@ func (r *TabletPostgresRepository) GetByID(ctx context.Context, id int) (*entiti
 	return tablet, nil
 }
 
+func (r *TabletPostgresRepository) GetByAssetCode(ctx context.Context, assetCode string) (*entities.Tablet, error) {
+	query := `
+		SELECT id, serial_number, model, status, assigned_user_id, user_cpf, assigned_at, created_at, updated_at
+		FROM tablets
+		WHERE asset_code = $1
+	`
+	
+	tablet := &entities.Tablet{}
+	err := r.db.QueryRow(ctx, query, assetCode).Scan(
+		&tablet.ID,
+		&tablet.SerialNumber,
+		&tablet.Model,
+		&tablet.Status,
+		&tablet.AssignedUserID,
+		&tablet.UserCPF,
+		&tablet.AssignedAt,
+		&tablet.CreatedAt,
+		&tablet.UpdatedAt,
+	)
+	
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("tablet not found")
+		}
+		return nil, fmt.Errorf("error getting tablet: %w", err)
+	}
+	
+	tablet.AssetCode = assetCode
+	
+	return tablet, nil
+}
+
 func (r *TabletPostgresRepository) GetByUserCPF(ctx context.Context, cpf string) ([]*entities.Tablet, error) {
 	query := `
 		SELECT id, serial_number, model, status, assigned_user_id, user_cpf, assigned_at, created_at, updated_at
@@ -147,33 +227,26 @@ func (r *TabletPostgresRepository) GetByAssignedUser(ctx context.Context, userID
 	return tablets, nil
 }
 
-func (r *TabletPostgresRepository) List(ctx context.Context, filters map[string]interface{}) ([]*entities.Tablet, error) {
+func (r *TabletPostgresRepository) List(ctx context.Context, q entities.ListQuery[entities.TabletFilter]) (*entities.Page[*entities.Tablet], error) {
 	query := `
 		SELECT id, serial_number, model, status, assigned_user_id, user_cpf, assigned_at, created_at, updated_at
 		FROM tablets
 	`
 	
-	var conditions []string
-	var args []interface{}
-	argIndex := 1
+	f := q.Filters
+	where := &db.Where{}
+	where.AddIf(f.SerialNumber != "", "serial_number = ?", f.SerialNumber)
+	where.AddIf(f.Status != "", "status = ?", f.Status)
+	where.AddIf(f.Model != "", "model ILIKE ?", "%"+f.Model+"%")
+	args := where.Args()
 	
-	if status, ok := filters["status"].(string); ok && status != "" {
-		conditions = append(conditions, fmt.Sprintf("status = $%d", argIndex))
-		args = append(args, status)
-		argIndex++
+	var total int
+	if err := r.db.QueryRow(ctx, "SELECT COUNT(*) FROM tablets"+where.SQL(), args...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("error counting tablets: %w", err)
 	}
 	
-	if model, ok := filters["model"].(string); ok && model != "" {
-		conditions = append(conditions, fmt.Sprintf("model ILIKE $%d", argIndex))
-		args = append(args, "%"+model+"%")
-		argIndex++
-	}
-	
-	if len(conditions) > 0 {
-		query += " WHERE " + strings.Join(conditions, " AND ")
-	}
-	
-	query += " ORDER BY created_at DESC"
+	query += where.SQL() + fmt.Sprintf(" ORDER BY created_at DESC, id LIMIT $%d OFFSET $%d", len(args)+1, len(args)+2)
+	args = append(args, q.Limit, q.Offset())
 	
 	rows, err := r.db.Query(ctx, query, args...)
 	if err != nil {
@@ -201,7 +274,8 @@ func (r *TabletPostgresRepository) List(ctx context.Context, filters map[string]
 		tablets = append(tablets, tablet)
 	}
 	
-	return tablets, nil
+	// Numbered pages only: this repository issues no cursors
+	return entities.NewPage(tablets, total, q, nil), nil
 }
 
 func (r *TabletPostgresRepository) Update(ctx context.Context, tablet *entities.Tablet) error {
@@ -247,3 +321,22 @@ func (r *TabletPostgresRepository) Delete(ctx context.Context, id int) error {
 	
 	return nil
 }
+
+func (r *TabletPostgresRepository) AddEvent(ctx context.Context, event *entities.TabletEvent) error {
+	query := `
+		INSERT INTO tablet_events (tablet_id, type, from_status, to_status, user_id, note,
+		                           from_municipality_id, to_municipality_id, created_at)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, NULLIF($6, ''), $7, $8, NOW())
+		RETURNING id, created_at
+	`
+
+	err := r.db.QueryRow(ctx, query,
+		event.TabletID, event.Type, event.FromStatus, event.ToStatus, event.UserID, event.Note,
+		event.FromMunicipalityID, event.ToMunicipalityID,
+	).Scan(&event.ID, &event.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error adding tablet event: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,15 @@
+package utils
+
+import "time"
+
+// Backoff is the wait after the given failed attempt: first, doubling each time up to max
+func Backoff(attempt int, first, max time.Duration) time.Duration {
+	delay := first
+	for i := 1; i < attempt && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	return delay
+}
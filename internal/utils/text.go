@@ -0,0 +1,20 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// FoldName lowercases a name and removes accents and repeated spaces, so names typed by
+// hand ("ponta pora") compare equal to the official ones ("Ponta Porã")
+func FoldName(name string) string {
+	folded, _, err := transform.String(transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC), name)
+	if err != nil {
+		folded = name
+	}
+	return strings.Join(strings.Fields(strings.ToLower(folded)), " ")
+}